@@ -14,6 +14,8 @@ import (
 
 	"github.com/Dragodui/diploma-server/internal/cache"
 	"github.com/Dragodui/diploma-server/internal/config"
+	"github.com/Dragodui/diploma-server/internal/database"
+	"github.com/Dragodui/diploma-server/internal/event"
 	"github.com/Dragodui/diploma-server/internal/http/handlers"
 	"github.com/Dragodui/diploma-server/internal/logger"
 	"github.com/Dragodui/diploma-server/internal/metrics"
@@ -50,6 +52,11 @@ func NewServer() (*Server, error) {
 		log.Printf("Warning: Failed to register GORM metrics plugin: %v", err)
 	}
 
+	// Amounts moved from float major units to integer minor units
+	if err := database.MigrateMoneyToMinorUnits(db); err != nil {
+		return nil, err
+	}
+
 	if err = db.AutoMigrate(
 		&models.User{},
 		&models.Home{},
@@ -60,6 +67,16 @@ func NewServer() (*Server, error) {
 		&models.Bill{},
 		&models.BillCategory{},
 		&models.BillSplit{},
+		&models.BillTemplate{},
+		&models.BillCategoryBudget{},
+		&models.BillRevision{},
+		&models.ImportProfile{},
+		&models.VendorRule{},
+		&models.BillImport{},
+		&models.BillReminderSettings{},
+		&models.BillReminder{},
+		&models.Settlement{},
+		&models.ExchangeRate{},
 		&models.ShoppingCategory{},
 		&models.ShoppingItem{},
 		&models.Poll{},
@@ -70,10 +87,21 @@ func NewServer() (*Server, error) {
 		&models.Room{},
 		&models.HomeAssistantConfig{},
 		&models.SmartDevice{},
+		&models.OutboxEvent{},
+		&models.OCRJob{},
+		&models.OCRJobFile{},
+		&models.OCRCacheEntry{},
 	); err != nil {
 		return nil, err
 	}
 
+	// Splits marked paid before settlements existed become settlements
+	if n, err := repository.NewSettlementRepository(db).BackfillFromPaidSplits(context.Background()); err != nil {
+		return nil, err
+	} else if n > 0 {
+		log.Printf("Backfilled %d settlements from paid splits", n)
+	}
+
 	// Seed database with test data
 	// if err = database.SeedDatabase(db); err != nil {
 	// 	log.Printf("Warning: Failed to seed database: %v", err)
@@ -85,6 +113,7 @@ func NewServer() (*Server, error) {
 	}
 
 	cacheClient := cache.NewRedisClient(cfg.RedisADDR, cfg.RedisPassword, cfg.RedisTLS)
+	event.SetRetention(cfg.EventRetention)
 
 	// Mailer
 	mailer := &utils.BrevoMailer{
@@ -103,32 +132,59 @@ func NewServer() (*Server, error) {
 	taskRepo := repository.NewTaskRepository(db)
 	billRepo := repository.NewBillRepository(db)
 	billCategoryRepo := repository.NewBillCategoryRepository(db)
+	billTemplateRepo := repository.NewBillTemplateRepository(db)
+	budgetRepo := repository.NewBudgetRepository(db)
+	billImportRepo := repository.NewBillImportRepository(db)
+	billReminderRepo := repository.NewBillReminderRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	settlementRepo := repository.NewSettlementRepository(db)
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
 	shoppingRepo := repository.NewShoppingRepository(db)
 	pollRepo := repository.NewPollRepository(db)
 	notificationRepo := repository.NewNotificationRepository(db)
 	smartHomeRepo := repository.NewSmartHomeRepository(db)
 	taskScheduleRepo := repository.NewTaskScheduleRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	ocrJobRepo := repository.NewOCRJobRepository(db)
+	ocrCacheRepo := repository.NewOCRCacheRepository(db)
+	transactor := repository.NewTransactor(db)
 
 	// services
-	notificationSvc := services.NewNotificationService(notificationRepo, cacheClient)
+	outboxSvc := services.NewOutboxService(transactor, outboxRepo, cacheClient)
+	rateSource := services.NewStaticRateSource(exchangeRateRepo)
+	notificationSvc := services.NewNotificationService(notificationRepo, cacheClient, outboxSvc)
 	authSvc := services.NewAuthService(userRepo, []byte(cfg.JWTSecret), cacheClient, 24*time.Hour, cfg.ClientURL, cfg.ServerURL, mailer)
-	homeSvc := services.NewHomeService(homeRepo, cacheClient, notificationSvc)
-	roomSvc := services.NewRoomService(roomRepo, cacheClient)
-	taskSvc := services.NewTaskService(taskRepo, cacheClient, notificationSvc)
-	billSvc := services.NewBillService(billRepo, cacheClient, notificationSvc)
-	billCategorySvc := services.NewBillCategoryService(billCategoryRepo, cacheClient)
-	shoppingSvc := services.NewShoppingService(shoppingRepo, cacheClient)
-	pollSvc := services.NewPollService(pollRepo, cacheClient, notificationSvc)
-	userService := services.NewUserService(userRepo, cacheClient)
+	homeSvc := services.NewHomeService(homeRepo, cacheClient, notificationSvc, outboxSvc)
+	roomSvc := services.NewRoomService(roomRepo, cacheClient, outboxSvc)
+	taskSvc := services.NewTaskService(taskRepo, cacheClient, notificationSvc, outboxSvc)
+	billSvc := services.NewBillService(billRepo, settlementRepo, budgetRepo, homeRepo, rateSource, cacheClient, notificationSvc, outboxSvc)
+	billTemplateSvc := services.NewBillTemplateService(billTemplateRepo, billSvc)
+	billCategorySvc := services.NewBillCategoryService(billCategoryRepo, cacheClient, outboxSvc)
+	budgetSvc := services.NewBudgetService(budgetRepo, billCategoryRepo, homeRepo, outboxSvc)
+	billImportSvc := services.NewBillImportService(billImportRepo, billRepo, billCategoryRepo, billSvc, homeRepo, notificationSvc, outboxSvc)
+	billReminderSvc := services.NewBillReminderService(billReminderRepo, notificationSvc, outboxSvc)
+	exportSvc := services.NewExportService(exportRepo, homeRepo)
+	ledgerSvc := services.NewLedgerService(billRepo, settlementRepo, homeRepo)
+	exchangeRateSvc := services.NewExchangeRateService(exchangeRateRepo, homeRepo, rateSource)
+	settlementSvc := services.NewSettlementService(settlementRepo, billRepo, homeRepo, cacheClient, notificationSvc, outboxSvc)
+	shoppingSvc := services.NewShoppingService(shoppingRepo, cacheClient, outboxSvc)
+	pollSvc := services.NewPollService(pollRepo, cacheClient, notificationSvc, outboxSvc)
+	userService := services.NewUserService(userRepo, cacheClient, outboxSvc)
 
 	imageService, err := services.NewImageService(cfg.AWSS3Bucket, cfg.AWSRegion)
 	if err != nil {
 		log.Fatalf("error running S3: %s", err.Error())
 	}
 
-	ocrSvc := services.NewOCRService(cfg.GeminiAPIKey)
+	ocrProvider, err := services.NewOCRProvider(cfg.OCRProvider, cfg.GeminiAPIKey)
+	if err != nil {
+		log.Fatalf("error configuring OCR: %s", err.Error())
+	}
+	ocrSvc := services.NewOCRService(ocrProvider, ocrCacheRepo, billRepo)
+	ocrJobSvc := services.NewOCRJobService(ocrJobRepo, ocrSvc, outboxSvc, cfg.OCRWorkers)
 	smartHomeSvc := services.NewSmartHomeService(smartHomeRepo, cacheClient, cfg.HAEncryptionKey)
-	taskScheduleSvc := services.NewTaskScheduleService(taskScheduleRepo, taskRepo, cacheClient, notificationSvc)
+	taskScheduleSvc := services.NewTaskScheduleService(taskScheduleRepo, taskRepo, cacheClient, notificationSvc, outboxSvc)
+	eventSvc := services.NewEventService(cacheClient)
 
 	// handlers
 	authHandler := handlers.NewAuthHandler(authSvc, cfg.ClientURL, cfg.Mode != "dev")
@@ -136,18 +192,27 @@ func NewServer() (*Server, error) {
 	roomHandler := handlers.NewRoomHandler(roomSvc, homeRepo)
 	taskHandler := handlers.NewTaskHandler(taskSvc, homeRepo)
 	billHandler := handlers.NewBillHandler(billSvc, homeRepo)
+	billTemplateHandler := handlers.NewBillTemplateHandler(billTemplateSvc, homeRepo)
+	billImportHandler := handlers.NewBillImportHandler(billImportSvc, homeRepo)
+	billReminderHandler := handlers.NewBillReminderHandler(billReminderSvc)
 	billCategoryHandler := handlers.NewBillCategoryHandler(billCategorySvc, homeRepo)
+	budgetHandler := handlers.NewBudgetHandler(budgetSvc)
+	exportHandler := handlers.NewExportHandler(exportSvc)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerSvc)
+	settlementHandler := handlers.NewSettlementHandler(settlementSvc, homeRepo)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(exchangeRateSvc)
 	shoppingHandler := handlers.NewShoppingHandler(shoppingSvc, homeRepo)
 	imageHandler := handlers.NewImageHandler(imageService)
 	pollHandler := handlers.NewPollHandler(pollSvc, homeRepo)
 	notificationHandler := handlers.NewNotificationHandler(notificationSvc)
 	userHandler := handlers.NewUserHandler(userService, imageService)
-	ocrHandler := handlers.NewOCRHandler(ocrSvc)
+	ocrHandler := handlers.NewOCRHandler(ocrSvc, ocrJobSvc)
 	smartHomeHandler := handlers.NewSmartHomeHandler(smartHomeSvc)
 	taskScheduleHandler := handlers.NewTaskScheduleHandler(taskScheduleSvc, homeRepo)
+	eventHandler := handlers.NewEventHandler(eventSvc)
 
 	// setup all routes
-	router := router.SetupRoutes(cfg, authHandler, homeHandler, taskHandler, taskScheduleHandler, billHandler, billCategoryHandler, roomHandler, shoppingHandler, imageHandler, pollHandler, notificationHandler, userHandler, ocrHandler, smartHomeHandler, cacheClient, homeRepo)
+	router := router.SetupRoutes(cfg, authHandler, homeHandler, taskHandler, taskScheduleHandler, billHandler, billTemplateHandler, billImportHandler, billReminderHandler, billCategoryHandler, budgetHandler, exportHandler, ledgerHandler, settlementHandler, exchangeRateHandler, roomHandler, shoppingHandler, imageHandler, pollHandler, notificationHandler, userHandler, ocrHandler, smartHomeHandler, eventHandler, cacheClient, homeRepo)
 
 	// Set startup metrics
 	metrics.ServerStartTime.Set(float64(time.Now().Unix()))
@@ -159,6 +224,18 @@ func NewServer() (*Server, error) {
 	// Start task schedule processor (checks every minute for due schedules)
 	go runTaskScheduler(taskScheduleSvc)
 
+	// Start recurring bill generator (checks every minute for started periods)
+	go runBillScheduler(billTemplateSvc)
+
+	// Start bill reminder job (checks every hour for bills coming due)
+	go runBillReminders(billReminderSvc)
+
+	// Start outbox relay (publishes committed real-time events to Redis)
+	go runOutboxRelay(outboxSvc)
+
+	// Start OCR workers (process receipts queued with POST /ocr/jobs)
+	go runOCRWorkers(ocrJobSvc, ocrSvc, cfg.OCRWorkers)
+
 	httpServer := &http.Server{
 		Addr:              ":" + cfg.Port,
 		Handler:           router,
@@ -227,6 +304,79 @@ func runTaskScheduler(svc *services.TaskScheduleService) {
 	}
 }
 
+func runBillScheduler(svc *services.BillTemplateService) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx := context.Background()
+		if err := svc.ProcessDueTemplates(ctx); err != nil {
+			logger.Info.Printf("[Scheduler] Error processing recurring bills: %v", err)
+		}
+	}
+}
+
+func runBillReminders(svc *services.BillReminderService) {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx := context.Background()
+		if _, err := svc.SendReminders(ctx, time.Now()); err != nil {
+			logger.Info.Printf("[Scheduler] Error sending bill reminders: %v", err)
+		}
+	}
+}
+
+func runOutboxRelay(svc *services.OutboxService) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(1 * time.Hour)
+	defer cleanup.Stop()
+	for {
+		ctx := context.Background()
+		select {
+		case <-ticker.C:
+		case <-svc.Wake():
+		case <-cleanup.C:
+			if err := svc.Cleanup(ctx); err != nil {
+				logger.Info.Printf("[Outbox] Error removing published events: %v", err)
+			}
+			continue
+		}
+		if _, err := svc.PublishPending(ctx); err != nil {
+			logger.Info.Printf("[Outbox] Error publishing events: %v", err)
+		}
+	}
+}
+
+func runOCRWorkers(svc *services.OCRJobService, ocrSvc *services.OCRService, workers int) {
+	ctx := context.Background()
+	for i := 0; i < workers; i++ {
+		go svc.Work(ctx)
+	}
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(1 * time.Hour)
+	defer cleanup.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-svc.Wake():
+		case <-cleanup.C:
+			if err := svc.Cleanup(ctx); err != nil {
+				logger.Info.Printf("[OCR] Error removing finished jobs: %v", err)
+			}
+			if err := ocrSvc.Cleanup(ctx); err != nil {
+				logger.Info.Printf("[OCR] Error removing cached results: %v", err)
+			}
+			continue
+		}
+		if _, err := svc.Dispatch(ctx); err != nil {
+			logger.Info.Printf("[OCR] Error dispatching jobs: %v", err)
+		}
+	}
+}
+
 func collectDBPoolStats(sqlDB *sql.DB) {
 	ticker := time.NewTicker(15 * time.Second)
 	defer ticker.Stop()
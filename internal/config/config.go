@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -50,6 +51,15 @@ type Config struct {
 
 	// Gemini API key for receipt OCR
 	GeminiAPIKey string
+
+	// Receipt OCR backend: gemini, text or fake
+	OCRProvider string
+
+	// Number of background OCR jobs processed at once
+	OCRWorkers int
+
+	// How long real-time events stay replayable
+	EventRetention time.Duration
 }
 
 func Load() *Config {
@@ -70,6 +80,16 @@ func Load() *Config {
 	// Parse optional SMTP port (not required when using Brevo API)
 	smtpPort, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
 
+	eventRetention, err := time.ParseDuration(getEnv("EVENT_RETENTION", "24h"))
+	if err != nil || eventRetention <= 0 {
+		log.Fatalf("EVENT_RETENTION must be a positive duration (e.g. 24h): %v", err)
+	}
+
+	ocrWorkers, err := strconv.Atoi(getEnv("OCR_WORKERS", "2"))
+	if err != nil || ocrWorkers < 1 {
+		log.Fatalf("OCR_WORKERS must be a positive number: %v", err)
+	}
+
 	// Initialize configuration struct using determined keys
 	cfg := &Config{
 		Mode:         getEnv("MODE", "dev"),
@@ -109,6 +129,13 @@ func Load() *Config {
 
 		// Gemini API for receipt OCR
 		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
+
+		// Without a Gemini key receipts are parsed offline from their text
+		OCRProvider: getEnv("OCR_PROVIDER", defaultOCRProvider()),
+		OCRWorkers:  ocrWorkers,
+
+		// Real-time event replay window
+		EventRetention: eventRetention,
 	}
 
 	// Fail in production if admin credentials are still default
@@ -119,6 +146,13 @@ func Load() *Config {
 	return cfg
 }
 
+func defaultOCRProvider() string {
+	if os.Getenv("GEMINI_API_KEY") != "" {
+		return "gemini"
+	}
+	return "text"
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
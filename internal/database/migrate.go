@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// moneyColumns were stored as floating point major units before amounts became integer minor units
+var moneyColumns = []struct{ table, column string }{
+	{"bills", "total_amount"},
+	{"bill_splits", "amount"},
+	{"bill_splits", "paid_amount"},
+	{"settlements", "amount"},
+}
+
+// MigrateMoneyToMinorUnits converts the money columns of an existing database from
+// floating point amounts to bigint minor units (12.34 becomes 1234). It has to run
+// before AutoMigrate, which would otherwise cast the old values and drop the cents.
+// Columns that are already bigint, or tables that don't exist yet, are skipped, so it
+// is safe to run on every start.
+func MigrateMoneyToMinorUnits(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range moneyColumns {
+			var dataType string
+			if err := tx.Raw(
+				"SELECT data_type FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?",
+				c.table, c.column,
+			).Scan(&dataType).Error; err != nil {
+				return err
+			}
+			if dataType == "" || dataType == "bigint" {
+				continue
+			}
+
+			stmt := fmt.Sprintf(
+				"ALTER TABLE %q ALTER COLUMN %q TYPE bigint USING ROUND(CAST(%q AS numeric) * 100)",
+				c.table, c.column, c.column,
+			)
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+			log.Printf("Converted %s.%s from %s to minor units", c.table, c.column, dataType)
+		}
+		return nil
+	})
+}
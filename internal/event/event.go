@@ -3,6 +3,7 @@ package event
 import (
 	"context"
 	"encoding/json"
+	"strconv"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -12,8 +13,10 @@ type Module string
 const (
 	ModuleBillCategory     Module = "BILL_CATEGORY"
 	ModuleBill             Module = "BILL"
+	ModuleBudget           Module = "BUDGET"
 	ModuleHome             Module = "HOME"
 	ModuleNotification     Module = "NOTIFICATION"
+	ModuleOCR              Module = "OCR"
 	ModuleHomeNotification Module = "HOME_NOTIFICATION"
 	ModulePoll             Module = "POLL"
 	ModuleRoom             Module = "ROOM"
@@ -26,29 +29,68 @@ const (
 type Action string
 
 const (
-	ActionCreated       Action = "CREATED"
-	ActionUpdated       Action = "UPDATED"
-	ActionDeleted       Action = "DELETED"
-	ActionMarkedPayed   Action = "MARKED_PAYED"
-	ActionClosed        Action = "CLOSED"
-	ActionVoted         Action = "VOTED"
-	ActionUnvoted       Action = "UNVOTED"
-	ActionMemberJoined  Action = "MEMBER_JOINED"
-	ActionMemberLeft    Action = "MEMBER_LEFT"
-	ActionMemberRemoved Action = "MEMBER_REMOVED"
-	ActionAssigned      Action = "ASSIGNED"
-	ActionCompleted     Action = "COMPLETED"
-	ActionUncompleted   Action = "UNCOMPLETED"
-	ActionMarkRead      Action = "MARK_READ"
+	ActionCreated          Action = "CREATED"
+	ActionUpdated          Action = "UPDATED"
+	ActionDeleted          Action = "DELETED"
+	ActionMarkedPayed      Action = "MARKED_PAYED"
+	ActionMarkedUnpayed    Action = "MARKED_UNPAYED"
+	ActionClosed           Action = "CLOSED"
+	ActionVoted            Action = "VOTED"
+	ActionUnvoted          Action = "UNVOTED"
+	ActionMemberJoined     Action = "MEMBER_JOINED"
+	ActionMemberLeft       Action = "MEMBER_LEFT"
+	ActionMemberRemoved    Action = "MEMBER_REMOVED"
+	ActionAssigned         Action = "ASSIGNED"
+	ActionCompleted        Action = "COMPLETED"
+	ActionUncompleted      Action = "UNCOMPLETED"
+	ActionMarkRead         Action = "MARK_READ"
+	ActionSettled          Action = "SETTLED"
+	ActionThresholdReached Action = "THRESHOLD_REACHED"
+	ActionFailed           Action = "FAILED"
 )
 
 type RealTimeEvent struct {
+	// ID is the event's position in its channel stream and serves as the replay cursor.
+	ID     string `json:"id,omitempty"`
 	Module Module `json:"module"`
 	Action Action `json:"action"`
 	Data   any    `json:"data"`
 }
 
-func SendEvent(ctx context.Context, cache *redis.Client, channel string, event *RealTimeEvent) {
-	payload, _ := json.Marshal(event)
-	cache.Publish(ctx, channel, payload)
+// HomeChannel returns the Pub/Sub channel for events visible to every member of a home.
+func HomeChannel(homeID int) string {
+	return "events:home:" + strconv.Itoa(homeID)
+}
+
+// UserChannel returns the Pub/Sub channel for events addressed to a single user.
+func UserChannel(userID int) string {
+	return "events:user:" + strconv.Itoa(userID)
+}
+
+// IsMembershipChange reports whether an event changes the set of homes a user belongs to.
+func IsMembershipChange(e *RealTimeEvent) bool {
+	if e.Module != ModuleHome {
+		return false
+	}
+	switch e.Action {
+	case ActionCreated, ActionDeleted, ActionMemberJoined, ActionMemberLeft, ActionMemberRemoved:
+		return true
+	}
+	return false
+}
+
+// SendEvent stores the event in the channel's stream and then publishes it,
+// so live subscribers receive the same ID that a replay would return.
+func SendEvent(ctx context.Context, cache *redis.Client, channel string, event *RealTimeEvent) error {
+	id, err := appendToStream(ctx, cache, channel, event)
+	if err != nil {
+		return err
+	}
+	event.ID = id
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return cache.Publish(ctx, channel, payload).Err()
 }
@@ -0,0 +1,127 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidCursor = errors.New("invalid event cursor")
+
+// retention is how long events stay replayable after they were sent.
+var retention = 24 * time.Hour
+
+// SetRetention changes how long events are kept in their streams.
+func SetRetention(d time.Duration) {
+	if d > 0 {
+		retention = d
+	}
+}
+
+func streamKey(channel string) string {
+	return "stream:" + channel
+}
+
+func appendToStream(ctx context.Context, cache *redis.Client, channel string, event *RealTimeEvent) (string, error) {
+	stored := *event
+	stored.ID = ""
+	payload, err := json.Marshal(&stored)
+	if err != nil {
+		return "", err
+	}
+
+	key := streamKey(channel)
+	minID := strconv.FormatInt(time.Now().Add(-retention).UnixMilli(), 10)
+	id, err := cache.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MinID:  minID,
+		Approx: true,
+		Values: map[string]interface{}{"event": payload},
+	}).Result()
+	if err != nil {
+		return "", err
+	}
+
+	// drop streams of channels that went quiet
+	cache.Expire(ctx, key, retention)
+
+	return id, nil
+}
+
+// ReadSince returns up to limit events sent on channel after the since cursor, oldest first.
+// An empty cursor returns the oldest retained events.
+func ReadSince(ctx context.Context, cache *redis.Client, channel, since string, limit int64) ([]RealTimeEvent, error) {
+	start := "-"
+	if since != "" {
+		if !ValidCursor(since) {
+			return nil, ErrInvalidCursor
+		}
+		start = "(" + since
+	}
+
+	entries, err := cache.XRangeN(ctx, streamKey(channel), start, "+", limit).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]RealTimeEvent, 0, len(entries))
+	for _, entry := range entries {
+		raw, _ := entry.Values["event"].(string)
+
+		var e RealTimeEvent
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			continue
+		}
+		e.ID = entry.ID
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// ValidCursor reports whether s is a stream ID of the form "<ms>" or "<ms>-<seq>".
+func ValidCursor(s string) bool {
+	_, _, ok := parseID(s)
+	return ok
+}
+
+// CompareIDs orders two stream IDs, returning -1, 0 or 1. Invalid IDs sort first.
+func CompareIDs(a, b string) int {
+	aMs, aSeq, _ := parseID(a)
+	bMs, bSeq, _ := parseID(b)
+
+	switch {
+	case aMs < bMs:
+		return -1
+	case aMs > bMs:
+		return 1
+	case aSeq < bSeq:
+		return -1
+	case aSeq > bSeq:
+		return 1
+	}
+	return 0
+}
+
+func parseID(s string) (uint64, uint64, bool) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !hasSeq {
+		return ms, 0, true
+	}
+
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/Dragodui/diploma-server/internal/http/middleware"
 	"github.com/Dragodui/diploma-server/internal/models"
@@ -24,12 +27,25 @@ func NewBillHandler(svc services.IBillService, homeRepo repository.HomeRepositor
 
 // GetByHomeID godoc
 // @Summary      Get bills by home ID
-// @Description  Get all bills in a home
+// @Description  Get one page of the bills in a home, newest first unless sorted otherwise. Pass next_cursor back as cursor for the next page.
 // @Tags         bill
 // @Produce      json
 // @Security     BearerAuth
 // @Param        home_id path int true "Home ID"
 // @Param        category_id query int false "Filter by category ID"
+// @Param        status query string false "paid, unpaid or overdue"
+// @Param        date_field query string false "What from and to apply to: period (default, bills whose period overlaps) or created"
+// @Param        from query string false "First day, YYYY-MM-DD"
+// @Param        to query string false "Last day, YYYY-MM-DD"
+// @Param        uploaded_by query int false "Filter by uploader"
+// @Param        participant_id query int false "Filter by a member with a split"
+// @Param        min_amount query string false "Smallest total, in the bill currency"
+// @Param        max_amount query string false "Largest total, in the bill currency"
+// @Param        search query string false "Text in the description"
+// @Param        sort query string false "created_at (default), period_start or total_amount"
+// @Param        order query string false "desc (default) or asc"
+// @Param        limit query int false "Page size, up to 100 (default 50)"
+// @Param        cursor query string false "next_cursor of the previous page"
 // @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
@@ -43,31 +59,179 @@ func (h *BillHandler) GetByHomeID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var categoryID *int
-	if catStr := r.URL.Query().Get("category_id"); catStr != "" {
-		catID, err := strconv.Atoi(catStr)
-		if err != nil {
-			utils.JSONError(w, "invalid category_id", http.StatusBadRequest)
-			return
-		}
-		categoryID = &catID
+	filter, err := billFilter(r)
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	filter.HomeID = homeID
 
-	bills, err := h.svc.GetBillsByHomeID(r.Context(), homeID, categoryID)
+	page, err := h.svc.GetBillsByHomeID(r.Context(), filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidBillFilter) {
+			utils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.SafeError(w, err, "Failed to retrieve bills", http.StatusInternalServerError)
 		return
 	}
 
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
-		"status": true,
-		"bills":  bills,
+		"status":      true,
+		"bills":       page.Bills,
+		"next_cursor": page.NextCursor,
 	})
 }
 
+// billFilter reads the query parameters of a bill listing
+func billFilter(r *http.Request) (models.BillFilter, error) {
+	q := r.URL.Query()
+	f := models.BillFilter{
+		Status:    q.Get("status"),
+		DateField: q.Get("date_field"),
+		Search:    q.Get("search"),
+		Sort:      q.Get("sort"),
+		Cursor:    q.Get("cursor"),
+	}
+
+	var err error
+	if f.CategoryID, err = queryID(q, "category_id"); err != nil {
+		return f, err
+	}
+	if f.UploadedBy, err = queryID(q, "uploaded_by"); err != nil {
+		return f, err
+	}
+	if f.ParticipantID, err = queryID(q, "participant_id"); err != nil {
+		return f, err
+	}
+	if f.From, err = queryDay(q, "from"); err != nil {
+		return f, err
+	}
+	if f.To, err = queryDay(q, "to"); err != nil {
+		return f, err
+	}
+	if f.MinAmount, err = queryMoney(q, "min_amount"); err != nil {
+		return f, err
+	}
+	if f.MaxAmount, err = queryMoney(q, "max_amount"); err != nil {
+		return f, err
+	}
+
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		f.Ascending = true
+	default:
+		return f, errors.New("order must be asc or desc")
+	}
+
+	if str := q.Get("limit"); str != "" {
+		limit, err := strconv.Atoi(str)
+		if err != nil || limit < 1 {
+			return f, errors.New("invalid limit")
+		}
+		f.Limit = limit
+	}
+
+	return f, nil
+}
+
+func queryID(q url.Values, name string) (*int, error) {
+	str := q.Get(name)
+	if str == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(str)
+	if err != nil {
+		return nil, errors.New("invalid " + name)
+	}
+	return &id, nil
+}
+
+func queryDay(q url.Values, name string) (*time.Time, error) {
+	str := q.Get(name)
+	if str == "" {
+		return nil, nil
+	}
+	day, err := time.Parse(time.DateOnly, str)
+	if err != nil {
+		return nil, errors.New("invalid " + name + " date, expected YYYY-MM-DD")
+	}
+	return &day, nil
+}
+
+func queryMoney(q url.Values, name string) (*models.Money, error) {
+	str := q.Get(name)
+	if str == "" {
+		return nil, nil
+	}
+	amount, err := models.ParseMoney(str)
+	if err != nil {
+		return nil, errors.New("invalid " + name)
+	}
+	return &amount, nil
+}
+
+// GetStats godoc
+// @Summary      Get spending statistics
+// @Description  Total, average and month-over-month spending in the home currency, grouped by category, uploader, split participant or month. Bills are dated by the start of their period.
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        from query string false "First day, YYYY-MM-DD"
+// @Param        to query string false "Last day, YYYY-MM-DD"
+// @Param        group_by query string false "category (default), uploader, participant or month"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/stats [get]
+func (h *BillHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	var from, to *time.Time
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		day, err := time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			utils.JSONError(w, "invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = &day
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		day, err := time.Parse(time.DateOnly, toStr)
+		if err != nil {
+			utils.JSONError(w, "invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = &day
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		utils.JSONError(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.svc.GetStats(r.Context(), homeID, from, to, r.URL.Query().Get("group_by"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStatsGroup) {
+			utils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.SafeError(w, err, "Failed to retrieve spending statistics", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "stats": stats})
+}
+
 // Create godoc
 // @Summary      Create a new bill
-// @Description  Create a new bill in a home
+// @Description  Create a new bill in a home. The created bill carries warnings when another bill of the home has the same receipt_hash or the same vendor, date and total in its ocr_data
 // @Tags         bill
 // @Accept       json
 // @Produce      json
@@ -103,12 +267,66 @@ func (h *BillHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.CreateBill(r.Context(), req.BillType, req.BillCategoryID, req.Description, req.ReceiptImage, req.TotalAmount, req.Start, req.End, req.OCRData, homeID, userID, req.Splits); err != nil {
+	bill, err := h.svc.CreateBill(r.Context(), req.BillType, req.BillCategoryID, req.Description, req.ReceiptImage, req.TotalAmount, req.Currency, req.Start, req.End, req.DueDate, req.OCRData, req.ReceiptHash, homeID, userID, req.SplitMode, req.Splits)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSplit) || errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.JSONError(w, "Invalid data", http.StatusBadRequest)
 		return
 	}
 
-	utils.JSON(w, http.StatusCreated, map[string]interface{}{"status": true, "message": "Created successfully"})
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{"status": true, "message": "Created successfully", "bill": bill})
+}
+
+// CreateFromReceipt godoc
+// @Summary      Create a bill from receipt items
+// @Description  Create a bill from OCR line items assigned to members. Each item is shared equally by its members, tax and discount lines are spread in proportion, and the item assignments are kept in ocr_data
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        input body models.CreateReceiptBillRequest true "Receipt items"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/receipt [post]
+func (h *BillHandler) CreateFromReceipt(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "Invalid home id", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateReceiptBillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	bill, err := h.svc.CreateBillFromReceipt(r.Context(), homeID, userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSplit) || errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.SafeError(w, err, "Failed to create bill", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{"status": true, "bill": bill})
 }
 
 // GetByID godoc
@@ -123,17 +341,11 @@ func (h *BillHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Failure      400  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
 // @Router       /homes/{home_id}/bills/{bill_id} [get]
 func (h *BillHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	billIDStr := chi.URLParam(r, "bill_id")
-	billID, err := strconv.Atoi(billIDStr)
-	if err != nil {
-		utils.JSONError(w, "invalid bill ID", http.StatusBadRequest)
-		return
-	}
-	bill, err := h.svc.GetBillByID(r.Context(), billID)
-	if err != nil {
-		utils.SafeError(w, err, "Failed to retrieve bill", http.StatusInternalServerError)
+	_, bill, ok := h.homeBill(w, r)
+	if !ok {
 		return
 	}
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
@@ -154,6 +366,7 @@ func (h *BillHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Failure      400  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
 // @Router       /homes/{home_id}/bills/{bill_id} [delete]
 func (h *BillHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r)
@@ -162,26 +375,59 @@ func (h *BillHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	billIDStr := chi.URLParam(r, "bill_id")
-	billID, err := strconv.Atoi(billIDStr)
-	if err != nil {
-		utils.JSONError(w, "invalid bill ID", http.StatusBadRequest)
+	homeID, bill, ok := h.homeBill(w, r)
+	if !ok {
 		return
 	}
+	billID := bill.ID
 
-	homeIDStr := chi.URLParam(r, "home_id")
-	homeID, err := strconv.Atoi(homeIDStr)
-	if err != nil {
-		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+	// Check ownership or admin
+	if bill.UploadedBy != userID {
+		isAdmin, _ := h.homeRepo.IsAdmin(r.Context(), homeID, userID)
+		if !isAdmin {
+			utils.JSONError(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := h.svc.Delete(r.Context(), billID); err != nil {
+		utils.SafeError(w, err, "Failed to delete bill", http.StatusInternalServerError)
+		return
+	}
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Deleted successfully"})
+}
+
+// Update godoc
+// @Summary      Update bill
+// @Description  Change any field of a bill and record the change in its history (uploader or admin only). Splits sent along replace the existing ones; a new total must match the splits.
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        bill_id path int true "Bill ID"
+// @Param        input body models.UpdateBillRequest true "Update Bill Request"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/{bill_id} [put]
+func (h *BillHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Check ownership or admin
-	bill, err := h.svc.GetBillByID(r.Context(), billID)
-	if err != nil {
-		utils.SafeError(w, err, "Failed to find bill", http.StatusInternalServerError)
+	homeID, bill, ok := h.homeBill(w, r)
+	if !ok {
 		return
 	}
+	billID := bill.ID
+
+	// Check ownership or admin
 	if bill.UploadedBy != userID {
 		isAdmin, _ := h.homeRepo.IsAdmin(r.Context(), homeID, userID)
 		if !isAdmin {
@@ -190,16 +436,61 @@ func (h *BillHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := h.svc.Delete(r.Context(), billID); err != nil {
-		utils.SafeError(w, err, "Failed to delete bill", http.StatusInternalServerError)
+	var req models.UpdateBillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
-	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Deleted successfully"})
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	updated, err := h.svc.UpdateBill(r.Context(), billID, userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSplit) || errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.SafeError(w, err, "Failed to update bill", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "bill": updated})
+}
+
+// GetRevisions godoc
+// @Summary      Get bill history
+// @Description  Get every change made to a bill, newest first
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        bill_id path int true "Bill ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/{bill_id}/revisions [get]
+func (h *BillHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	_, bill, ok := h.homeBill(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.svc.GetRevisions(r.Context(), bill.ID)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to retrieve bill history", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "revisions": revisions})
 }
 
 // MarkPayed godoc
 // @Summary      Mark bill as payed
-// @Description  Mark a bill as payed
+// @Description  Mark a bill as payed. PATCH on the bill itself is kept as an alias for older clients; edits go through PUT.
 // @Tags         bill
 // @Produce      json
 // @Security     BearerAuth
@@ -209,16 +500,22 @@ func (h *BillHandler) Delete(w http.ResponseWriter, r *http.Request) {
 // @Failure      400  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/{bill_id}/mark-payed [patch]
 // @Router       /homes/{home_id}/bills/{bill_id} [patch]
 func (h *BillHandler) MarkPayed(w http.ResponseWriter, r *http.Request) {
-	billIDStr := chi.URLParam(r, "bill_id")
-	billID, err := strconv.Atoi(billIDStr)
-	if err != nil {
-		utils.JSONError(w, "invalid bill ID", http.StatusBadRequest)
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	_, bill, ok := h.homeBill(w, r)
+	if !ok {
 		return
 	}
 
-	if err := h.svc.MarkBillPayed(r.Context(), billID); err != nil {
+	if err := h.svc.MarkBillPayed(r.Context(), bill.ID, userID); err != nil {
 		utils.SafeError(w, err, "Failed to mark bill as paid", http.StatusInternalServerError)
 		return
 	}
@@ -226,6 +523,40 @@ func (h *BillHandler) MarkPayed(w http.ResponseWriter, r *http.Request) {
 	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Updated successfully"})
 }
 
+// MarkUnpayed godoc
+// @Summary      Mark bill as unpayed
+// @Description  Undo marking a bill as payed
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        bill_id path int true "Bill ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/{bill_id}/mark-unpayed [patch]
+func (h *BillHandler) MarkUnpayed(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	_, bill, ok := h.homeBill(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.MarkBillUnpayed(r.Context(), bill.ID, userID); err != nil {
+		utils.SafeError(w, err, "Failed to mark bill as unpaid", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Updated successfully"})
+}
+
 // UpdateSplits godoc
 // @Summary      Update bill splits
 // @Description  Update how a bill is split between users (uploader or admin only)
@@ -235,9 +566,11 @@ func (h *BillHandler) MarkPayed(w http.ResponseWriter, r *http.Request) {
 // @Security     BearerAuth
 // @Param        home_id path int true "Home ID"
 // @Param        bill_id path int true "Bill ID"
+// @Param        input body models.UpdateSplitsRequest true "Update Splits Request"
 // @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]interface{}
 // @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
 // @Router       /homes/{home_id}/bills/{bill_id}/splits [put]
 func (h *BillHandler) UpdateSplits(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r)
@@ -246,26 +579,13 @@ func (h *BillHandler) UpdateSplits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	homeIDStr := chi.URLParam(r, "home_id")
-	homeID, err := strconv.Atoi(homeIDStr)
-	if err != nil {
-		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
-		return
-	}
-
-	billIDStr := chi.URLParam(r, "bill_id")
-	billID, err := strconv.Atoi(billIDStr)
-	if err != nil {
-		utils.JSONError(w, "invalid bill ID", http.StatusBadRequest)
+	homeID, bill, ok := h.homeBill(w, r)
+	if !ok {
 		return
 	}
+	billID := bill.ID
 
 	// Check ownership or admin
-	bill, err := h.svc.GetBillByID(r.Context(), billID)
-	if err != nil {
-		utils.SafeError(w, err, "Failed to find bill", http.StatusInternalServerError)
-		return
-	}
 	if bill.UploadedBy != userID {
 		isAdmin, _ := h.homeRepo.IsAdmin(r.Context(), homeID, userID)
 		if !isAdmin {
@@ -280,7 +600,16 @@ func (h *BillHandler) UpdateSplits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.UpdateSplits(r.Context(), billID, req.Splits); err != nil {
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	if err := h.svc.UpdateSplits(r.Context(), billID, userID, req.SplitMode, req.Splits); err != nil {
+		if errors.Is(err, services.ErrInvalidSplit) {
+			utils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.SafeError(w, err, "Failed to update splits", http.StatusInternalServerError)
 		return
 	}
@@ -307,13 +636,6 @@ func (h *BillHandler) MarkSplitPaid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	homeIDStr := chi.URLParam(r, "home_id")
-	homeID, err := strconv.Atoi(homeIDStr)
-	if err != nil {
-		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
-		return
-	}
-
 	splitIDStr := chi.URLParam(r, "split_id")
 	splitID, err := strconv.Atoi(splitIDStr)
 	if err != nil {
@@ -321,9 +643,14 @@ func (h *BillHandler) MarkSplitPaid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	homeID, bill, ok := h.homeBill(w, r)
+	if !ok {
+		return
+	}
+
 	// Check if user owns this split or is an admin
 	split, err := h.svc.GetSplitByID(r.Context(), splitID)
-	if err != nil || split == nil {
+	if err != nil || split == nil || split.BillID != bill.ID {
 		utils.JSONError(w, "split not found", http.StatusNotFound)
 		return
 	}
@@ -342,3 +669,30 @@ func (h *BillHandler) MarkSplitPaid(w http.ResponseWriter, r *http.Request) {
 
 	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Split marked as paid"})
 }
+
+// homeBill loads the bill in the URL. RequireMember only vouches for the home in the URL,
+// so a bill of another home is reported as not found.
+func (h *BillHandler) homeBill(w http.ResponseWriter, r *http.Request) (int, *models.Bill, bool) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return 0, nil, false
+	}
+
+	billID, err := strconv.Atoi(chi.URLParam(r, "bill_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid bill ID", http.StatusBadRequest)
+		return 0, nil, false
+	}
+
+	bill, err := h.svc.GetBillByID(r.Context(), billID)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to find bill", http.StatusInternalServerError)
+		return 0, nil, false
+	}
+	if bill == nil || bill.HomeID != homeID {
+		utils.JSONError(w, "bill not found", http.StatusNotFound)
+		return 0, nil, false
+	}
+	return homeID, bill, true
+}
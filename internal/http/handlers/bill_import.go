@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Dragodui/diploma-server/internal/http/middleware"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+const maxImportFileSize = 5 << 20 // 5 MB
+
+type BillImportHandler struct {
+	svc      services.IBillImportService
+	homeRepo repository.HomeRepository
+}
+
+func NewBillImportHandler(svc services.IBillImportService, homeRepo repository.HomeRepository) *BillImportHandler {
+	return &BillImportHandler{svc: svc, homeRepo: homeRepo}
+}
+
+func importError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrImportProfileNotFound), errors.Is(err, services.ErrVendorRuleNotFound),
+		errors.Is(err, services.ErrImportNotFound), errors.Is(err, services.ErrCategoryNotFound):
+		utils.JSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrImportCommitted):
+		utils.JSONError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidImportFile), errors.Is(err, services.ErrInvalidImportProfile),
+		errors.Is(err, services.ErrInvalidImportLine), errors.Is(err, services.ErrUnsupportedCurrency):
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+	default:
+		utils.SafeError(w, err, message, http.StatusInternalServerError)
+	}
+}
+
+// Preview godoc
+// @Summary      Upload a bank statement
+// @Description  Parse a CSV bank export with an import profile and return a preview of the rows; nothing is saved as a bill until the import is committed
+// @Tags         bill
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        file formData file true "CSV bank statement"
+// @Param        profile_id formData int true "Import profile ID"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/import [post]
+func (h *BillImportHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxImportFileSize); err != nil {
+		utils.JSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	profileID, err := strconv.Atoi(r.FormValue("profile_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid profile ID", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.JSONError(w, "Missing file field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxImportFileSize {
+		utils.JSONError(w, fmt.Sprintf("File size exceeds maximum of %d bytes", maxImportFileSize), http.StatusBadRequest)
+		return
+	}
+
+	imp, err := h.svc.Preview(r.Context(), homeID, userID, profileID, header.Filename, file)
+	if err != nil {
+		importError(w, err, "Failed to read statement")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{"status": true, "import": imp})
+}
+
+// GetImport godoc
+// @Summary      Get a statement import
+// @Description  Get the rows of an uploaded statement and whether it was committed
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        import_id path int true "Import ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/import/{import_id} [get]
+func (h *BillImportHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+	importID, err := strconv.Atoi(chi.URLParam(r, "import_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid import ID", http.StatusBadRequest)
+		return
+	}
+
+	imp, err := h.svc.GetImport(r.Context(), homeID, importID)
+	if err != nil {
+		importError(w, err, "Failed to retrieve import")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "import": imp})
+}
+
+// Commit godoc
+// @Summary      Commit a statement import
+// @Description  Create bills from the previewed rows. Without lines every new row is imported; listing a duplicate imports it anyway (uploader or admin only)
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        import_id path int true "Import ID"
+// @Param        input body models.CommitImportRequest false "Rows to import"
+// @Success      201  {object}  models.ImportResult
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/import/{import_id}/commit [post]
+func (h *BillImportHandler) Commit(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+	importID, err := strconv.Atoi(chi.URLParam(r, "import_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid import ID", http.StatusBadRequest)
+		return
+	}
+
+	// the body is optional; without one every new row is imported
+	var req models.CommitImportRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	imp, err := h.svc.GetImport(r.Context(), homeID, importID)
+	if err != nil {
+		importError(w, err, "Failed to retrieve import")
+		return
+	}
+
+	// Check ownership or admin
+	if imp.UserID != userID {
+		isAdmin, _ := h.homeRepo.IsAdmin(r.Context(), homeID, userID)
+		if !isAdmin {
+			utils.JSONError(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	result, err := h.svc.Commit(r.Context(), homeID, userID, importID, req)
+	if err != nil {
+		importError(w, err, "Failed to import bills")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, result)
+}
+
+// CreateProfile godoc
+// @Summary      Create an import profile
+// @Description  Describe the CSV layout of a bank export: delimiter, columns, date format and which sign marks an expense
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        input body models.CreateImportProfileRequest true "Import profile"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/import/profiles [post]
+func (h *BillImportHandler) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateImportProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	profile, err := h.svc.CreateProfile(r.Context(), homeID, req)
+	if err != nil {
+		importError(w, err, "Failed to create import profile")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{"status": true, "profile": profile})
+}
+
+// GetProfiles godoc
+// @Summary      Get import profiles
+// @Description  Get the bank statement layouts configured for a home
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/import/profiles [get]
+func (h *BillImportHandler) GetProfiles(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	profiles, err := h.svc.GetProfiles(r.Context(), homeID)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to retrieve import profiles", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "profiles": profiles})
+}
+
+// DeleteProfile godoc
+// @Summary      Delete an import profile
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        profile_id path int true "Profile ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/import/profiles/{profile_id} [delete]
+func (h *BillImportHandler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+	profileID, err := strconv.Atoi(chi.URLParam(r, "profile_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid profile ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.DeleteProfile(r.Context(), homeID, profileID); err != nil {
+		importError(w, err, "Failed to delete import profile")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Deleted successfully"})
+}
+
+// CreateRule godoc
+// @Summary      Create a vendor rule
+// @Description  File imported bills whose description contains the pattern under a category; the longest matching pattern wins
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        input body models.CreateVendorRuleRequest true "Vendor rule"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/import/rules [post]
+func (h *BillImportHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateVendorRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	rule, err := h.svc.CreateRule(r.Context(), homeID, req)
+	if err != nil {
+		importError(w, err, "Failed to create vendor rule")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{"status": true, "rule": rule})
+}
+
+// GetRules godoc
+// @Summary      Get vendor rules
+// @Description  Get the rules that assign categories to imported bills
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/import/rules [get]
+func (h *BillImportHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := h.svc.GetRules(r.Context(), homeID)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to retrieve vendor rules", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "rules": rules})
+}
+
+// DeleteRule godoc
+// @Summary      Delete a vendor rule
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        rule_id path int true "Rule ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/import/rules/{rule_id} [delete]
+func (h *BillImportHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+	ruleID, err := strconv.Atoi(chi.URLParam(r, "rule_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.DeleteRule(r.Context(), homeID, ruleID); err != nil {
+		importError(w, err, "Failed to delete vendor rule")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Deleted successfully"})
+}
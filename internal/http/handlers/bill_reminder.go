@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type BillReminderHandler struct {
+	svc services.IBillReminderService
+}
+
+func NewBillReminderHandler(svc services.IBillReminderService) *BillReminderHandler {
+	return &BillReminderHandler{svc}
+}
+
+// GetSettings godoc
+// @Summary      Get bill reminder settings
+// @Description  How many days before a bill is due its members are reminded to pay
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/reminders [get]
+func (h *BillReminderHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.svc.GetSettings(r.Context(), homeID)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to retrieve reminder settings", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "settings": settings})
+}
+
+// SetSettings godoc
+// @Summary      Set bill reminder settings
+// @Description  Change how many days before a bill is due its members are reminded to pay, 0 to 30 (admin only)
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        input body models.SetReminderSettingsRequest true "Set Reminder Settings Request"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/reminders [put]
+func (h *BillReminderHandler) SetSettings(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SetReminderSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	settings, err := h.svc.SetSettings(r.Context(), homeID, *req.LeadDays)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to save reminder settings", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "settings": settings})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Dragodui/diploma-server/internal/http/middleware"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type BillTemplateHandler struct {
+	svc      services.IBillTemplateService
+	homeRepo repository.HomeRepository
+}
+
+func NewBillTemplateHandler(svc services.IBillTemplateService, homeRepo repository.HomeRepository) *BillTemplateHandler {
+	return &BillTemplateHandler{svc: svc, homeRepo: homeRepo}
+}
+
+// Create godoc
+// @Summary      Create a recurring bill
+// @Description  Create a template the scheduler turns into a bill with splits at the start of every period
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        input body models.CreateBillTemplateRequest true "Create Bill Template Request"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/templates [post]
+func (h *BillTemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateBillTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	template, err := h.svc.CreateTemplate(r.Context(), homeID, userID, req.BillType, req.BillCategoryID, req.Description, req.Amount, req.Currency,
+		req.RecurrenceType, req.Interval, req.StartDate, req.SplitMode, req.Splits)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSplit) || errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.SafeError(w, err, "Failed to create recurring bill", http.StatusBadRequest)
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{"status": true, "template": template})
+}
+
+// GetByHomeID godoc
+// @Summary      Get recurring bills
+// @Description  Get all recurring bill templates in a home
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/templates [get]
+func (h *BillTemplateHandler) GetByHomeID(w http.ResponseWriter, r *http.Request) {
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	templates, err := h.svc.GetTemplatesByHomeID(r.Context(), homeID)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to retrieve recurring bills", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "templates": templates})
+}
+
+// Delete godoc
+// @Summary      Delete a recurring bill
+// @Description  Stop generating bills from a template; bills already created are kept (creator or admin only)
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        template_id path int true "Template ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bills/templates/{template_id} [delete]
+func (h *BillTemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	templateIDStr := chi.URLParam(r, "template_id")
+	templateID, err := strconv.Atoi(templateIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	template, err := h.svc.GetTemplateByID(r.Context(), templateID)
+	if err != nil || template.HomeID != homeID {
+		utils.JSONError(w, "template not found", http.StatusNotFound)
+		return
+	}
+
+	// Check ownership or admin
+	if template.CreatedBy != userID {
+		isAdmin, _ := h.homeRepo.IsAdmin(r.Context(), homeID, userID)
+		if !isAdmin {
+			utils.JSONError(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := h.svc.DeleteTemplate(r.Context(), templateID); err != nil {
+		utils.SafeError(w, err, "Failed to delete recurring bill", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Deleted successfully"})
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/http/middleware"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type BudgetHandler struct {
+	svc services.IBudgetService
+}
+
+func NewBudgetHandler(svc services.IBudgetService) *BudgetHandler {
+	return &BudgetHandler{svc}
+}
+
+// budgetPath reads the home and category IDs of a budget route
+func budgetPath(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	categoryID, err := strconv.Atoi(chi.URLParam(r, "category_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid category ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return homeID, categoryID, true
+}
+
+// budgetMonth reads the optional ?month=YYYY-MM, defaulting to the current month
+func budgetMonth(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	monthStr := r.URL.Query().Get("month")
+	if monthStr == "" {
+		return time.Now(), true
+	}
+	month, err := time.Parse("2006-01", monthStr)
+	if err != nil {
+		utils.JSONError(w, "invalid month, expected YYYY-MM", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+func budgetError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, services.ErrCategoryNotFound) || errors.Is(err, services.ErrBudgetNotFound) {
+		utils.JSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	utils.SafeError(w, err, message, http.StatusInternalServerError)
+}
+
+// Get godoc
+// @Summary      Get category budget
+// @Description  Get the monthly budget of a bill category
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        category_id path int true "Category ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bill_categories/{category_id}/budget [get]
+func (h *BudgetHandler) Get(w http.ResponseWriter, r *http.Request) {
+	homeID, categoryID, ok := budgetPath(w, r)
+	if !ok {
+		return
+	}
+
+	budget, err := h.svc.GetBudget(r.Context(), homeID, categoryID)
+	if err != nil {
+		budgetError(w, err, "Failed to retrieve budget")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "budget": budget})
+}
+
+// Set godoc
+// @Summary      Set category budget
+// @Description  Create or change the monthly budget of a bill category, in the home currency (admin only)
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        category_id path int true "Category ID"
+// @Param        input body models.SetBudgetRequest true "Set Budget Request"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bill_categories/{category_id}/budget [put]
+func (h *BudgetHandler) Set(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	homeID, categoryID, ok := budgetPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SetBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	budget, err := h.svc.SetBudget(r.Context(), homeID, categoryID, userID, req.Amount)
+	if err != nil {
+		budgetError(w, err, "Failed to set budget")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "budget": budget})
+}
+
+// Delete godoc
+// @Summary      Delete category budget
+// @Description  Remove the monthly budget of a bill category (admin only)
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        category_id path int true "Category ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bill_categories/{category_id}/budget [delete]
+func (h *BudgetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	homeID, categoryID, ok := budgetPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteBudget(r.Context(), homeID, categoryID); err != nil {
+		budgetError(w, err, "Failed to delete budget")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Deleted successfully"})
+}
+
+// GetStatus godoc
+// @Summary      Get category budget status
+// @Description  How much of a category's budget is spent and remaining in a month, in the home currency
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        category_id path int true "Category ID"
+// @Param        month query string false "Month, YYYY-MM (defaults to the current month)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bill_categories/{category_id}/budget/status [get]
+func (h *BudgetHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	homeID, categoryID, ok := budgetPath(w, r)
+	if !ok {
+		return
+	}
+	month, ok := budgetMonth(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.svc.GetStatus(r.Context(), homeID, categoryID, month)
+	if err != nil {
+		budgetError(w, err, "Failed to retrieve budget status")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "budget": status})
+}
+
+// GetAllStatuses godoc
+// @Summary      Get all budget statuses
+// @Description  Spent and remaining amounts of every category budget in the home for a month
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        month query string false "Month, YYYY-MM (defaults to the current month)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/bill_categories/budgets [get]
+func (h *BudgetHandler) GetAllStatuses(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+	month, ok := budgetMonth(w, r)
+	if !ok {
+		return
+	}
+
+	statuses, err := h.svc.GetStatuses(r.Context(), homeID, month)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to retrieve budgets", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "budgets": statuses})
+}
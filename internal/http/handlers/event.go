@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = 500
+)
+
+type EventHandler struct {
+	svc services.IEventService
+}
+
+func NewEventHandler(svc services.IEventService) *EventHandler {
+	return &EventHandler{svc}
+}
+
+// GetByHomeID godoc
+// @Summary      Get home events
+// @Description  Get the real-time events of a home sent after a cursor, oldest first
+// @Tags         event
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        since query string false "ID of the last event the client received"
+// @Param        limit query int false "Maximum number of events (default 100, max 500)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/events [get]
+func (h *EventHandler) GetByHomeID(w http.ResponseWriter, r *http.Request) {
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	limit := int64(defaultEventsLimit)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit <= 0 {
+			utils.JSONError(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		if limit > maxEventsLimit {
+			limit = maxEventsLimit
+		}
+	}
+
+	events, err := h.svc.GetHomeEvents(r.Context(), homeID, r.URL.Query().Get("since"), limit)
+	if err != nil {
+		if errors.Is(err, event.ErrInvalidCursor) {
+			utils.JSONError(w, "invalid since cursor", http.StatusBadRequest)
+			return
+		}
+		utils.SafeError(w, err, "Failed to retrieve events", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"status": true,
+		"events": events,
+	})
+}
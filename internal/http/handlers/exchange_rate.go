@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type ExchangeRateHandler struct {
+	svc services.IExchangeRateService
+}
+
+func NewExchangeRateHandler(svc services.IExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{svc}
+}
+
+// GetAll godoc
+// @Summary      Get exchange rates
+// @Description  Get the rate every supported currency converts into the home currency with
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/exchange_rates [get]
+func (h *ExchangeRateHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	rates, err := h.svc.GetRates(r.Context(), homeID)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to retrieve exchange rates", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"status": true,
+		"rates":  rates,
+	})
+}
+
+// Set godoc
+// @Summary      Set exchange rate
+// @Description  Override the built-in rate of a currency for this home (admin only)
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        currency path string true "Currency code"
+// @Param        input body models.SetExchangeRateRequest true "Set Exchange Rate Request"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/exchange_rates/{currency} [put]
+func (h *ExchangeRateHandler) Set(w http.ResponseWriter, r *http.Request) {
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+	currency := strings.ToUpper(chi.URLParam(r, "currency"))
+
+	var req models.SetExchangeRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	if err := h.svc.SetRate(r.Context(), homeID, currency, req.Rate); err != nil {
+		h.writeError(w, err, "Failed to set exchange rate")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Exchange rate updated"})
+}
+
+// Delete godoc
+// @Summary      Reset exchange rate
+// @Description  Drop the home's override so the built-in rate applies again (admin only)
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        currency path string true "Currency code"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/exchange_rates/{currency} [delete]
+func (h *ExchangeRateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+	currency := strings.ToUpper(chi.URLParam(r, "currency"))
+
+	if err := h.svc.DeleteRate(r.Context(), homeID, currency); err != nil {
+		h.writeError(w, err, "Failed to reset exchange rate")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Exchange rate reset"})
+}
+
+func (h *ExchangeRateHandler) writeError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, services.ErrUnsupportedCurrency) || errors.Is(err, services.ErrBaseCurrencyRate) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	utils.SafeError(w, err, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/logger"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type ExportHandler struct {
+	svc services.IExportService
+}
+
+func NewExportHandler(svc services.IExportService) *ExportHandler {
+	return &ExportHandler{svc: svc}
+}
+
+// countingWriter tells whether any of an export reached the client, after which
+// an error can no longer be reported as a JSON response
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// stream sets the download headers and runs write; they only reach the client with the first byte
+func stream(w http.ResponseWriter, r *http.Request, contentType, name string, write func(ctx context.Context, homeID int, w io.Writer) error) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="home-%d-%s"`, homeID, name))
+
+	cw := &countingWriter{w: w}
+	err = write(r.Context(), homeID, cw)
+	if err == nil {
+		return
+	}
+	if cw.n > 0 {
+		// the status is already sent; the client sees a truncated file
+		logger.Info.Printf("[Export] Export %s of home %d aborted: %v", name, homeID, err)
+		return
+	}
+
+	w.Header().Del("Content-Disposition")
+	if errors.Is(err, services.ErrUnknownExport) {
+		utils.JSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	utils.SafeError(w, err, "Failed to export home data", http.StatusInternalServerError)
+}
+
+// CSV godoc
+// @Summary      Export home data as CSV
+// @Description  Stream bills with splits, tasks with assignments, shopping items or poll results as CSV (admin only)
+// @Tags         export
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        dataset path string true "bills, tasks, shopping or polls"
+// @Success      200  {file}    file
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/export/{dataset}.csv [get]
+func (h *ExportHandler) CSV(w http.ResponseWriter, r *http.Request) {
+	dataset := chi.URLParam(r, "dataset")
+	name := fmt.Sprintf("%s-%s.csv", dataset, time.Now().Format(time.DateOnly))
+	stream(w, r, "text/csv; charset=utf-8", name, func(ctx context.Context, homeID int, w io.Writer) error {
+		return h.svc.WriteCSV(ctx, homeID, dataset, w)
+	})
+}
+
+// JSON godoc
+// @Summary      Export home data as JSON
+// @Description  Stream all bills, tasks, shopping items and poll results of a home as a single JSON archive (admin only)
+// @Tags         export
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/export/archive.json [get]
+func (h *ExportHandler) JSON(w http.ResponseWriter, r *http.Request) {
+	name := fmt.Sprintf("archive-%s.json", time.Now().Format(time.DateOnly))
+	stream(w, r, "application/json", name, h.svc.WriteJSON)
+}
+
+// Calendar godoc
+// @Summary      Export due tasks as a calendar
+// @Description  Stream the open tasks with a due date as an iCalendar (.ics) file (admin only)
+// @Tags         export
+// @Produce      text/calendar
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/export/tasks.ics [get]
+func (h *ExportHandler) Calendar(w http.ResponseWriter, r *http.Request) {
+	stream(w, r, "text/calendar; charset=utf-8", "tasks.ics", h.svc.WriteCalendar)
+}
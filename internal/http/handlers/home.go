@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -23,7 +24,7 @@ func NewHomeHandler(svc services.IHomeService) *HomeHandler {
 
 // Create godoc
 // @Summary      Create a new home
-// @Description  Create a new home with a name
+// @Description  Create a new home with a name and the currency balances are kept in
 // @Tags         home
 // @Accept       json
 // @Produce      json
@@ -52,7 +53,11 @@ func (h *HomeHandler) Create(w http.ResponseWriter, r *http.Request) {
 		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	if err := h.svc.CreateHome(r.Context(), req.Name, userID); err != nil {
+	if err := h.svc.CreateHome(r.Context(), req.Name, req.Currency, userID); err != nil {
+		if errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.JSONError(w, "Invalid data", http.StatusBadRequest)
 		return
 	}
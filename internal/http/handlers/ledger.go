@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type LedgerHandler struct {
+	svc services.ILedgerService
+}
+
+func NewLedgerHandler(svc services.ILedgerService) *LedgerHandler {
+	return &LedgerHandler{svc}
+}
+
+// GetBalances godoc
+// @Summary      Get home balances
+// @Description  Get each member's net position across unpaid bill splits and the transfers that settle them
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/balances [get]
+func (h *LedgerHandler) GetBalances(w http.ResponseWriter, r *http.Request) {
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	balances, err := h.svc.GetBalances(r.Context(), homeID)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to retrieve balances", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"status":    true,
+		"balances":  balances.Balances,
+		"transfers": balances.Transfers,
+	})
+}
@@ -1,120 +1,249 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/Dragodui/diploma-server/internal/http/middleware"
+	"github.com/Dragodui/diploma-server/internal/logger"
+	"github.com/Dragodui/diploma-server/internal/models"
 	"github.com/Dragodui/diploma-server/internal/services"
 	"github.com/Dragodui/diploma-server/internal/utils"
-	"github.com/google/uuid"
+	"github.com/go-chi/chi/v5"
 )
 
-const maxOCRFileSize = 10 << 20 // 10 MB
+const (
+	maxOCRFileSize = 10 << 20 // 10 MB
+	// a long receipt is photographed in a few pieces
+	maxOCRFiles = 5
+)
 
+// allowedOCRTypes maps detected content types to the MIME type passed to the OCR provider
 var allowedOCRTypes = map[string]string{
-	"application/pdf": ".pdf",
-	"image/jpeg":      ".jpg",
-	"image/png":       ".png",
-	"image/gif":       ".gif",
-	"image/webp":      ".webp",
-	"image/bmp":       ".bmp",
+	"application/pdf":           "application/pdf",
+	"image/jpeg":                "image/jpeg",
+	"image/png":                 "image/png",
+	"image/gif":                 "image/gif",
+	"image/webp":                "image/webp",
+	"image/bmp":                 "image/bmp",
+	"text/plain; charset=utf-8": "text/plain",
 }
 
 type OCRHandler struct {
-	svc services.IOCRService
+	svc  services.IOCRService
+	jobs services.IOCRJobService
+}
+
+func NewOCRHandler(svc services.IOCRService, jobs services.IOCRJobService) *OCRHandler {
+	return &OCRHandler{svc: svc, jobs: jobs}
+}
+
+// ocrHomeID returns the home of the home-scoped OCR routes, or 0 on the others
+func ocrHomeID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	homeIDStr := chi.URLParam(r, "home_id")
+	if homeIDStr == "" {
+		return 0, true
+	}
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "Invalid home id", http.StatusBadRequest)
+		return 0, false
+	}
+	return homeID, true
+}
+
+// readReceipt reads and checks the uploaded files of a receipt, writing the error response when it fails
+func readReceipt(w http.ResponseWriter, r *http.Request) ([]models.OCRFile, bool) {
+	if err := r.ParseMultipartForm(maxOCRFileSize); err != nil {
+		utils.JSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+		return nil, false
+	}
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		utils.JSONError(w, "Missing file field", http.StatusBadRequest)
+		return nil, false
+	}
+	if len(headers) > maxOCRFiles {
+		utils.JSONError(w, fmt.Sprintf("At most %d files per receipt", maxOCRFiles), http.StatusBadRequest)
+		return nil, false
+	}
+
+	files := make([]models.OCRFile, 0, len(headers))
+	for _, header := range headers {
+		file, ok := readReceiptFile(w, header)
+		if !ok {
+			return nil, false
+		}
+		files = append(files, file)
+	}
+	return files, true
 }
 
-func NewOCRHandler(svc services.IOCRService) *OCRHandler {
-	return &OCRHandler{svc: svc}
+func readReceiptFile(w http.ResponseWriter, header *multipart.FileHeader) (models.OCRFile, bool) {
+	if header.Size > maxOCRFileSize {
+		utils.JSONError(w, fmt.Sprintf("File size exceeds maximum of %d bytes", maxOCRFileSize), http.StatusBadRequest)
+		return models.OCRFile{}, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		utils.JSONError(w, "Failed to read file", http.StatusBadRequest)
+		return models.OCRFile{}, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxOCRFileSize))
+	if err != nil || len(data) == 0 {
+		utils.JSONError(w, "Failed to read file", http.StatusBadRequest)
+		return models.OCRFile{}, false
+	}
+
+	// Detect content type from file content
+	contentType := http.DetectContentType(data)
+
+	// Check if PDF by extension (DetectContentType may not detect PDF reliably)
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	if ext == ".pdf" {
+		contentType = "application/pdf"
+	}
+
+	// Validate content type
+	mimeType, ok := allowedOCRTypes[contentType]
+	if !ok {
+		utils.JSONError(w, fmt.Sprintf("Unsupported file type: %s. Allowed: PDF, JPEG, PNG, GIF, WebP, BMP, TXT", contentType), http.StatusBadRequest)
+		return models.OCRFile{}, false
+	}
+
+	return models.OCRFile{Data: data, MimeType: mimeType}, true
 }
 
 // Process godoc
-// @Summary      Process receipt file (image or PDF) with OCR
-// @Description  Upload a file directly for OCR processing. Supports images and PDFs.
+// @Summary      Process receipt files (images, PDF or text) with OCR
+// @Description  Upload a receipt directly for OCR processing: one file, or up to 5 photos of a long receipt in order, each in its own file field. Supports images, PDFs and plain text; the offline provider reads only PDFs with a text layer and plain text. Pages are merged into one result, with overlapping lines kept once, and pages lists the confidence of each page.
 // @Tags         ocr
 // @Accept       multipart/form-data
 // @Produce      json
 // @Security     BearerAuth
-// @Param        file formData file true "Receipt file (image or PDF)"
+// @Param        file formData file true "Receipt file (image, PDF or text); repeat the field for more pieces of one receipt"
 // @Param        language formData string false "Language of the receipt text"
+// @Param        home_id path int false "Home ID; on the home route a bill category is suggested from the home's past bills"
 // @Success      200  {object}  models.OCRResult
 // @Failure      400  {object}  map[string]interface{}
+// @Failure      415  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /ocr/process [post]
+// @Router       /homes/{home_id}/ocr/process [post]
 func (h *OCRHandler) Process(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(maxOCRFileSize); err != nil {
-		utils.JSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+	homeID, ok := ocrHomeID(w, r)
+	if !ok {
 		return
 	}
-
-	file, header, err := r.FormFile("file")
-	if err != nil {
-		utils.JSONError(w, "Missing file field", http.StatusBadRequest)
+	files, ok := readReceipt(w, r)
+	if !ok {
 		return
 	}
-	defer file.Close()
 
-	if header.Size > maxOCRFileSize {
-		utils.JSONError(w, fmt.Sprintf("File size exceeds maximum of %d bytes", maxOCRFileSize), http.StatusBadRequest)
+	result, err := h.svc.Process(r.Context(), files, r.FormValue("language"))
+	if errors.Is(err, services.ErrUnsupportedOCRFile) {
+		utils.JSONError(w, err.Error(), http.StatusUnsupportedMediaType)
 		return
 	}
-
-	// Detect content type from file content
-	buf := make([]byte, 512)
-	n, err := file.Read(buf)
 	if err != nil {
-		utils.JSONError(w, "Failed to read file", http.StatusBadRequest)
+		utils.SafeError(w, err, "OCR processing failed", http.StatusInternalServerError)
 		return
 	}
-	contentType := http.DetectContentType(buf[:n])
 
-	// Reset file reader
-	if _, err := file.Seek(0, 0); err != nil {
-		utils.JSONError(w, "Failed to process file", http.StatusInternalServerError)
-		return
+	if homeID != 0 {
+		// the receipt is read either way, a suggestion is a bonus
+		if err := h.svc.SuggestCategory(r.Context(), homeID, result); err != nil {
+			logger.Info.Printf("[OCR] Error suggesting a category for home %d: %v", homeID, err)
+		}
 	}
 
-	// Check if PDF by extension (DetectContentType may not detect PDF reliably)
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if ext == ".pdf" {
-		contentType = "application/pdf"
+	utils.JSON(w, http.StatusOK, result)
+}
+
+// CreateJob godoc
+// @Summary      Queue a receipt for OCR
+// @Description  Upload a receipt, in up to 5 files like for /ocr/process, and get a job ID at once. Poll the job for its result, or wait for the OCR COMPLETED (or FAILED) event on your event channel.
+// @Tags         ocr
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file formData file true "Receipt file (image, PDF or text); repeat the field for more pieces of one receipt"
+// @Param        language formData string false "Language of the receipt text"
+// @Param        home_id path int false "Home ID; on the home route a bill category is suggested from the home's past bills"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /ocr/jobs [post]
+// @Router       /homes/{home_id}/ocr/jobs [post]
+func (h *OCRHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
-	// Validate content type
-	fileExt, ok := allowedOCRTypes[contentType]
+	homeID, ok := ocrHomeID(w, r)
+	if !ok {
+		return
+	}
+	files, ok := readReceipt(w, r)
 	if !ok {
-		utils.JSONError(w, fmt.Sprintf("Unsupported file type: %s. Allowed: PDF, JPEG, PNG, GIF, WebP, BMP", contentType), http.StatusBadRequest)
 		return
 	}
 
-	// Save to temp file
-	tempPath := filepath.Join(os.TempDir(), fmt.Sprintf("ocr_%s%s", uuid.New().String(), fileExt))
-	outFile, err := os.Create(tempPath)
+	job, err := h.jobs.Submit(r.Context(), userID, homeID, files, r.FormValue("language"))
 	if err != nil {
-		utils.JSONError(w, "Failed to process file", http.StatusInternalServerError)
+		utils.SafeError(w, err, "Failed to queue OCR job", http.StatusInternalServerError)
 		return
 	}
 
-	if _, err := io.Copy(outFile, file); err != nil {
-		outFile.Close()
-		os.Remove(tempPath)
-		utils.JSONError(w, "Failed to save file", http.StatusInternalServerError)
+	utils.JSON(w, http.StatusAccepted, map[string]interface{}{
+		"status": true,
+		"job":    job,
+	})
+}
+
+// GetJob godoc
+// @Summary      Get an OCR job
+// @Description  Get the status of an OCR job and, once completed, its result
+// @Tags         ocr
+// @Produce      json
+// @Security     BearerAuth
+// @Param        job_id path string true "Job ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /ocr/jobs/{job_id} [get]
+func (h *OCRHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	outFile.Close()
-	defer os.Remove(tempPath)
 
-	language := r.FormValue("language")
-
-	result, err := h.svc.ProcessFile(r.Context(), tempPath, language)
+	job, err := h.jobs.Get(r.Context(), userID, chi.URLParam(r, "job_id"))
+	if errors.Is(err, services.ErrOCRJobNotFound) {
+		utils.JSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		utils.SafeError(w, err, "OCR processing failed", http.StatusInternalServerError)
+		utils.SafeError(w, err, "Failed to get OCR job", http.StatusInternalServerError)
 		return
 	}
 
-	utils.JSON(w, http.StatusOK, result)
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"status": true,
+		"job":    job,
+	})
 }
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Dragodui/diploma-server/internal/http/middleware"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type SettlementHandler struct {
+	svc      services.ISettlementService
+	homeRepo repository.HomeRepository
+}
+
+func NewSettlementHandler(svc services.ISettlementService, homeRepo repository.HomeRepository) *SettlementHandler {
+	return &SettlementHandler{svc: svc, homeRepo: homeRepo}
+}
+
+// Create godoc
+// @Summary      Record a payment
+// @Description  Record a full or partial payment from one member to another. Recording on behalf of someone else is allowed for the receiver or an admin.
+// @Tags         bill
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        input body models.CreateSettlementRequest true "Create Settlement Request"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/settlements [post]
+func (h *SettlementHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateSettlementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	fromID := userID
+	if req.FromUserID != nil {
+		fromID = *req.FromUserID
+	}
+
+	// Only the payer, the receiver or an admin may record a payment
+	if fromID != userID && req.ToUserID != userID {
+		isAdmin, _ := h.homeRepo.IsAdmin(r.Context(), homeID, userID)
+		if !isAdmin {
+			utils.JSONError(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	for _, memberID := range []int{fromID, req.ToUserID} {
+		isMember, err := h.homeRepo.IsMember(r.Context(), homeID, memberID)
+		if err != nil {
+			utils.SafeError(w, err, "Failed to record payment", http.StatusInternalServerError)
+			return
+		}
+		if !isMember {
+			utils.JSONError(w, "both users must be members of this home", http.StatusBadRequest)
+			return
+		}
+	}
+
+	settlement, err := h.svc.RecordSettlement(r.Context(), homeID, fromID, req.ToUserID, req.Amount, req.Note)
+	if err != nil {
+		if errors.Is(err, services.ErrSelfSettlement) {
+			utils.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.SafeError(w, err, "Failed to record payment", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{
+		"status":     true,
+		"settlement": settlement,
+	})
+}
+
+// GetByHomeID godoc
+// @Summary      Get payments by home ID
+// @Description  Get all recorded payments between members of a home, newest first
+// @Tags         bill
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/settlements [get]
+func (h *SettlementHandler) GetByHomeID(w http.ResponseWriter, r *http.Request) {
+	homeIDStr := chi.URLParam(r, "home_id")
+	homeID, err := strconv.Atoi(homeIDStr)
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	settlements, err := h.svc.GetSettlementsByHomeID(r.Context(), homeID)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to retrieve payments", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"status":      true,
+		"settlements": settlements,
+	})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -475,3 +476,90 @@ func (h *ShoppingHandler) EditItem(w http.ResponseWriter, r *http.Request) {
 
 	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Edited successfully"})
 }
+
+// ReconcileReceipt godoc
+// @Summary      Match receipt items against the shopping list
+// @Description  Propose which open shopping items of the home the items of a scanned receipt cover, matching names loosely. Receipt items that match nothing are listed as unmatched. Nothing is changed until the purchases are confirmed
+// @Tags         shopping
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        input body models.ReconcileReceiptRequest true "Receipt items"
+// @Success      200  {object}  models.ShoppingReconciliation
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/shopping/reconcile [post]
+func (h *ShoppingHandler) ReconcileReceipt(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ReconcileReceiptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	reconciliation, err := h.svc.ReconcileReceipt(r.Context(), homeID, req.Items)
+	if err != nil {
+		utils.SafeError(w, err, "Failed to match the receipt", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "reconciliation": reconciliation})
+}
+
+// ConfirmPurchases godoc
+// @Summary      Mark receipt matches as bought
+// @Description  Mark the accepted matches of a receipt as bought, recording the price paid for each. Either all of the items are marked or none
+// @Tags         shopping
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        home_id path int true "Home ID"
+// @Param        input body models.ConfirmPurchasesRequest true "Purchases"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /homes/{home_id}/shopping/reconcile/confirm [post]
+func (h *ShoppingHandler) ConfirmPurchases(w http.ResponseWriter, r *http.Request) {
+	homeID, err := strconv.Atoi(chi.URLParam(r, "home_id"))
+	if err != nil {
+		utils.JSONError(w, "invalid home ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ConfirmPurchasesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.JSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.JSONValidationErrors(w, err)
+		return
+	}
+
+	if err := h.svc.ConfirmPurchases(r.Context(), homeID, req.Purchases); err != nil {
+		switch {
+		case errors.Is(err, services.ErrShoppingItemNotOpen):
+			utils.JSONError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, models.ErrInvalidMoney):
+			utils.JSONError(w, "Invalid price", http.StatusBadRequest)
+		default:
+			utils.SafeError(w, err, "Failed to mark items as bought", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Marked successfully"})
+}
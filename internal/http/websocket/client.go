@@ -0,0 +1,284 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/repository"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	writeTimeout = 10 * time.Second
+
+	// sendQueueSize is how many messages may wait for a client before it counts as a slow consumer
+	sendQueueSize = 256
+)
+
+var errNotMember = errors.New("not a member of this home")
+
+// Client holds the state of a single WebSocket connection.
+type Client struct {
+	conn   *websocket.Conn
+	userID int
+	hub    *Hub
+
+	send      chan *redis.Message // messages routed to this client by the hub
+	done      chan struct{}       // closed when the client must disconnect
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	homes   map[int]bool // homes the user is an approved member of
+	muted   map[int]bool // member homes the client has unsubscribed from
+	lastAck string       // ID of the last event the client acknowledged
+
+	// event streams read to replay missed events; replay is off while nil
+	cache *redis.Client
+
+	// gorilla/websocket allows only one concurrent writer. writeMu also guards replayed,
+	// the last replayed ID per channel, so live events already replayed are skipped
+	writeMu  sync.Mutex
+	replayed map[string]string
+}
+
+func NewClient(conn *websocket.Conn, userID int, hub *Hub) *Client {
+	return &Client{
+		conn:   conn,
+		userID: userID,
+		hub:    hub,
+		send:   make(chan *redis.Message, sendQueueSize),
+		done:   make(chan struct{}),
+		homes:  make(map[int]bool),
+		muted:  make(map[int]bool),
+
+		replayed: make(map[string]string),
+	}
+}
+
+// Messages returns the client's send queue.
+func (c *Client) Messages() <-chan *redis.Message {
+	return c.send
+}
+
+// Done is closed once the client has been told to disconnect.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Client) close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+func (c *Client) LastAck() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastAck
+}
+
+// SyncHomes reloads the user's memberships, subscribing to newly joined homes
+// and unsubscribing from homes the user no longer belongs to.
+func (c *Client) SyncHomes(ctx context.Context, homeRepo repository.HomeRepository) error {
+	homeIDs, err := homeRepo.GetUserHomeIDs(ctx, c.userID)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := make(map[int]bool, len(homeIDs))
+	var subscribe []string
+	for _, id := range homeIDs {
+		current[id] = true
+		if !c.homes[id] && !c.muted[id] {
+			subscribe = append(subscribe, event.HomeChannel(id))
+		}
+	}
+
+	var unsubscribe []string
+	for id := range c.homes {
+		if !current[id] {
+			if !c.muted[id] {
+				unsubscribe = append(unsubscribe, event.HomeChannel(id))
+			}
+			delete(c.muted, id)
+		}
+	}
+
+	if len(subscribe) > 0 {
+		if err := c.hub.Subscribe(ctx, c, subscribe...); err != nil {
+			return err
+		}
+	}
+	if len(unsubscribe) > 0 {
+		if err := c.hub.Unsubscribe(ctx, c, unsubscribe...); err != nil {
+			return err
+		}
+	}
+
+	c.homes = current
+	return nil
+}
+
+// channels lists the channels the client currently receives events from.
+func (c *Client) channels() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	channels := []string{event.UserChannel(c.userID)}
+	for id := range c.homes {
+		if !c.muted[id] {
+			channels = append(channels, event.HomeChannel(id))
+		}
+	}
+	return channels
+}
+
+// Follow resumes events for a home the client previously unsubscribed from. When since
+// is set, the events of the home sent after it are replayed first.
+func (c *Client) Follow(ctx context.Context, homeID int, since string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.homes[homeID] {
+		return errNotMember
+	}
+	if !c.muted[homeID] {
+		return nil
+	}
+
+	channel := event.HomeChannel(homeID)
+	if since == "" || c.cache == nil {
+		if err := c.hub.Subscribe(ctx, c, channel); err != nil {
+			return err
+		}
+		delete(c.muted, homeID)
+		return nil
+	}
+
+	// live events wait for the writer until the missed ones are out, so none is sent twice
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.hub.Subscribe(ctx, c, channel); err != nil {
+		return err
+	}
+	delete(c.muted, homeID)
+
+	if err := c.replayLocked(ctx, []string{channel}, since); err != nil {
+		log.Printf("Failed to replay events of home %d for user %d: %v", homeID, c.userID, err)
+	}
+	return nil
+}
+
+// Unfollow stops events for a home without affecting the user's membership.
+func (c *Client) Unfollow(ctx context.Context, homeID int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.homes[homeID] {
+		return errNotMember
+	}
+	if c.muted[homeID] {
+		return nil
+	}
+
+	if err := c.hub.Unsubscribe(ctx, c, event.HomeChannel(homeID)); err != nil {
+		return err
+	}
+	c.muted[homeID] = true
+	return nil
+}
+
+// Ack records the last event the client has handled. It is the replay cursor when the
+// client subscribes to a home again without giving one.
+func (c *Client) Ack(eventID string) {
+	c.mu.Lock()
+	c.lastAck = eventID
+	c.mu.Unlock()
+}
+
+// replay writes the events sent after since on the given channels, oldest first.
+func (c *Client) replay(ctx context.Context, channels []string, since string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.replayLocked(ctx, channels, since)
+}
+
+func (c *Client) replayLocked(ctx context.Context, channels []string, since string) error {
+	var missed []event.RealTimeEvent
+	for _, channel := range channels {
+		events, err := event.ReadSince(ctx, c.cache, channel, since, replayLimit)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			last := events[len(events)-1].ID
+			if event.CompareIDs(last, c.replayed[channel]) > 0 {
+				c.replayed[channel] = last
+			}
+		}
+		missed = append(missed, events...)
+	}
+
+	sort.SliceStable(missed, func(i, j int) bool {
+		return event.CompareIDs(missed[i].ID, missed[j].ID) < 0
+	})
+
+	for i := range missed {
+		data, err := json.Marshal(&missed[i])
+		if err != nil {
+			return err
+		}
+		if err := c.writeLocked(websocket.TextMessage, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEvent sends a live event unless it has already gone out in a replay.
+func (c *Client) writeEvent(channel, eventID string, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if last, ok := c.replayed[channel]; ok && eventID != "" && event.CompareIDs(eventID, last) <= 0 {
+		return nil
+	}
+	return c.writeLocked(websocket.TextMessage, data)
+}
+
+func (c *Client) write(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.writeLocked(messageType, data)
+}
+
+func (c *Client) writeLocked(messageType int, data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteMessage(messageType, data)
+}
+
+func (c *Client) writeJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.write(websocket.TextMessage, data)
+}
+
+func (c *Client) ping() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+}
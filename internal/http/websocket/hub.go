@@ -0,0 +1,228 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/Dragodui/diploma-server/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// Subscriber is the Redis Pub/Sub connection shared by the hub. *redis.PubSub implements it.
+type Subscriber interface {
+	Subscribe(ctx context.Context, channels ...string) error
+	Unsubscribe(ctx context.Context, channels ...string) error
+	Channel(opts ...redis.ChannelOption) <-chan *redis.Message
+	Close() error
+}
+
+// Hub owns the process-wide Redis subscription and routes each message in memory
+// to the send queues of the clients listening on its channel.
+type Hub struct {
+	sub Subscriber
+
+	mu       sync.Mutex
+	channels map[string]map[*Client]bool // channel -> listening clients
+	clients  map[*Client]map[string]bool // client -> channels it listens on
+
+	// subMu puts the Redis calls in order without holding up dispatch; subscribed is
+	// what Redis has been asked for and is guarded by subMu
+	subMu      sync.Mutex
+	subscribed map[string]bool
+}
+
+func NewHub(sub Subscriber) *Hub {
+	return &Hub{
+		sub:        sub,
+		channels:   make(map[string]map[*Client]bool),
+		clients:    make(map[*Client]map[string]bool),
+		subscribed: make(map[string]bool),
+	}
+}
+
+// Run forwards messages from Redis until the subscription is closed.
+func (h *Hub) Run() {
+	for msg := range h.sub.Channel() {
+		h.dispatch(msg)
+	}
+}
+
+func (h *Hub) Close() error {
+	return h.sub.Close()
+}
+
+// Subscribe starts routing the given channels to the client. Redis is only asked to
+// subscribe to channels that had no listeners yet.
+func (h *Hub) Subscribe(ctx context.Context, client *Client, channels ...string) error {
+	h.mu.Lock()
+	var attached []string
+	for _, channel := range channels {
+		if h.channels[channel] == nil {
+			h.channels[channel] = make(map[*Client]bool)
+		}
+		if h.channels[channel][client] {
+			continue
+		}
+		h.channels[channel][client] = true
+		attached = append(attached, channel)
+
+		if h.clients[client] == nil {
+			h.clients[client] = make(map[string]bool)
+		}
+		h.clients[client][channel] = true
+	}
+	h.mu.Unlock()
+
+	if err := h.sync(ctx, attached); err != nil {
+		h.mu.Lock()
+		for _, channel := range attached {
+			h.remove(client, channel)
+		}
+		h.mu.Unlock()
+
+		if err := h.sync(context.Background(), attached); err != nil {
+			log.Printf("Failed to unsubscribe from %v: %v", attached, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Unsubscribe stops routing the given channels to the client.
+func (h *Hub) Unsubscribe(ctx context.Context, client *Client, channels ...string) error {
+	h.mu.Lock()
+	var emptied []string
+	for _, channel := range channels {
+		if h.remove(client, channel) {
+			emptied = append(emptied, channel)
+		}
+	}
+	h.mu.Unlock()
+
+	return h.sync(ctx, emptied)
+}
+
+// Unregister removes the client from every channel.
+func (h *Hub) Unregister(client *Client) {
+	h.mu.Lock()
+	emptied := h.detach(client)
+	h.mu.Unlock()
+
+	if err := h.sync(context.Background(), emptied); err != nil {
+		log.Printf("Failed to unsubscribe from %v: %v", emptied, err)
+	}
+}
+
+// dispatch queues msg for every listening client without blocking. Clients whose
+// queue is full are disconnected so one slow phone cannot stall the others.
+func (h *Hub) dispatch(msg *redis.Message) {
+	h.mu.Lock()
+	var emptied []string
+	for client := range h.channels[msg.Channel] {
+		select {
+		case client.send <- msg:
+		default:
+			metrics.WSDroppedMessagesTotal.Inc()
+			log.Printf("Disconnecting slow WS consumer for user %d", client.userID)
+			emptied = append(emptied, h.detach(client)...)
+			client.close()
+		}
+	}
+	h.mu.Unlock()
+
+	if len(emptied) > 0 {
+		// the next message must not wait for Redis
+		go func() {
+			if err := h.sync(context.Background(), emptied); err != nil {
+				log.Printf("Failed to unsubscribe from %v: %v", emptied, err)
+			}
+		}()
+	}
+}
+
+// detach removes the client from all channels and returns the channels left without listeners.
+// The caller must hold h.mu.
+func (h *Hub) detach(client *Client) []string {
+	var emptied []string
+	for channel := range h.clients[client] {
+		if h.remove(client, channel) {
+			emptied = append(emptied, channel)
+		}
+	}
+	return emptied
+}
+
+// remove drops one channel of a client and reports whether the channel has no listeners left.
+// The caller must hold h.mu.
+func (h *Hub) remove(client *Client, channel string) bool {
+	listeners, ok := h.channels[channel]
+	if !ok || !listeners[client] {
+		return false
+	}
+
+	delete(listeners, client)
+	delete(h.clients[client], channel)
+	if len(h.clients[client]) == 0 {
+		delete(h.clients, client)
+	}
+
+	if len(listeners) == 0 {
+		delete(h.channels, channel)
+		return true
+	}
+	return false
+}
+
+// sync makes Redis subscribed to those of the channels that have listeners and to none
+// of the others. Calls run one at a time and read the listeners afresh, so the last one
+// leaves Redis in line with the hub however subscribes and unsubscribes interleave.
+func (h *Hub) sync(ctx context.Context, channels []string) error {
+	if len(channels) == 0 {
+		return nil
+	}
+
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+
+	var add, drop []string
+	seen := make(map[string]bool, len(channels))
+	h.mu.Lock()
+	for _, channel := range channels {
+		if seen[channel] {
+			continue
+		}
+		seen[channel] = true
+
+		listened := h.channels[channel] != nil
+		switch {
+		case listened && !h.subscribed[channel]:
+			add = append(add, channel)
+		case !listened && h.subscribed[channel]:
+			drop = append(drop, channel)
+		}
+	}
+	h.mu.Unlock()
+
+	var errs []error
+	if len(drop) > 0 {
+		if err := h.sub.Unsubscribe(ctx, drop...); err != nil {
+			errs = append(errs, err)
+		} else {
+			for _, channel := range drop {
+				delete(h.subscribed, channel)
+			}
+		}
+	}
+	if len(add) > 0 {
+		if err := h.sub.Subscribe(ctx, add...); err != nil {
+			errs = append(errs, err)
+		} else {
+			for _, channel := range add {
+				h.subscribed[channel] = true
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
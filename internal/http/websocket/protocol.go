@@ -0,0 +1,115 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+)
+
+// Message types sent by the client.
+const (
+	MessageSubscribe   = "subscribe"
+	MessageUnsubscribe = "unsubscribe"
+	MessagePing        = "ping"
+	MessageAck         = "ack"
+)
+
+// Message types sent by the server in reply to client messages.
+const (
+	MessageSubscribed   = "subscribed"
+	MessageUnsubscribed = "unsubscribed"
+	MessagePong         = "pong"
+	MessageError        = "error"
+)
+
+// Error codes carried by error frames.
+const (
+	ErrCodeInvalidMessage = "invalid_message"
+	ErrCodeUnknownType    = "unknown_type"
+	ErrCodeInvalidHome    = "invalid_home"
+	ErrCodeNotMember      = "not_member"
+	ErrCodeInternal       = "internal_error"
+)
+
+// ClientMessage is a command sent by the client.
+// ID is optional and is echoed back in the reply so clients can match them up.
+// Since is the replay cursor of a subscribe; without it the last acknowledged event is used.
+type ClientMessage struct {
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	HomeID  int    `json:"home_id,omitempty"`
+	EventID string `json:"event_id,omitempty"`
+	Since   string `json:"since,omitempty"`
+}
+
+// ServerMessage is a reply to a ClientMessage. Error frames carry Code and Message.
+type ServerMessage struct {
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	HomeID  int    `json:"home_id,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func errorFrame(id, code, message string) *ServerMessage {
+	return &ServerMessage{Type: MessageError, ID: id, Code: code, Message: message}
+}
+
+// HandleMessage applies a client command and returns the reply to send, if any.
+func (c *Client) HandleMessage(ctx context.Context, data []byte) *ServerMessage {
+	var msg ClientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return errorFrame("", ErrCodeInvalidMessage, "message must be a JSON object")
+	}
+
+	switch msg.Type {
+	case MessagePing:
+		return &ServerMessage{Type: MessagePong, ID: msg.ID}
+
+	case MessageAck:
+		if msg.EventID == "" {
+			return errorFrame(msg.ID, ErrCodeInvalidMessage, "event_id is required")
+		}
+		if !event.ValidCursor(msg.EventID) {
+			return errorFrame(msg.ID, ErrCodeInvalidMessage, "event_id is not a valid event ID")
+		}
+		c.Ack(msg.EventID)
+		return nil
+
+	case MessageSubscribe, MessageUnsubscribe:
+		if msg.HomeID <= 0 {
+			return errorFrame(msg.ID, ErrCodeInvalidHome, "home_id is required")
+		}
+
+		var err error
+		reply := MessageSubscribed
+		if msg.Type == MessageSubscribe {
+			if msg.Since != "" && !event.ValidCursor(msg.Since) {
+				return errorFrame(msg.ID, ErrCodeInvalidMessage, "since is not a valid event ID")
+			}
+			since := msg.Since
+			if since == "" {
+				since = c.LastAck()
+			}
+			err = c.Follow(ctx, msg.HomeID, since)
+		} else {
+			err = c.Unfollow(ctx, msg.HomeID)
+			reply = MessageUnsubscribed
+		}
+
+		if errors.Is(err, errNotMember) {
+			return errorFrame(msg.ID, ErrCodeNotMember, "you are not a member of this home")
+		}
+		if err != nil {
+			log.Printf("WS %s for home %d failed: %v", msg.Type, msg.HomeID, err)
+			return errorFrame(msg.ID, ErrCodeInternal, "failed to update subscription")
+		}
+		return &ServerMessage{Type: reply, ID: msg.ID, HomeID: msg.HomeID}
+
+	default:
+		return errorFrame(msg.ID, ErrCodeUnknownType, "unknown message type")
+	}
+}
@@ -2,12 +2,16 @@ package ws
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/Dragodui/diploma-server/internal/config"
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/metrics"
+	"github.com/Dragodui/diploma-server/internal/repository"
 	"github.com/Dragodui/diploma-server/pkg/security"
 	"github.com/gorilla/websocket"
 	"github.com/redis/go-redis/v9"
@@ -16,17 +20,22 @@ import (
 const (
 	pingInterval = 30 * time.Second
 	pongTimeout  = 60 * time.Second
+
+	// replayLimit caps how many missed events are replayed per channel on reconnect or resubscribe
+	replayLimit = 500
 )
 
 // handler for all ws connections
 type WSHandler struct {
 	Upgrader  websocket.Upgrader
-	Clients   map[*websocket.Conn]bool
+	Clients   map[*websocket.Conn]*Client
 	Mu        sync.Mutex
 	jwtSecret []byte
+	homeRepo  repository.HomeRepository
+	hub       *Hub
 }
 
-func NewWSHandler(cfg *config.Config) *WSHandler {
+func NewWSHandler(cfg *config.Config, homeRepo repository.HomeRepository, hub *Hub) *WSHandler {
 	return &WSHandler{
 		Upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
@@ -47,8 +56,10 @@ func NewWSHandler(cfg *config.Config) *WSHandler {
 				return false
 			},
 		},
-		Clients:   make(map[*websocket.Conn]bool),
+		Clients:   make(map[*websocket.Conn]*Client),
 		jwtSecret: []byte(cfg.JWTSecret),
+		homeRepo:  homeRepo,
+		hub:       hub,
 	}
 }
 
@@ -66,28 +77,54 @@ func (h *WSHandler) HandleWS(w http.ResponseWriter, r *http.Request, cache *redi
 		return
 	}
 
-	if _, err := security.ParseToken(tokenStr, h.jwtSecret); err != nil {
+	claims, err := security.ParseToken(tokenStr, h.jwtSecret)
+	if err != nil {
 		http.Error(w, "invalid token", http.StatusUnauthorized)
 		return
 	}
 
+	// Optional cursor: replay events the client missed since this ID
+	since := r.URL.Query().Get("since")
+	if since != "" && !event.ValidCursor(since) {
+		http.Error(w, "invalid since cursor", http.StatusBadRequest)
+		return
+	}
+
 	conn, err := h.Upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
 		return
 	}
 
+	ctx := context.Background()
+	client := NewClient(conn, claims.UserID, h.hub)
+	client.cache = cache
+
 	// lock to update connections list
 	h.Mu.Lock()
-	h.Clients[conn] = true
+	h.Clients[conn] = client
 	h.Mu.Unlock()
+	metrics.WSConnectedClients.Inc()
+
+	if err := h.hub.Subscribe(ctx, client, event.UserChannel(claims.UserID)); err != nil {
+		log.Printf("Failed to subscribe user %d to own events: %v", claims.UserID, err)
+		h.removeClient(conn)
+		return
+	}
 
-	go h.readPump(conn)
-	go h.subscribeToCache(conn, cache)
+	if err := client.SyncHomes(ctx, h.homeRepo); err != nil {
+		log.Printf("Failed to subscribe user %d to home events: %v", claims.UserID, err)
+		h.removeClient(conn)
+		return
+	}
+
+	go h.readPump(client)
+	go h.writePump(client, since)
 }
 
-// readPump reads from the connection to handle pong responses and detect disconnects.
-func (h *WSHandler) readPump(conn *websocket.Conn) {
+// readPump handles client commands, pong responses and disconnects.
+func (h *WSHandler) readPump(client *Client) {
+	conn := client.conn
 	defer h.removeClient(conn)
 
 	conn.SetReadDeadline(time.Now().Add(pongTimeout))
@@ -96,39 +133,70 @@ func (h *WSHandler) readPump(conn *websocket.Conn) {
 		return nil
 	})
 
+	ctx := context.Background()
 	for {
 		// ReadMessage blocks until a message arrives or the connection errors out.
-		// We discard client messages; this loop exists only to detect disconnects.
-		if _, _, err := conn.ReadMessage(); err != nil {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
 			break
 		}
+		conn.SetReadDeadline(time.Now().Add(pongTimeout))
+
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		if reply := client.HandleMessage(ctx, data); reply != nil {
+			if err := client.writeJSON(reply); err != nil {
+				log.Printf("Error writing WS reply: %v", err)
+				break
+			}
+		}
 	}
 }
 
-func (h *WSHandler) subscribeToCache(conn *websocket.Conn, cache *redis.Client) {
-	defer h.removeClient(conn)
+// writePump drains the client's send queue, which the hub fills with events from the
+// user's own channel and from the channels of the homes the client is subscribed to.
+// When since is set, missed events are replayed from the streams first.
+func (h *WSHandler) writePump(client *Client, since string) {
+	defer h.removeClient(client.conn)
+
+	ctx := context.Background()
 
-	pubsub := cache.Subscribe(context.Background(), "updates")
-	defer pubsub.Close()
+	if since != "" {
+		if err := client.replay(ctx, client.channels(), since); err != nil {
+			log.Printf("Failed to replay events for user %d: %v", client.userID, err)
+			return
+		}
+	}
 
 	// start sending pings to keep the connection alive
 	ticker := time.NewTicker(pingInterval)
 	defer ticker.Stop()
 
-	msgCh := pubsub.Channel()
-
 	for {
 		select {
-		case msg, ok := <-msgCh:
-			if !ok {
-				return
+		case <-client.done:
+			return
+
+		case msg := <-client.send:
+			var e event.RealTimeEvent
+			_ = json.Unmarshal([]byte(msg.Payload), &e)
+
+			// membership changes alter which homes this socket may listen to
+			if event.IsMembershipChange(&e) {
+				if err := client.SyncHomes(ctx, h.homeRepo); err != nil {
+					log.Printf("Failed to resync home subscriptions for user %d: %v", client.userID, err)
+				}
 			}
-			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg.Payload)); err != nil {
+
+			if err := client.writeEvent(msg.Channel, e.ID, []byte(msg.Payload)); err != nil {
 				log.Printf("Error writing WS message: %v", err)
 				return
 			}
+
 		case <-ticker.C:
-			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+			if err := client.ping(); err != nil {
 				log.Printf("Ping failed: %v", err)
 				return
 			}
@@ -136,12 +204,19 @@ func (h *WSHandler) subscribeToCache(conn *websocket.Conn, cache *redis.Client)
 	}
 }
 
-// removeClient safely closes the connection and removes it from the client map.
+// removeClient safely detaches the client from the hub, closes the connection and removes it from the client map.
 func (h *WSHandler) removeClient(conn *websocket.Conn) {
 	h.Mu.Lock()
-	if _, ok := h.Clients[conn]; ok {
-		delete(h.Clients, conn)
-		conn.Close()
-	}
+	client, ok := h.Clients[conn]
+	delete(h.Clients, conn)
 	h.Mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	h.hub.Unregister(client)
+	client.close()
+	conn.Close()
+	metrics.WSConnectedClients.Dec()
 }
@@ -299,6 +299,75 @@ var (
 		},
 	)
 
+	OcrJobsQueued = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ocr_jobs_queued",
+			Help: "Number of OCR jobs waiting for a worker",
+		},
+	)
+
+	OcrJobsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ocr_jobs_total",
+			Help: "Total number of OCR job outcomes",
+		},
+		[]string{"status"},
+	)
+
+	OcrJobDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "ocr_job_duration_seconds",
+			Help:    "Time between an OCR job being submitted and finished",
+			Buckets: []float64{0.5, 1, 2, 5, 10, 30, 60, 300, 900},
+		},
+	)
+
+	// WebSocket Metrics
+	WSConnectedClients = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ws_connected_clients",
+			Help: "Number of currently connected WebSocket clients",
+		},
+	)
+
+	WSDroppedMessagesTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ws_dropped_messages_total",
+			Help: "Total number of real-time messages dropped because a client's send queue was full",
+		},
+	)
+
+	// Outbox Metrics
+	OutboxPendingEvents = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "outbox_pending_events",
+			Help: "Number of outbox events not yet published",
+		},
+	)
+
+	OutboxLagSeconds = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "outbox_lag_seconds",
+			Help: "Age of the oldest unpublished outbox event in seconds",
+		},
+	)
+
+	OutboxPublishTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_publish_total",
+			Help: "Total number of outbox publish attempts",
+		},
+		[]string{"status"},
+	)
+
+	OutboxPublishDelay = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "outbox_publish_delay_seconds",
+			Help:    "Time between an outbox event being written and published",
+			Buckets: []float64{0.05, 0.1, 0.5, 1, 2, 5, 30, 60, 300},
+		},
+	)
+
 	// Application Info
 	AppInfo = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -13,20 +13,50 @@ type Bill struct {
 	Type           string         `json:"type"` // Kept for backward compatibility or as fallback
 	Payed          bool           `json:"is_payed"`
 	PaymentDate    *time.Time     `json:"payment_date"`
-	TotalAmount    float64        `json:"total_amount"`
+	TotalAmount    Money          `gorm:"type:bigint" json:"total_amount"`
+	Currency       string         `gorm:"size:3;not null;default:'USD'" json:"currency"`
+	ExchangeRate   float64        `gorm:"not null;default:1" json:"exchange_rate"` // to the home currency, taken when the bill was created
 	Start          time.Time      `json:"period_start"`
 	End            time.Time      `json:"period_end"`
+	DueDate        *time.Time     `gorm:"index" json:"due_date"`
 	UploadedBy     int            `json:"uploaded_by"`
 	Description    string         `json:"description"`
 	ReceiptImage   *string        `json:"receipt_image"`
 	OCRData        datatypes.JSON `json:"ocr_data"`
+	TemplateID     *int           `gorm:"index" json:"template_id"`                    // set on bills generated from a recurring template
+	ImportHash     string         `gorm:"size:64;index" json:"-"`                      // set on bills imported from a bank statement
+	ReceiptHash    string         `gorm:"size:64;index" json:"receipt_hash,omitempty"` // SHA-256 of the scanned receipt file
 	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
 
 	//relations
 	Home         *Home         `gorm:"foreignKey:HomeID;constraint:OnDelete:CASCADE" json:"home,omitempty"`
 	User         *User         `gorm:"foreignKey:UploadedBy;constraint:OnDelete:CASCADE" json:"user,omitempty"`
 	BillCategory *BillCategory `gorm:"foreignKey:BillCategoryID;constraint:OnDelete:SET NULL" json:"bill_category,omitempty"`
+	Template     *BillTemplate `gorm:"foreignKey:TemplateID;constraint:OnDelete:SET NULL" json:"template,omitempty"`
 	BillSplits   []BillSplit   `gorm:"foreignKey:BillID" json:"splits,omitempty"`
+
+	// filled in on create, not stored
+	Warnings []BillWarning `gorm:"-" json:"warnings,omitempty"`
+}
+
+// Kinds of warnings about a new bill
+const (
+	BillWarningDuplicateReceipt  = "duplicate_receipt"  // the same receipt file is on another bill
+	BillWarningPossibleDuplicate = "possible_duplicate" // another bill has the same vendor, date and total
+)
+
+// BillWarning points at an existing bill the new one may duplicate. The bill is created anyway.
+type BillWarning struct {
+	Code    string `json:"code"`
+	BillID  int    `json:"bill_id"`
+	Message string `json:"message"`
+}
+
+// DaysUntil counts the calendar days (UTC) from now to t, negative once t has passed
+func DaysUntil(now, t time.Time) int {
+	from := now.UTC().Truncate(24 * time.Hour)
+	to := t.UTC().Truncate(24 * time.Hour)
+	return int(to.Sub(from).Hours() / 24)
 }
 
 type CreateBillRequest struct {
@@ -34,9 +64,30 @@ type CreateBillRequest struct {
 	BillCategoryID *int           `json:"bill_category_id"`
 	Description    string         `json:"description"`
 	ReceiptImage   *string        `json:"receipt_image"`
-	TotalAmount    float64        `json:"total_amount" validate:"required,gte=0"`
+	TotalAmount    Money          `json:"total_amount" validate:"required,gte=0"`
+	Currency       string         `json:"currency" validate:"omitempty,len=3"` // defaults to the home currency
 	Start          time.Time      `json:"period_start" validate:"required"`
 	End            time.Time      `json:"period_end" validate:"required"`
+	DueDate        *time.Time     `json:"due_date"`
 	OCRData        datatypes.JSON `json:"ocr_data" validate:"required"`
+	ReceiptHash    string         `json:"receipt_hash" validate:"omitempty,hexadecimal,len=64"`             // receipt_hash of the OCR result
+	SplitMode      string         `json:"split_mode" validate:"omitempty,oneof=equal percent shares exact"` // defaults to exact
 	Splits         []SplitInput   `json:"splits,omitempty" gorm:"-"`
 }
+
+// UpdateBillRequest changes only the fields that are sent. A bill_category_id of 0
+// removes the category, remove_due_date the due date; splits, when sent, replace the existing ones.
+type UpdateBillRequest struct {
+	BillType       *string      `json:"type"`
+	BillCategoryID *int         `json:"bill_category_id"`
+	Description    *string      `json:"description"`
+	TotalAmount    *Money       `json:"total_amount" validate:"omitempty,gt=0"`
+	Currency       *string      `json:"currency" validate:"omitempty,len=3"`
+	Start          *time.Time   `json:"period_start"`
+	End            *time.Time   `json:"period_end"`
+	DueDate        *time.Time   `json:"due_date"`
+	RemoveDueDate  bool         `json:"remove_due_date"`
+	Payed          *bool        `json:"is_payed"`
+	SplitMode      string       `json:"split_mode" validate:"omitempty,oneof=equal percent shares exact"`
+	Splits         []SplitInput `json:"splits"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Bill listing filters and orders
+const (
+	BillStatusPaid    = "paid"
+	BillStatusUnpaid  = "unpaid"
+	BillStatusOverdue = "overdue"
+
+	BillDatePeriod  = "period"
+	BillDateCreated = "created"
+
+	BillSortCreated = "created_at"
+	BillSortStart   = "period_start"
+	BillSortAmount  = "total_amount"
+
+	BillListDefaultLimit = 50
+	BillListMaxLimit     = 100
+)
+
+// BillFilter selects one page of a home's bills. From and To are inclusive days; on
+// the period they match every bill whose period overlaps them. Amounts are compared
+// in each bill's own currency.
+type BillFilter struct {
+	HomeID        int        `json:"home_id"`
+	CategoryID    *int       `json:"category_id,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	DateField     string     `json:"date_field,omitempty"` // defaults to period
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	UploadedBy    *int       `json:"uploaded_by,omitempty"`
+	ParticipantID *int       `json:"participant_id,omitempty"`
+	MinAmount     *Money     `json:"min_amount,omitempty"`
+	MaxAmount     *Money     `json:"max_amount,omitempty"`
+	Search        string     `json:"search,omitempty"` // in the description
+	Sort          string     `json:"sort,omitempty"`   // defaults to created_at
+	Ascending     bool       `json:"ascending,omitempty"`
+	Limit         int        `json:"limit"`
+	Cursor        string     `json:"cursor,omitempty"` // next_cursor of the previous page
+
+	After *BillCursor `json:"-"` // the decoded cursor
+}
+
+// BillCursor is the position of the last bill of a page: its sort value and, to keep
+// bills with equal values in a stable order, its ID
+type BillCursor struct {
+	Sort   string     `json:"s"`
+	Time   *time.Time `json:"t,omitempty"`
+	Amount Money      `json:"a,omitempty"`
+	ID     int        `json:"id"`
+}
+
+type BillPage struct {
+	Bills      []Bill `json:"bills"`
+	NextCursor string `json:"next_cursor,omitempty"` // empty on the last page
+}
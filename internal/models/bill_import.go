@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Which sign marks an expense in a bank export
+const (
+	AmountSignNegative = "negative" // expenses are negative, e.g. a current account export
+	AmountSignPositive = "positive" // expenses are positive, e.g. a credit card statement
+)
+
+// Status of a row in an import preview
+const (
+	ImportRowNew       = "new"
+	ImportRowDuplicate = "duplicate" // already imported, or matches an existing bill
+	ImportRowIncome    = "income"    // money coming in, never imported as a bill
+	ImportRowInvalid   = "invalid"
+)
+
+const (
+	ImportStatusPreview   = "preview"
+	ImportStatusCommitted = "committed"
+)
+
+// ImportProfile describes the CSV layout of one bank's export. Columns are named by
+// their header, or by 1-based position when the file has no header.
+type ImportProfile struct {
+	ID                int       `gorm:"autoIncrement;primaryKey" json:"id"`
+	HomeID            int       `gorm:"not null;index" json:"home_id"`
+	Name              string    `gorm:"size:64;not null" json:"name"`
+	Delimiter         string    `gorm:"size:1;not null;default:','" json:"delimiter"`
+	HasHeader         bool      `gorm:"not null;default:true" json:"has_header"`
+	SkipLines         int       `gorm:"not null;default:0" json:"skip_lines"` // preamble before the header
+	DateColumn        string    `gorm:"size:64;not null" json:"date_column"`
+	DateFormat        string    `gorm:"size:32;not null" json:"date_format"` // e.g. DD.MM.YYYY
+	AmountColumn      string    `gorm:"size:64;not null" json:"amount_column"`
+	AmountSign        string    `gorm:"size:8;not null;default:'negative'" json:"amount_sign"`
+	DecimalSeparator  string    `gorm:"size:1;not null;default:'.'" json:"decimal_separator"`
+	DescriptionColumn string    `gorm:"size:64;not null" json:"description_column"`
+	Currency          string    `gorm:"size:3" json:"currency"` // defaults to the home currency
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Home *Home `gorm:"foreignKey:HomeID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+type CreateImportProfileRequest struct {
+	Name              string `json:"name" validate:"required,max=64"`
+	Delimiter         string `json:"delimiter" validate:"omitempty,len=1"` // defaults to a comma
+	HasHeader         *bool  `json:"has_header"`                           // defaults to true
+	SkipLines         int    `json:"skip_lines" validate:"min=0,max=50"`
+	DateColumn        string `json:"date_column" validate:"required,max=64"`
+	DateFormat        string `json:"date_format" validate:"required,max=32"`
+	AmountColumn      string `json:"amount_column" validate:"required,max=64"`
+	AmountSign        string `json:"amount_sign" validate:"omitempty,oneof=negative positive"`
+	DecimalSeparator  string `json:"decimal_separator" validate:"omitempty,len=1"` // "." or ","
+	DescriptionColumn string `json:"description_column" validate:"required,max=64"`
+	Currency          string `json:"currency" validate:"omitempty,len=3"`
+}
+
+// VendorRule files bills whose description contains Pattern under a category.
+// When several rules match, the longest pattern wins.
+type VendorRule struct {
+	ID             int       `gorm:"autoIncrement;primaryKey" json:"id"`
+	HomeID         int       `gorm:"not null;index" json:"home_id"`
+	Pattern        string    `gorm:"size:128;not null" json:"pattern"`
+	BillCategoryID int       `gorm:"not null" json:"bill_category_id"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Home         *Home         `gorm:"foreignKey:HomeID;constraint:OnDelete:CASCADE" json:"-"`
+	BillCategory *BillCategory `gorm:"foreignKey:BillCategoryID;constraint:OnDelete:CASCADE" json:"bill_category,omitempty"`
+}
+
+type CreateVendorRuleRequest struct {
+	Pattern        string `json:"pattern" validate:"required,max=128"`
+	BillCategoryID int    `json:"bill_category_id" validate:"required"`
+}
+
+// ImportRow is one transaction of an uploaded statement
+type ImportRow struct {
+	Line           int       `json:"line"`
+	Date           time.Time `json:"date"`
+	Amount         Money     `json:"amount"`
+	Description    string    `json:"description"`
+	BillCategoryID *int      `json:"bill_category_id"`
+	Hash           string    `json:"hash"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// BillImport is an uploaded statement waiting to be committed into bills
+type BillImport struct {
+	ID          int                            `gorm:"autoIncrement;primaryKey" json:"id"`
+	HomeID      int                            `gorm:"not null;index" json:"home_id"`
+	UserID      int                            `gorm:"not null" json:"user_id"`
+	ProfileID   int                            `json:"profile_id"`
+	FileName    string                         `json:"file_name"`
+	Currency    string                         `gorm:"size:3" json:"currency"`
+	Status      string                         `gorm:"size:16;not null;default:'preview'" json:"status"`
+	Rows        datatypes.JSONSlice[ImportRow] `json:"rows"`
+	CreatedAt   time.Time                      `gorm:"autoCreateTime" json:"created_at"`
+	CommittedAt *time.Time                     `json:"committed_at"`
+
+	Home *Home `gorm:"foreignKey:HomeID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// CommitImportRequest picks the rows to turn into bills. Without lines every new row is
+// imported; listing a duplicate imports it anyway. Categories override the category per line.
+type CommitImportRequest struct {
+	Lines      []int       `json:"lines"`
+	Categories map[int]int `json:"categories"` // line -> bill category ID, 0 for none
+}
+
+type ImportResult struct {
+	Created int    `json:"created"`
+	Skipped int    `json:"skipped"`
+	Bills   []Bill `json:"bills"`
+}
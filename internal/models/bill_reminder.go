@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Reminders sent for an unpaid split, each at most once per due date
+const (
+	ReminderUpcoming = "upcoming" // within the home's lead time
+	ReminderDue      = "due"      // on the due date
+	ReminderOverdue  = "overdue"  // after the due date
+)
+
+// DefaultReminderLeadDays is used by homes that never set their own lead time
+const DefaultReminderLeadDays = 3
+
+// BillReminderSettings is how many days before a bill is due its members are reminded
+type BillReminderSettings struct {
+	HomeID    int       `gorm:"primaryKey;autoIncrement:false" json:"home_id"`
+	LeadDays  int       `gorm:"not null" json:"lead_days"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Home *Home `gorm:"foreignKey:HomeID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+type SetReminderSettingsRequest struct {
+	LeadDays *int `json:"lead_days" validate:"required,gte=0,lte=30"`
+}
+
+// BillReminder records a reminder that was sent. The due date is part of the key, so
+// moving a bill's due date lets its reminders go out again.
+type BillReminder struct {
+	ID      int       `gorm:"autoIncrement;primaryKey" json:"id"`
+	BillID  int       `gorm:"not null;uniqueIndex:idx_bill_reminder" json:"bill_id"`
+	UserID  int       `gorm:"not null;uniqueIndex:idx_bill_reminder" json:"user_id"`
+	Kind    string    `gorm:"size:16;not null;uniqueIndex:idx_bill_reminder" json:"kind"`
+	DueDate time.Time `gorm:"not null;uniqueIndex:idx_bill_reminder" json:"due_date"`
+	SentAt  time.Time `gorm:"autoCreateTime" json:"sent_at"`
+
+	Bill *Bill `gorm:"foreignKey:BillID;constraint:OnDelete:CASCADE" json:"-"`
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
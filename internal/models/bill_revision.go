@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// BillRevision is one edit of a bill: who made it and what every changed field was before and after
+type BillRevision struct {
+	ID        int                              `gorm:"autoIncrement;primaryKey" json:"id"`
+	BillID    int                              `gorm:"not null;index" json:"bill_id"`
+	ChangedBy int                              `gorm:"not null" json:"changed_by"`
+	Changes   datatypes.JSONSlice[FieldChange] `json:"changes"`
+	CreatedAt time.Time                        `gorm:"autoCreateTime" json:"created_at"`
+
+	Bill *Bill `gorm:"foreignKey:BillID;constraint:OnDelete:CASCADE" json:"-"`
+	User *User `gorm:"foreignKey:ChangedBy;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
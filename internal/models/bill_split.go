@@ -1,21 +1,38 @@
 package models
 
 type BillSplit struct {
-	ID     int     `gorm:"autoIncrement;primaryKey" json:"id"`
-	BillID int     `gorm:"not null" json:"bill_id"`
-	UserID int     `gorm:"not null" json:"user_id"`
-	Amount float64 `gorm:"not null" json:"amount"`
-	Paid   bool    `gorm:"default:false" json:"paid"`
+	ID         int   `gorm:"autoIncrement;primaryKey" json:"id"`
+	BillID     int   `gorm:"not null" json:"bill_id"`
+	UserID     int   `gorm:"not null" json:"user_id"`
+	Amount     Money `gorm:"type:bigint;not null" json:"amount"`
+	PaidAmount Money `gorm:"type:bigint;default:0" json:"paid_amount"` // derived from settlements
+	Paid       bool  `gorm:"default:false" json:"paid"`
 
 	Bill *Bill `gorm:"foreignKey:BillID;constraint:OnDelete:CASCADE" json:"bill,omitempty"`
 	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
 }
 
+// Split modes decide how a bill total is divided between participants
+const (
+	SplitModeEqual   = "equal"
+	SplitModePercent = "percent"
+	SplitModeShares  = "shares"
+	SplitModeExact   = "exact"
+)
+
+// MaxSplitShares caps the shares of one participant
+const MaxSplitShares = 1000000
+
+// SplitInput is one participant of a split. Which of Amount, Percent or Shares is
+// read depends on the split mode; equal splits only need the user.
 type SplitInput struct {
-	UserID int     `json:"user_id"`
-	Amount float64 `json:"amount"`
+	UserID  int     `json:"user_id"`
+	Amount  Money   `json:"amount,omitempty"`
+	Percent float64 `json:"percent,omitempty"`
+	Shares  int     `json:"shares,omitempty"`
 }
 
 type UpdateSplitsRequest struct {
-	Splits []SplitInput `json:"splits"`
+	SplitMode string       `json:"split_mode" validate:"omitempty,oneof=equal percent shares exact"`
+	Splits    []SplitInput `json:"splits"`
 }
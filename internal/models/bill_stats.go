@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Ways of grouping spending statistics
+const (
+	StatsGroupCategory    = "category"
+	StatsGroupUploader    = "uploader"
+	StatsGroupParticipant = "participant"
+	StatsGroupMonth       = "month"
+)
+
+// SpendingFilter selects the bills that go into spending statistics. Bills are
+// placed by the start of their period; From and To are inclusive days.
+type SpendingFilter struct {
+	HomeID  int
+	From    *time.Time
+	To      *time.Time
+	GroupBy string
+}
+
+// SpendingStat sums the bills of one group in the home currency. Grouped by
+// participant it sums their splits instead of whole bills.
+type SpendingStat struct {
+	Key     string `json:"key"` // category ID (0 for none), user ID or YYYY-MM
+	Label   string `json:"label"`
+	Total   Money  `json:"total"`
+	Count   int    `json:"count"`
+	Average Money  `json:"average"`
+	Change  *Money `json:"change,omitempty"` // month grouping only: difference to the previous month
+
+	Months []MonthlySpending `json:"months,omitempty"`
+}
+
+// MonthlySpending is one month of a group. Change is the difference to the previous
+// month and is empty when that month is not covered by the data.
+type MonthlySpending struct {
+	Month   string `json:"month"` // YYYY-MM
+	Total   Money  `json:"total"`
+	Count   int    `json:"count"`
+	Average Money  `json:"average"`
+	Change  *Money `json:"change"`
+}
+
+type SpendingStats struct {
+	Currency string         `json:"currency"`
+	GroupBy  string         `json:"group_by"`
+	From     *time.Time     `json:"from"`
+	To       *time.Time     `json:"to"`
+	Total    Money          `json:"total"`
+	Count    int            `json:"count"`
+	Average  Money          `json:"average"`
+	Groups   []SpendingStat `json:"groups"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Recurrence rules of a bill template
+const (
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+	RecurrenceYearly  = "yearly"
+)
+
+// BillTemplate is a bill that repeats every period, like rent or internet. The scheduler
+// turns each period into a regular Bill with its splits, uploaded by the template's creator.
+type BillTemplate struct {
+	ID             int                             `gorm:"autoIncrement;primaryKey" json:"id"`
+	HomeID         int                             `gorm:"not null;index" json:"home_id"`
+	CreatedBy      int                             `gorm:"not null" json:"created_by"`
+	BillType       string                          `gorm:"size:64" json:"type"`
+	BillCategoryID *int                            `json:"bill_category_id"`
+	Description    string                          `json:"description"`
+	Amount         Money                           `gorm:"type:bigint;not null" json:"amount"`
+	Currency       string                          `gorm:"size:3" json:"currency"` // empty means the home currency
+	RecurrenceType string                          `gorm:"not null;size:16" json:"recurrence_type"`
+	Interval       int                             `gorm:"column:recurrence_interval;not null;default:1" json:"interval"` // every n weeks, months or years
+	SplitMode      string                          `gorm:"size:16" json:"split_mode"`
+	Splits         datatypes.JSONSlice[SplitInput] `json:"splits"`
+	StartDate      time.Time                       `gorm:"not null" json:"start_date"`
+	Occurrences    int                             `gorm:"not null;default:0" json:"occurrences"` // periods generated so far
+	NextRunDate    time.Time                       `gorm:"not null;index" json:"next_run_date"`
+	IsActive       bool                            `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt      time.Time                       `gorm:"autoCreateTime" json:"created_at"`
+
+	// relations
+	Home         *Home         `gorm:"foreignKey:HomeID;constraint:OnDelete:CASCADE" json:"home,omitempty"`
+	Creator      *User         `gorm:"foreignKey:CreatedBy;constraint:OnDelete:CASCADE" json:"creator,omitempty"`
+	BillCategory *BillCategory `gorm:"foreignKey:BillCategoryID;constraint:OnDelete:SET NULL" json:"bill_category,omitempty"`
+}
+
+type CreateBillTemplateRequest struct {
+	BillType       string       `json:"type"` // Optional if CategoryID is provided
+	BillCategoryID *int         `json:"bill_category_id"`
+	Description    string       `json:"description"`
+	Amount         Money        `json:"amount" validate:"required,gt=0"`
+	Currency       string       `json:"currency" validate:"omitempty,len=3"`
+	RecurrenceType string       `json:"recurrence_type" validate:"required,oneof=weekly monthly yearly"`
+	Interval       int          `json:"interval" validate:"omitempty,min=1,max=12"` // defaults to 1
+	StartDate      time.Time    `json:"start_date" validate:"required"`
+	SplitMode      string       `json:"split_mode" validate:"omitempty,oneof=equal percent shares exact"`
+	Splits         []SplitInput `json:"splits,omitempty"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Budget alert thresholds, in percent of the monthly budget
+const (
+	BudgetWarningPercent  = 80
+	BudgetExceededPercent = 100
+)
+
+// BillCategoryBudget is what a home means to spend on a bill category per calendar month, in the home currency
+type BillCategoryBudget struct {
+	ID             int       `gorm:"autoIncrement;primaryKey" json:"id"`
+	HomeID         int       `gorm:"not null;index" json:"home_id"`
+	BillCategoryID int       `gorm:"not null;uniqueIndex" json:"bill_category_id"`
+	Amount         Money     `gorm:"type:bigint;not null" json:"amount"`
+	CreatedBy      int       `json:"created_by"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// relations
+	Home         *Home         `gorm:"foreignKey:HomeID;constraint:OnDelete:CASCADE" json:"home,omitempty"`
+	BillCategory *BillCategory `gorm:"foreignKey:BillCategoryID;constraint:OnDelete:CASCADE" json:"bill_category,omitempty"`
+}
+
+type SetBudgetRequest struct {
+	Amount Money `json:"amount" validate:"required,gt=0"`
+}
+
+// BudgetStatus is how much of a budget has been spent in one month. Remaining is
+// negative once the budget is exceeded.
+type BudgetStatus struct {
+	BillCategoryID int     `json:"bill_category_id"`
+	CategoryName   string  `json:"category_name"`
+	Currency       string  `json:"currency"`
+	Period         string  `json:"period"` // YYYY-MM
+	Budget         Money   `json:"budget"`
+	Spent          Money   `json:"spent"`
+	Remaining      Money   `json:"remaining"`
+	PercentUsed    float64 `json:"percent_used"`
+}
+
+// BudgetAlert is sent when a new bill pushes a category past one of the thresholds
+type BudgetAlert struct {
+	BudgetStatus
+	Threshold int `json:"threshold"`
+}
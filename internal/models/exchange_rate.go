@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// DefaultCurrency is used for homes and bills that don't name a currency
+const DefaultCurrency = "USD"
+
+// ExchangeRate overrides the built-in rate for one home: one unit of Currency is worth Rate units of BaseCurrency
+type ExchangeRate struct {
+	ID           int       `gorm:"autoIncrement;primaryKey" json:"id"`
+	HomeID       int       `gorm:"not null;uniqueIndex:idx_home_rate" json:"home_id"`
+	BaseCurrency string    `gorm:"size:3;not null;uniqueIndex:idx_home_rate" json:"base_currency"`
+	Currency     string    `gorm:"size:3;not null;uniqueIndex:idx_home_rate" json:"currency"`
+	Rate         float64   `gorm:"not null" json:"rate"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Home *Home `gorm:"foreignKey:HomeID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// EffectiveRate is the rate a home currently converts a currency with
+type EffectiveRate struct {
+	Currency     string  `json:"currency"`
+	BaseCurrency string  `json:"base_currency"`
+	Rate         float64 `json:"rate"`
+	Custom       bool    `json:"custom"` // set by a home admin rather than the built-in table
+}
+
+type SetExchangeRateRequest struct {
+	Rate float64 `json:"rate" validate:"required,gt=0"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Datasets a home can be exported as CSV
+const (
+	ExportBills    = "bills"
+	ExportTasks    = "tasks"
+	ExportShopping = "shopping"
+	ExportPolls    = "polls"
+)
+
+// PollResult is a poll as it is exported: vote counts only, so anonymous polls stay anonymous
+type PollResult struct {
+	ID        int            `json:"id"`
+	Question  string         `json:"question"`
+	Type      string         `json:"type"`
+	Status    string         `json:"status"`
+	EndsAt    *time.Time     `json:"ends_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	Options   []OptionResult `json:"options"`
+}
+
+type OptionResult struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Votes int    `json:"votes"`
+}
+
+func NewPollResult(p *Poll) PollResult {
+	result := PollResult{
+		ID:        p.ID,
+		Question:  p.Question,
+		Type:      p.Type,
+		Status:    p.Status,
+		EndsAt:    p.EndsAt,
+		CreatedAt: p.CreatedAt,
+		Options:   make([]OptionResult, len(p.Options)),
+	}
+	for i, o := range p.Options {
+		result.Options[i] = OptionResult{ID: o.ID, Title: o.Title, Votes: len(o.Votes)}
+	}
+	return result
+}
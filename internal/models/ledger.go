@@ -0,0 +1,27 @@
+package models
+
+// Debt is the unpaid amount one member owes another, summed over all bills
+type Debt struct {
+	DebtorID   int   `json:"debtor_id"`
+	CreditorID int   `json:"creditor_id"`
+	Amount     Money `json:"amount"`
+}
+
+// MemberBalance is a member's net position: positive means they are owed money
+type MemberBalance struct {
+	UserID int   `json:"user_id"`
+	Net    Money `json:"net"`
+}
+
+// Transfer is a single settle-up payment from one member to another
+type Transfer struct {
+	From   int   `json:"from_user_id"`
+	To     int   `json:"to_user_id"`
+	Amount Money `json:"amount"`
+}
+
+type HomeBalances struct {
+	Currency  string          `json:"currency"`
+	Balances  []MemberBalance `json:"balances"`
+	Transfers []Transfer      `json:"transfers"`
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor units (hundredths of the currency unit). It is stored
+// as a bigint and travels through the JSON API as a decimal number such as 12.34,
+// parsed digit by digit so no binary floating point is involved.
+type Money int64
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// MoneyFromFloat rounds a floating point amount to the nearest minor unit.
+// Only use it at the edges, e.g. for exchange rate conversions.
+func MoneyFromFloat(amount float64) Money {
+	return Money(math.Round(amount * 100))
+}
+
+// Float is the amount in major units, for display and metrics only
+func (m Money) Float() float64 {
+	return float64(m) / 100
+}
+
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a number or a numeric string. Digits past the second
+// decimal place are rounded half away from zero.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	parsed, err := ParseMoney(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// ParseMoney reads a plain decimal amount like "12.5", "-0.07" or "1e2"
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidMoney
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	// split off an exponent, which JSON allows for numbers
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e > 15 || e < -15 {
+			return 0, ErrInvalidMoney
+		}
+		exp = e
+		s = s[:i]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, ErrInvalidMoney
+	}
+	digits := intPart + fracPart
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, ErrInvalidMoney
+		}
+	}
+
+	// position of the decimal point once shifted to minor units
+	point := len(intPart) + exp + 2
+	if point < 0 {
+		return 0, nil
+	}
+	for len(digits) < point {
+		digits += "0"
+	}
+	whole, rest := digits[:point], digits[point:]
+	whole = strings.TrimLeft(whole, "0")
+	if len(whole) > 18 {
+		return 0, ErrInvalidMoney
+	}
+
+	var v int64
+	if whole != "" {
+		var err error
+		if v, err = strconv.ParseInt(whole, 10, 64); err != nil {
+			return 0, ErrInvalidMoney
+		}
+	}
+	if rest != "" && rest[0] >= '5' {
+		v++
+	}
+	if neg {
+		v = -v
+	}
+	return Money(v), nil
+}
@@ -1,18 +1,60 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
 // OCRItem represents a single line item from a receipt
 type OCRItem struct {
 	Name     string  `json:"name"`
 	Quantity float64 `json:"quantity,omitempty"`
-	Price    float64 `json:"price"`
+	Price    Money   `json:"price"`          // discounts are negative
+	Kind     string  `json:"kind,omitempty"` // one of the ReceiptLine kinds
 }
 
 // OCRResult contains structured data extracted from a receipt
 type OCRResult struct {
-	Vendor     string    `json:"vendor"`     // Store/company name
-	Date       string    `json:"date"`       // Receipt date
-	Total      float64   `json:"total"`      // Total amount
-	Items      []OCRItem `json:"items"`      // List of items
-	RawText    string    `json:"raw_text"`   // Raw text for debugging
-	Confidence float64   `json:"confidence"` // Recognition confidence (0-1)
+	Vendor      string     `json:"vendor"`                 // Store/company name
+	Date        string     `json:"date"`                   // Receipt date, as printed
+	ParsedDate  *time.Time `json:"parsed_date,omitempty"`  // Receipt date, when it could be read
+	Currency    string     `json:"currency,omitempty"`     // ISO 4217 code, when it could be told
+	Total       Money      `json:"total"`                  // Total amount
+	Items       []OCRItem  `json:"items"`                  // Products bought
+	Adjustments []OCRItem  `json:"adjustments,omitempty"`  // Tax, discount and deposit lines
+	Tax         Money      `json:"tax"`                    // Sum of the tax lines
+	Discount    Money      `json:"discount"`               // Sum of the discounts, as a positive amount
+	Deposit     Money      `json:"deposit"`                // Sum of the deposits
+	TaxIncluded bool       `json:"tax_included"`           // The item prices already contain the tax
+	RawText     string     `json:"raw_text"`               // Raw text for debugging
+	Confidence  float64    `json:"confidence"`             // Recognition confidence (0-1)
+	Pages       []OCRPage  `json:"pages,omitempty"`        // Confidence of each page read
+	ReceiptHash string     `json:"receipt_hash,omitempty"` // SHA-256 of the file, to pass on to the bill
+
+	// Category of the home's past bills from the same vendor; only set for OCR run for a home
+	SuggestedCategoryID *int `json:"suggested_category_id,omitempty"`
+}
+
+// OCRPage is how well one page of a receipt was read. A multi-page PDF read page by
+// page has one per page; other files count as a single page.
+type OCRPage struct {
+	Page       int     `json:"page"` // across all files, from 1
+	File       int     `json:"file"` // the uploaded file it is in, from 1
+	Confidence float64 `json:"confidence"`
+}
+
+// OCRFile is one uploaded file of a receipt: a whole document, or a photo of part of it
+type OCRFile struct {
+	Data     []byte
+	MimeType string
+}
+
+// OCRCacheEntry keeps the result of a file read by a provider, so the same receipt
+// uploaded again is not sent to the provider twice
+type OCRCacheEntry struct {
+	Hash      string         `gorm:"primaryKey;size:64" json:"hash"`
+	Provider  string         `gorm:"primaryKey;size:16" json:"provider"`
+	Result    datatypes.JSON `gorm:"not null" json:"result"`
+	CreatedAt time.Time      `gorm:"autoCreateTime;index" json:"created_at"`
 }
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	OCRJobQueued     = "queued"
+	OCRJobProcessing = "processing"
+	OCRJobCompleted  = "completed"
+	OCRJobFailed     = "failed"
+)
+
+// OCRJob is a receipt waiting for, or done with, OCR in the background
+type OCRJob struct {
+	ID            string         `gorm:"primaryKey;size:36" json:"id"`
+	UserID        int            `gorm:"not null;index" json:"user_id"`
+	HomeID        *int           `json:"home_id,omitempty"` // set when queued for a home, to suggest a bill category
+	Status        string         `gorm:"not null;size:16;index" json:"status"`
+	MimeType      string         `gorm:"not null;size:64" json:"mime_type"` // of the first file
+	Language      string         `gorm:"size:32" json:"language,omitempty"`
+	FileCount     int            `gorm:"not null;default:1" json:"files"`
+	Result        datatypes.JSON `json:"result,omitempty" swaggertype:"object"`
+	Attempts      int            `gorm:"not null;default:0" json:"attempts"`
+	LastError     string         `gorm:"type:text" json:"error,omitempty"`
+	NextAttemptAt time.Time      `gorm:"not null;index" json:"-"`
+	StartedAt     *time.Time     `json:"started_at,omitempty"`
+	FinishedAt    *time.Time     `gorm:"index" json:"finished_at,omitempty"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+
+	Files []OCRJobFile `gorm:"foreignKey:JobID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// OCRJobFile is one uploaded file of a job, dropped once the job is finished
+type OCRJobFile struct {
+	ID       int    `gorm:"autoIncrement;primaryKey" json:"id"`
+	JobID    string `gorm:"size:36;not null;index" json:"job_id"`
+	Position int    `gorm:"not null" json:"position"`
+	MimeType string `gorm:"not null;size:64" json:"mime_type"`
+	Data     []byte `gorm:"type:bytea;not null" json:"-"`
+}
+
+// Finished tells whether the job will change no more
+func (j *OCRJob) Finished() bool {
+	return j.Status == OCRJobCompleted || j.Status == OCRJobFailed
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// OutboxEvent is a real-time event saved in the same transaction as the change it
+// describes and published to Redis by the outbox relay afterwards.
+type OutboxEvent struct {
+	ID            int            `gorm:"autoIncrement;primaryKey" json:"id"`
+	Channel       string         `gorm:"not null;size:64" json:"channel"`
+	Module        string         `gorm:"not null;size:32" json:"module"`
+	Action        string         `gorm:"not null;size:32" json:"action"`
+	Data          datatypes.JSON `json:"data"`
+	Attempts      int            `gorm:"not null;default:0" json:"attempts"`
+	LastError     string         `gorm:"type:text" json:"last_error"`
+	NextAttemptAt time.Time      `gorm:"not null;index" json:"next_attempt_at"`
+	PublishedAt   *time.Time     `gorm:"index" json:"published_at"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Kinds of receipt lines
+const (
+	ReceiptLineItem     = "item"
+	ReceiptLineTax      = "tax"
+	ReceiptLineDiscount = "discount"
+	ReceiptLineDeposit  = "deposit"
+)
+
+// ReceiptLine is one line of a scanned receipt. An item or a deposit is shared equally by its
+// users; tax and discount lines are spread over everyone in proportion to what their items cost.
+type ReceiptLine struct {
+	Name     string      `json:"name" validate:"required"`
+	Quantity float64     `json:"quantity,omitempty"`
+	Price    Money       `json:"price"`                                                     // line total; discounts may be negative
+	Kind     string      `json:"kind" validate:"omitempty,oneof=item tax discount deposit"` // defaults to item
+	UserIDs  []int       `json:"user_ids,omitempty"`                                        // who shares an item
+	Shares   []ItemShare `json:"shares,omitempty"`                                          // filled in by the server
+}
+
+// ItemShare is a user's part of one item, before tax and discounts
+type ItemShare struct {
+	UserID int   `json:"user_id"`
+	Amount Money `json:"amount"`
+}
+
+// CreateReceiptBillRequest creates a bill from OCR line items, splitting it by who had what
+type CreateReceiptBillRequest struct {
+	BillType       string        `json:"type"`
+	BillCategoryID *int          `json:"bill_category_id"`
+	Description    string        `json:"description"` // defaults to the vendor
+	ReceiptImage   *string       `json:"receipt_image"`
+	ReceiptHash    string        `json:"receipt_hash" validate:"omitempty,hexadecimal,len=64"` // receipt_hash of the OCR result
+	Currency       string        `json:"currency" validate:"omitempty,len=3"`
+	Start          time.Time     `json:"period_start" validate:"required"`
+	End            time.Time     `json:"period_end" validate:"required"`
+	DueDate        *time.Time    `json:"due_date"`
+	Vendor         string        `json:"vendor"`
+	Date           string        `json:"date"`
+	Total          Money         `json:"total" validate:"gte=0"` // receipt total; when set the lines must add up to it
+	Items          []ReceiptLine `json:"items" validate:"required,min=1,dive"`
+}
+
+// ReceiptSplit is stored in Bill.OCRData of a bill split by receipt items
+type ReceiptSplit struct {
+	Vendor   string        `json:"vendor,omitempty"`
+	Date     string        `json:"date,omitempty"`
+	Items    []ReceiptLine `json:"items"`
+	Subtotal Money         `json:"subtotal"`
+	Tax      Money         `json:"tax"`
+	Discount Money         `json:"discount"`
+	Total    Money         `json:"total"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Settlement is a payment from one member to another that pays down their bill splits
+type Settlement struct {
+	ID         int       `gorm:"autoIncrement;primaryKey" json:"id"`
+	HomeID     int       `gorm:"not null;index" json:"home_id"`
+	FromUserID int       `gorm:"not null" json:"from_user_id"`
+	ToUserID   int       `gorm:"not null" json:"to_user_id"`
+	Amount     Money     `gorm:"type:bigint;not null" json:"amount"` // in the home currency
+	Currency   string    `gorm:"size:3;not null;default:'USD'" json:"currency"`
+	SplitID    *int      `gorm:"index" json:"split_id"` // set when the payment was made for one specific split
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Home     *Home `gorm:"foreignKey:HomeID;constraint:OnDelete:CASCADE" json:"home,omitempty"`
+	FromUser *User `gorm:"foreignKey:FromUserID;constraint:OnDelete:CASCADE" json:"from_user,omitempty"`
+	ToUser   *User `gorm:"foreignKey:ToUserID;constraint:OnDelete:CASCADE" json:"to_user,omitempty"`
+}
+
+type CreateSettlementRequest struct {
+	FromUserID *int   `json:"from_user_id"` // defaults to the current user
+	ToUserID   int    `json:"to_user_id" validate:"required"`
+	Amount     Money  `json:"amount" validate:"required,gt=0"`
+	Note       string `json:"note" validate:"max=255"`
+}
@@ -11,6 +11,7 @@ type ShoppingItem struct {
 	Image      *string    `json:"image"`
 	Link       *string    `json:"link"`
 	BoughtDate *time.Time `json:"bought_date"`
+	Price      *Money     `json:"price"` // what it cost when bought from a receipt
 	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
 
 	// relations
@@ -0,0 +1,30 @@
+package models
+
+// ReconcileReceiptRequest carries the items read from a receipt, as returned by OCR
+type ReconcileReceiptRequest struct {
+	Items []OCRItem `json:"items" validate:"required,min=1"`
+}
+
+// ShoppingMatch pairs an open shopping list item with the receipt line that bought it
+type ShoppingMatch struct {
+	Item        ShoppingItem `json:"item"`
+	ReceiptItem OCRItem      `json:"receipt_item"`
+	Score       float64      `json:"score"` // how alike the names are, from 0 to 1
+}
+
+// ShoppingReconciliation proposes which open items a receipt covers. Nothing is marked
+// bought until the proposal is confirmed.
+type ShoppingReconciliation struct {
+	Matches   []ShoppingMatch `json:"matches"`
+	Unmatched []OCRItem       `json:"unmatched"` // receipt items not on the list
+}
+
+type ShoppingPurchase struct {
+	ItemID int    `json:"item_id" validate:"required"`
+	Price  *Money `json:"price,omitempty"`
+}
+
+// ConfirmPurchasesRequest marks the accepted matches bought, with the prices paid
+type ConfirmPurchasesRequest struct {
+	Purchases []ShoppingPurchase `json:"purchases" validate:"required,min=1,dive"`
+}
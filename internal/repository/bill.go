@@ -3,22 +3,36 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Dragodui/diploma-server/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BillRepository interface {
 	Create(ctx context.Context, b *models.Bill) error
 	FindByID(ctx context.Context, id int) (*models.Bill, error)
-	FindByHomeID(ctx context.Context, homeID int, categoryID *int) ([]models.Bill, error)
+	FindByHomeID(ctx context.Context, f models.BillFilter) ([]models.Bill, error)
 	Delete(ctx context.Context, id int) error
-	MarkPayed(ctx context.Context, id int) error
+	Update(ctx context.Context, b *models.Bill) error
 	CreateSplits(ctx context.Context, billID int, splits []models.BillSplit) error
 	UpdateSplits(ctx context.Context, billID int, splits []models.BillSplit) error
 	MarkSplitPaid(ctx context.Context, splitID int) error
 	FindSplitByID(ctx context.Context, splitID int) (*models.BillSplit, error)
+	FindSplitsBetween(ctx context.Context, homeID, debtorID, creditorID int) ([]models.BillSplit, error)
+	SetSplitPayment(ctx context.Context, splitID int, paidAmount models.Money, paid bool) error
+	FindSplitDebts(ctx context.Context, homeID int) ([]models.Debt, error)
+	CreateRevision(ctx context.Context, rev *models.BillRevision) error
+	FindRevisions(ctx context.Context, billID int) ([]models.BillRevision, error)
+	FindImportHashes(ctx context.Context, homeID int, hashes []string) (map[string]bool, error)
+	FindDuplicates(ctx context.Context, homeID int, receiptHash, vendor, date string, total models.Money) ([]models.Bill, error)
+	FindCategorized(ctx context.Context, homeID, limit int) ([]models.Bill, error)
+	SpendingSummary(ctx context.Context, f models.SpendingFilter) (*models.SpendingStat, error)
+	SpendingByGroup(ctx context.Context, f models.SpendingFilter) ([]models.SpendingStat, error)
+	SpendingByMonth(ctx context.Context, f models.SpendingFilter) (map[string][]models.MonthlySpending, error)
 }
 
 type billRepo struct {
@@ -30,13 +44,13 @@ func NewBillRepository(db *gorm.DB) BillRepository {
 }
 
 func (r *billRepo) Create(ctx context.Context, b *models.Bill) error {
-	return r.db.WithContext(ctx).Create(b).Error
+	return dbFor(ctx, r.db).Create(b).Error
 }
 
 func (r *billRepo) FindByID(ctx context.Context, id int) (*models.Bill, error) {
 	var bill models.Bill
 
-	if err := r.db.WithContext(ctx).
+	if err := dbFor(ctx, r.db).
 		Preload("User").
 		Preload("BillSplits").
 		Preload("BillSplits.User").
@@ -51,12 +65,71 @@ func (r *billRepo) FindByID(ctx context.Context, id int) (*models.Bill, error) {
 	return &bill, nil
 }
 
-func (r *billRepo) FindByHomeID(ctx context.Context, homeID int, categoryID *int) ([]models.Bill, error) {
+// FindByHomeID returns up to f.Limit bills matching the filter, after f.After in the filter's order
+func (r *billRepo) FindByHomeID(ctx context.Context, f models.BillFilter) ([]models.Bill, error) {
 	var bills []models.Bill
 
-	query := r.db.WithContext(ctx).Where("home_id = ?", homeID)
-	if categoryID != nil {
-		query = query.Where("bill_category_id = ?", *categoryID)
+	query := dbFor(ctx, r.db).Where("home_id = ?", f.HomeID)
+	if f.CategoryID != nil {
+		query = query.Where("bill_category_id = ?", *f.CategoryID)
+	}
+
+	switch f.Status {
+	case models.BillStatusPaid:
+		query = query.Where("payed = ?", true)
+	case models.BillStatusUnpaid:
+		query = query.Where("payed = ?", false)
+	case models.BillStatusOverdue:
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		query = query.Where("payed = ? AND due_date < ?", false, today)
+	}
+
+	if f.DateField == models.BillDateCreated {
+		if f.From != nil {
+			query = query.Where("created_at >= ?", *f.From)
+		}
+		if f.To != nil {
+			query = query.Where("created_at < ?", f.To.AddDate(0, 0, 1))
+		}
+	} else {
+		if f.From != nil {
+			query = query.Where(`"end" >= ?`, *f.From)
+		}
+		if f.To != nil {
+			query = query.Where("start < ?", f.To.AddDate(0, 0, 1))
+		}
+	}
+
+	if f.UploadedBy != nil {
+		query = query.Where("uploaded_by = ?", *f.UploadedBy)
+	}
+	if f.ParticipantID != nil {
+		query = query.Where("EXISTS (SELECT 1 FROM bill_splits WHERE bill_splits.bill_id = bills.id AND bill_splits.user_id = ?)", *f.ParticipantID)
+	}
+	if f.MinAmount != nil {
+		query = query.Where("total_amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		query = query.Where("total_amount <= ?", *f.MaxAmount)
+	}
+	if f.Search != "" {
+		query = query.Where("description ILIKE ?", "%"+likeEscaper.Replace(f.Search)+"%")
+	}
+
+	column := billSortColumns[f.Sort]
+	if column == "" {
+		column = billSortColumns[models.BillSortCreated]
+	}
+	direction, compare := "DESC", "<"
+	if f.Ascending {
+		direction, compare = "ASC", ">"
+	}
+	if f.After != nil {
+		var value interface{} = f.After.Amount
+		if f.After.Time != nil {
+			value = *f.After.Time
+		}
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, compare), value, f.After.ID)
 	}
 
 	if err := query.
@@ -64,7 +137,8 @@ func (r *billRepo) FindByHomeID(ctx context.Context, homeID int, categoryID *int
 		Preload("BillSplits").
 		Preload("BillSplits.User").
 		Preload("BillCategory").
-		Order("created_at DESC").
+		Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(f.Limit).
 		Find(&bills).Error; err != nil {
 		return nil, err
 	}
@@ -72,39 +146,37 @@ func (r *billRepo) FindByHomeID(ctx context.Context, homeID int, categoryID *int
 	return bills, nil
 }
 
-func (r *billRepo) Delete(ctx context.Context, id int) error {
-	if err := r.db.WithContext(ctx).Where("bill_id = ?", id).Delete(&models.BillSplit{}).Error; err != nil {
-		return err
-	}
-	return r.db.WithContext(ctx).Delete(&models.Bill{}, id).Error
+var billSortColumns = map[string]string{
+	models.BillSortCreated: "created_at",
+	models.BillSortStart:   "start",
+	models.BillSortAmount:  "total_amount",
 }
 
-func (r *billRepo) MarkPayed(ctx context.Context, id int) error {
-	var bill models.Bill
-	if err := r.db.WithContext(ctx).First(&bill, id).Error; err != nil {
-		return err
-	}
+// likeEscaper makes user text match literally inside a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
 
-	bill.Payed = true
-	now := time.Now()
-	bill.PaymentDate = &now
-	if err := r.db.WithContext(ctx).Save(&bill).Error; err != nil {
+func (r *billRepo) Delete(ctx context.Context, id int) error {
+	if err := dbFor(ctx, r.db).Where("bill_id = ?", id).Delete(&models.BillSplit{}).Error; err != nil {
 		return err
 	}
+	return dbFor(ctx, r.db).Delete(&models.Bill{}, id).Error
+}
 
-	return nil
+// Update saves the bill's own columns; splits are replaced through UpdateSplits
+func (r *billRepo) Update(ctx context.Context, b *models.Bill) error {
+	return dbFor(ctx, r.db).Omit(clause.Associations).Save(b).Error
 }
 
 func (r *billRepo) CreateSplits(ctx context.Context, billID int, splits []models.BillSplit) error {
 	for i := range splits {
 		splits[i].BillID = billID
 	}
-	return r.db.WithContext(ctx).Create(&splits).Error
+	return dbFor(ctx, r.db).Create(&splits).Error
 }
 
 func (r *billRepo) UpdateSplits(ctx context.Context, billID int, splits []models.BillSplit) error {
 	// Delete existing splits and create new ones
-	if err := r.db.WithContext(ctx).Where("bill_id = ?", billID).Delete(&models.BillSplit{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("bill_id = ?", billID).Delete(&models.BillSplit{}).Error; err != nil {
 		return err
 	}
 	if len(splits) == 0 {
@@ -113,12 +185,12 @@ func (r *billRepo) UpdateSplits(ctx context.Context, billID int, splits []models
 	for i := range splits {
 		splits[i].BillID = billID
 	}
-	return r.db.WithContext(ctx).Create(&splits).Error
+	return dbFor(ctx, r.db).Create(&splits).Error
 }
 
 func (r *billRepo) FindSplitByID(ctx context.Context, splitID int) (*models.BillSplit, error) {
 	var split models.BillSplit
-	if err := r.db.WithContext(ctx).First(&split, splitID).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&split, splitID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -128,5 +200,260 @@ func (r *billRepo) FindSplitByID(ctx context.Context, splitID int) (*models.Bill
 }
 
 func (r *billRepo) MarkSplitPaid(ctx context.Context, splitID int) error {
-	return r.db.WithContext(ctx).Model(&models.BillSplit{}).Where("id = ?", splitID).Update("paid", true).Error
+	return dbFor(ctx, r.db).Model(&models.BillSplit{}).Where("id = ?", splitID).Updates(map[string]interface{}{
+		"paid":        true,
+		"paid_amount": gorm.Expr("amount"),
+	}).Error
+}
+
+// FindSplitsBetween returns the debtor's splits on bills the creditor uploaded, oldest bill first
+func (r *billRepo) FindSplitsBetween(ctx context.Context, homeID, debtorID, creditorID int) ([]models.BillSplit, error) {
+	var splits []models.BillSplit
+	if err := dbFor(ctx, r.db).
+		Select("bill_splits.*").
+		Joins("JOIN bills ON bills.id = bill_splits.bill_id").
+		Where("bills.home_id = ? AND bill_splits.user_id = ? AND bills.uploaded_by = ?", homeID, debtorID, creditorID).
+		Preload("Bill").
+		Order("bills.created_at, bill_splits.id").
+		Find(&splits).Error; err != nil {
+		return nil, err
+	}
+	return splits, nil
+}
+
+func (r *billRepo) SetSplitPayment(ctx context.Context, splitID int, paidAmount models.Money, paid bool) error {
+	return dbFor(ctx, r.db).Model(&models.BillSplit{}).Where("id = ?", splitID).Updates(map[string]interface{}{
+		"paid_amount": paidAmount,
+		"paid":        paid,
+	}).Error
+}
+
+// FindSplitDebts sums splits per debtor and creditor in the home currency, the creditor being
+// whoever uploaded the bill. Payments are tracked as settlements, so paid splits are still counted here.
+func (r *billRepo) FindSplitDebts(ctx context.Context, homeID int) ([]models.Debt, error) {
+	var debts []models.Debt
+	if err := dbFor(ctx, r.db).
+		Table("bill_splits").
+		Select("bill_splits.user_id AS debtor_id, bills.uploaded_by AS creditor_id, CAST(SUM(ROUND(CAST(bill_splits.amount * bills.exchange_rate AS numeric))) AS bigint) AS amount").
+		Joins("JOIN bills ON bills.id = bill_splits.bill_id").
+		Where("bills.home_id = ? AND bill_splits.user_id <> bills.uploaded_by", homeID).
+		Group("bill_splits.user_id, bills.uploaded_by").
+		Scan(&debts).Error; err != nil {
+		return nil, err
+	}
+	return debts, nil
+}
+
+func (r *billRepo) CreateRevision(ctx context.Context, rev *models.BillRevision) error {
+	return dbFor(ctx, r.db).Create(rev).Error
+}
+
+// FindRevisions returns the bill's edit history, newest first
+func (r *billRepo) FindRevisions(ctx context.Context, billID int) ([]models.BillRevision, error) {
+	var revisions []models.BillRevision
+	if err := dbFor(ctx, r.db).
+		Where("bill_id = ?", billID).
+		Preload("User").
+		Order("created_at DESC, id DESC").
+		Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// FindImportHashes reports which of the hashes were already imported into the home
+func (r *billRepo) FindImportHashes(ctx context.Context, homeID int, hashes []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(hashes) == 0 {
+		return found, nil
+	}
+
+	var existing []string
+	if err := dbFor(ctx, r.db).
+		Model(&models.Bill{}).
+		Where("home_id = ? AND import_hash IN ?", homeID, hashes).
+		Pluck("import_hash", &existing).Error; err != nil {
+		return nil, err
+	}
+	for _, h := range existing {
+		found[h] = true
+	}
+	return found, nil
+}
+
+// FindDuplicates returns bills of the home with the same receipt file, or with the same
+// vendor, receipt date and total in their OCR data. Empty values are not matched.
+func (r *billRepo) FindDuplicates(ctx context.Context, homeID int, receiptHash, vendor, date string, total models.Money) ([]models.Bill, error) {
+	var conditions []string
+	var args []interface{}
+	if receiptHash != "" {
+		conditions = append(conditions, "receipt_hash = ?")
+		args = append(args, receiptHash)
+	}
+	if vendor != "" && date != "" {
+		conditions = append(conditions, "(lower(ocr_data->>'vendor') = lower(?) AND ocr_data->>'date' = ? AND total_amount = ?)")
+		args = append(args, vendor, date, total)
+	}
+
+	var bills []models.Bill
+	if len(conditions) == 0 {
+		return bills, nil
+	}
+
+	if err := dbFor(ctx, r.db).
+		Where("home_id = ?", homeID).
+		Where(strings.Join(conditions, " OR "), args...).
+		Order("id").
+		Find(&bills).Error; err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
+
+// FindCategorized returns the home's latest bills that have a category, without relations
+func (r *billRepo) FindCategorized(ctx context.Context, homeID, limit int) ([]models.Bill, error) {
+	var bills []models.Bill
+	if err := dbFor(ctx, r.db).
+		Select("id", "bill_category_id", "description", "ocr_data", "created_at").
+		Where("home_id = ? AND bill_category_id IS NOT NULL", homeID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&bills).Error; err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
+
+type spendingRow struct {
+	GroupKey string
+	Label    string
+	Month    string
+	Total    models.Money
+	Count    int
+	Average  models.Money
+	Change   *models.Money
+}
+
+// spendingQuery selects the rows a filter covers and returns the SQL expression of each
+// row's amount in the home currency: whole bills, or splits when grouping by participant.
+func spendingQuery(db *gorm.DB, f models.SpendingFilter) (*gorm.DB, string) {
+	var query *gorm.DB
+	amount := "ROUND(CAST(bills.total_amount * bills.exchange_rate AS numeric))"
+	if f.GroupBy == models.StatsGroupParticipant {
+		query = db.Table("bill_splits").Joins("JOIN bills ON bills.id = bill_splits.bill_id")
+		amount = "ROUND(CAST(bill_splits.amount * bills.exchange_rate AS numeric))"
+	} else {
+		query = db.Table("bills")
+	}
+
+	query = query.Where("bills.home_id = ?", f.HomeID)
+	if f.From != nil {
+		query = query.Where("bills.start >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("bills.start < ?", f.To.AddDate(0, 0, 1))
+	}
+	return query, amount
+}
+
+// spendingGroup adds the joins a grouping needs and returns its key and label expressions
+func spendingGroup(query *gorm.DB, groupBy string) (*gorm.DB, string, string) {
+	switch groupBy {
+	case models.StatsGroupUploader:
+		return query.Joins("JOIN users ON users.id = bills.uploaded_by"), "CAST(bills.uploaded_by AS text)", "users.name"
+	case models.StatsGroupParticipant:
+		return query.Joins("JOIN users ON users.id = bill_splits.user_id"), "CAST(bill_splits.user_id AS text)", "users.name"
+	case models.StatsGroupMonth:
+		month := "to_char(date_trunc('month', bills.start), 'YYYY-MM')"
+		return query, month, month
+	default:
+		return query.Joins("LEFT JOIN bill_categories ON bill_categories.id = bills.bill_category_id"),
+			"CAST(COALESCE(bills.bill_category_id, 0) AS text)", "COALESCE(bill_categories.name, 'Uncategorized')"
+	}
+}
+
+func aggregates(amount string) string {
+	return "CAST(COALESCE(SUM(" + amount + "), 0) AS bigint) AS total, COUNT(*) AS count, CAST(COALESCE(ROUND(AVG(" + amount + ")), 0) AS bigint) AS average"
+}
+
+// SpendingSummary totals everything the filter covers
+func (r *billRepo) SpendingSummary(ctx context.Context, f models.SpendingFilter) (*models.SpendingStat, error) {
+	query, amount := spendingQuery(dbFor(ctx, r.db), f)
+
+	var row spendingRow
+	if err := query.Select(aggregates(amount)).Scan(&row).Error; err != nil {
+		return nil, err
+	}
+	return &models.SpendingStat{Total: row.Total, Count: row.Count, Average: row.Average}, nil
+}
+
+// SpendingByGroup totals the filtered rows per group, biggest first; months are returned in order
+func (r *billRepo) SpendingByGroup(ctx context.Context, f models.SpendingFilter) ([]models.SpendingStat, error) {
+	query, amount := spendingQuery(dbFor(ctx, r.db), f)
+	query, key, label := spendingGroup(query, f.GroupBy)
+
+	order := "total DESC, group_key"
+	if f.GroupBy == models.StatsGroupMonth {
+		order = "group_key"
+	}
+
+	var rows []spendingRow
+	if err := query.
+		Select(key + " AS group_key, " + label + " AS label, " + aggregates(amount)).
+		Group("group_key, label").
+		Order(order).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.SpendingStat, len(rows))
+	for i, row := range rows {
+		stats[i] = models.SpendingStat{Key: row.GroupKey, Label: row.Label, Total: row.Total, Count: row.Count, Average: row.Average}
+	}
+	return stats, nil
+}
+
+// SpendingByMonth totals every group per month, keyed by group. The change to the previous
+// month treats a month without bills as zero, as long as it lies within the data or the filter.
+func (r *billRepo) SpendingByMonth(ctx context.Context, f models.SpendingFilter) (map[string][]models.MonthlySpending, error) {
+	query, amount := spendingQuery(dbFor(ctx, r.db), f)
+	query, key, _ := spendingGroup(query, f.GroupBy)
+	if f.GroupBy == models.StatsGroupMonth {
+		// a single series of months
+		key = "''"
+	}
+
+	monthly := query.
+		Select(key + " AS group_key, date_trunc('month', bills.start) AS month, " + aggregates(amount)).
+		Group("group_key, month")
+
+	// the first month of the range had a previous month of zero if the range started earlier
+	rangeStart := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	if f.From != nil {
+		rangeStart = time.Date(f.From.Year(), f.From.Month(), 1, 0, 0, 0, 0, f.From.Location())
+	}
+	previous := "OVER (PARTITION BY group_key ORDER BY month)"
+	change := "CASE WHEN LAG(month) " + previous + " = month - interval '1 month' THEN total - LAG(total) " + previous +
+		" WHEN LAG(month) " + previous + " IS NOT NULL OR month > ? THEN total END AS change"
+
+	var rows []spendingRow
+	if err := dbFor(ctx, r.db).
+		Table("(?) AS monthly", monthly).
+		Select("group_key, to_char(month, 'YYYY-MM') AS month, total, count, average, "+change, rangeStart).
+		Order("group_key, month").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	months := make(map[string][]models.MonthlySpending)
+	for _, row := range rows {
+		months[row.GroupKey] = append(months[row.GroupKey], models.MonthlySpending{
+			Month:   row.Month,
+			Total:   row.Total,
+			Count:   row.Count,
+			Average: row.Average,
+			Change:  row.Change,
+		})
+	}
+	return months, nil
 }
@@ -26,22 +26,22 @@ func NewBillCategoryRepository(db *gorm.DB) *BillCategoryRepository {
 }
 
 func (r *BillCategoryRepository) Create(ctx context.Context, category *models.BillCategory) error {
-	return r.db.WithContext(ctx).Create(category).Error
+	return dbFor(ctx, r.db).Create(category).Error
 }
 
 func (r *BillCategoryRepository) GetByHomeID(ctx context.Context, homeID int) ([]models.BillCategory, error) {
 	var categories []models.BillCategory
-	err := r.db.WithContext(ctx).Where("home_id = ?", homeID).Find(&categories).Error
+	err := dbFor(ctx, r.db).Where("home_id = ?", homeID).Find(&categories).Error
 	return categories, err
 }
 
 func (r *BillCategoryRepository) Delete(ctx context.Context, id int) error {
-	return r.db.WithContext(ctx).Delete(&models.BillCategory{}, id).Error
+	return dbFor(ctx, r.db).Delete(&models.BillCategory{}, id).Error
 }
 
 func (r *BillCategoryRepository) GetByID(ctx context.Context, id int) (*models.BillCategory, error) {
 	var category models.BillCategory
-	err := r.db.WithContext(ctx).First(&category, id).Error
+	err := dbFor(ctx, r.db).First(&category, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +49,7 @@ func (r *BillCategoryRepository) GetByID(ctx context.Context, id int) (*models.B
 }
 
 func (r *BillCategoryRepository) Update(ctx context.Context, category *models.BillCategory, updates map[string]interface{}) (*models.BillCategory, error) {
-	err := r.db.WithContext(ctx).Model(category).Clauses(clause.Returning{}).Updates(updates).Error
+	err := dbFor(ctx, r.db).Model(category).Clauses(clause.Returning{}).Updates(updates).Error
 	if err != nil {
 		return nil, err
 	}
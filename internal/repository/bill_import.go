@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type BillImportRepository interface {
+	CreateProfile(ctx context.Context, p *models.ImportProfile) error
+	FindProfile(ctx context.Context, id int) (*models.ImportProfile, error)
+	FindProfilesByHome(ctx context.Context, homeID int) ([]models.ImportProfile, error)
+	DeleteProfile(ctx context.Context, id int) error
+	CreateRule(ctx context.Context, rule *models.VendorRule) error
+	FindRule(ctx context.Context, id int) (*models.VendorRule, error)
+	FindRulesByHome(ctx context.Context, homeID int) ([]models.VendorRule, error)
+	DeleteRule(ctx context.Context, id int) error
+	Create(ctx context.Context, imp *models.BillImport) error
+	FindByID(ctx context.Context, id int) (*models.BillImport, error)
+	MarkCommitted(ctx context.Context, id int, at time.Time) (bool, error)
+}
+
+type billImportRepo struct {
+	db *gorm.DB
+}
+
+func NewBillImportRepository(db *gorm.DB) BillImportRepository {
+	return &billImportRepo{db}
+}
+
+func (r *billImportRepo) CreateProfile(ctx context.Context, p *models.ImportProfile) error {
+	return dbFor(ctx, r.db).Create(p).Error
+}
+
+func (r *billImportRepo) FindProfile(ctx context.Context, id int) (*models.ImportProfile, error) {
+	var profile models.ImportProfile
+	if err := dbFor(ctx, r.db).First(&profile, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *billImportRepo) FindProfilesByHome(ctx context.Context, homeID int) ([]models.ImportProfile, error) {
+	var profiles []models.ImportProfile
+	if err := dbFor(ctx, r.db).Where("home_id = ?", homeID).Order("name").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (r *billImportRepo) DeleteProfile(ctx context.Context, id int) error {
+	return dbFor(ctx, r.db).Delete(&models.ImportProfile{}, id).Error
+}
+
+func (r *billImportRepo) CreateRule(ctx context.Context, rule *models.VendorRule) error {
+	return dbFor(ctx, r.db).Create(rule).Error
+}
+
+func (r *billImportRepo) FindRule(ctx context.Context, id int) (*models.VendorRule, error) {
+	var rule models.VendorRule
+	if err := dbFor(ctx, r.db).First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *billImportRepo) FindRulesByHome(ctx context.Context, homeID int) ([]models.VendorRule, error) {
+	var rules []models.VendorRule
+	if err := dbFor(ctx, r.db).
+		Preload("BillCategory").
+		Where("home_id = ?", homeID).
+		Order("id").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *billImportRepo) DeleteRule(ctx context.Context, id int) error {
+	return dbFor(ctx, r.db).Delete(&models.VendorRule{}, id).Error
+}
+
+func (r *billImportRepo) Create(ctx context.Context, imp *models.BillImport) error {
+	return dbFor(ctx, r.db).Create(imp).Error
+}
+
+func (r *billImportRepo) FindByID(ctx context.Context, id int) (*models.BillImport, error) {
+	var imp models.BillImport
+	if err := dbFor(ctx, r.db).First(&imp, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// MarkCommitted moves a preview to committed; false means it was committed already
+func (r *billImportRepo) MarkCommitted(ctx context.Context, id int, at time.Time) (bool, error) {
+	result := dbFor(ctx, r.db).
+		Model(&models.BillImport{}).
+		Where("id = ? AND status = ?", id, models.ImportStatusPreview).
+		Updates(map[string]interface{}{"status": models.ImportStatusCommitted, "committed_at": at})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
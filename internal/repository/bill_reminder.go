@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BillReminderRepository interface {
+	UpsertSettings(ctx context.Context, s *models.BillReminderSettings) error
+	FindSettings(ctx context.Context, homeID int) (*models.BillReminderSettings, error)
+	LeadDays(ctx context.Context, homeIDs []int) (map[int]int, error)
+	FindUnpaidDueSplits(ctx context.Context, dueBefore time.Time) ([]models.BillSplit, error)
+	MarkSent(ctx context.Context, reminder *models.BillReminder) (bool, error)
+}
+
+type billReminderRepo struct {
+	db *gorm.DB
+}
+
+func NewBillReminderRepository(db *gorm.DB) BillReminderRepository {
+	return &billReminderRepo{db}
+}
+
+func (r *billReminderRepo) UpsertSettings(ctx context.Context, s *models.BillReminderSettings) error {
+	return dbFor(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "home_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"lead_days", "updated_at"}),
+	}).Create(s).Error
+}
+
+func (r *billReminderRepo) FindSettings(ctx context.Context, homeID int) (*models.BillReminderSettings, error) {
+	var settings models.BillReminderSettings
+	if err := dbFor(ctx, r.db).Where("home_id = ?", homeID).First(&settings).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// LeadDays returns the lead time of the homes that set one
+func (r *billReminderRepo) LeadDays(ctx context.Context, homeIDs []int) (map[int]int, error) {
+	var settings []models.BillReminderSettings
+	if err := dbFor(ctx, r.db).Where("home_id IN ?", homeIDs).Find(&settings).Error; err != nil {
+		return nil, err
+	}
+
+	leadDays := make(map[int]int, len(settings))
+	for _, s := range settings {
+		leadDays[s.HomeID] = s.LeadDays
+	}
+	return leadDays, nil
+}
+
+// FindUnpaidDueSplits returns the unpaid splits of unpaid bills due before dueBefore, with
+// their bill. Splits owed to the uploader and splits already reminded as overdue are left out.
+func (r *billReminderRepo) FindUnpaidDueSplits(ctx context.Context, dueBefore time.Time) ([]models.BillSplit, error) {
+	var splits []models.BillSplit
+	if err := dbFor(ctx, r.db).
+		Select("bill_splits.*").
+		Joins("JOIN bills ON bills.id = bill_splits.bill_id").
+		Where("bills.due_date IS NOT NULL AND bills.due_date < ? AND bills.payed = ?", dueBefore, false).
+		Where("bill_splits.paid = ? AND bill_splits.user_id <> bills.uploaded_by", false).
+		Where(`NOT EXISTS (SELECT 1 FROM bill_reminders br WHERE br.bill_id = bill_splits.bill_id
+			AND br.user_id = bill_splits.user_id AND br.kind = ? AND br.due_date = bills.due_date)`, models.ReminderOverdue).
+		Preload("Bill").
+		Order("bill_splits.id").
+		Find(&splits).Error; err != nil {
+		return nil, err
+	}
+	return splits, nil
+}
+
+// MarkSent records a reminder, reporting false when the same one was already sent
+func (r *billReminderRepo) MarkSent(ctx context.Context, reminder *models.BillReminder) (bool, error) {
+	res := dbFor(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
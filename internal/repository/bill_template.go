@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type BillTemplateRepository interface {
+	Create(ctx context.Context, t *models.BillTemplate) error
+	FindByID(ctx context.Context, id int) (*models.BillTemplate, error)
+	FindByHomeID(ctx context.Context, homeID int) ([]models.BillTemplate, error)
+	FindDue(ctx context.Context, now time.Time) ([]models.BillTemplate, error)
+	Advance(ctx context.Context, id, occurrences int, nextRun time.Time) (bool, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type billTemplateRepo struct {
+	db *gorm.DB
+}
+
+func NewBillTemplateRepository(db *gorm.DB) BillTemplateRepository {
+	return &billTemplateRepo{db}
+}
+
+func (r *billTemplateRepo) Create(ctx context.Context, t *models.BillTemplate) error {
+	return dbFor(ctx, r.db).Create(t).Error
+}
+
+func (r *billTemplateRepo) FindByID(ctx context.Context, id int) (*models.BillTemplate, error) {
+	var template models.BillTemplate
+	err := dbFor(ctx, r.db).Preload("BillCategory").First(&template, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &template, err
+}
+
+func (r *billTemplateRepo) FindByHomeID(ctx context.Context, homeID int) ([]models.BillTemplate, error) {
+	var templates []models.BillTemplate
+	err := dbFor(ctx, r.db).
+		Preload("BillCategory").
+		Where("home_id = ?", homeID).
+		Order("next_run_date").
+		Find(&templates).Error
+	return templates, err
+}
+
+func (r *billTemplateRepo) FindDue(ctx context.Context, now time.Time) ([]models.BillTemplate, error) {
+	var templates []models.BillTemplate
+	err := dbFor(ctx, r.db).
+		Where("is_active = ? AND next_run_date <= ?", true, now).
+		Order("next_run_date").
+		Find(&templates).Error
+	return templates, err
+}
+
+// Advance moves the template to its next period, but only if no one else generated the
+// current one in the meantime. It reports whether the template was advanced.
+func (r *billTemplateRepo) Advance(ctx context.Context, id, occurrences int, nextRun time.Time) (bool, error) {
+	res := dbFor(ctx, r.db).
+		Model(&models.BillTemplate{}).
+		Where("id = ? AND occurrences = ?", id, occurrences-1).
+		Updates(map[string]interface{}{
+			"occurrences":   occurrences,
+			"next_run_date": nextRun,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *billTemplateRepo) Delete(ctx context.Context, id int) error {
+	return dbFor(ctx, r.db).Delete(&models.BillTemplate{}, id).Error
+}
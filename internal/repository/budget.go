@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BudgetRepository interface {
+	Upsert(ctx context.Context, b *models.BillCategoryBudget) error
+	FindByCategoryID(ctx context.Context, categoryID int) (*models.BillCategoryBudget, error)
+	FindByHomeID(ctx context.Context, homeID int) ([]models.BillCategoryBudget, error)
+	Delete(ctx context.Context, categoryID int) error
+	SpentByCategory(ctx context.Context, homeID int, from, to time.Time) (map[int]models.Money, error)
+}
+
+type budgetRepo struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepository(db *gorm.DB) BudgetRepository {
+	return &budgetRepo{db}
+}
+
+func (r *budgetRepo) Upsert(ctx context.Context, b *models.BillCategoryBudget) error {
+	return dbFor(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bill_category_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"amount", "updated_at"}),
+	}).Create(b).Error
+}
+
+func (r *budgetRepo) FindByCategoryID(ctx context.Context, categoryID int) (*models.BillCategoryBudget, error) {
+	var budget models.BillCategoryBudget
+	if err := dbFor(ctx, r.db).
+		Preload("BillCategory").
+		Where("bill_category_id = ?", categoryID).
+		First(&budget).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *budgetRepo) FindByHomeID(ctx context.Context, homeID int) ([]models.BillCategoryBudget, error) {
+	var budgets []models.BillCategoryBudget
+	if err := dbFor(ctx, r.db).
+		Preload("BillCategory").
+		Where("home_id = ?", homeID).
+		Order("bill_category_id").
+		Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+func (r *budgetRepo) Delete(ctx context.Context, categoryID int) error {
+	return dbFor(ctx, r.db).Where("bill_category_id = ?", categoryID).Delete(&models.BillCategoryBudget{}).Error
+}
+
+// SpentByCategory sums the bills of every category in the home currency, placing bills
+// by the start of their period within [from, to)
+func (r *budgetRepo) SpentByCategory(ctx context.Context, homeID int, from, to time.Time) (map[int]models.Money, error) {
+	var rows []struct {
+		BillCategoryID int
+		Spent          models.Money
+	}
+	if err := dbFor(ctx, r.db).
+		Table("bills").
+		Select("bill_category_id, CAST(SUM(ROUND(CAST(total_amount * exchange_rate AS numeric))) AS bigint) AS spent").
+		Where("home_id = ? AND bill_category_id IS NOT NULL AND start >= ? AND start < ?", homeID, from, to).
+		Group("bill_category_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	spent := make(map[int]models.Money, len(rows))
+	for _, row := range rows {
+		spent[row.BillCategoryID] = row.Spent
+	}
+	return spent, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExchangeRateRepository interface {
+	Find(ctx context.Context, homeID int, base, currency string) (*models.ExchangeRate, error)
+	FindByHome(ctx context.Context, homeID int, base string) ([]models.ExchangeRate, error)
+	Upsert(ctx context.Context, rate *models.ExchangeRate) error
+	Delete(ctx context.Context, homeID int, base, currency string) error
+}
+
+type exchangeRateRepo struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRateRepo{db}
+}
+
+func (r *exchangeRateRepo) Find(ctx context.Context, homeID int, base, currency string) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	if err := dbFor(ctx, r.db).
+		Where("home_id = ? AND base_currency = ? AND currency = ?", homeID, base, currency).
+		First(&rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *exchangeRateRepo) FindByHome(ctx context.Context, homeID int, base string) ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	if err := dbFor(ctx, r.db).
+		Where("home_id = ? AND base_currency = ?", homeID, base).
+		Order("currency").
+		Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (r *exchangeRateRepo) Upsert(ctx context.Context, rate *models.ExchangeRate) error {
+	return dbFor(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "home_id"}, {Name: "base_currency"}, {Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(rate).Error
+}
+
+func (r *exchangeRateRepo) Delete(ctx context.Context, homeID int, base, currency string) error {
+	return dbFor(ctx, r.db).
+		Where("home_id = ? AND base_currency = ? AND currency = ?", homeID, base, currency).
+		Delete(&models.ExchangeRate{}).Error
+}
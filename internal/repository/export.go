@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize bounds how many rows of a home are held in memory while exporting
+const exportBatchSize = 200
+
+// ExportRepository reads everything of a home in batches, so exports of large homes
+// are streamed to the client instead of being loaded at once.
+type ExportRepository interface {
+	StreamBills(ctx context.Context, homeID int, fn func([]models.Bill) error) error
+	StreamTasks(ctx context.Context, homeID int, fn func([]models.Task) error) error
+	StreamDueTasks(ctx context.Context, homeID int, fn func([]models.Task) error) error
+	StreamShoppingItems(ctx context.Context, homeID int, fn func([]models.ShoppingItem) error) error
+	ShoppingCategoryNames(ctx context.Context, homeID int) (map[int]string, error)
+	StreamPolls(ctx context.Context, homeID int, fn func([]models.Poll) error) error
+}
+
+type exportRepo struct {
+	db *gorm.DB
+}
+
+func NewExportRepository(db *gorm.DB) ExportRepository {
+	return &exportRepo{db}
+}
+
+func (r *exportRepo) StreamBills(ctx context.Context, homeID int, fn func([]models.Bill) error) error {
+	var batch []models.Bill
+	return dbFor(ctx, r.db).
+		Preload("User").
+		Preload("BillCategory").
+		Preload("BillSplits", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("BillSplits.User").
+		Where("home_id = ?", homeID).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+func (r *exportRepo) StreamTasks(ctx context.Context, homeID int, fn func([]models.Task) error) error {
+	var batch []models.Task
+	return dbFor(ctx, r.db).
+		Preload("Room").
+		Preload("Creator").
+		Preload("TaskAssignments", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("TaskAssignments.User").
+		Where("home_id = ?", homeID).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+// StreamDueTasks reads the tasks with a due date that nobody has completed yet
+func (r *exportRepo) StreamDueTasks(ctx context.Context, homeID int, fn func([]models.Task) error) error {
+	var batch []models.Task
+	return dbFor(ctx, r.db).
+		Preload("Room").
+		Preload("TaskAssignments.User").
+		Where("home_id = ? AND due_date IS NOT NULL", homeID).
+		Where("NOT EXISTS (SELECT 1 FROM task_assignments ta WHERE ta.task_id = tasks.id AND ta.status = ?)", "completed").
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+func (r *exportRepo) StreamShoppingItems(ctx context.Context, homeID int, fn func([]models.ShoppingItem) error) error {
+	var batch []models.ShoppingItem
+	return dbFor(ctx, r.db).
+		Preload("User").
+		Joins("JOIN shopping_categories ON shopping_categories.id = shopping_items.category_id").
+		Where("shopping_categories.home_id = ?", homeID).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+func (r *exportRepo) ShoppingCategoryNames(ctx context.Context, homeID int) (map[int]string, error) {
+	var categories []models.ShoppingCategory
+	if err := dbFor(ctx, r.db).Select("id", "name").Where("home_id = ?", homeID).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+	return names, nil
+}
+
+// StreamPolls reads polls with their options and votes, but not who voted
+func (r *exportRepo) StreamPolls(ctx context.Context, homeID int, fn func([]models.Poll) error) error {
+	var batch []models.Poll
+	return dbFor(ctx, r.db).
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Options.Votes").
+		Where("home_id = ?", homeID).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
@@ -30,6 +30,7 @@ type HomeRepository interface {
 	GenerateUniqueInviteCode(ctx context.Context) (string, error)
 	GetUserHome(ctx context.Context, userID int) (*models.Home, error)
 	GetUserHomes(ctx context.Context, userID int) ([]models.Home, error)
+	GetUserHomeIDs(ctx context.Context, userID int) ([]int, error)
 	UpdateMemberRole(ctx context.Context, homeID int, userID int, role string) error
 }
 
@@ -42,12 +43,12 @@ func NewHomeRepository(db *gorm.DB) HomeRepository {
 }
 
 func (r *homeRepo) Create(ctx context.Context, h *models.Home) error {
-	return r.db.WithContext(ctx).Create(h).Error
+	return dbFor(ctx, r.db).Create(h).Error
 }
 
 func (r *homeRepo) RegenerateCode(ctx context.Context, code string, id int) error {
 	var home models.Home
-	if err := r.db.WithContext(ctx).First(&home, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&home, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil
 		}
@@ -55,7 +56,7 @@ func (r *homeRepo) RegenerateCode(ctx context.Context, code string, id int) erro
 	}
 
 	home.InviteCode = code
-	return r.db.WithContext(ctx).Save(&home).Error
+	return dbFor(ctx, r.db).Save(&home).Error
 
 }
 
@@ -63,7 +64,7 @@ func (r *homeRepo) FindByID(ctx context.Context, id int) (*models.Home, error) {
 	var home models.Home
 
 	// taking memberships also
-	if err := r.db.WithContext(ctx).Preload("Memberships").Preload("Memberships.User").First(&home, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Memberships").Preload("Memberships.User").First(&home, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -77,7 +78,7 @@ func (r *homeRepo) FindByInviteCode(ctx context.Context, inviteCode string) (*mo
 	var home models.Home
 
 	// taking memberships also
-	if err := r.db.WithContext(ctx).Preload("Memberships").Preload("Memberships.User").Where("invite_code = ?", inviteCode).First(&home).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Memberships").Preload("Memberships.User").Where("invite_code = ?", inviteCode).First(&home).Error; err != nil {
 		return nil, err
 	}
 
@@ -86,83 +87,83 @@ func (r *homeRepo) FindByInviteCode(ctx context.Context, inviteCode string) (*mo
 
 func (r *homeRepo) Delete(ctx context.Context, id int) error {
 	// 1. Delete HomeMemberships
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Delete(&models.HomeMembership{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Delete(&models.HomeMembership{}).Error; err != nil {
 		return err
 	}
 
 	// 2. Delete HomeNotifications
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Delete(&models.HomeNotification{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Delete(&models.HomeNotification{}).Error; err != nil {
 		return err
 	}
 
 	// 3. Delete Bills
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Delete(&models.Bill{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Delete(&models.Bill{}).Error; err != nil {
 		return err
 	}
 
 	// 4. Delete Tasks (and assignments)
 	var tasks []models.Task
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Find(&tasks).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Find(&tasks).Error; err != nil {
 		return err
 	}
 	for _, task := range tasks {
-		if err := r.db.WithContext(ctx).Where("task_id = ?", task.ID).Delete(&models.TaskAssignment{}).Error; err != nil {
+		if err := dbFor(ctx, r.db).Where("task_id = ?", task.ID).Delete(&models.TaskAssignment{}).Error; err != nil {
 			return err
 		}
 	}
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Delete(&models.Task{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Delete(&models.Task{}).Error; err != nil {
 		return err
 	}
 
 	// 5. Delete ShoppingCategories (and items)
 	var categories []models.ShoppingCategory
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Find(&categories).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Find(&categories).Error; err != nil {
 		return err
 	}
 	for _, cat := range categories {
-		if err := r.db.WithContext(ctx).Where("category_id = ?", cat.ID).Delete(&models.ShoppingItem{}).Error; err != nil {
+		if err := dbFor(ctx, r.db).Where("category_id = ?", cat.ID).Delete(&models.ShoppingItem{}).Error; err != nil {
 			return err
 		}
 	}
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Delete(&models.ShoppingCategory{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Delete(&models.ShoppingCategory{}).Error; err != nil {
 		return err
 	}
 
 	// 6. Delete Polls (and options/votes)
 	var polls []models.Poll
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Find(&polls).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Find(&polls).Error; err != nil {
 		return err
 	}
 	for _, poll := range polls {
 		var options []models.Option
-		if err := r.db.WithContext(ctx).Where("poll_id = ?", poll.ID).Find(&options).Error; err != nil {
+		if err := dbFor(ctx, r.db).Where("poll_id = ?", poll.ID).Find(&options).Error; err != nil {
 			return err
 		}
 		for _, opt := range options {
-			if err := r.db.WithContext(ctx).Where("option_id = ?", opt.ID).Delete(&models.Vote{}).Error; err != nil {
+			if err := dbFor(ctx, r.db).Where("option_id = ?", opt.ID).Delete(&models.Vote{}).Error; err != nil {
 				return err
 			}
 		}
-		if err := r.db.WithContext(ctx).Where("poll_id = ?", poll.ID).Delete(&models.Option{}).Error; err != nil {
+		if err := dbFor(ctx, r.db).Where("poll_id = ?", poll.ID).Delete(&models.Option{}).Error; err != nil {
 			return err
 		}
 	}
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Delete(&models.Poll{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Delete(&models.Poll{}).Error; err != nil {
 		return err
 	}
 
 	// 7. Delete Rooms
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Delete(&models.Room{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Delete(&models.Room{}).Error; err != nil {
 		return err
 	}
 
 	// 8. Delete Home
-	return r.db.WithContext(ctx).Delete(&models.Home{}, id).Error
+	return dbFor(ctx, r.db).Delete(&models.Home{}, id).Error
 }
 
 func (r *homeRepo) AddMember(ctx context.Context, id int, userID int, role string, status string) error {
 
-	if err := r.db.WithContext(ctx).Create(&models.HomeMembership{
+	if err := dbFor(ctx, r.db).Create(&models.HomeMembership{
 		HomeID: id,
 		UserID: userID,
 		Role:   role,
@@ -177,7 +178,7 @@ func (r *homeRepo) AddMember(ctx context.Context, id int, userID int, role strin
 func (r *homeRepo) IsMember(ctx context.Context, id int, userID int) (bool, error) {
 
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&models.HomeMembership{}).Where("home_id = ? AND user_id = ? AND status = 'approved'", id, userID).Count(&count).Error; err != nil {
+	if err := dbFor(ctx, r.db).Model(&models.HomeMembership{}).Where("home_id = ? AND user_id = ? AND status = 'approved'", id, userID).Count(&count).Error; err != nil {
 		return false, err
 	}
 
@@ -186,14 +187,14 @@ func (r *homeRepo) IsMember(ctx context.Context, id int, userID int) (bool, erro
 
 func (r *homeRepo) IsPendingMember(ctx context.Context, id int, userID int) (bool, error) {
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&models.HomeMembership{}).Where("home_id = ? AND user_id = ? AND status = 'pending'", id, userID).Count(&count).Error; err != nil {
+	if err := dbFor(ctx, r.db).Model(&models.HomeMembership{}).Where("home_id = ? AND user_id = ? AND status = 'pending'", id, userID).Count(&count).Error; err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
 
 func (r *homeRepo) ApproveMember(ctx context.Context, homeID int, userID int) error {
-	result := r.db.WithContext(ctx).Model(&models.HomeMembership{}).
+	result := dbFor(ctx, r.db).Model(&models.HomeMembership{}).
 		Where("home_id = ? AND user_id = ? AND status = 'pending'", homeID, userID).
 		Update("status", "approved")
 	if result.Error != nil {
@@ -206,7 +207,7 @@ func (r *homeRepo) ApproveMember(ctx context.Context, homeID int, userID int) er
 }
 
 func (r *homeRepo) RejectMember(ctx context.Context, homeID int, userID int) error {
-	result := r.db.WithContext(ctx).
+	result := dbFor(ctx, r.db).
 		Where("home_id = ? AND user_id = ? AND status = 'pending'", homeID, userID).
 		Delete(&models.HomeMembership{})
 	if result.Error != nil {
@@ -220,7 +221,7 @@ func (r *homeRepo) RejectMember(ctx context.Context, homeID int, userID int) err
 
 func (r *homeRepo) GetPendingMembers(ctx context.Context, homeID int) ([]models.HomeMembership, error) {
 	var members []models.HomeMembership
-	if err := r.db.WithContext(ctx).Where("home_id = ? AND status = 'pending'", homeID).Preload("User").Find(&members).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ? AND status = 'pending'", homeID).Preload("User").Find(&members).Error; err != nil {
 		return nil, err
 	}
 	return members, nil
@@ -228,7 +229,7 @@ func (r *homeRepo) GetPendingMembers(ctx context.Context, homeID int) ([]models.
 
 func (r *homeRepo) DeleteMember(ctx context.Context, id int, userID int) error {
 
-	if err := r.db.WithContext(ctx).Where("home_id = ? AND user_id = ?", id, userID).Delete(&models.HomeMembership{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ? AND user_id = ?", id, userID).Delete(&models.HomeMembership{}).Error; err != nil {
 		return err
 	}
 
@@ -237,7 +238,7 @@ func (r *homeRepo) DeleteMember(ctx context.Context, id int, userID int) error {
 
 func (r *homeRepo) GetMembers(ctx context.Context, homeID int) ([]models.HomeMembership, error) {
 	var members []models.HomeMembership
-	if err := r.db.WithContext(ctx).Where("home_id = ? AND status = 'approved'", homeID).Preload("User").Find(&members).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ? AND status = 'approved'", homeID).Preload("User").Find(&members).Error; err != nil {
 		return nil, err
 	}
 	return members, nil
@@ -248,7 +249,7 @@ func (r *homeRepo) GenerateUniqueInviteCode(ctx context.Context) (string, error)
 		code := utils.RandString(8)
 
 		var count int64
-		if err := r.db.WithContext(ctx).Model(&models.Home{}).
+		if err := dbFor(ctx, r.db).Model(&models.Home{}).
 			Where("invite_code = ?", code).
 			Count(&count).Error; err != nil {
 			return "", err
@@ -262,7 +263,7 @@ func (r *homeRepo) GenerateUniqueInviteCode(ctx context.Context) (string, error)
 
 func (r *homeRepo) IsAdmin(ctx context.Context, id int, userID int) (bool, error) {
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&models.HomeMembership{}).Where("home_id = ? AND user_id = ? AND role='admin'", id, userID).Count(&count).Error; err != nil {
+	if err := dbFor(ctx, r.db).Model(&models.HomeMembership{}).Where("home_id = ? AND user_id = ? AND role='admin'", id, userID).Count(&count).Error; err != nil {
 		return false, err
 	}
 
@@ -272,15 +273,25 @@ func (r *homeRepo) IsAdmin(ctx context.Context, id int, userID int) (bool, error
 func (r *homeRepo) GetUserHomes(ctx context.Context, userID int) ([]models.Home, error) {
 	var homes []models.Home
 
-	if err := r.db.WithContext(ctx).Model(&models.Home{}).Joins("JOIN home_memberships ON home_memberships.home_id = homes.id").Where("home_memberships.user_id = ? AND home_memberships.status = 'approved'", userID).Preload("Memberships").Preload("Memberships.User").Find(&homes).Error; err != nil {
+	if err := dbFor(ctx, r.db).Model(&models.Home{}).Joins("JOIN home_memberships ON home_memberships.home_id = homes.id").Where("home_memberships.user_id = ? AND home_memberships.status = 'approved'", userID).Preload("Memberships").Preload("Memberships.User").Find(&homes).Error; err != nil {
 		return nil, err
 	}
 
 	return homes, nil
 }
 
+func (r *homeRepo) GetUserHomeIDs(ctx context.Context, userID int) ([]int, error) {
+	var ids []int
+
+	if err := dbFor(ctx, r.db).Model(&models.HomeMembership{}).Where("user_id = ? AND status = 'approved'", userID).Pluck("home_id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
 func (r *homeRepo) UpdateMemberRole(ctx context.Context, homeID int, userID int, role string) error {
-	result := r.db.WithContext(ctx).Model(&models.HomeMembership{}).
+	result := dbFor(ctx, r.db).Model(&models.HomeMembership{}).
 		Where("home_id = ? AND user_id = ? AND status = 'approved'", homeID, userID).
 		Update("role", role)
 	if result.Error != nil {
@@ -295,7 +306,7 @@ func (r *homeRepo) UpdateMemberRole(ctx context.Context, homeID int, userID int,
 func (r *homeRepo) GetUserHome(ctx context.Context, userID int) (*models.Home, error) {
 	var home models.Home
 
-	if err := r.db.WithContext(ctx).Model(&models.Home{}).Joins("JOIN home_memberships ON home_memberships.home_id = homes.id").Where("home_memberships.user_id = ? AND home_memberships.status = 'approved'", userID).Preload("Memberships").Preload("Memberships.User").First(&home).Error; err != nil {
+	if err := dbFor(ctx, r.db).Model(&models.Home{}).Joins("JOIN home_memberships ON home_memberships.home_id = homes.id").Where("home_memberships.user_id = ? AND home_memberships.status = 'approved'", userID).Preload("Memberships").Preload("Memberships.User").First(&home).Error; err != nil {
 		return nil, err
 	}
 
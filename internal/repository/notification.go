@@ -27,12 +27,12 @@ func NewNotificationRepository(db *gorm.DB) NotificationRepository {
 
 // user notifications
 func (r *notificationRepo) Create(ctx context.Context, n *models.Notification) error {
-	return r.db.WithContext(ctx).Create(n).Error
+	return dbFor(ctx, r.db).Create(n).Error
 }
 
 func (r *notificationRepo) FindByUserID(ctx context.Context, id int) ([]models.Notification, error) {
 	var notifications []models.Notification
-	if err := r.db.WithContext(ctx).Where("\"to\" = ?", id).Find(&notifications).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("\"to\" = ?", id).Find(&notifications).Error; err != nil {
 		return nil, err
 	}
 
@@ -43,12 +43,12 @@ func (r *notificationRepo) FindByUserID(ctx context.Context, id int) ([]models.N
 func (r *notificationRepo) MarkAsRead(ctx context.Context, id int) error {
 	var notification models.Notification
 
-	if err := r.db.WithContext(ctx).First(&notification, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&notification, id).Error; err != nil {
 		return err
 	}
 
 	notification.Read = true
-	if err := r.db.WithContext(ctx).Save(notification).Error; err != nil {
+	if err := dbFor(ctx, r.db).Save(notification).Error; err != nil {
 		return err
 	}
 
@@ -57,12 +57,12 @@ func (r *notificationRepo) MarkAsRead(ctx context.Context, id int) error {
 
 // home notifications
 func (r *notificationRepo) CreateHomeNotification(ctx context.Context, n *models.HomeNotification) error {
-	return r.db.WithContext(ctx).Create(n).Error
+	return dbFor(ctx, r.db).Create(n).Error
 }
 
 func (r *notificationRepo) FindByHomeID(ctx context.Context, id int) ([]models.HomeNotification, error) {
 	var notifications []models.HomeNotification
-	if err := r.db.WithContext(ctx).Where("home_id = ?", id).Find(&notifications).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", id).Find(&notifications).Error; err != nil {
 		return nil, err
 	}
 
@@ -73,12 +73,12 @@ func (r *notificationRepo) FindByHomeID(ctx context.Context, id int) ([]models.H
 func (r *notificationRepo) MarkAsReadForHomeNotification(ctx context.Context, id int) error {
 	var notification models.Notification
 
-	if err := r.db.WithContext(ctx).First(&notification, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&notification, id).Error; err != nil {
 		return err
 	}
 
 	notification.Read = true
-	if err := r.db.WithContext(ctx).Save(notification).Error; err != nil {
+	if err := dbFor(ctx, r.db).Save(notification).Error; err != nil {
 		return err
 	}
 
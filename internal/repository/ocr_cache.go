@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OCRCacheRepository interface {
+	Find(ctx context.Context, hash, provider string) (*models.OCRCacheEntry, error)
+	Save(ctx context.Context, entry *models.OCRCacheEntry) error
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
+type ocrCacheRepo struct {
+	db *gorm.DB
+}
+
+func NewOCRCacheRepository(db *gorm.DB) OCRCacheRepository {
+	return &ocrCacheRepo{db}
+}
+
+func (r *ocrCacheRepo) Find(ctx context.Context, hash, provider string) (*models.OCRCacheEntry, error) {
+	var entry models.OCRCacheEntry
+
+	if err := dbFor(ctx, r.db).Where("hash = ? AND provider = ?", hash, provider).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (r *ocrCacheRepo) Save(ctx context.Context, entry *models.OCRCacheEntry) error {
+	return dbFor(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "hash"}, {Name: "provider"}},
+			DoUpdates: clause.AssignmentColumns([]string{"result", "created_at"}),
+		}).
+		Create(entry).Error
+}
+
+func (r *ocrCacheRepo) DeleteBefore(ctx context.Context, before time.Time) error {
+	return dbFor(ctx, r.db).
+		Where("created_at < ?", before).
+		Delete(&models.OCRCacheEntry{}).Error
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OCRJobRepository interface {
+	Create(ctx context.Context, job *models.OCRJob) error
+	FindByID(ctx context.Context, id string) (*models.OCRJob, error)
+	FindFiles(ctx context.Context, jobID string) ([]models.OCRJobFile, error)
+	// FindDue locks up to limit queued jobs whose next attempt is due, and processing jobs
+	// started before staleBefore whose worker died, oldest first. Call it inside a transaction.
+	FindDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]models.OCRJob, error)
+	MarkProcessing(ctx context.Context, ids []string, startedAt time.Time) error
+	// Complete stores the result of a job still processing, drops its files and reports whether it was
+	Complete(ctx context.Context, id string, result datatypes.JSON, finishedAt time.Time) (bool, error)
+	Retry(ctx context.Context, id, lastError string, nextAttemptAt time.Time) error
+	Fail(ctx context.Context, id, lastError string, finishedAt time.Time) (bool, error)
+	CountQueued(ctx context.Context) (int64, error)
+	DeleteFinishedBefore(ctx context.Context, before time.Time) error
+}
+
+type ocrJobRepo struct {
+	db *gorm.DB
+}
+
+func NewOCRJobRepository(db *gorm.DB) OCRJobRepository {
+	return &ocrJobRepo{db}
+}
+
+func (r *ocrJobRepo) Create(ctx context.Context, job *models.OCRJob) error {
+	return dbFor(ctx, r.db).Create(job).Error
+}
+
+func (r *ocrJobRepo) FindByID(ctx context.Context, id string) (*models.OCRJob, error) {
+	var job models.OCRJob
+
+	if err := dbFor(ctx, r.db).Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *ocrJobRepo) FindFiles(ctx context.Context, jobID string) ([]models.OCRJobFile, error) {
+	var files []models.OCRJobFile
+	if err := dbFor(ctx, r.db).
+		Where("job_id = ?", jobID).
+		Order("position ASC").
+		Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (r *ocrJobRepo) FindDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]models.OCRJob, error) {
+	var jobs []models.OCRJob
+
+	if err := dbFor(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Select("id", "user_id", "status", "attempts", "created_at").
+		Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND started_at < ?)",
+			models.OCRJobQueued, now, models.OCRJobProcessing, staleBefore).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *ocrJobRepo) MarkProcessing(ctx context.Context, ids []string, startedAt time.Time) error {
+	return dbFor(ctx, r.db).
+		Model(&models.OCRJob{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":     models.OCRJobProcessing,
+			"started_at": startedAt,
+			"attempts":   gorm.Expr("attempts + 1"),
+		}).Error
+}
+
+func (r *ocrJobRepo) Complete(ctx context.Context, id string, result datatypes.JSON, finishedAt time.Time) (bool, error) {
+	return r.finish(ctx, id, map[string]interface{}{
+		"status":      models.OCRJobCompleted,
+		"result":      result,
+		"last_error":  "",
+		"finished_at": finishedAt,
+	})
+}
+
+func (r *ocrJobRepo) Retry(ctx context.Context, id, lastError string, nextAttemptAt time.Time) error {
+	return dbFor(ctx, r.db).
+		Model(&models.OCRJob{}).
+		Where("id = ? AND status = ?", id, models.OCRJobProcessing).
+		Updates(map[string]interface{}{
+			"status":          models.OCRJobQueued,
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+}
+
+func (r *ocrJobRepo) Fail(ctx context.Context, id, lastError string, finishedAt time.Time) (bool, error) {
+	return r.finish(ctx, id, map[string]interface{}{
+		"status":      models.OCRJobFailed,
+		"last_error":  lastError,
+		"finished_at": finishedAt,
+	})
+}
+
+// finish updates a job only while it is processing, so a job reclaimed from a slow worker
+// finishes once, and drops the uploaded files it no longer needs
+func (r *ocrJobRepo) finish(ctx context.Context, id string, updates map[string]interface{}) (bool, error) {
+	res := dbFor(ctx, r.db).
+		Model(&models.OCRJob{}).
+		Where("id = ? AND status = ?", id, models.OCRJobProcessing).
+		Updates(updates)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	return true, dbFor(ctx, r.db).Where("job_id = ?", id).Delete(&models.OCRJobFile{}).Error
+}
+
+func (r *ocrJobRepo) CountQueued(ctx context.Context) (int64, error) {
+	var count int64
+	err := dbFor(ctx, r.db).
+		Model(&models.OCRJob{}).
+		Where("status = ?", models.OCRJobQueued).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *ocrJobRepo) DeleteFinishedBefore(ctx context.Context, before time.Time) error {
+	return dbFor(ctx, r.db).
+		Where("finished_at IS NOT NULL AND finished_at < ?", before).
+		Delete(&models.OCRJob{}).Error
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Create(ctx context.Context, e *models.OutboxEvent) error
+	// FindDue locks up to limit unpublished events whose next attempt is due, oldest first.
+	// Rows locked by another relay are skipped, so call it inside a transaction.
+	FindDue(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id int, publishedAt time.Time) error
+	MarkFailed(ctx context.Context, id int, lastError string, nextAttemptAt time.Time) error
+	CountPending(ctx context.Context) (int64, error)
+	OldestPendingCreatedAt(ctx context.Context) (*time.Time, error)
+	DeletePublishedBefore(ctx context.Context, before time.Time) error
+}
+
+type outboxRepo struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepo{db}
+}
+
+func (r *outboxRepo) Create(ctx context.Context, e *models.OutboxEvent) error {
+	return dbFor(ctx, r.db).Create(e).Error
+}
+
+func (r *outboxRepo) FindDue(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+
+	if err := dbFor(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("published_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepo) MarkPublished(ctx context.Context, id int, publishedAt time.Time) error {
+	return dbFor(ctx, r.db).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"published_at": publishedAt,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   "",
+		}).Error
+}
+
+func (r *outboxRepo) MarkFailed(ctx context.Context, id int, lastError string, nextAttemptAt time.Time) error {
+	return dbFor(ctx, r.db).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+}
+
+func (r *outboxRepo) CountPending(ctx context.Context) (int64, error) {
+	var count int64
+	err := dbFor(ctx, r.db).
+		Model(&models.OutboxEvent{}).
+		Where("published_at IS NULL").
+		Count(&count).Error
+	return count, err
+}
+
+func (r *outboxRepo) OldestPendingCreatedAt(ctx context.Context) (*time.Time, error) {
+	var e models.OutboxEvent
+
+	if err := dbFor(ctx, r.db).
+		Where("published_at IS NULL").
+		Order("id ASC").
+		First(&e).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &e.CreatedAt, nil
+}
+
+func (r *outboxRepo) DeletePublishedBefore(ctx context.Context, before time.Time) error {
+	return dbFor(ctx, r.db).
+		Where("published_at IS NOT NULL AND published_at < ?", before).
+		Delete(&models.OutboxEvent{}).Error
+}
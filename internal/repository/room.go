@@ -24,12 +24,12 @@ func NewRoomRepository(db *gorm.DB) RoomRepository {
 }
 
 func (r *roomRepo) Create(ctx context.Context, room *models.Room) error {
-	return r.db.WithContext(ctx).Create(room).Error
+	return dbFor(ctx, r.db).Create(room).Error
 }
 
 func (r *roomRepo) FindByID(ctx context.Context, id int) (*models.Room, error) {
 	var room models.Room
-	if err := r.db.WithContext(ctx).First(&room, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&room, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -39,13 +39,13 @@ func (r *roomRepo) FindByID(ctx context.Context, id int) (*models.Room, error) {
 }
 
 func (r *roomRepo) Delete(ctx context.Context, id int) error {
-	return r.db.WithContext(ctx).Delete(&models.Room{}, id).Error
+	return dbFor(ctx, r.db).Delete(&models.Room{}, id).Error
 }
 
 func (r *roomRepo) FindByHomeID(ctx context.Context, homeID int) (*[]models.Room, error) {
 	var rooms []models.Room
 
-	if err := r.db.WithContext(ctx).Where("home_id=?", homeID).Find(&rooms).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id=?", homeID).Find(&rooms).Error; err != nil {
 		return nil, err
 	}
 
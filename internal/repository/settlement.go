@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type SettlementRepository interface {
+	Create(ctx context.Context, s *models.Settlement) error
+	FindByHomeID(ctx context.Context, homeID int) ([]models.Settlement, error)
+	FindBetween(ctx context.Context, homeID, fromID, toID int) ([]models.Settlement, error)
+	SumByPair(ctx context.Context, homeID int) ([]models.Debt, error)
+	BackfillFromPaidSplits(ctx context.Context) (int64, error)
+}
+
+type settlementRepo struct {
+	db *gorm.DB
+}
+
+func NewSettlementRepository(db *gorm.DB) SettlementRepository {
+	return &settlementRepo{db}
+}
+
+func (r *settlementRepo) Create(ctx context.Context, s *models.Settlement) error {
+	return dbFor(ctx, r.db).Create(s).Error
+}
+
+func (r *settlementRepo) FindByHomeID(ctx context.Context, homeID int) ([]models.Settlement, error) {
+	var settlements []models.Settlement
+	if err := dbFor(ctx, r.db).
+		Where("home_id = ?", homeID).
+		Preload("FromUser").
+		Preload("ToUser").
+		Order("created_at DESC").
+		Find(&settlements).Error; err != nil {
+		return nil, err
+	}
+	return settlements, nil
+}
+
+func (r *settlementRepo) FindBetween(ctx context.Context, homeID, fromID, toID int) ([]models.Settlement, error) {
+	var settlements []models.Settlement
+	if err := dbFor(ctx, r.db).
+		Where("home_id = ? AND from_user_id = ? AND to_user_id = ?", homeID, fromID, toID).
+		Order("id").
+		Find(&settlements).Error; err != nil {
+		return nil, err
+	}
+	return settlements, nil
+}
+
+// SumByPair totals payments per payer and receiver. Receiving a payment puts the
+// receiver in the payer's debt, so the payer is reported as the creditor.
+func (r *settlementRepo) SumByPair(ctx context.Context, homeID int) ([]models.Debt, error) {
+	var debts []models.Debt
+	if err := dbFor(ctx, r.db).
+		Model(&models.Settlement{}).
+		Select("to_user_id AS debtor_id, from_user_id AS creditor_id, CAST(SUM(amount) AS bigint) AS amount").
+		Where("home_id = ?", homeID).
+		Group("to_user_id, from_user_id").
+		Scan(&debts).Error; err != nil {
+		return nil, err
+	}
+	return debts, nil
+}
+
+// BackfillFromPaidSplits records a settlement for every split that was marked paid
+// before settlements existed. It is safe to run on every start.
+func (r *settlementRepo) BackfillFromPaidSplits(ctx context.Context) (int64, error) {
+	var created int64
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO settlements (home_id, from_user_id, to_user_id, amount, currency, split_id, note, created_at)
+			SELECT b.home_id, s.user_id, b.uploaded_by, CAST(ROUND(CAST(s.amount * b.exchange_rate AS numeric)) AS bigint), h.currency, s.id, 'Split marked as paid', NOW()
+			FROM bill_splits s
+			JOIN bills b ON b.id = s.bill_id
+			JOIN homes h ON h.id = b.home_id
+			WHERE s.paid AND s.paid_amount = 0 AND s.user_id <> b.uploaded_by
+			AND NOT EXISTS (SELECT 1 FROM settlements st WHERE st.split_id = s.id)`)
+		if res.Error != nil {
+			return res.Error
+		}
+		created = res.RowsAffected
+
+		return tx.Exec("UPDATE bill_splits SET paid_amount = amount WHERE paid AND paid_amount = 0").Error
+	})
+	return created, err
+}
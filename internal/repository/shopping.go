@@ -7,6 +7,7 @@ import (
 
 	"github.com/Dragodui/diploma-server/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ShoppingRepository interface {
@@ -21,6 +22,8 @@ type ShoppingRepository interface {
 	CreateItem(ctx context.Context, i *models.ShoppingItem) error
 	FindItemByID(ctx context.Context, id int) (*models.ShoppingItem, error)
 	FindItemsByCategoryID(ctx context.Context, id int) ([]models.ShoppingItem, error)
+	FindOpenItemsForHome(ctx context.Context, homeID int) ([]models.ShoppingItem, error)
+	FindItemsForUpdate(ctx context.Context, homeID int, ids []int) ([]models.ShoppingItem, error)
 	DeleteItem(ctx context.Context, id int) error
 	MarkIsBought(ctx context.Context, id int) error
 	EditItem(ctx context.Context, item *models.ShoppingItem, updates map[string]interface{}) error
@@ -36,13 +39,13 @@ func NewShoppingRepository(db *gorm.DB) ShoppingRepository {
 
 // categories
 func (r *shoppingRepo) CreateCategory(ctx context.Context, c *models.ShoppingCategory) error {
-	return r.db.WithContext(ctx).Create(c).Error
+	return dbFor(ctx, r.db).Create(c).Error
 }
 
 func (r *shoppingRepo) FindAllCategories(ctx context.Context, homeID int) (*[]models.ShoppingCategory, error) {
 	var categories []models.ShoppingCategory
 
-	if err := r.db.WithContext(ctx).Where("home_id=?", homeID).Find(&categories).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id=?", homeID).Find(&categories).Error; err != nil {
 		return nil, err
 	}
 
@@ -51,7 +54,7 @@ func (r *shoppingRepo) FindAllCategories(ctx context.Context, homeID int) (*[]mo
 
 func (r *shoppingRepo) FindCategoryByID(ctx context.Context, id int) (*models.ShoppingCategory, error) {
 	var category models.ShoppingCategory
-	if err := r.db.WithContext(ctx).Preload("Items").Preload("Items.User").First(&category, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Items").Preload("Items.User").First(&category, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -62,26 +65,56 @@ func (r *shoppingRepo) FindCategoryByID(ctx context.Context, id int) (*models.Sh
 }
 
 func (r *shoppingRepo) EditCategory(ctx context.Context, category *models.ShoppingCategory, updates map[string]interface{}) error {
-	return r.db.WithContext(ctx).Model(category).Updates(updates).Error
+	return dbFor(ctx, r.db).Model(category).Updates(updates).Error
 }
 
 func (r *shoppingRepo) DeleteCategory(ctx context.Context, id int) error {
 	// Delete items first
-	if err := r.db.WithContext(ctx).Where("category_id = ?", id).Delete(&models.ShoppingItem{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("category_id = ?", id).Delete(&models.ShoppingItem{}).Error; err != nil {
 		return err
 	}
-	return r.db.WithContext(ctx).Delete(&models.ShoppingCategory{}, id).Error
+	return dbFor(ctx, r.db).Delete(&models.ShoppingCategory{}, id).Error
 }
 
 // items
 func (r *shoppingRepo) CreateItem(ctx context.Context, i *models.ShoppingItem) error {
-	return r.db.WithContext(ctx).Create(i).Error
+	return dbFor(ctx, r.db).Create(i).Error
 }
 
 func (r *shoppingRepo) FindItemsByCategoryID(ctx context.Context, id int) ([]models.ShoppingItem, error) {
 	var items []models.ShoppingItem
 	// Use Find() instead of First() to get all items, not just one
-	if err := r.db.WithContext(ctx).Preload("User").Where("category_id = ?", id).Find(&items).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("User").Where("category_id = ?", id).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FindOpenItemsForHome returns the items in the home's categories that are not bought yet, oldest first
+func (r *shoppingRepo) FindOpenItemsForHome(ctx context.Context, homeID int) ([]models.ShoppingItem, error) {
+	var items []models.ShoppingItem
+	if err := dbFor(ctx, r.db).
+		Joins("JOIN shopping_categories ON shopping_categories.id = shopping_items.category_id").
+		Where("shopping_categories.home_id = ? AND shopping_items.is_bought = ?", homeID, false).
+		Order("shopping_items.created_at, shopping_items.id").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FindItemsForUpdate returns those of the items that are in the home's categories, bought or not,
+// and locks them until the transaction ends
+func (r *shoppingRepo) FindItemsForUpdate(ctx context.Context, homeID int, ids []int) ([]models.ShoppingItem, error) {
+	var items []models.ShoppingItem
+	if err := dbFor(ctx, r.db).
+		Joins("JOIN shopping_categories ON shopping_categories.id = shopping_items.category_id").
+		Where("shopping_categories.home_id = ? AND shopping_items.id IN ?", homeID, ids).
+		Order("shopping_items.id").
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "shopping_items"}}).
+		Find(&items).Error; err != nil {
 		return nil, err
 	}
 
@@ -90,7 +123,7 @@ func (r *shoppingRepo) FindItemsByCategoryID(ctx context.Context, id int) ([]mod
 
 func (r *shoppingRepo) FindItemByID(ctx context.Context, id int) (*models.ShoppingItem, error) {
 	var item models.ShoppingItem
-	if err := r.db.WithContext(ctx).First(&item, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&item, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -101,13 +134,13 @@ func (r *shoppingRepo) FindItemByID(ctx context.Context, id int) (*models.Shoppi
 }
 
 func (r *shoppingRepo) DeleteItem(ctx context.Context, id int) error {
-	return r.db.WithContext(ctx).Delete(&models.ShoppingItem{}, id).Error
+	return dbFor(ctx, r.db).Delete(&models.ShoppingItem{}, id).Error
 }
 
 func (r *shoppingRepo) MarkIsBought(ctx context.Context, id int) error {
 	var item models.ShoppingItem
 
-	if err := r.db.WithContext(ctx).First(&item, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&item, id).Error; err != nil {
 		return err
 	}
 	currBought := item.IsBought
@@ -120,7 +153,7 @@ func (r *shoppingRepo) MarkIsBought(ctx context.Context, id int) error {
 		item.BoughtDate = &now
 	}
 
-	if err := r.db.WithContext(ctx).Save(&item).Error; err != nil {
+	if err := dbFor(ctx, r.db).Save(&item).Error; err != nil {
 		return err
 	}
 
@@ -128,5 +161,5 @@ func (r *shoppingRepo) MarkIsBought(ctx context.Context, id int) error {
 }
 
 func (r *shoppingRepo) EditItem(ctx context.Context, item *models.ShoppingItem, updates map[string]interface{}) error {
-	return r.db.WithContext(ctx).Model(item).Updates(updates).Error
+	return dbFor(ctx, r.db).Model(item).Updates(updates).Error
 }
@@ -36,12 +36,12 @@ func NewSmartHomeRepository(db *gorm.DB) SmartHomeRepository {
 // Config operations
 
 func (r *smartHomeRepo) CreateConfig(ctx context.Context, config *models.HomeAssistantConfig) error {
-	return r.db.WithContext(ctx).Create(config).Error
+	return dbFor(ctx, r.db).Create(config).Error
 }
 
 func (r *smartHomeRepo) GetConfigByHomeID(ctx context.Context, homeID int) (*models.HomeAssistantConfig, error) {
 	var config models.HomeAssistantConfig
-	if err := r.db.WithContext(ctx).Where("home_id = ?", homeID).First(&config).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ?", homeID).First(&config).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -51,22 +51,22 @@ func (r *smartHomeRepo) GetConfigByHomeID(ctx context.Context, homeID int) (*mod
 }
 
 func (r *smartHomeRepo) UpdateConfig(ctx context.Context, config *models.HomeAssistantConfig) error {
-	return r.db.WithContext(ctx).Save(config).Error
+	return dbFor(ctx, r.db).Save(config).Error
 }
 
 func (r *smartHomeRepo) DeleteConfig(ctx context.Context, homeID int) error {
-	return r.db.WithContext(ctx).Where("home_id = ?", homeID).Delete(&models.HomeAssistantConfig{}).Error
+	return dbFor(ctx, r.db).Where("home_id = ?", homeID).Delete(&models.HomeAssistantConfig{}).Error
 }
 
 // Device operations
 
 func (r *smartHomeRepo) CreateDevice(ctx context.Context, device *models.SmartDevice) error {
-	return r.db.WithContext(ctx).Create(device).Error
+	return dbFor(ctx, r.db).Create(device).Error
 }
 
 func (r *smartHomeRepo) GetDeviceByID(ctx context.Context, id int) (*models.SmartDevice, error) {
 	var device models.SmartDevice
-	if err := r.db.WithContext(ctx).Preload("Room").First(&device, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Room").First(&device, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -77,7 +77,7 @@ func (r *smartHomeRepo) GetDeviceByID(ctx context.Context, id int) (*models.Smar
 
 func (r *smartHomeRepo) GetDevicesByHomeID(ctx context.Context, homeID int) ([]models.SmartDevice, error) {
 	var devices []models.SmartDevice
-	if err := r.db.WithContext(ctx).Preload("Room").Where("home_id = ?", homeID).Order("created_at DESC").Find(&devices).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Room").Where("home_id = ?", homeID).Order("created_at DESC").Find(&devices).Error; err != nil {
 		return nil, err
 	}
 	return devices, nil
@@ -85,7 +85,7 @@ func (r *smartHomeRepo) GetDevicesByHomeID(ctx context.Context, homeID int) ([]m
 
 func (r *smartHomeRepo) GetDevicesByRoomID(ctx context.Context, roomID int) ([]models.SmartDevice, error) {
 	var devices []models.SmartDevice
-	if err := r.db.WithContext(ctx).Preload("Room").Where("room_id = ?", roomID).Order("created_at DESC").Find(&devices).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Room").Where("room_id = ?", roomID).Order("created_at DESC").Find(&devices).Error; err != nil {
 		return nil, err
 	}
 	return devices, nil
@@ -93,7 +93,7 @@ func (r *smartHomeRepo) GetDevicesByRoomID(ctx context.Context, roomID int) ([]m
 
 func (r *smartHomeRepo) GetDeviceByEntityID(ctx context.Context, homeID int, entityID string) (*models.SmartDevice, error) {
 	var device models.SmartDevice
-	if err := r.db.WithContext(ctx).Where("home_id = ? AND entity_id = ?", homeID, entityID).First(&device).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("home_id = ? AND entity_id = ?", homeID, entityID).First(&device).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -103,11 +103,11 @@ func (r *smartHomeRepo) GetDeviceByEntityID(ctx context.Context, homeID int, ent
 }
 
 func (r *smartHomeRepo) UpdateDevice(ctx context.Context, device *models.SmartDevice) error {
-	return r.db.WithContext(ctx).Save(device).Error
+	return dbFor(ctx, r.db).Save(device).Error
 }
 
 func (r *smartHomeRepo) DeleteDevice(ctx context.Context, id int, homeID int) error {
-	result := r.db.WithContext(ctx).Where("id = ? AND home_id = ?", id, homeID).Delete(&models.SmartDevice{})
+	result := dbFor(ctx, r.db).Where("id = ? AND home_id = ?", id, homeID).Delete(&models.SmartDevice{})
 	if result.Error != nil {
 		return result.Error
 	}
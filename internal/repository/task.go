@@ -37,13 +37,13 @@ func NewTaskRepository(db *gorm.DB) TaskRepository {
 }
 
 func (r *taskRepo) Create(ctx context.Context, t *models.Task) error {
-	return r.db.WithContext(ctx).Create(t).Error
+	return dbFor(ctx, r.db).Create(t).Error
 }
 
 func (r *taskRepo) FindByID(ctx context.Context, id int) (*models.Task, error) {
 	var task models.Task
 	// we need preload to room field was not empty
-	err := r.db.WithContext(ctx).Preload("Room").Preload("Schedule").First(&task, id).Error
+	err := dbFor(ctx, r.db).Preload("Room").Preload("Schedule").First(&task, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
@@ -52,7 +52,7 @@ func (r *taskRepo) FindByID(ctx context.Context, id int) (*models.Task, error) {
 
 func (r *taskRepo) FindByHomeID(ctx context.Context, homeID int) (*[]models.Task, error) {
 	var tasks []models.Task
-	if err := r.db.WithContext(ctx).Preload("Room").Preload("Schedule").Preload("TaskAssignments").Preload("TaskAssignments.User").Where("home_id=?", homeID).Find(&tasks).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Room").Preload("Schedule").Preload("TaskAssignments").Preload("TaskAssignments.User").Where("home_id=?", homeID).Find(&tasks).Error; err != nil {
 		return nil, err
 	}
 
@@ -61,16 +61,16 @@ func (r *taskRepo) FindByHomeID(ctx context.Context, homeID int) (*[]models.Task
 
 func (r *taskRepo) Delete(ctx context.Context, id int) error {
 	// Delete associated schedule first
-	if err := r.db.WithContext(ctx).Where("task_id = ?", id).Delete(&models.TaskSchedule{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("task_id = ?", id).Delete(&models.TaskSchedule{}).Error; err != nil {
 		return err
 	}
 
 	// Delete associated task assignments
-	if err := r.db.WithContext(ctx).Where("task_id = ?", id).Delete(&models.TaskAssignment{}).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("task_id = ?", id).Delete(&models.TaskAssignment{}).Error; err != nil {
 		return err
 	}
 
-	if err := r.db.WithContext(ctx).Delete(&models.Task{}, id).Error; err != nil {
+	if err := dbFor(ctx, r.db).Delete(&models.Task{}, id).Error; err != nil {
 		return err
 	}
 	return nil
@@ -78,7 +78,7 @@ func (r *taskRepo) Delete(ctx context.Context, id int) error {
 
 func (r *taskRepo) AssignUser(ctx context.Context, taskID, userID int, date time.Time) error {
 	var task models.Task
-	if err := r.db.WithContext(ctx).First(&task, taskID).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&task, taskID).Error; err != nil {
 		return err
 	}
 	newTaskAssignment := models.TaskAssignment{
@@ -87,7 +87,7 @@ func (r *taskRepo) AssignUser(ctx context.Context, taskID, userID int, date time
 		Status:       "assigned",
 		AssignedDate: date,
 	}
-	if err := r.db.WithContext(ctx).Create(&newTaskAssignment).Error; err != nil {
+	if err := dbFor(ctx, r.db).Create(&newTaskAssignment).Error; err != nil {
 		return err
 	}
 
@@ -97,7 +97,7 @@ func (r *taskRepo) AssignUser(ctx context.Context, taskID, userID int, date time
 func (r *taskRepo) FindAssignmentsForUser(ctx context.Context, userID int, homeID int) (*[]models.TaskAssignment, error) {
 	var assignments []models.TaskAssignment
 
-	if err := r.db.WithContext(ctx).
+	if err := dbFor(ctx, r.db).
 		Joins("JOIN tasks ON task_assignments.task_id = tasks.id").
 		Where("task_assignments.user_id = ? AND tasks.home_id = ?", userID, homeID).
 		Find(&assignments).Error; err != nil {
@@ -110,7 +110,7 @@ func (r *taskRepo) FindAssignmentsForUser(ctx context.Context, userID int, homeI
 func (r *taskRepo) FindClosestAssignmentForUser(ctx context.Context, userID int) (*models.TaskAssignment, error) {
 	var assignment models.TaskAssignment
 
-	if err := r.db.WithContext(ctx).Preload("Task").Where("user_id=? AND status != 'completed'", userID).Order("assigned_date asc").First(&assignment).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Task").Where("user_id=? AND status != 'completed'", userID).Order("assigned_date asc").First(&assignment).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -122,14 +122,14 @@ func (r *taskRepo) FindClosestAssignmentForUser(ctx context.Context, userID int)
 
 func (r *taskRepo) MarkUncompleted(ctx context.Context, assignmentID int) error {
 	var assignment models.TaskAssignment
-	if err := r.db.WithContext(ctx).First(&assignment, assignmentID).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&assignment, assignmentID).Error; err != nil {
 		return err
 	}
 
 	assignment.Status = "assigned"
 	assignment.CompleteDate = nil
 
-	if err := r.db.WithContext(ctx).Save(&assignment).Error; err != nil {
+	if err := dbFor(ctx, r.db).Save(&assignment).Error; err != nil {
 		return err
 	}
 
@@ -139,7 +139,7 @@ func (r *taskRepo) MarkUncompleted(ctx context.Context, assignmentID int) error
 func (r *taskRepo) FindAssignmentByTaskAndUser(ctx context.Context, taskID, userID int) (*models.TaskAssignment, error) {
 	var assignment models.TaskAssignment
 
-	if err := r.db.WithContext(ctx).Where("task_id = ? AND user_id = ?", taskID, userID).First(&assignment).Error; err != nil {
+	if err := dbFor(ctx, r.db).Where("task_id = ? AND user_id = ?", taskID, userID).First(&assignment).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -151,7 +151,7 @@ func (r *taskRepo) FindAssignmentByTaskAndUser(ctx context.Context, taskID, user
 
 func (r *taskRepo) FindAssignmentByID(ctx context.Context, assignmentID int) (*models.TaskAssignment, error) {
 	var assignment models.TaskAssignment
-	if err := r.db.WithContext(ctx).Preload("Task").First(&assignment, assignmentID).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Task").First(&assignment, assignmentID).Error; err != nil {
 		return nil, err
 	}
 	return &assignment, nil
@@ -159,11 +159,11 @@ func (r *taskRepo) FindAssignmentByID(ctx context.Context, assignmentID int) (*m
 
 func (r *taskRepo) FindUserByAssignmentID(ctx context.Context, assignmentID int) (*models.User, error) {
 	var assignment models.TaskAssignment
-	if err := r.db.WithContext(ctx).First(&assignment, assignmentID).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&assignment, assignmentID).Error; err != nil {
 		return nil, err
 	}
 	var user models.User
-	if err := r.db.WithContext(ctx).First(&user, assignment.UserID).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&user, assignment.UserID).Error; err != nil {
 		return nil, err
 	}
 
@@ -172,7 +172,7 @@ func (r *taskRepo) FindUserByAssignmentID(ctx context.Context, assignmentID int)
 
 func (r *taskRepo) MarkCompleted(ctx context.Context, assignmentID int) error {
 	var assignment models.TaskAssignment
-	if err := r.db.WithContext(ctx).First(&assignment, assignmentID).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&assignment, assignmentID).Error; err != nil {
 		return err
 	}
 
@@ -180,7 +180,7 @@ func (r *taskRepo) MarkCompleted(ctx context.Context, assignmentID int) error {
 	assignment.Status = "completed"
 	assignment.CompleteDate = &now
 
-	if err := r.db.WithContext(ctx).Save(&assignment).Error; err != nil {
+	if err := dbFor(ctx, r.db).Save(&assignment).Error; err != nil {
 		return err
 	}
 
@@ -188,7 +188,7 @@ func (r *taskRepo) MarkCompleted(ctx context.Context, assignmentID int) error {
 }
 
 func (r *taskRepo) DeleteAssignment(ctx context.Context, assignmentID int) error {
-	if err := r.db.WithContext(ctx).Delete(&models.TaskAssignment{}, assignmentID).Error; err != nil {
+	if err := dbFor(ctx, r.db).Delete(&models.TaskAssignment{}, assignmentID).Error; err != nil {
 		return err
 	}
 
@@ -198,12 +198,12 @@ func (r *taskRepo) DeleteAssignment(ctx context.Context, assignmentID int) error
 func (r *taskRepo) ReassignRoom(ctx context.Context, taskID, roomID int) error {
 	var task models.Task
 
-	if err := r.db.WithContext(ctx).First(&task, taskID).Error; err != nil {
+	if err := dbFor(ctx, r.db).First(&task, taskID).Error; err != nil {
 		return err
 	}
 
 	task.RoomID = &roomID
-	if err := r.db.WithContext(ctx).Save(&task).Error; err != nil {
+	if err := dbFor(ctx, r.db).Save(&task).Error; err != nil {
 		return err
 	}
 
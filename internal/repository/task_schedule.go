@@ -28,12 +28,12 @@ func NewTaskScheduleRepository(db *gorm.DB) TaskScheduleRepository {
 }
 
 func (r *taskScheduleRepo) Create(ctx context.Context, schedule *models.TaskSchedule) error {
-	return r.db.WithContext(ctx).Create(schedule).Error
+	return dbFor(ctx, r.db).Create(schedule).Error
 }
 
 func (r *taskScheduleRepo) FindByID(ctx context.Context, id int) (*models.TaskSchedule, error) {
 	var schedule models.TaskSchedule
-	err := r.db.WithContext(ctx).Preload("Task").First(&schedule, id).Error
+	err := dbFor(ctx, r.db).Preload("Task").First(&schedule, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
@@ -42,7 +42,7 @@ func (r *taskScheduleRepo) FindByID(ctx context.Context, id int) (*models.TaskSc
 
 func (r *taskScheduleRepo) FindByTaskID(ctx context.Context, taskID int) (*models.TaskSchedule, error) {
 	var schedule models.TaskSchedule
-	err := r.db.WithContext(ctx).Preload("Task").Where("task_id = ?", taskID).First(&schedule).Error
+	err := dbFor(ctx, r.db).Preload("Task").Where("task_id = ?", taskID).First(&schedule).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
@@ -51,7 +51,7 @@ func (r *taskScheduleRepo) FindByTaskID(ctx context.Context, taskID int) (*model
 
 func (r *taskScheduleRepo) FindByHomeID(ctx context.Context, homeID int) ([]models.TaskSchedule, error) {
 	var schedules []models.TaskSchedule
-	err := r.db.WithContext(ctx).
+	err := dbFor(ctx, r.db).
 		Preload("Task").
 		Joins("JOIN tasks ON task_schedules.task_id = tasks.id").
 		Where("tasks.home_id = ? AND task_schedules.is_active = ?", homeID, true).
@@ -61,7 +61,7 @@ func (r *taskScheduleRepo) FindByHomeID(ctx context.Context, homeID int) ([]mode
 
 func (r *taskScheduleRepo) FindDueSchedules(ctx context.Context, now time.Time) ([]models.TaskSchedule, error) {
 	var schedules []models.TaskSchedule
-	err := r.db.WithContext(ctx).
+	err := dbFor(ctx, r.db).
 		Preload("Task").
 		Where("is_active = ? AND next_run_date <= ?", true, now).
 		Find(&schedules).Error
@@ -69,9 +69,9 @@ func (r *taskScheduleRepo) FindDueSchedules(ctx context.Context, now time.Time)
 }
 
 func (r *taskScheduleRepo) Update(ctx context.Context, schedule *models.TaskSchedule) error {
-	return r.db.WithContext(ctx).Save(schedule).Error
+	return dbFor(ctx, r.db).Save(schedule).Error
 }
 
 func (r *taskScheduleRepo) Delete(ctx context.Context, id int) error {
-	return r.db.WithContext(ctx).Delete(&models.TaskSchedule{}, id).Error
+	return dbFor(ctx, r.db).Delete(&models.TaskSchedule{}, id).Error
 }
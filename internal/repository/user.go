@@ -32,12 +32,12 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 func (r *userRepo) Create(ctx context.Context, u *models.User) error {
-	return r.db.WithContext(ctx).Create(u).Error
+	return dbFor(ctx, r.db).Create(u).Error
 }
 
 func (r *userRepo) FindByID(ctx context.Context, id int) (*models.User, error) {
 	var u models.User
-	err := r.db.WithContext(ctx).Where("id=?", id).First(&u).Error
+	err := dbFor(ctx, r.db).Where("id=?", id).First(&u).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
@@ -47,7 +47,7 @@ func (r *userRepo) FindByID(ctx context.Context, id int) (*models.User, error) {
 
 func (r *userRepo) FindByName(ctx context.Context, name string) (*models.User, error) {
 	var u models.User
-	err := r.db.WithContext(ctx).Where("name=?", name).First(&u).Error
+	err := dbFor(ctx, r.db).Where("name=?", name).First(&u).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
@@ -57,7 +57,7 @@ func (r *userRepo) FindByName(ctx context.Context, name string) (*models.User, e
 
 func (r *userRepo) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var u models.User
-	err := r.db.WithContext(ctx).Where("email=?", email).First(&u).Error
+	err := dbFor(ctx, r.db).Where("email=?", email).First(&u).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
@@ -66,7 +66,7 @@ func (r *userRepo) FindByEmail(ctx context.Context, email string) (*models.User,
 }
 
 func (r *userRepo) SetVerifyToken(ctx context.Context, email, token string, expiresAt time.Time) error {
-	return r.db.WithContext(ctx).Model(&models.User{}).
+	return dbFor(ctx, r.db).Model(&models.User{}).
 		Where("email = ?", email).
 		Updates(map[string]interface{}{
 			"verify_token":      token,
@@ -75,7 +75,7 @@ func (r *userRepo) SetVerifyToken(ctx context.Context, email, token string, expi
 }
 
 func (r *userRepo) VerifyEmail(ctx context.Context, token string) error {
-	res := r.db.WithContext(ctx).Model(&models.User{}).Where("verify_token = ? AND verify_expires_at > ?", token, time.Now()).Updates(map[string]any{
+	res := dbFor(ctx, r.db).Model(&models.User{}).Where("verify_token = ? AND verify_expires_at > ?", token, time.Now()).Updates(map[string]any{
 		"email_verified":    true,
 		"verify_token":      nil,
 		"verify_expires_at": nil,
@@ -91,7 +91,7 @@ func (r *userRepo) VerifyEmail(ctx context.Context, token string) error {
 
 func (r *userRepo) GetByVerifyToken(ctx context.Context, token string) (*models.User, error) {
 	var u models.User
-	err := r.db.WithContext(ctx).Where("verify_token = ? AND verify_expires_at > ?", token, time.Now()).
+	err := dbFor(ctx, r.db).Where("verify_token = ? AND verify_expires_at > ?", token, time.Now()).
 		First(&u).Error
 	if err != nil {
 		return nil, err
@@ -101,7 +101,7 @@ func (r *userRepo) GetByVerifyToken(ctx context.Context, token string) (*models.
 
 func (r *userRepo) GetByResetToken(ctx context.Context, token string) (*models.User, error) {
 	var u models.User
-	err := r.db.WithContext(ctx).Where("reset_token = ? AND reset_expires_at > ?", token, time.Now()).
+	err := dbFor(ctx, r.db).Where("reset_token = ? AND reset_expires_at > ?", token, time.Now()).
 		First(&u).Error
 	if err != nil {
 		return nil, err
@@ -110,7 +110,7 @@ func (r *userRepo) GetByResetToken(ctx context.Context, token string) (*models.U
 }
 
 func (r *userRepo) SetResetToken(ctx context.Context, email, token string, expiresAt time.Time) error {
-	result := r.db.WithContext(ctx).Model(&models.User{}).
+	result := dbFor(ctx, r.db).Model(&models.User{}).
 		Where("email = ?", email).
 		Updates(map[string]interface{}{
 			"reset_token":      token,
@@ -128,7 +128,7 @@ func (r *userRepo) SetResetToken(ctx context.Context, email, token string, expir
 }
 
 func (r *userRepo) UpdatePassword(ctx context.Context, userID int, newHash string) error {
-	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).
+	return dbFor(ctx, r.db).Model(&models.User{}).Where("id = ?", userID).
 		Updates(map[string]interface{}{
 			"password_hash":    newHash,
 			"reset_token":      nil,
@@ -137,5 +137,5 @@ func (r *userRepo) UpdatePassword(ctx context.Context, userID int, newHash strin
 }
 
 func (r *userRepo) Update(ctx context.Context, user *models.User, updates map[string]interface{}) error {
-	return r.db.WithContext(ctx).Model(user).Updates(updates).Error
+	return dbFor(ctx, r.db).Model(user).Updates(updates).Error
 }
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -28,7 +29,15 @@ func SetupRoutes(
 	taskHandler *handlers.TaskHandler,
 	taskScheduleHandler *handlers.TaskScheduleHandler,
 	billHandler *handlers.BillHandler,
+	billTemplateHandler *handlers.BillTemplateHandler,
+	billImportHandler *handlers.BillImportHandler,
+	billReminderHandler *handlers.BillReminderHandler,
 	billCategoryHandler *handlers.BillCategoryHandler,
+	budgetHandler *handlers.BudgetHandler,
+	exportHandler *handlers.ExportHandler,
+	ledgerHandler *handlers.LedgerHandler,
+	settlementHandler *handlers.SettlementHandler,
+	exchangeRateHandler *handlers.ExchangeRateHandler,
 	roomHandler *handlers.RoomHandler,
 	shoppingHandler *handlers.ShoppingHandler,
 	imageHandler *handlers.ImageHandler,
@@ -37,6 +46,7 @@ func SetupRoutes(
 	userHandler *handlers.UserHandler,
 	ocrHandler *handlers.OCRHandler,
 	smartHomeHandler *handlers.SmartHomeHandler,
+	eventHandler *handlers.EventHandler,
 
 	// redis client
 	cache *redis.Client,
@@ -45,8 +55,12 @@ func SetupRoutes(
 	homeRepo repository.HomeRepository,
 
 ) http.Handler {
+	// one shared Redis subscription fans real time updates out to all sockets
+	hub := ws.NewHub(cache.Subscribe(context.Background()))
+	go hub.Run()
+
 	// websockets handler for real time updates
-	wsHandler := ws.NewWSHandler(cfg)
+	wsHandler := ws.NewWSHandler(cfg, homeRepo, hub)
 	// Rate limiter
 	rateLimiter := ratelimiter.NewIpRateLimiter()
 
@@ -143,6 +157,8 @@ func SetupRoutes(
 				// OCR processing - limited to 5 per minute (resource intensive)
 				ocrLimit := middleware.StrictRateLimitMiddleware(rateLimiter, 5, 0.083) // 5 tokens, refill 0.083/sec = 5/min
 				r.With(ocrLimit).Post("/ocr/process", ocrHandler.Process)
+				r.With(ocrLimit).Post("/ocr/jobs", ocrHandler.CreateJob)
+				r.Get("/ocr/jobs/{job_id}", ocrHandler.GetJob)
 
 				// Homes and nested resources
 				r.Route("/homes", func(r chi.Router) {
@@ -170,6 +186,13 @@ func SetupRoutes(
 						r.With(middleware.RequireAdmin(homeRepo)).Patch("/members/{user_id}/role", homeHandler.UpdateMemberRole)
 						r.With(middleware.RequireAdmin(homeRepo)).Post("/regenerate_code", homeHandler.RegenerateInviteCode)
 
+						// Replay of real-time events missed while offline
+						r.With(middleware.RequireMember(homeRepo)).Get("/events", eventHandler.GetByHomeID)
+
+						// OCR with a bill category suggested from the home's bills
+						r.With(middleware.RequireMember(homeRepo), ocrLimit).Post("/ocr/process", ocrHandler.Process)
+						r.With(middleware.RequireMember(homeRepo), ocrLimit).Post("/ocr/jobs", ocrHandler.CreateJob)
+
 						// Notifications for home
 						r.Route("/notifications", func(r chi.Router) {
 							r.Get("/", notificationHandler.GetByHomeID)
@@ -215,19 +238,74 @@ func SetupRoutes(
 						r.Route("/bills", func(r chi.Router) {
 							r.With(middleware.RequireMember(homeRepo)).Get("/", billHandler.GetByHomeID)
 							r.With(middleware.RequireMember(homeRepo)).Post("/", billHandler.Create)
+							r.With(middleware.RequireMember(homeRepo)).Post("/receipt", billHandler.CreateFromReceipt)
+							r.With(middleware.RequireMember(homeRepo)).Get("/stats", billHandler.GetStats)
+							// Recurring bills
+							r.With(middleware.RequireMember(homeRepo)).Get("/templates", billTemplateHandler.GetByHomeID)
+							r.With(middleware.RequireMember(homeRepo)).Post("/templates", billTemplateHandler.Create)
+							r.With(middleware.RequireMember(homeRepo)).Delete("/templates/{template_id}", billTemplateHandler.Delete)
+							// Due date reminders
+							r.With(middleware.RequireMember(homeRepo)).Get("/reminders", billReminderHandler.GetSettings)
+							r.With(middleware.RequireAdmin(homeRepo)).Put("/reminders", billReminderHandler.SetSettings)
+							// Bank statement import
+							r.Route("/import", func(r chi.Router) {
+								r.With(middleware.RequireMember(homeRepo)).Post("/", billImportHandler.Preview)
+								r.With(middleware.RequireMember(homeRepo)).Get("/profiles", billImportHandler.GetProfiles)
+								r.With(middleware.RequireAdmin(homeRepo)).Post("/profiles", billImportHandler.CreateProfile)
+								r.With(middleware.RequireAdmin(homeRepo)).Delete("/profiles/{profile_id}", billImportHandler.DeleteProfile)
+								r.With(middleware.RequireMember(homeRepo)).Get("/rules", billImportHandler.GetRules)
+								r.With(middleware.RequireAdmin(homeRepo)).Post("/rules", billImportHandler.CreateRule)
+								r.With(middleware.RequireAdmin(homeRepo)).Delete("/rules/{rule_id}", billImportHandler.DeleteRule)
+								r.With(middleware.RequireMember(homeRepo)).Get("/{import_id}", billImportHandler.GetImport)
+								r.With(middleware.RequireMember(homeRepo)).Post("/{import_id}/commit", billImportHandler.Commit)
+							})
 							r.With(middleware.RequireMember(homeRepo)).Get("/{bill_id}", billHandler.GetByID)
 							r.With(middleware.RequireMember(homeRepo)).Delete("/{bill_id}", billHandler.Delete)
+							r.With(middleware.RequireMember(homeRepo)).Put("/{bill_id}", billHandler.Update)
+							// PATCH on the bill itself still marks it payed for older clients
 							r.With(middleware.RequireMember(homeRepo)).Patch("/{bill_id}", billHandler.MarkPayed)
+							r.With(middleware.RequireMember(homeRepo)).Get("/{bill_id}/revisions", billHandler.GetRevisions)
+							r.With(middleware.RequireMember(homeRepo)).Patch("/{bill_id}/mark-payed", billHandler.MarkPayed)
+							r.With(middleware.RequireMember(homeRepo)).Patch("/{bill_id}/mark-unpayed", billHandler.MarkUnpayed)
 							r.With(middleware.RequireMember(homeRepo)).Put("/{bill_id}/splits", billHandler.UpdateSplits)
 							r.With(middleware.RequireMember(homeRepo)).Patch("/{bill_id}/splits/{split_id}/paid", billHandler.MarkSplitPaid)
 						})
 
+						// Who owes whom across all bills
+						r.With(middleware.RequireMember(homeRepo)).Get("/balances", ledgerHandler.GetBalances)
+
+						// Payments between members
+						r.Route("/settlements", func(r chi.Router) {
+							r.With(middleware.RequireMember(homeRepo)).Get("/", settlementHandler.GetByHomeID)
+							r.With(middleware.RequireMember(homeRepo)).Post("/", settlementHandler.Create)
+						})
+
+						// Currency conversion into the home currency
+						r.Route("/exchange_rates", func(r chi.Router) {
+							r.With(middleware.RequireMember(homeRepo)).Get("/", exchangeRateHandler.GetAll)
+							r.With(middleware.RequireAdmin(homeRepo)).Put("/{currency}", exchangeRateHandler.Set)
+							r.With(middleware.RequireAdmin(homeRepo)).Delete("/{currency}", exchangeRateHandler.Delete)
+						})
+
+						// Data export, streamed as a download
+						r.Route("/export", func(r chi.Router) {
+							r.With(middleware.RequireAdmin(homeRepo)).Get("/archive.json", exportHandler.JSON)
+							r.With(middleware.RequireAdmin(homeRepo)).Get("/tasks.ics", exportHandler.Calendar)
+							r.With(middleware.RequireAdmin(homeRepo)).Get("/{dataset}.csv", exportHandler.CSV)
+						})
+
 						// Bill Categories
 						r.Route("/bill_categories", func(r chi.Router) {
 							r.With(middleware.RequireMember(homeRepo)).Get("/", billCategoryHandler.GetAll)
 							r.With(middleware.RequireMember(homeRepo)).Post("/", billCategoryHandler.Create)
 							r.With(middleware.RequireMember(homeRepo)).Delete("/{category_id}", billCategoryHandler.Delete)
 							r.With(middleware.RequireMember(homeRepo)).Patch("/{category_id}", billCategoryHandler.Update)
+							// Monthly budgets
+							r.With(middleware.RequireMember(homeRepo)).Get("/budgets", budgetHandler.GetAllStatuses)
+							r.With(middleware.RequireMember(homeRepo)).Get("/{category_id}/budget", budgetHandler.Get)
+							r.With(middleware.RequireAdmin(homeRepo)).Put("/{category_id}/budget", budgetHandler.Set)
+							r.With(middleware.RequireAdmin(homeRepo)).Delete("/{category_id}/budget", budgetHandler.Delete)
+							r.With(middleware.RequireMember(homeRepo)).Get("/{category_id}/budget/status", budgetHandler.GetStatus)
 						})
 
 						// Shopping
@@ -247,6 +325,9 @@ func SetupRoutes(
 								r.With(middleware.RequireMember(homeRepo)).Put("/{item_id}", shoppingHandler.EditItem)
 								r.With(middleware.RequireMember(homeRepo)).Patch("/{item_id}", shoppingHandler.MarkIsBought)
 							})
+							// Receipts
+							r.With(middleware.RequireMember(homeRepo)).Post("/reconcile", shoppingHandler.ReconcileReceipt)
+							r.With(middleware.RequireMember(homeRepo)).Post("/reconcile/confirm", shoppingHandler.ConfirmPurchases)
 						})
 						r.Route("/polls", func(r chi.Router) {
 
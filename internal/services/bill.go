@@ -2,12 +2,13 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Dragodui/diploma-server/internal/event"
-	"github.com/Dragodui/diploma-server/internal/logger"
 	"github.com/Dragodui/diploma-server/internal/metrics"
 	"github.com/Dragodui/diploma-server/internal/models"
 	"github.com/Dragodui/diploma-server/internal/repository"
@@ -16,50 +17,43 @@ import (
 	"gorm.io/datatypes"
 )
 
+// ErrInvalidStatsGroup is returned for a grouping the spending statistics don't support
+var ErrInvalidStatsGroup = errors.New("group_by must be category, uploader, participant or month")
+
 type BillService struct {
-	repo     repository.BillRepository
-	cache    *redis.Client
-	notifSvc INotificationService
+	repo           repository.BillRepository
+	settlementRepo repository.SettlementRepository
+	budgetRepo     repository.BudgetRepository
+	homeRepo       repository.HomeRepository
+	rates          RateSource
+	cache          *redis.Client
+	notifSvc       INotificationService
+	outbox         IOutboxService
 }
 
 type IBillService interface {
-	CreateBill(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount float64, start, end time.Time,
-		ocrData datatypes.JSON, homeID, uploadedBy int, splits []models.SplitInput) error
+	CreateBill(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount models.Money, currency string, start, end time.Time, dueDate *time.Time,
+		ocrData datatypes.JSON, receiptHash string, homeID, uploadedBy int, splitMode string, splits []models.SplitInput) (*models.Bill, error)
+	CreateBillFromReceipt(ctx context.Context, homeID, uploadedBy int, req models.CreateReceiptBillRequest) (*models.Bill, error)
 	GetBillByID(ctx context.Context, id int) (*models.Bill, error)
-	GetBillsByHomeID(ctx context.Context, homeID int, categoryID *int) ([]models.Bill, error)
+	GetBillsByHomeID(ctx context.Context, f models.BillFilter) (*models.BillPage, error)
 	Delete(ctx context.Context, id int) error
-	MarkBillPayed(ctx context.Context, id int) error
-	UpdateSplits(ctx context.Context, billID int, splits []models.SplitInput) error
+	UpdateBill(ctx context.Context, billID, userID int, req models.UpdateBillRequest) (*models.Bill, error)
+	GetRevisions(ctx context.Context, billID int) ([]models.BillRevision, error)
+	GetStats(ctx context.Context, homeID int, from, to *time.Time, groupBy string) (*models.SpendingStats, error)
+	MarkBillPayed(ctx context.Context, id, userID int) error
+	MarkBillUnpayed(ctx context.Context, id, userID int) error
+	UpdateSplits(ctx context.Context, billID, userID int, splitMode string, splits []models.SplitInput) error
 	MarkSplitPaid(ctx context.Context, splitID int) error
 	GetSplitByID(ctx context.Context, splitID int) (*models.BillSplit, error)
 }
 
-func NewBillService(repo repository.BillRepository, cache *redis.Client, notifSvc INotificationService) *BillService {
-	return &BillService{repo: repo, cache: cache, notifSvc: notifSvc}
+func NewBillService(repo repository.BillRepository, settlementRepo repository.SettlementRepository, budgetRepo repository.BudgetRepository, homeRepo repository.HomeRepository, rates RateSource, cache *redis.Client, notifSvc INotificationService, outbox IOutboxService) *BillService {
+	return &BillService{repo: repo, settlementRepo: settlementRepo, budgetRepo: budgetRepo, homeRepo: homeRepo, rates: rates, cache: cache, notifSvc: notifSvc, outbox: outbox}
 }
 
-func validateSplits(splits []models.SplitInput, totalAmount float64) error {
-	var sum float64
-	for _, sp := range splits {
-		if sp.Amount <= 0 {
-			return fmt.Errorf("split amount must be greater than 0")
-		}
-		sum += sp.Amount
-	}
-	if sum > totalAmount {
-		return fmt.Errorf("total split amount (%.2f) exceeds bill total (%.2f)", sum, totalAmount)
-	}
-	return nil
-}
-
-func (s *BillService) CreateBill(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount float64, start, end time.Time,
-	ocrData datatypes.JSON, homeID, uploadedBy int, splits []models.SplitInput) error {
-
-	if len(splits) > 0 {
-		if err := validateSplits(splits, totalAmount); err != nil {
-			return err
-		}
-	}
+func (s *BillService) CreateBill(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount models.Money, currency string, start, end time.Time, dueDate *time.Time,
+	ocrData datatypes.JSON, receiptHash string, homeID, uploadedBy int, splitMode string, splits []models.SplitInput) (*models.Bill, error) {
 
 	bill := &models.Bill{
 		HomeID:         homeID,
@@ -69,47 +63,267 @@ func (s *BillService) CreateBill(ctx context.Context, billType string, billCateg
 		Description:    description,
 		ReceiptImage:   receiptImage,
 		TotalAmount:    totalAmount,
+		Currency:       currency,
 		Start:          start,
 		End:            end,
+		DueDate:        dueDate,
+		Payed:          false,
+		OCRData:        ocrData,
+		ReceiptHash:    receiptHash,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := s.checkDuplicates(ctx, bill); err != nil {
+		return nil, err
+	}
+	if err := s.createBill(ctx, bill, splitMode, splits, nil); err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
+
+// CreateBillFromReceipt creates a bill from OCR line items. The splits follow who had which
+// item, and the item assignments are kept in the bill's OCR data.
+func (s *BillService) CreateBillFromReceipt(ctx context.Context, homeID, uploadedBy int, req models.CreateReceiptBillRequest) (*models.Bill, error) {
+	receipt, splits, err := splitReceipt(req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if req.Total != 0 && req.Total != receipt.Total {
+		return nil, splitError("receipt lines add up to %s, receipt total is %s", receipt.Total, req.Total)
+	}
+	receipt.Vendor = req.Vendor
+	receipt.Date = req.Date
+
+	ocrData, err := json.Marshal(receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	description := req.Description
+	if description == "" {
+		description = req.Vendor
+	}
+	bill := &models.Bill{
+		HomeID:         homeID,
+		UploadedBy:     uploadedBy,
+		Type:           req.BillType,
+		BillCategoryID: req.BillCategoryID,
+		Description:    description,
+		ReceiptImage:   req.ReceiptImage,
+		TotalAmount:    receipt.Total,
+		Currency:       req.Currency,
+		Start:          req.Start,
+		End:            req.End,
+		DueDate:        req.DueDate,
 		Payed:          false,
 		OCRData:        ocrData,
+		ReceiptHash:    req.ReceiptHash,
 		CreatedAt:      time.Now(),
 	}
 
-	if err := s.repo.Create(ctx, bill); err != nil {
+	if err := s.checkDuplicates(ctx, bill); err != nil {
+		return nil, err
+	}
+	if err := s.createBill(ctx, bill, models.SplitModeExact, splits, nil); err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
+
+// receiptVendor reads the vendor and date from a bill's OCR data, which is free-form
+func receiptVendor(ocrData datatypes.JSON) (string, string) {
+	var receipt struct {
+		Vendor string `json:"vendor"`
+		Date   string `json:"date"`
+	}
+	if len(ocrData) > 0 {
+		_ = json.Unmarshal(ocrData, &receipt)
+	}
+	return strings.TrimSpace(receipt.Vendor), strings.TrimSpace(receipt.Date)
+}
+
+// checkDuplicates warns about bills of the home made from the same receipt: the same
+// file, or the same vendor, date and total read from it. Housemates often scan one
+// receipt twice, but two equal receipts can be real, so the new bill is not refused.
+func (s *BillService) checkDuplicates(ctx context.Context, bill *models.Bill) error {
+	// without a vendor and date only the hash is compared
+	vendor, date := receiptVendor(bill.OCRData)
+	if bill.ReceiptHash == "" && (vendor == "" || date == "") {
+		return nil
+	}
+
+	duplicates, err := s.repo.FindDuplicates(ctx, bill.HomeID, bill.ReceiptHash, vendor, date, bill.TotalAmount)
+	if err != nil {
 		return err
 	}
+	for _, dup := range duplicates {
+		if bill.ReceiptHash != "" && dup.ReceiptHash == bill.ReceiptHash {
+			bill.Warnings = append(bill.Warnings, models.BillWarning{
+				Code:    models.BillWarningDuplicateReceipt,
+				BillID:  dup.ID,
+				Message: fmt.Sprintf("This receipt was already added as bill #%d", dup.ID),
+			})
+			continue
+		}
+		bill.Warnings = append(bill.Warnings, models.BillWarning{
+			Code:    models.BillWarningPossibleDuplicate,
+			BillID:  dup.ID,
+			Message: fmt.Sprintf("Bill #%d has the same vendor, date and total", dup.ID),
+		})
+	}
+	return nil
+}
+
+// GenerateFromTemplate creates the bill of one template period. advance runs in the same
+// transaction as the bill insert, so a period that fails to advance is never generated.
+func (s *BillService) GenerateFromTemplate(ctx context.Context, tpl *models.BillTemplate, start, end time.Time, advance func(ctx context.Context) error) (*models.Bill, error) {
+	templateID := tpl.ID
+	bill := &models.Bill{
+		HomeID:         tpl.HomeID,
+		UploadedBy:     tpl.CreatedBy,
+		Type:           tpl.BillType,
+		BillCategoryID: tpl.BillCategoryID,
+		Description:    tpl.Description,
+		TotalAmount:    tpl.Amount,
+		Currency:       tpl.Currency,
+		Start:          start,
+		End:            end,
+		Payed:          false,
+		OCRData:        datatypes.JSON("{}"),
+		TemplateID:     &templateID,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := s.createBill(ctx, bill, tpl.SplitMode, tpl.Splits, advance); err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
+
+// CreateImportedBill turns one row of a bank statement into a bill. The home is told
+// about the whole import at once instead of once per bill; budget alerts still go out
+// for the bill that crosses a threshold.
+func (s *BillService) CreateImportedBill(ctx context.Context, homeID, uploadedBy int, row models.ImportRow, currency string) (*models.Bill, error) {
+	bill := &models.Bill{
+		HomeID:         homeID,
+		UploadedBy:     uploadedBy,
+		Type:           "other",
+		BillCategoryID: row.BillCategoryID,
+		Description:    row.Description,
+		TotalAmount:    row.Amount,
+		Currency:       currency,
+		Start:          row.Date,
+		End:            row.Date,
+		Payed:          true,
+		PaymentDate:    &row.Date,
+		OCRData:        datatypes.JSON("{}"),
+		ImportHash:     row.Hash,
+		CreatedAt:      time.Now(),
+	}
 
-	// Create splits if provided
+	if err := s.createBill(ctx, bill, "", nil, nil); err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
+
+// createBill stores the bill and its splits, then tells the home about it.
+// inTx, when set, runs inside the same transaction.
+func (s *BillService) createBill(ctx context.Context, bill *models.Bill, splitMode string, splits []models.SplitInput, inTx func(ctx context.Context) error) error {
+	var billSplits []models.BillSplit
 	if len(splits) > 0 {
-		billSplits := make([]models.BillSplit, len(splits))
-		for i, sp := range splits {
-			billSplits[i] = models.BillSplit{
-				UserID: sp.UserID,
-				Amount: sp.Amount,
+		var err error
+		billSplits, err = computeSplits(splitMode, bill.TotalAmount, splits)
+		if err != nil {
+			return err
+		}
+	}
+
+	// snapshot the rate into the home currency so later rate changes don't move old balances
+	base, err := homeCurrency(ctx, s.homeRepo, bill.HomeID)
+	if err != nil {
+		return err
+	}
+	if bill.Currency == "" {
+		bill.Currency = base
+	}
+	bill.ExchangeRate, err = s.rates.Rate(ctx, bill.HomeID, bill.Currency, base)
+	if err != nil {
+		return err
+	}
+
+	var alert *models.BudgetAlert
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, bill); err != nil {
+			return err
+		}
+
+		// Create splits if provided
+		if len(billSplits) > 0 {
+			if err := s.repo.CreateSplits(ctx, bill.ID, billSplits); err != nil {
+				return err
+			}
+
+			// earlier overpayments may already cover the new splits
+			if _, err := s.reconcileSplits(ctx, bill.HomeID, bill.UploadedBy, billSplits); err != nil {
+				return err
+			}
+		}
+
+		if inTx != nil {
+			if err := inTx(ctx); err != nil {
+				return err
 			}
 		}
-		if err := s.repo.CreateSplits(ctx, bill.ID, billSplits); err != nil {
+
+		if err := s.outbox.Add(ctx, event.HomeChannel(bill.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleBill,
+			Action: event.ActionCreated,
+			Data:   bill,
+		}); err != nil {
+			return err
+		}
+
+		// the bill may push its category past a budget threshold
+		var err error
+		alert, err = budgetAlert(ctx, s.budgetRepo, bill, nil, base)
+		if err != nil || alert == nil {
 			return err
 		}
+		return s.outbox.Add(ctx, event.HomeChannel(bill.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleBudget,
+			Action: event.ActionThresholdReached,
+			Data:   alert,
+		})
+	})
+	if err != nil {
+		return err
 	}
 
+	bill.BillSplits = billSplits
 	metrics.BillsTotal.Inc()
 	metrics.BillOperationsTotal.WithLabelValues("create").Inc()
 
-	// Notify home about new expense
-	fromID := uploadedBy
-	desc := fmt.Sprintf("New expense added: $%.2f", totalAmount)
-	if description != "" {
-		desc = fmt.Sprintf("New expense added: %s ($%.2f)", description, totalAmount)
+	invalidateBills(ctx, s.cache, bill.HomeID, nil)
+
+	// imported bills arrive in bulk and aren't announced one by one
+	if bill.ImportHash == "" {
+		fromID := bill.UploadedBy
+		prefix := "New expense added"
+		if bill.TemplateID != nil {
+			prefix = "Recurring expense added"
+		}
+		desc := prefix + ": " + formatMoney(bill.TotalAmount, bill.Currency)
+		if bill.Description != "" {
+			desc = fmt.Sprintf("%s: %s (%s)", prefix, bill.Description, formatMoney(bill.TotalAmount, bill.Currency))
+		}
+		_ = s.notifSvc.CreateHomeNotification(ctx, &fromID, bill.HomeID, desc)
 	}
-	_ = s.notifSvc.CreateHomeNotification(ctx, &fromID, homeID, desc)
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleBill,
-		Action: event.ActionCreated,
-		Data:   bill,
-	})
+	if alert != nil {
+		_ = s.notifSvc.CreateHomeNotification(ctx, nil, bill.HomeID, budgetAlertMessage(alert))
+	}
 
 	return nil
 }
@@ -134,124 +348,456 @@ func (s *BillService) GetBillByID(ctx context.Context, id int) (*models.Bill, er
 	return bill, nil
 }
 
-func (s *BillService) GetBillsByHomeID(ctx context.Context, homeID int, categoryID *int) ([]models.Bill, error) {
-	return s.repo.FindByHomeID(ctx, homeID, categoryID)
+// GetStats sums the home's spending in the home currency. Every group other than
+// a month also carries its months, so changes between months can be shown per group.
+func (s *BillService) GetStats(ctx context.Context, homeID int, from, to *time.Time, groupBy string) (*models.SpendingStats, error) {
+	if groupBy == "" {
+		groupBy = models.StatsGroupCategory
+	}
+	switch groupBy {
+	case models.StatsGroupCategory, models.StatsGroupUploader, models.StatsGroupParticipant, models.StatsGroupMonth:
+	default:
+		return nil, ErrInvalidStatsGroup
+	}
+
+	currency, err := homeCurrency(ctx, s.homeRepo, homeID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := models.SpendingFilter{HomeID: homeID, From: from, To: to, GroupBy: groupBy}
+	summary, err := s.repo.SpendingSummary(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.repo.SpendingByGroup(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	months, err := s.repo.SpendingByMonth(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if groupBy == models.StatsGroupMonth {
+		changes := make(map[string]*models.Money)
+		for _, m := range months[""] {
+			changes[m.Month] = m.Change
+		}
+		for i := range groups {
+			groups[i].Change = changes[groups[i].Key]
+		}
+	} else {
+		for i := range groups {
+			groups[i].Months = months[groups[i].Key]
+		}
+	}
+
+	return &models.SpendingStats{
+		Currency: currency,
+		GroupBy:  groupBy,
+		From:     from,
+		To:       to,
+		Total:    summary.Total,
+		Count:    summary.Count,
+		Average:  summary.Average,
+		Groups:   groups,
+	}, nil
 }
 
 func (s *BillService) Delete(ctx context.Context, id int) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
+	bill, err := s.repo.FindByID(ctx, id)
+	if err != nil {
 		return err
 	}
+	if bill == nil {
+		return errors.New("bill not found")
+	}
 
-	metrics.BillsTotal.Dec()
-	metrics.BillOperationsTotal.WithLabelValues("delete").Inc()
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
 
-	key := utils.GetBillKey(id)
-	if err := utils.DeleteFromCache(ctx, key, s.cache); err != nil {
-		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
-	}
+		// payments made towards this bill become credit for other bills
+		if _, err := s.reconcileSplits(ctx, bill.HomeID, bill.UploadedBy, bill.BillSplits); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleBill,
-		Action: event.ActionDeleted,
-		Data:   map[string]int{"id": id},
+		return s.outbox.Add(ctx, event.HomeChannel(bill.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleBill,
+			Action: event.ActionDeleted,
+			Data:   map[string]int{"id": id},
+		})
 	})
+	if err != nil {
+		return err
+	}
+
+	metrics.BillsTotal.Dec()
+	metrics.BillOperationsTotal.WithLabelValues("delete").Inc()
+
+	invalidateBills(ctx, s.cache, bill.HomeID, []int{id})
 
 	return nil
 }
 
-func (s *BillService) MarkBillPayed(ctx context.Context, id int) error {
-	// change payed status
-	if err := s.repo.MarkPayed(ctx, id); err != nil {
-		return err
-	}
+// UpdateBill changes the fields set in req and records what changed as a revision by userID.
+// A new total has to be matched by the splits: either the ones sent along, or the existing ones.
+func (s *BillService) UpdateBill(ctx context.Context, billID, userID int, req models.UpdateBillRequest) (*models.Bill, error) {
+	return s.updateBill(ctx, billID, userID, req, event.ActionUpdated, "update")
+}
 
-	metrics.BillOperationsTotal.WithLabelValues("mark_paid").Inc()
+func (s *BillService) MarkBillPayed(ctx context.Context, id, userID int) error {
+	payed := true
+	_, err := s.updateBill(ctx, id, userID, models.UpdateBillRequest{Payed: &payed}, event.ActionMarkedPayed, "mark_paid")
+	return err
+}
 
-	// remove from cache
-	key := utils.GetBillKey(id)
-	if err := utils.DeleteFromCache(ctx, key, s.cache); err != nil {
-		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
+func (s *BillService) MarkBillUnpayed(ctx context.Context, id, userID int) error {
+	payed := false
+	_, err := s.updateBill(ctx, id, userID, models.UpdateBillRequest{Payed: &payed}, event.ActionMarkedUnpayed, "mark_unpaid")
+	return err
+}
+
+// UpdateSplits replaces the bill's splits; an empty list removes them all
+func (s *BillService) UpdateSplits(ctx context.Context, billID, userID int, splitMode string, splits []models.SplitInput) error {
+	if splits == nil {
+		splits = []models.SplitInput{}
 	}
+	_, err := s.updateBill(ctx, billID, userID, models.UpdateBillRequest{SplitMode: splitMode, Splits: splits}, event.ActionUpdated, "update_splits")
+	return err
+}
 
-	// get new bill data
-	bill, err := s.repo.FindByID(ctx, id)
+func (s *BillService) GetRevisions(ctx context.Context, billID int) ([]models.BillRevision, error) {
+	return s.repo.FindRevisions(ctx, billID)
+}
+
+func (s *BillService) updateBill(ctx context.Context, billID, userID int, req models.UpdateBillRequest, action event.Action, operation string) (*models.Bill, error) {
+	bill, err := s.repo.FindByID(ctx, billID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if bill == nil {
-		return errors.New("bill not found")
+		return nil, errors.New("bill not found")
 	}
 
-	// write to cache
-	if err := utils.WriteToCache(ctx, key, bill, s.cache); err != nil {
-		logger.Info.Printf("Failed to write to cache [%s]: %v", key, err)
+	old := *bill
+	var changes []models.FieldChange
+	changed := func(field string, from, to any) {
+		changes = append(changes, models.FieldChange{Field: field, Old: from, New: to})
 	}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleBill,
-		Action: event.ActionMarkedPayed,
-		Data:   bill,
-	})
+	if req.BillType != nil && *req.BillType != bill.Type {
+		changed("type", bill.Type, *req.BillType)
+		bill.Type = *req.BillType
+	}
+	if req.BillCategoryID != nil {
+		var categoryID *int
+		if *req.BillCategoryID != 0 {
+			categoryID = req.BillCategoryID
+		}
+		if !sameID(bill.BillCategoryID, categoryID) {
+			changed("bill_category_id", bill.BillCategoryID, categoryID)
+			bill.BillCategoryID = categoryID
+			bill.BillCategory = nil
+		}
+	}
+	if req.Description != nil && *req.Description != bill.Description {
+		changed("description", bill.Description, *req.Description)
+		bill.Description = *req.Description
+	}
+	if req.TotalAmount != nil && *req.TotalAmount != bill.TotalAmount {
+		if *req.TotalAmount <= 0 {
+			return nil, errors.New("total_amount must be greater than 0")
+		}
+		changed("total_amount", bill.TotalAmount, *req.TotalAmount)
+		bill.TotalAmount = *req.TotalAmount
+	}
+	if req.Currency != nil && *req.Currency != bill.Currency {
+		// a new currency needs a new snapshot of the rate into the home currency
+		base, err := homeCurrency(ctx, s.homeRepo, bill.HomeID)
+		if err != nil {
+			return nil, err
+		}
+		rate, err := s.rates.Rate(ctx, bill.HomeID, *req.Currency, base)
+		if err != nil {
+			return nil, err
+		}
+		changed("currency", bill.Currency, *req.Currency)
+		bill.Currency = *req.Currency
+		if rate != bill.ExchangeRate {
+			changed("exchange_rate", bill.ExchangeRate, rate)
+			bill.ExchangeRate = rate
+		}
+	}
+	if req.Start != nil && !req.Start.Equal(bill.Start) {
+		changed("period_start", bill.Start, *req.Start)
+		bill.Start = *req.Start
+	}
+	if req.End != nil && !req.End.Equal(bill.End) {
+		changed("period_end", bill.End, *req.End)
+		bill.End = *req.End
+	}
+	if req.RemoveDueDate {
+		if bill.DueDate != nil {
+			changed("due_date", bill.DueDate, nil)
+			bill.DueDate = nil
+		}
+	} else if req.DueDate != nil && (bill.DueDate == nil || !req.DueDate.Equal(*bill.DueDate)) {
+		changed("due_date", bill.DueDate, *req.DueDate)
+		bill.DueDate = req.DueDate
+	}
+	if req.Payed != nil && *req.Payed != bill.Payed {
+		changed("is_payed", bill.Payed, *req.Payed)
+		bill.Payed = *req.Payed
+		bill.PaymentDate = nil
+		if bill.Payed {
+			now := time.Now()
+			bill.PaymentDate = &now
+		}
+	}
 
-	return nil
-}
+	var billSplits []models.BillSplit
+	splitsChanged := false
+	if req.Splits != nil {
+		if len(req.Splits) > 0 {
+			billSplits, err = computeSplits(req.SplitMode, bill.TotalAmount, req.Splits)
+			if err != nil {
+				return nil, err
+			}
+		}
+		splitsChanged = !sameSplits(bill.BillSplits, billSplits)
+	} else if bill.TotalAmount != old.TotalAmount && len(bill.BillSplits) > 0 {
+		var sum models.Money
+		for _, sp := range bill.BillSplits {
+			sum += sp.Amount
+		}
+		if sum != bill.TotalAmount {
+			return nil, splitError("splits add up to %s, new total is %s; send the splits along with the new total", sum, bill.TotalAmount)
+		}
+	}
+	if splitsChanged {
+		changed("splits", splitSummary(bill.BillSplits), splitSummary(billSplits))
+	}
 
-func (s *BillService) UpdateSplits(ctx context.Context, billID int, splits []models.SplitInput) error {
-	if len(splits) > 0 {
-		bill, err := s.repo.FindByID(ctx, billID)
-		if err != nil {
+	if len(changes) == 0 {
+		return bill, nil
+	}
+
+	// a new total, category, rate or period may push a category past a budget threshold
+	budgetChanged := bill.TotalAmount != old.TotalAmount || !sameID(bill.BillCategoryID, old.BillCategoryID) ||
+		bill.ExchangeRate != old.ExchangeRate || !bill.Start.Equal(old.Start)
+
+	var billIDs []int
+	var alert *models.BudgetAlert
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, bill); err != nil {
 			return err
 		}
-		if bill == nil {
-			return errors.New("bill not found")
+		if splitsChanged {
+			if err := s.repo.UpdateSplits(ctx, billID, billSplits); err != nil {
+				return err
+			}
 		}
-		if err := validateSplits(splits, bill.TotalAmount); err != nil {
+
+		// new splits or a new rate change what every debtor owes in the home currency
+		if splitsChanged || bill.ExchangeRate != old.ExchangeRate {
+			var err error
+			billIDs, err = s.reconcileSplits(ctx, bill.HomeID, bill.UploadedBy, old.BillSplits, billSplits)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.CreateRevision(ctx, &models.BillRevision{
+			BillID:    billID,
+			ChangedBy: userID,
+			Changes:   changes,
+		}); err != nil {
 			return err
 		}
-	}
 
-	billSplits := make([]models.BillSplit, len(splits))
-	for i, sp := range splits {
-		billSplits[i] = models.BillSplit{
-			UserID: sp.UserID,
-			Amount: sp.Amount,
+		if budgetChanged {
+			base, err := homeCurrency(ctx, s.homeRepo, bill.HomeID)
+			if err != nil {
+				return err
+			}
+			if alert, err = budgetAlert(ctx, s.budgetRepo, bill, &old, base); err != nil {
+				return err
+			}
 		}
-	}
 
-	if err := s.repo.UpdateSplits(ctx, billID, billSplits); err != nil {
-		return err
+		updated, err := s.repo.FindByID(ctx, billID)
+		if err != nil {
+			return err
+		}
+		if updated != nil {
+			bill = updated
+		}
+
+		if err := s.outbox.Add(ctx, event.HomeChannel(bill.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleBill,
+			Action: action,
+			Data:   bill,
+		}); err != nil {
+			return err
+		}
+		if alert == nil {
+			return nil
+		}
+		return s.outbox.Add(ctx, event.HomeChannel(bill.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleBudget,
+			Action: event.ActionThresholdReached,
+			Data:   alert,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	metrics.BillOperationsTotal.WithLabelValues(operation).Inc()
+
 	// Invalidate cache
-	key := utils.GetBillKey(billID)
-	if err := utils.DeleteFromCache(ctx, key, s.cache); err != nil {
-		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
-	}
+	invalidateBills(ctx, s.cache, bill.HomeID, append(billIDs, billID))
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleBill,
-		Action: event.ActionUpdated,
-		Data:   map[string]int{"billID": billID},
-	})
+	if alert != nil {
+		_ = s.notifSvc.CreateHomeNotification(ctx, nil, bill.HomeID, budgetAlertMessage(alert))
+	}
 
-	return nil
+	return bill, nil
 }
 
 func (s *BillService) GetSplitByID(ctx context.Context, splitID int) (*models.BillSplit, error) {
 	return s.repo.FindSplitByID(ctx, splitID)
 }
 
+// MarkSplitPaid records a settlement for whatever is still owed on the split.
+// A split owed to oneself has nobody to pay and is simply marked paid.
 func (s *BillService) MarkSplitPaid(ctx context.Context, splitID int) error {
-	if err := s.repo.MarkSplitPaid(ctx, splitID); err != nil {
+	split, err := s.repo.FindSplitByID(ctx, splitID)
+	if err != nil {
+		return err
+	}
+	if split == nil {
+		return errors.New("split not found")
+	}
+	bill, err := s.repo.FindByID(ctx, split.BillID)
+	if err != nil {
 		return err
 	}
+	if bill == nil {
+		return errors.New("bill not found")
+	}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleBill,
-		Action: event.ActionUpdated,
-		Data:   map[string]int{"splitID": splitID},
+	var settlement *models.Settlement
+	var billIDs []int
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if split.UserID == bill.UploadedBy {
+			if err := s.repo.MarkSplitPaid(ctx, splitID); err != nil {
+				return err
+			}
+		} else {
+			remaining, err := outstandingOnSplit(ctx, s.repo, s.settlementRepo, bill.HomeID, split.UserID, bill.UploadedBy, split.ID)
+			if err != nil {
+				return err
+			}
+			if remaining > 0 {
+				currency, err := homeCurrency(ctx, s.homeRepo, bill.HomeID)
+				if err != nil {
+					return err
+				}
+				settlement = &models.Settlement{
+					HomeID:     bill.HomeID,
+					FromUserID: split.UserID,
+					ToUserID:   bill.UploadedBy,
+					Amount:     remaining,
+					Currency:   currency,
+					SplitID:    &split.ID,
+					Note:       "Split marked as paid",
+				}
+				if err := s.settlementRepo.Create(ctx, settlement); err != nil {
+					return err
+				}
+			}
+
+			billIDs, err = reconcileSplits(ctx, s.repo, s.settlementRepo, bill.HomeID, split.UserID, bill.UploadedBy)
+			if err != nil {
+				return err
+			}
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(bill.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleBill,
+			Action: event.ActionUpdated,
+			Data:   map[string]int{"splitID": splitID},
+		})
 	})
+	if err != nil {
+		return err
+	}
+
+	invalidateBills(ctx, s.cache, bill.HomeID, append(billIDs, bill.ID))
+
+	if settlement != nil {
+		from := settlement.FromUserID
+		_ = s.notifSvc.Create(ctx, &from, settlement.ToUserID, "You received a payment of "+formatMoney(settlement.Amount, settlement.Currency))
+	}
 
 	return nil
 }
+
+// reconcileSplits re-derives the paid state of every debtor in the given splits towards the bill's uploader
+func (s *BillService) reconcileSplits(ctx context.Context, homeID, creditorID int, splitSets ...[]models.BillSplit) ([]int, error) {
+	seen := make(map[int]bool)
+	var billIDs []int
+	for _, splits := range splitSets {
+		for _, sp := range splits {
+			if seen[sp.UserID] {
+				continue
+			}
+			seen[sp.UserID] = true
+
+			ids, err := reconcileSplits(ctx, s.repo, s.settlementRepo, homeID, sp.UserID, creditorID)
+			if err != nil {
+				return nil, err
+			}
+			billIDs = append(billIDs, ids...)
+		}
+	}
+	return billIDs, nil
+}
+
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sameSplits reports whether both sets charge the same users the same amounts
+func sameSplits(a, b []models.BillSplit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	amounts := make(map[int]models.Money, len(a))
+	for _, sp := range a {
+		amounts[sp.UserID] = sp.Amount
+	}
+	for _, sp := range b {
+		amount, ok := amounts[sp.UserID]
+		if !ok || amount != sp.Amount {
+			return false
+		}
+	}
+	return true
+}
+
+// splitSummary is how splits are shown in a revision
+func splitSummary(splits []models.BillSplit) []models.SplitInput {
+	summary := make([]models.SplitInput, len(splits))
+	for i, sp := range splits {
+		summary[i] = models.SplitInput{UserID: sp.UserID, Amount: sp.Amount}
+	}
+	return summary
+}
@@ -21,12 +21,13 @@ type IBillCategoryService interface {
 }
 
 type BillCategoryService struct {
-	cache *redis.Client
-	repo  repository.IBillCategoryRepository
+	cache  *redis.Client
+	repo   repository.IBillCategoryRepository
+	outbox IOutboxService
 }
 
-func NewBillCategoryService(repo repository.IBillCategoryRepository, cache *redis.Client) *BillCategoryService {
-	return &BillCategoryService{repo: repo, cache: cache}
+func NewBillCategoryService(repo repository.IBillCategoryRepository, cache *redis.Client, outbox IOutboxService) *BillCategoryService {
+	return &BillCategoryService{repo: repo, cache: cache, outbox: outbox}
 }
 
 func (s *BillCategoryService) GetCategoryByID(ctx context.Context, id int) (*models.BillCategory, error) {
@@ -46,17 +47,17 @@ func (s *BillCategoryService) CreateCategory(ctx context.Context, homeID int, na
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.Create(ctx, category); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, category); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleBillCategory,
-		Action: event.ActionCreated,
-		Data:   category,
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleBillCategory,
+			Action: event.ActionCreated,
+			Data:   category,
+		})
 	})
-
-	return nil
 }
 
 func (s *BillCategoryService) GetCategories(ctx context.Context, homeID int) ([]models.BillCategory, error) {
@@ -101,17 +102,26 @@ func (s *BillCategoryService) UpdateCategory(ctx context.Context, categoryID int
 		updates["color"] = *color
 	}
 
-	newCategory, err := s.repo.Update(ctx, category, updates)
+	var newCategory *models.BillCategory
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		newCategory, err = s.repo.Update(ctx, category, updates)
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(category.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleBillCategory,
+			Action: event.ActionUpdated,
+			Data:   newCategory,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleBillCategory,
-		Action: event.ActionUpdated,
-		Data:   newCategory,
-	})
-
+	// cached bill pages carry the category name
+	invalidateBills(ctx, s.cache, category.HomeID, nil)
 	return newCategory, nil
 }
 
@@ -121,15 +131,22 @@ func (s *BillCategoryService) DeleteCategory(ctx context.Context, id int, homeID
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
-		return err
-	}
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleBillCategory,
-		Action: event.ActionDeleted,
-		Data:   map[string]int{"id": id},
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleBillCategory,
+			Action: event.ActionDeleted,
+			Data:   map[string]int{"id": id},
+		})
 	})
+	if err != nil {
+		return err
+	}
 
+	// the category's bills are left without one
+	invalidateBills(ctx, s.cache, homeID, nil)
 	return nil
 }
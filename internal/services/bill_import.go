@@ -0,0 +1,503 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+)
+
+const maxImportRows = 5000
+
+var (
+	ErrImportProfileNotFound = errors.New("import profile not found")
+	ErrVendorRuleNotFound    = errors.New("vendor rule not found")
+	ErrImportNotFound        = errors.New("import not found")
+	ErrImportCommitted       = errors.New("import was already committed")
+	ErrInvalidImportFile     = errors.New("invalid statement file")
+	ErrInvalidImportProfile  = errors.New("invalid import profile")
+	ErrInvalidImportLine     = errors.New("line cannot be imported")
+)
+
+// dateTokens turn a profile date format like DD.MM.YYYY into a Go layout
+var dateTokens = strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02")
+
+// BillImporter creates the bills of a committed import; BillService implements it
+type BillImporter interface {
+	CreateImportedBill(ctx context.Context, homeID, uploadedBy int, row models.ImportRow, currency string) (*models.Bill, error)
+}
+
+type IBillImportService interface {
+	CreateProfile(ctx context.Context, homeID int, req models.CreateImportProfileRequest) (*models.ImportProfile, error)
+	GetProfiles(ctx context.Context, homeID int) ([]models.ImportProfile, error)
+	DeleteProfile(ctx context.Context, homeID, profileID int) error
+	CreateRule(ctx context.Context, homeID int, req models.CreateVendorRuleRequest) (*models.VendorRule, error)
+	GetRules(ctx context.Context, homeID int) ([]models.VendorRule, error)
+	DeleteRule(ctx context.Context, homeID, ruleID int) error
+	Preview(ctx context.Context, homeID, userID, profileID int, fileName string, file io.Reader) (*models.BillImport, error)
+	GetImport(ctx context.Context, homeID, importID int) (*models.BillImport, error)
+	Commit(ctx context.Context, homeID, userID, importID int, req models.CommitImportRequest) (*models.ImportResult, error)
+}
+
+type BillImportService struct {
+	repo         repository.BillImportRepository
+	billRepo     repository.BillRepository
+	categoryRepo repository.IBillCategoryRepository
+	bills        BillImporter
+	homeRepo     repository.HomeRepository
+	notifSvc     INotificationService
+	outbox       IOutboxService
+}
+
+func NewBillImportService(repo repository.BillImportRepository, billRepo repository.BillRepository, categoryRepo repository.IBillCategoryRepository, bills BillImporter,
+	homeRepo repository.HomeRepository, notifSvc INotificationService, outbox IOutboxService) *BillImportService {
+	return &BillImportService{repo: repo, billRepo: billRepo, categoryRepo: categoryRepo, bills: bills, homeRepo: homeRepo, notifSvc: notifSvc, outbox: outbox}
+}
+
+func (s *BillImportService) CreateProfile(ctx context.Context, homeID int, req models.CreateImportProfileRequest) (*models.ImportProfile, error) {
+	profile := &models.ImportProfile{
+		HomeID:            homeID,
+		Name:              req.Name,
+		Delimiter:         req.Delimiter,
+		HasHeader:         req.HasHeader == nil || *req.HasHeader,
+		SkipLines:         req.SkipLines,
+		DateColumn:        strings.TrimSpace(req.DateColumn),
+		DateFormat:        req.DateFormat,
+		AmountColumn:      strings.TrimSpace(req.AmountColumn),
+		AmountSign:        req.AmountSign,
+		DecimalSeparator:  req.DecimalSeparator,
+		DescriptionColumn: strings.TrimSpace(req.DescriptionColumn),
+		Currency:          strings.ToUpper(req.Currency),
+	}
+	if profile.Delimiter == "" {
+		profile.Delimiter = ","
+	}
+	if profile.AmountSign == "" {
+		profile.AmountSign = models.AmountSignNegative
+	}
+	if profile.DecimalSeparator == "" {
+		profile.DecimalSeparator = "."
+	}
+
+	switch profile.Delimiter {
+	case `"`, "\r", "\n":
+		return nil, fmt.Errorf("%w: unsupported delimiter", ErrInvalidImportProfile)
+	}
+	if profile.DecimalSeparator != "." && profile.DecimalSeparator != "," {
+		return nil, fmt.Errorf("%w: decimal_separator must be . or ,", ErrInvalidImportProfile)
+	}
+	if !strings.Contains(profile.DateFormat, "YY") || !strings.Contains(profile.DateFormat, "MM") || !strings.Contains(profile.DateFormat, "DD") {
+		return nil, fmt.Errorf("%w: date_format needs YYYY or YY, MM and DD", ErrInvalidImportProfile)
+	}
+	if !profile.HasHeader {
+		for _, column := range []string{profile.DateColumn, profile.AmountColumn, profile.DescriptionColumn} {
+			if n, err := strconv.Atoi(column); err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: without a header columns are numbered from 1", ErrInvalidImportProfile)
+			}
+		}
+	}
+	if profile.Currency != "" && !IsSupportedCurrency(profile.Currency) {
+		return nil, ErrUnsupportedCurrency
+	}
+
+	if err := s.repo.CreateProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (s *BillImportService) GetProfiles(ctx context.Context, homeID int) ([]models.ImportProfile, error) {
+	return s.repo.FindProfilesByHome(ctx, homeID)
+}
+
+func (s *BillImportService) DeleteProfile(ctx context.Context, homeID, profileID int) error {
+	if _, err := s.profile(ctx, homeID, profileID); err != nil {
+		return err
+	}
+	return s.repo.DeleteProfile(ctx, profileID)
+}
+
+func (s *BillImportService) CreateRule(ctx context.Context, homeID int, req models.CreateVendorRuleRequest) (*models.VendorRule, error) {
+	pattern := strings.TrimSpace(req.Pattern)
+	if pattern == "" {
+		return nil, errors.New("pattern is required")
+	}
+	category, err := s.categoryRepo.GetByID(ctx, req.BillCategoryID)
+	if err != nil || category == nil || category.HomeID != homeID {
+		return nil, ErrCategoryNotFound
+	}
+
+	rule := &models.VendorRule{
+		HomeID:         homeID,
+		Pattern:        pattern,
+		BillCategoryID: req.BillCategoryID,
+	}
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	rule.BillCategory = category
+	return rule, nil
+}
+
+func (s *BillImportService) GetRules(ctx context.Context, homeID int) ([]models.VendorRule, error) {
+	return s.repo.FindRulesByHome(ctx, homeID)
+}
+
+func (s *BillImportService) DeleteRule(ctx context.Context, homeID, ruleID int) error {
+	rule, err := s.repo.FindRule(ctx, ruleID)
+	if err != nil {
+		return err
+	}
+	if rule == nil || rule.HomeID != homeID {
+		return ErrVendorRuleNotFound
+	}
+	return s.repo.DeleteRule(ctx, ruleID)
+}
+
+// Preview parses a statement with the profile's layout and stores the rows for review.
+// Nothing becomes a bill until the import is committed.
+func (s *BillImportService) Preview(ctx context.Context, homeID, userID, profileID int, fileName string, file io.Reader) (*models.BillImport, error) {
+	profile, err := s.profile(ctx, homeID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.repo.FindRulesByHome(ctx, homeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := parseStatement(profile, file)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, 0, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		if row.Status == models.ImportRowInvalid {
+			continue
+		}
+		row.BillCategoryID = matchVendorRule(rules, row.Description)
+		if row.Status == models.ImportRowNew {
+			hashes = append(hashes, row.Hash)
+		}
+	}
+
+	existing, err := s.billRepo.FindImportHashes(ctx, homeID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i].Status == models.ImportRowNew && existing[rows[i].Hash] {
+			rows[i].Status = models.ImportRowDuplicate
+		}
+	}
+
+	currency := profile.Currency
+	if currency == "" {
+		if currency, err = homeCurrency(ctx, s.homeRepo, homeID); err != nil {
+			return nil, err
+		}
+	}
+
+	imp := &models.BillImport{
+		HomeID:    homeID,
+		UserID:    userID,
+		ProfileID: profile.ID,
+		FileName:  fileName,
+		Currency:  currency,
+		Status:    models.ImportStatusPreview,
+		Rows:      rows,
+	}
+	if err := s.repo.Create(ctx, imp); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+func (s *BillImportService) GetImport(ctx context.Context, homeID, importID int) (*models.BillImport, error) {
+	imp, err := s.repo.FindByID(ctx, importID)
+	if err != nil {
+		return nil, err
+	}
+	if imp == nil || imp.HomeID != homeID {
+		return nil, ErrImportNotFound
+	}
+	return imp, nil
+}
+
+// Commit creates a bill for every selected row of a preview, all in one transaction.
+// Without explicit lines only new rows are imported, re-checked against bills imported
+// since the preview; listing a duplicate line imports it anyway.
+func (s *BillImportService) Commit(ctx context.Context, homeID, userID, importID int, req models.CommitImportRequest) (*models.ImportResult, error) {
+	imp, err := s.GetImport(ctx, homeID, importID)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status != models.ImportStatusPreview {
+		return nil, ErrImportCommitted
+	}
+
+	selected := make(map[int]bool, len(req.Lines))
+	for _, line := range req.Lines {
+		selected[line] = true
+	}
+	byLine := make(map[int]models.ImportRow, len(imp.Rows))
+	for _, row := range imp.Rows {
+		byLine[row.Line] = row
+	}
+	for line := range selected {
+		row, ok := byLine[line]
+		if !ok || (row.Status != models.ImportRowNew && row.Status != models.ImportRowDuplicate) {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidImportLine, line)
+		}
+	}
+	for line, categoryID := range req.Categories {
+		if _, ok := byLine[line]; !ok {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidImportLine, line)
+		}
+		if categoryID == 0 {
+			continue
+		}
+		category, err := s.categoryRepo.GetByID(ctx, categoryID)
+		if err != nil || category == nil || category.HomeID != homeID {
+			return nil, ErrCategoryNotFound
+		}
+	}
+
+	result := &models.ImportResult{Bills: []models.Bill{}}
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		committed, err := s.repo.MarkCommitted(ctx, imp.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !committed {
+			return ErrImportCommitted
+		}
+
+		var existing map[string]bool
+		if len(selected) == 0 {
+			hashes := make([]string, 0, len(imp.Rows))
+			for _, row := range imp.Rows {
+				if row.Status == models.ImportRowNew {
+					hashes = append(hashes, row.Hash)
+				}
+			}
+			if existing, err = s.billRepo.FindImportHashes(ctx, homeID, hashes); err != nil {
+				return err
+			}
+		}
+
+		for _, row := range imp.Rows {
+			if len(selected) > 0 {
+				if !selected[row.Line] {
+					result.Skipped++
+					continue
+				}
+			} else if row.Status != models.ImportRowNew || existing[row.Hash] {
+				result.Skipped++
+				continue
+			}
+
+			if categoryID, ok := req.Categories[row.Line]; ok {
+				row.BillCategoryID = nil
+				if categoryID != 0 {
+					row.BillCategoryID = &categoryID
+				}
+			}
+
+			bill, err := s.bills.CreateImportedBill(ctx, homeID, userID, row, imp.Currency)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			result.Bills = append(result.Bills, *bill)
+		}
+		result.Created = len(result.Bills)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Created > 0 {
+		desc := fmt.Sprintf("%d expenses imported from %s", result.Created, imp.FileName)
+		_ = s.notifSvc.CreateHomeNotification(ctx, &userID, homeID, desc)
+	}
+
+	return result, nil
+}
+
+// profile returns the import profile if it belongs to the home
+func (s *BillImportService) profile(ctx context.Context, homeID, profileID int) (*models.ImportProfile, error) {
+	profile, err := s.repo.FindProfile(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil || profile.HomeID != homeID {
+		return nil, ErrImportProfileNotFound
+	}
+	return profile, nil
+}
+
+// parseStatement reads the transactions of a bank export. Rows that can't be read are kept
+// as invalid with the reason, so the preview shows what was left out.
+func parseStatement(profile *models.ImportProfile, file io.Reader) ([]models.ImportRow, error) {
+	br := bufio.NewReader(file)
+
+	// Excel likes to start UTF-8 exports with a byte order mark
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		_, _ = br.Discard(3)
+	}
+	for i := 0; i < profile.SkipLines; i++ {
+		if _, err := br.ReadString('\n'); err != nil {
+			return nil, fmt.Errorf("%w: fewer lines than skip_lines", ErrInvalidImportFile)
+		}
+	}
+
+	reader := csv.NewReader(br)
+	reader.Comma = rune(profile.Delimiter[0])
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	dateCol, amountCol, descCol := -1, -1, -1
+	if profile.HasHeader {
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("%w: missing header", ErrInvalidImportFile)
+		}
+		dateCol = columnIndex(header, profile.DateColumn)
+		amountCol = columnIndex(header, profile.AmountColumn)
+		descCol = columnIndex(header, profile.DescriptionColumn)
+		if dateCol < 0 || amountCol < 0 || descCol < 0 {
+			return nil, fmt.Errorf("%w: header does not match the profile columns", ErrInvalidImportFile)
+		}
+	} else {
+		dateCol = columnIndex(nil, profile.DateColumn)
+		amountCol = columnIndex(nil, profile.AmountColumn)
+		descCol = columnIndex(nil, profile.DescriptionColumn)
+	}
+
+	layout := dateTokens.Replace(profile.DateFormat)
+	occurrences := make(map[string]int)
+	rows := []models.ImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+		if blankRecord(record) {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidImportFile, maxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := models.ImportRow{Line: line + profile.SkipLines, Status: models.ImportRowNew}
+		rows = append(rows, row)
+		current := &rows[len(rows)-1]
+
+		if dateCol >= len(record) || amountCol >= len(record) || descCol >= len(record) {
+			current.Status, current.Error = models.ImportRowInvalid, "missing columns"
+			continue
+		}
+		current.Description = strings.Join(strings.Fields(record[descCol]), " ")
+
+		date, err := time.ParseInLocation(layout, strings.TrimSpace(record[dateCol]), time.UTC)
+		if err != nil {
+			current.Status, current.Error = models.ImportRowInvalid, "invalid date"
+			continue
+		}
+		current.Date = date
+
+		amount, err := parseStatementAmount(record[amountCol], profile.DecimalSeparator)
+		if err != nil || amount == 0 {
+			current.Status, current.Error = models.ImportRowInvalid, "invalid amount"
+			continue
+		}
+		if profile.AmountSign == models.AmountSignNegative {
+			amount = -amount
+		}
+		if amount < 0 {
+			current.Status = models.ImportRowIncome
+			amount = -amount
+		}
+		current.Amount = amount
+
+		// identical transactions on the same day are told apart by their position
+		key := strings.Join([]string{date.Format(time.DateOnly), amount.String(), strings.ToLower(current.Description)}, "|")
+		occurrences[key]++
+		sum := sha256.Sum256([]byte(key + "|" + strconv.Itoa(occurrences[key])))
+		current.Hash = hex.EncodeToString(sum[:])
+	}
+
+	return rows, nil
+}
+
+// columnIndex finds a column by header name, or by its 1-based position
+func columnIndex(header []string, column string) int {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i
+		}
+	}
+	if n, err := strconv.Atoi(column); err == nil && n >= 1 {
+		return n - 1
+	}
+	return -1
+}
+
+func blankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseStatementAmount reads amounts like "-1.234,56" or "1 234.56", dropping thousands separators
+func parseStatementAmount(value, decimalSeparator string) (models.Money, error) {
+	value = strings.NewReplacer(" ", "", "\u00a0", "", "'", "").Replace(strings.TrimSpace(value))
+	if decimalSeparator == "," {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	return models.ParseMoney(value)
+}
+
+// matchVendorRule returns the category of the longest rule pattern found in the description
+func matchVendorRule(rules []models.VendorRule, description string) *int {
+	description = strings.ToLower(description)
+	var best *models.VendorRule
+	for i := range rules {
+		rule := &rules[i]
+		if !strings.Contains(description, strings.ToLower(rule.Pattern)) {
+			continue
+		}
+		if best == nil || len(rule.Pattern) > len(best.Pattern) {
+			best = rule
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	categoryID := best.BillCategoryID
+	return &categoryID
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Dragodui/diploma-server/internal/logger"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrInvalidBillFilter is returned for bill listing parameters that can't be applied
+var ErrInvalidBillFilter = errors.New("invalid bill filter")
+
+// GetBillsByHomeID returns one page of the home's bills. Pages are cached under a per-home
+// version that every change to the home's bills bumps. Overdue listings change with the
+// date alone and are always read from the database.
+func (s *BillService) GetBillsByHomeID(ctx context.Context, f models.BillFilter) (*models.BillPage, error) {
+	if err := normalizeBillFilter(&f); err != nil {
+		return nil, err
+	}
+
+	key := ""
+	if f.Status != models.BillStatusOverdue {
+		var err error
+		if key, err = billPageKey(ctx, s.cache, f); err != nil {
+			logger.Info.Printf("Failed to read bill list version of home %d: %v", f.HomeID, err)
+		}
+	}
+	if key != "" {
+		cached, err := utils.GetFromCache[models.BillPage](ctx, key, s.cache)
+		if cached != nil && err == nil {
+			return cached, nil
+		}
+	}
+
+	// one bill more than asked for tells whether there is a next page
+	query := f
+	query.Limit = f.Limit + 1
+	bills, err := s.repo.FindByHomeID(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.BillPage{Bills: bills}
+	if len(bills) > f.Limit {
+		page.Bills = bills[:f.Limit]
+		page.NextCursor = encodeBillCursor(f.Sort, &page.Bills[f.Limit-1])
+	}
+	if page.Bills == nil {
+		page.Bills = []models.Bill{}
+	}
+
+	if key != "" {
+		if err := utils.WriteToCache(ctx, key, page, s.cache); err != nil {
+			logger.Info.Printf("Failed to write to cache [%s]: %v", key, err)
+		}
+	}
+
+	return page, nil
+}
+
+// normalizeBillFilter fills in the defaults and decodes the cursor
+func normalizeBillFilter(f *models.BillFilter) error {
+	switch f.Status {
+	case "", models.BillStatusPaid, models.BillStatusUnpaid, models.BillStatusOverdue:
+	default:
+		return fmt.Errorf("%w: status must be paid, unpaid or overdue", ErrInvalidBillFilter)
+	}
+
+	if f.DateField == "" {
+		f.DateField = models.BillDatePeriod
+	}
+	if f.DateField != models.BillDatePeriod && f.DateField != models.BillDateCreated {
+		return fmt.Errorf("%w: date_field must be period or created", ErrInvalidBillFilter)
+	}
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		return fmt.Errorf("%w: to must not be before from", ErrInvalidBillFilter)
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MaxAmount < *f.MinAmount {
+		return fmt.Errorf("%w: max_amount must not be below min_amount", ErrInvalidBillFilter)
+	}
+
+	if f.Sort == "" {
+		f.Sort = models.BillSortCreated
+	}
+	switch f.Sort {
+	case models.BillSortCreated, models.BillSortStart, models.BillSortAmount:
+	default:
+		return fmt.Errorf("%w: sort must be created_at, period_start or total_amount", ErrInvalidBillFilter)
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = models.BillListDefaultLimit
+	}
+	if f.Limit > models.BillListMaxLimit {
+		f.Limit = models.BillListMaxLimit
+	}
+	f.Search = strings.TrimSpace(f.Search)
+
+	f.After = nil
+	if f.Cursor != "" {
+		cursor, err := decodeBillCursor(f.Cursor)
+		if err != nil || cursor.Sort != f.Sort || (f.Sort != models.BillSortAmount && cursor.Time == nil) {
+			return fmt.Errorf("%w: cursor does not belong to this listing", ErrInvalidBillFilter)
+		}
+		f.After = cursor
+	}
+	return nil
+}
+
+func encodeBillCursor(sort string, last *models.Bill) string {
+	cursor := models.BillCursor{Sort: sort, ID: last.ID}
+	switch sort {
+	case models.BillSortAmount:
+		cursor.Amount = last.TotalAmount
+	case models.BillSortStart:
+		cursor.Time = &last.Start
+	default:
+		cursor.Time = &last.CreatedAt
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeBillCursor(s string) (*models.BillCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var cursor models.BillCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// billPageKey is the cache key of a page under the home's current bill list version
+func billPageKey(ctx context.Context, cache *redis.Client, f models.BillFilter) (string, error) {
+	version, err := cache.Get(ctx, utils.GetBillsVersionKey(f.HomeID)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return utils.GetBillsPageKey(f.HomeID, version, hex.EncodeToString(sum[:8])), nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/logger"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+)
+
+// maxReminderLeadDays bounds how far ahead the reminder job looks, matching SetReminderSettingsRequest
+const maxReminderLeadDays = 30
+
+type IBillReminderService interface {
+	GetSettings(ctx context.Context, homeID int) (*models.BillReminderSettings, error)
+	SetSettings(ctx context.Context, homeID, leadDays int) (*models.BillReminderSettings, error)
+	SendReminders(ctx context.Context, now time.Time) (int, error)
+}
+
+type BillReminderService struct {
+	repo     repository.BillReminderRepository
+	notifSvc INotificationService
+	outbox   IOutboxService
+}
+
+func NewBillReminderService(repo repository.BillReminderRepository, notifSvc INotificationService, outbox IOutboxService) *BillReminderService {
+	return &BillReminderService{repo: repo, notifSvc: notifSvc, outbox: outbox}
+}
+
+// GetSettings returns the home's reminder settings, or the defaults when it never set any
+func (s *BillReminderService) GetSettings(ctx context.Context, homeID int) (*models.BillReminderSettings, error) {
+	settings, err := s.repo.FindSettings(ctx, homeID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.BillReminderSettings{HomeID: homeID, LeadDays: models.DefaultReminderLeadDays}
+	}
+	return settings, nil
+}
+
+func (s *BillReminderService) SetSettings(ctx context.Context, homeID, leadDays int) (*models.BillReminderSettings, error) {
+	if leadDays < 0 || leadDays > maxReminderLeadDays {
+		return nil, fmt.Errorf("lead_days must be between 0 and %d", maxReminderLeadDays)
+	}
+
+	settings := &models.BillReminderSettings{HomeID: homeID, LeadDays: leadDays}
+	if err := s.repo.UpsertSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SendReminders notifies members with unpaid splits of bills due within their home's lead
+// time, due today or overdue. Each reminder is recorded in the same transaction as its
+// notification, so a reminder is never sent twice.
+func (s *BillReminderService) SendReminders(ctx context.Context, now time.Time) (int, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	splits, err := s.repo.FindUnpaidDueSplits(ctx, today.AddDate(0, 0, maxReminderLeadDays+1))
+	if err != nil {
+		return 0, err
+	}
+	if len(splits) == 0 {
+		return 0, nil
+	}
+
+	seen := make(map[int]bool)
+	var homeIDs []int
+	for _, split := range splits {
+		if split.Bill != nil && !seen[split.Bill.HomeID] {
+			seen[split.Bill.HomeID] = true
+			homeIDs = append(homeIDs, split.Bill.HomeID)
+		}
+	}
+	leadDays, err := s.repo.LeadDays(ctx, homeIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, split := range splits {
+		bill := split.Bill
+		if bill == nil || bill.DueDate == nil {
+			continue
+		}
+		lead, ok := leadDays[bill.HomeID]
+		if !ok {
+			lead = models.DefaultReminderLeadDays
+		}
+
+		days := models.DaysUntil(now, *bill.DueDate)
+		kind := reminderKind(days, lead)
+		if kind == "" {
+			continue
+		}
+
+		ok, err := s.remind(ctx, &split, kind, days)
+		if err != nil {
+			logger.Info.Printf("Failed to send %s reminder for bill %d to user %d: %v", kind, bill.ID, split.UserID, err)
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// reminderKind picks the reminder for a bill due in the given number of days, if any is due
+func reminderKind(days, leadDays int) string {
+	switch {
+	case days < 0:
+		return models.ReminderOverdue
+	case days == 0:
+		return models.ReminderDue
+	case days <= leadDays:
+		return models.ReminderUpcoming
+	}
+	return ""
+}
+
+func (s *BillReminderService) remind(ctx context.Context, split *models.BillSplit, kind string, days int) (bool, error) {
+	bill := split.Bill
+	sent := false
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		sent, err = s.repo.MarkSent(ctx, &models.BillReminder{
+			BillID:  bill.ID,
+			UserID:  split.UserID,
+			Kind:    kind,
+			DueDate: *bill.DueDate,
+		})
+		if err != nil || !sent {
+			return err
+		}
+
+		return s.notifSvc.Create(ctx, nil, split.UserID, reminderText(bill, split.Amount, kind, days))
+	})
+	return sent, err
+}
+
+func reminderText(bill *models.Bill, amount models.Money, kind string, days int) string {
+	name := bill.Description
+	if name == "" {
+		name = bill.Type
+	}
+	share := fmt.Sprintf("Your share of %q (%s %s)", name, amount, bill.Currency)
+
+	switch kind {
+	case models.ReminderOverdue:
+		return fmt.Sprintf("%s was due on %s and is overdue", share, bill.DueDate.UTC().Format(time.DateOnly))
+	case models.ReminderDue:
+		return share + " is due today"
+	}
+	if days == 1 {
+		return share + " is due tomorrow"
+	}
+	return fmt.Sprintf("%s is due in %d days", share, days)
+}
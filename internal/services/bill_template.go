@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/logger"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+)
+
+// maxCatchUpPeriods caps the bills one template generates per tick, so a template started
+// long ago catches up over several ticks instead of flooding the home at once
+const maxCatchUpPeriods = 3
+
+// errPeriodTaken rolls back a generated bill when another worker already produced the period
+var errPeriodTaken = errors.New("template period already generated")
+
+// BillGenerator creates the bills of recurring templates; BillService implements it
+type BillGenerator interface {
+	GenerateFromTemplate(ctx context.Context, tpl *models.BillTemplate, start, end time.Time, advance func(ctx context.Context) error) (*models.Bill, error)
+}
+
+type IBillTemplateService interface {
+	CreateTemplate(ctx context.Context, homeID, createdBy int, billType string, billCategoryID *int, description string, amount models.Money, currency string,
+		recurrenceType string, interval int, startDate time.Time, splitMode string, splits []models.SplitInput) (*models.BillTemplate, error)
+	GetTemplateByID(ctx context.Context, id int) (*models.BillTemplate, error)
+	GetTemplatesByHomeID(ctx context.Context, homeID int) ([]models.BillTemplate, error)
+	DeleteTemplate(ctx context.Context, id int) error
+	ProcessDueTemplates(ctx context.Context) error
+}
+
+type BillTemplateService struct {
+	repo  repository.BillTemplateRepository
+	bills BillGenerator
+}
+
+func NewBillTemplateService(repo repository.BillTemplateRepository, bills BillGenerator) *BillTemplateService {
+	return &BillTemplateService{repo: repo, bills: bills}
+}
+
+func (s *BillTemplateService) CreateTemplate(ctx context.Context, homeID, createdBy int, billType string, billCategoryID *int, description string, amount models.Money, currency string,
+	recurrenceType string, interval int, startDate time.Time, splitMode string, splits []models.SplitInput) (*models.BillTemplate, error) {
+
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+	switch recurrenceType {
+	case models.RecurrenceWeekly, models.RecurrenceMonthly, models.RecurrenceYearly:
+	default:
+		return nil, errors.New("recurrence_type must be weekly, monthly, or yearly")
+	}
+	if interval <= 0 {
+		interval = 1
+	}
+	if currency != "" && !IsSupportedCurrency(currency) {
+		return nil, ErrUnsupportedCurrency
+	}
+
+	// reject a split that could never be generated
+	if len(splits) > 0 {
+		if _, err := computeSplits(splitMode, amount, splits); err != nil {
+			return nil, err
+		}
+	}
+
+	template := &models.BillTemplate{
+		HomeID:         homeID,
+		CreatedBy:      createdBy,
+		BillType:       billType,
+		BillCategoryID: billCategoryID,
+		Description:    description,
+		Amount:         amount,
+		Currency:       currency,
+		RecurrenceType: recurrenceType,
+		Interval:       interval,
+		SplitMode:      splitMode,
+		Splits:         splits,
+		StartDate:      startDate,
+		NextRunDate:    startDate,
+		IsActive:       true,
+	}
+
+	if err := s.repo.Create(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *BillTemplateService) GetTemplateByID(ctx context.Context, id int) (*models.BillTemplate, error) {
+	template, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, errors.New("template not found")
+	}
+	return template, nil
+}
+
+func (s *BillTemplateService) GetTemplatesByHomeID(ctx context.Context, homeID int) ([]models.BillTemplate, error) {
+	return s.repo.FindByHomeID(ctx, homeID)
+}
+
+// DeleteTemplate stops future bills; bills already generated are kept
+func (s *BillTemplateService) DeleteTemplate(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// ProcessDueTemplates generates a bill for every template period that has started,
+// catching up on periods missed while the server was down a few at a time.
+func (s *BillTemplateService) ProcessDueTemplates(ctx context.Context) error {
+	now := time.Now()
+	templates, err := s.repo.FindDue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for i := range templates {
+		tpl := &templates[i]
+
+		for generated := 0; generated < maxCatchUpPeriods && !tpl.NextRunDate.After(now); generated++ {
+			start := tpl.NextRunDate
+			occurrences := tpl.Occurrences + 1
+			next := periodStart(tpl.StartDate, tpl.RecurrenceType, tpl.Interval, occurrences)
+
+			bill, err := s.bills.GenerateFromTemplate(ctx, tpl, start, next.AddDate(0, 0, -1), func(ctx context.Context) error {
+				advanced, err := s.repo.Advance(ctx, tpl.ID, occurrences, next)
+				if err != nil {
+					return err
+				}
+				if !advanced {
+					return errPeriodTaken
+				}
+				return nil
+			})
+			if errors.Is(err, errPeriodTaken) {
+				break
+			}
+			if err != nil {
+				logger.Info.Printf("[Scheduler] Failed to generate bill from template %d: %v", tpl.ID, err)
+				break
+			}
+
+			tpl.Occurrences = occurrences
+			tpl.NextRunDate = next
+			logger.Info.Printf("[Scheduler] Generated bill %d from template %d for %s", bill.ID, tpl.ID, start.Format("2006-01-02"))
+		}
+	}
+
+	return nil
+}
+
+// periodStart returns when the nth period of a template begins. Months are counted from
+// the start date rather than the previous period, so a template starting on the 31st
+// falls on the last day of shorter months and returns to the 31st afterwards.
+func periodStart(start time.Time, recurrenceType string, interval, n int) time.Time {
+	switch recurrenceType {
+	case models.RecurrenceWeekly:
+		return start.AddDate(0, 0, 7*interval*n)
+	case models.RecurrenceYearly:
+		return addMonths(start, 12*interval*n)
+	default:
+		return addMonths(start, interval*n)
+	}
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
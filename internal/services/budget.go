@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrBudgetNotFound   = errors.New("budget not found")
+)
+
+type IBudgetService interface {
+	SetBudget(ctx context.Context, homeID, categoryID, userID int, amount models.Money) (*models.BillCategoryBudget, error)
+	GetBudget(ctx context.Context, homeID, categoryID int) (*models.BillCategoryBudget, error)
+	DeleteBudget(ctx context.Context, homeID, categoryID int) error
+	GetStatus(ctx context.Context, homeID, categoryID int, month time.Time) (*models.BudgetStatus, error)
+	GetStatuses(ctx context.Context, homeID int, month time.Time) ([]models.BudgetStatus, error)
+}
+
+type BudgetService struct {
+	repo         repository.BudgetRepository
+	categoryRepo repository.IBillCategoryRepository
+	homeRepo     repository.HomeRepository
+	outbox       IOutboxService
+}
+
+func NewBudgetService(repo repository.BudgetRepository, categoryRepo repository.IBillCategoryRepository, homeRepo repository.HomeRepository, outbox IOutboxService) *BudgetService {
+	return &BudgetService{repo: repo, categoryRepo: categoryRepo, homeRepo: homeRepo, outbox: outbox}
+}
+
+// SetBudget creates or replaces the monthly budget of a category, in the home currency
+func (s *BudgetService) SetBudget(ctx context.Context, homeID, categoryID, userID int, amount models.Money) (*models.BillCategoryBudget, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+	category, err := s.category(ctx, homeID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := &models.BillCategoryBudget{
+		HomeID:         homeID,
+		BillCategoryID: categoryID,
+		Amount:         amount,
+		CreatedBy:      userID,
+	}
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Upsert(ctx, budget); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleBudget,
+			Action: event.ActionUpdated,
+			Data:   budget,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	budget.BillCategory = category
+	return budget, nil
+}
+
+func (s *BudgetService) GetBudget(ctx context.Context, homeID, categoryID int) (*models.BillCategoryBudget, error) {
+	if _, err := s.category(ctx, homeID, categoryID); err != nil {
+		return nil, err
+	}
+	budget, err := s.repo.FindByCategoryID(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, ErrBudgetNotFound
+	}
+	return budget, nil
+}
+
+func (s *BudgetService) DeleteBudget(ctx context.Context, homeID, categoryID int) error {
+	if _, err := s.GetBudget(ctx, homeID, categoryID); err != nil {
+		return err
+	}
+
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, categoryID); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleBudget,
+			Action: event.ActionDeleted,
+			Data:   map[string]int{"bill_category_id": categoryID},
+		})
+	})
+}
+
+// GetStatus reports how much of the category's budget was spent in the month containing month
+func (s *BudgetService) GetStatus(ctx context.Context, homeID, categoryID int, month time.Time) (*models.BudgetStatus, error) {
+	budget, err := s.GetBudget(ctx, homeID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	currency, err := homeCurrency(ctx, s.homeRepo, homeID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := monthRange(month)
+	spent, err := s.repo.SpentByCategory(ctx, homeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	status := budgetStatus(budget, spent[categoryID], currency, from)
+	return &status, nil
+}
+
+// GetStatuses reports every budget of the home for the month containing month
+func (s *BudgetService) GetStatuses(ctx context.Context, homeID int, month time.Time) ([]models.BudgetStatus, error) {
+	budgets, err := s.repo.FindByHomeID(ctx, homeID)
+	if err != nil {
+		return nil, err
+	}
+	currency, err := homeCurrency(ctx, s.homeRepo, homeID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := monthRange(month)
+	spent, err := s.repo.SpentByCategory(ctx, homeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]models.BudgetStatus, len(budgets))
+	for i := range budgets {
+		statuses[i] = budgetStatus(&budgets[i], spent[budgets[i].BillCategoryID], currency, from)
+	}
+	return statuses, nil
+}
+
+// category returns the bill category if it belongs to the home
+func (s *BudgetService) category(ctx context.Context, homeID, categoryID int) (*models.BillCategory, error) {
+	category, err := s.categoryRepo.GetByID(ctx, categoryID)
+	if err != nil || category == nil || category.HomeID != homeID {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// budgetAlert returns the highest threshold a new or edited bill pushed its category's budget
+// past this month, if any. previous is the bill as it was before an edit, nil for a new one.
+// It runs in the bill's transaction, so the spending already includes the bill.
+func budgetAlert(ctx context.Context, repo repository.BudgetRepository, bill, previous *models.Bill, currency string) (*models.BudgetAlert, error) {
+	if bill.BillCategoryID == nil {
+		return nil, nil
+	}
+	from, to := monthRange(time.Now().In(bill.Start.Location()))
+	if bill.Start.Before(from) || !bill.Start.Before(to) {
+		return nil, nil
+	}
+
+	budget, err := repo.FindByCategoryID(ctx, *bill.BillCategoryID)
+	if err != nil || budget == nil {
+		return nil, err
+	}
+	spentByCategory, err := repo.SpentByCategory(ctx, bill.HomeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	spent := spentByCategory[*bill.BillCategoryID]
+	before := spent - billInBase(bill)
+	// an edited bill already counted towards the budget if it was in the same category and month
+	if previous != nil && sameID(previous.BillCategoryID, bill.BillCategoryID) && !previous.Start.Before(from) && previous.Start.Before(to) {
+		before += billInBase(previous)
+	}
+	for _, threshold := range []int{models.BudgetExceededPercent, models.BudgetWarningPercent} {
+		limit := budget.Amount * models.Money(threshold)
+		if spent*100 >= limit && before*100 < limit {
+			return &models.BudgetAlert{
+				BudgetStatus: budgetStatus(budget, spent, currency, from),
+				Threshold:    threshold,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// billInBase is the bill's total converted to the home currency
+func billInBase(bill *models.Bill) models.Money {
+	return models.MoneyFromFloat(bill.TotalAmount.Float() * bill.ExchangeRate)
+}
+
+func budgetAlertMessage(alert *models.BudgetAlert) string {
+	spent := formatMoney(alert.Spent, alert.Currency)
+	budget := formatMoney(alert.Budget, alert.Currency)
+	if alert.Threshold >= models.BudgetExceededPercent {
+		return fmt.Sprintf("%s budget exceeded: %s of %s spent this month", alert.CategoryName, spent, budget)
+	}
+	return fmt.Sprintf("%s budget is %.0f%% used: %s of %s spent this month", alert.CategoryName, alert.PercentUsed, spent, budget)
+}
+
+func budgetStatus(budget *models.BillCategoryBudget, spent models.Money, currency string, period time.Time) models.BudgetStatus {
+	status := models.BudgetStatus{
+		BillCategoryID: budget.BillCategoryID,
+		Currency:       currency,
+		Period:         period.Format("2006-01"),
+		Budget:         budget.Amount,
+		Spent:          spent,
+		Remaining:      budget.Amount - spent,
+	}
+	if budget.BillCategory != nil {
+		status.CategoryName = budget.BillCategory.Name
+	}
+	if budget.Amount > 0 {
+		status.PercentUsed = math.Round(float64(spent)/float64(budget.Amount)*10000) / 100
+	}
+	return status
+}
+
+// monthRange returns the calendar month containing t as [from, to)
+func monthRange(t time.Time) (time.Time, time.Time) {
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return from, from.AddDate(0, 1, 0)
+}
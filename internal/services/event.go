@@ -0,0 +1,25 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/redis/go-redis/v9"
+)
+
+type EventService struct {
+	cache *redis.Client
+}
+
+type IEventService interface {
+	GetHomeEvents(ctx context.Context, homeID int, since string, limit int64) ([]event.RealTimeEvent, error)
+}
+
+func NewEventService(cache *redis.Client) *EventService {
+	return &EventService{cache: cache}
+}
+
+// GetHomeEvents returns the home's events sent after the since cursor, oldest first.
+func (s *EventService) GetHomeEvents(ctx context.Context, homeID int, since string, limit int64) ([]event.RealTimeEvent, error) {
+	return event.ReadSince(ctx, s.cache, event.HomeChannel(homeID), since, limit)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+)
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrBaseCurrencyRate    = errors.New("the home currency always converts at 1")
+)
+
+// defaultRates is the built-in table, in US dollars per unit. It is deliberately
+// static so bills can be created without network access; home admins override
+// the rates they care about.
+var defaultRates = map[string]float64{
+	"AUD": 0.66,
+	"CAD": 0.73,
+	"CHF": 1.13,
+	"CZK": 0.044,
+	"DKK": 0.145,
+	"EUR": 1.08,
+	"GBP": 1.27,
+	"HUF": 0.0027,
+	"JPY": 0.0067,
+	"NOK": 0.094,
+	"PLN": 0.25,
+	"RON": 0.22,
+	"SEK": 0.095,
+	"UAH": 0.024,
+	"USD": 1,
+}
+
+// IsSupportedCurrency reports whether the currency code is known to the built-in table
+func IsSupportedCurrency(code string) bool {
+	_, ok := defaultRates[code]
+	return ok
+}
+
+// RateSource converts between currencies for a home
+type RateSource interface {
+	// Rate returns how many units of to one unit of from is worth
+	Rate(ctx context.Context, homeID int, from, to string) (float64, error)
+}
+
+// StaticRateSource uses the home's own overrides and falls back to the built-in table
+type StaticRateSource struct {
+	repo repository.ExchangeRateRepository
+}
+
+func NewStaticRateSource(repo repository.ExchangeRateRepository) *StaticRateSource {
+	return &StaticRateSource{repo: repo}
+}
+
+func (s *StaticRateSource) Rate(ctx context.Context, homeID int, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	if !IsSupportedCurrency(from) || !IsSupportedCurrency(to) {
+		return 0, ErrUnsupportedCurrency
+	}
+
+	override, err := s.repo.Find(ctx, homeID, to, from)
+	if err != nil {
+		return 0, err
+	}
+	if override != nil {
+		return override.Rate, nil
+	}
+
+	return roundRate(defaultRates[from] / defaultRates[to]), nil
+}
+
+type ExchangeRateService struct {
+	repo     repository.ExchangeRateRepository
+	homeRepo repository.HomeRepository
+	rates    RateSource
+}
+
+type IExchangeRateService interface {
+	GetRates(ctx context.Context, homeID int) ([]models.EffectiveRate, error)
+	SetRate(ctx context.Context, homeID int, currency string, rate float64) error
+	DeleteRate(ctx context.Context, homeID int, currency string) error
+}
+
+func NewExchangeRateService(repo repository.ExchangeRateRepository, homeRepo repository.HomeRepository, rates RateSource) *ExchangeRateService {
+	return &ExchangeRateService{repo: repo, homeRepo: homeRepo, rates: rates}
+}
+
+// GetRates lists the rate of every supported currency into the home currency
+func (s *ExchangeRateService) GetRates(ctx context.Context, homeID int) ([]models.EffectiveRate, error) {
+	base, err := homeCurrency(ctx, s.homeRepo, homeID)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.repo.FindByHome(ctx, homeID, base)
+	if err != nil {
+		return nil, err
+	}
+	custom := make(map[string]bool, len(overrides))
+	for _, o := range overrides {
+		custom[o.Currency] = true
+	}
+
+	codes := make([]string, 0, len(defaultRates))
+	for code := range defaultRates {
+		if code != base {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	rates := make([]models.EffectiveRate, 0, len(codes))
+	for _, code := range codes {
+		rate, err := s.rates.Rate(ctx, homeID, code, base)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, models.EffectiveRate{Currency: code, BaseCurrency: base, Rate: rate, Custom: custom[code]})
+	}
+
+	return rates, nil
+}
+
+func (s *ExchangeRateService) SetRate(ctx context.Context, homeID int, currency string, rate float64) error {
+	base, err := s.checkCurrency(ctx, homeID, currency)
+	if err != nil {
+		return err
+	}
+	if rate <= 0 {
+		return errors.New("rate must be greater than 0")
+	}
+
+	return s.repo.Upsert(ctx, &models.ExchangeRate{
+		HomeID:       homeID,
+		BaseCurrency: base,
+		Currency:     currency,
+		Rate:         roundRate(rate),
+	})
+}
+
+// DeleteRate drops the home's override so the built-in rate applies again
+func (s *ExchangeRateService) DeleteRate(ctx context.Context, homeID int, currency string) error {
+	base, err := s.checkCurrency(ctx, homeID, currency)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, homeID, base, currency)
+}
+
+func (s *ExchangeRateService) checkCurrency(ctx context.Context, homeID int, currency string) (string, error) {
+	if !IsSupportedCurrency(currency) {
+		return "", ErrUnsupportedCurrency
+	}
+	base, err := homeCurrency(ctx, s.homeRepo, homeID)
+	if err != nil {
+		return "", err
+	}
+	if currency == base {
+		return "", ErrBaseCurrencyRate
+	}
+	return base, nil
+}
+
+func homeCurrency(ctx context.Context, homeRepo repository.HomeRepository, homeID int) (string, error) {
+	home, err := homeRepo.FindByID(ctx, homeID)
+	if err != nil {
+		return "", err
+	}
+	if home == nil {
+		return "", errors.New("home not found")
+	}
+	if home.Currency == "" {
+		return models.DefaultCurrency, nil
+	}
+	return home.Currency, nil
+}
+
+// roundRate keeps rates to six decimal places
+func roundRate(rate float64) float64 {
+	return math.Round(rate*1e6) / 1e6
+}
+
+// formatMoney renders an amount for notification text, e.g. "12.50 EUR"
+func formatMoney(amount models.Money, currency string) string {
+	return amount.String() + " " + currency
+}
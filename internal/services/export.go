@@ -0,0 +1,354 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+)
+
+var ErrUnknownExport = errors.New("unknown export, expected bills, tasks, shopping or polls")
+
+type IExportService interface {
+	WriteCSV(ctx context.Context, homeID int, dataset string, w io.Writer) error
+	WriteJSON(ctx context.Context, homeID int, w io.Writer) error
+	WriteCalendar(ctx context.Context, homeID int, w io.Writer) error
+}
+
+type ExportService struct {
+	repo     repository.ExportRepository
+	homeRepo repository.HomeRepository
+}
+
+func NewExportService(repo repository.ExportRepository, homeRepo repository.HomeRepository) *ExportService {
+	return &ExportService{repo: repo, homeRepo: homeRepo}
+}
+
+// WriteCSV streams one dataset of the home as CSV, one row per split, assignment or poll option
+func (s *ExportService) WriteCSV(ctx context.Context, homeID int, dataset string, w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	var err error
+	switch dataset {
+	case models.ExportBills:
+		err = s.billsCSV(ctx, homeID, cw)
+	case models.ExportTasks:
+		err = s.tasksCSV(ctx, homeID, cw)
+	case models.ExportShopping:
+		err = s.shoppingCSV(ctx, homeID, cw)
+	case models.ExportPolls:
+		err = s.pollsCSV(ctx, homeID, cw)
+	default:
+		return ErrUnknownExport
+	}
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *ExportService) billsCSV(ctx context.Context, homeID int, cw *csv.Writer) error {
+	if err := cw.Write([]string{
+		"bill_id", "period_start", "period_end", "type", "category", "description", "total_amount", "currency", "exchange_rate",
+		"payed", "payment_date", "uploaded_by", "split_user", "split_amount", "split_paid_amount", "split_paid",
+	}); err != nil {
+		return err
+	}
+
+	return s.repo.StreamBills(ctx, homeID, func(bills []models.Bill) error {
+		for _, b := range bills {
+			category := ""
+			if b.BillCategory != nil {
+				category = b.BillCategory.Name
+			}
+			row := []string{
+				strconv.Itoa(b.ID), b.Start.Format(time.DateOnly), b.End.Format(time.DateOnly), csvText(b.Type), csvText(category),
+				csvText(b.Description), b.TotalAmount.String(), b.Currency, strconv.FormatFloat(b.ExchangeRate, 'f', -1, 64),
+				strconv.FormatBool(b.Payed), csvTime(b.PaymentDate), csvText(userName(b.User, b.UploadedBy)),
+			}
+
+			if len(b.BillSplits) == 0 {
+				if err := cw.Write(append(row, "", "", "", "")); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, split := range b.BillSplits {
+				if err := cw.Write(append(row[:len(row):len(row)],
+					csvText(userName(split.User, split.UserID)), split.Amount.String(), split.PaidAmount.String(), strconv.FormatBool(split.Paid),
+				)); err != nil {
+					return err
+				}
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+func (s *ExportService) tasksCSV(ctx context.Context, homeID int, cw *csv.Writer) error {
+	if err := cw.Write([]string{
+		"task_id", "name", "description", "room", "schedule_type", "due_date", "created_by", "created_at",
+		"assignee", "assignment_status", "assigned_date", "complete_date",
+	}); err != nil {
+		return err
+	}
+
+	return s.repo.StreamTasks(ctx, homeID, func(tasks []models.Task) error {
+		for _, t := range tasks {
+			room := ""
+			if t.Room != nil {
+				room = t.Room.Name
+			}
+			row := []string{
+				strconv.Itoa(t.ID), csvText(t.Name), csvText(t.Description), csvText(room), t.ScheduleType, csvTime(t.DueDate),
+				csvText(userName(t.Creator, t.CreatedBy)), t.CreatedAt.UTC().Format(time.RFC3339),
+			}
+
+			if len(t.TaskAssignments) == 0 {
+				if err := cw.Write(append(row, "", "", "", "")); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, a := range t.TaskAssignments {
+				if err := cw.Write(append(row[:len(row):len(row)],
+					csvText(userName(a.User, a.UserID)), a.Status, a.AssignedDate.UTC().Format(time.RFC3339), csvTime(a.CompleteDate),
+				)); err != nil {
+					return err
+				}
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+func (s *ExportService) shoppingCSV(ctx context.Context, homeID int, cw *csv.Writer) error {
+	categories, err := s.repo.ShoppingCategoryNames(ctx, homeID)
+	if err != nil {
+		return err
+	}
+	if err := cw.Write([]string{"item_id", "category", "name", "added_by", "is_bought", "bought_date", "link", "created_at"}); err != nil {
+		return err
+	}
+
+	return s.repo.StreamShoppingItems(ctx, homeID, func(items []models.ShoppingItem) error {
+		for _, item := range items {
+			link := ""
+			if item.Link != nil {
+				link = *item.Link
+			}
+			if err := cw.Write([]string{
+				strconv.Itoa(item.ID), csvText(categories[item.CategoryID]), csvText(item.Name), csvText(userName(item.User, item.UploadedBy)),
+				strconv.FormatBool(item.IsBought), csvTime(item.BoughtDate), csvText(link), item.CreatedAt.UTC().Format(time.RFC3339),
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+func (s *ExportService) pollsCSV(ctx context.Context, homeID int, cw *csv.Writer) error {
+	if err := cw.Write([]string{"poll_id", "question", "type", "status", "ends_at", "created_at", "option", "votes"}); err != nil {
+		return err
+	}
+
+	return s.repo.StreamPolls(ctx, homeID, func(polls []models.Poll) error {
+		for i := range polls {
+			result := models.NewPollResult(&polls[i])
+			for _, o := range result.Options {
+				if err := cw.Write([]string{
+					strconv.Itoa(result.ID), csvText(result.Question), result.Type, result.Status, csvTime(result.EndsAt),
+					result.CreatedAt.UTC().Format(time.RFC3339), csvText(o.Title), strconv.Itoa(o.Votes),
+				}); err != nil {
+					return err
+				}
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+// WriteJSON streams every dataset of the home as a single JSON document
+func (s *ExportService) WriteJSON(ctx context.Context, homeID int, w io.Writer) error {
+	home, err := s.homeRepo.FindByID(ctx, homeID)
+	if err != nil {
+		return err
+	}
+	if home == nil {
+		return errors.New("home not found")
+	}
+
+	bw := bufio.NewWriter(w)
+	header, err := json.Marshal(map[string]interface{}{"id": home.ID, "name": home.Name, "currency": home.Currency})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(bw, `{"home":%s,"exported_at":"%s"`, header, time.Now().UTC().Format(time.RFC3339))
+
+	if err := writeJSONArray(ctx, bw, "bills", s.repo.StreamBills, homeID); err != nil {
+		return err
+	}
+	if err := writeJSONArray(ctx, bw, "tasks", s.repo.StreamTasks, homeID); err != nil {
+		return err
+	}
+	if err := writeJSONArray(ctx, bw, "shopping_items", s.repo.StreamShoppingItems, homeID); err != nil {
+		return err
+	}
+	polls := func(ctx context.Context, homeID int, fn func([]models.PollResult) error) error {
+		return s.repo.StreamPolls(ctx, homeID, func(batch []models.Poll) error {
+			results := make([]models.PollResult, len(batch))
+			for i := range batch {
+				results[i] = models.NewPollResult(&batch[i])
+			}
+			return fn(results)
+		})
+	}
+	if err := writeJSONArray(ctx, bw, "polls", polls, homeID); err != nil {
+		return err
+	}
+
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// writeJSONArray writes `,"key":[...]` with the items of a stream, flushing after every batch
+func writeJSONArray[T any](ctx context.Context, bw *bufio.Writer, key string, stream func(ctx context.Context, homeID int, fn func([]T) error) error, homeID int) error {
+	fmt.Fprintf(bw, `,%q:[`, key)
+	first := true
+	err := stream(ctx, homeID, func(batch []T) error {
+		for _, item := range batch {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if !first {
+				bw.WriteByte(',')
+			}
+			first = false
+			bw.Write(data)
+		}
+		return bw.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	bw.WriteByte(']')
+	return nil
+}
+
+// WriteCalendar streams the home's open tasks with a due date as an iCalendar feed
+func (s *ExportService) WriteCalendar(ctx context.Context, homeID int, w io.Writer) error {
+	home, err := s.homeRepo.FindByID(ctx, homeID)
+	if err != nil {
+		return err
+	}
+	if home == nil {
+		return errors.New("home not found")
+	}
+
+	bw := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format(icsTimeLayout)
+	writeICSLine(bw, "BEGIN:VCALENDAR")
+	writeICSLine(bw, "VERSION:2.0")
+	writeICSLine(bw, "PRODID:-//Diploma Server//Home Tasks//EN")
+	writeICSLine(bw, "CALSCALE:GREGORIAN")
+	writeICSLine(bw, "X-WR-CALNAME:"+icsText(home.Name+" tasks"))
+
+	err = s.repo.StreamDueTasks(ctx, homeID, func(tasks []models.Task) error {
+		for _, t := range tasks {
+			description := t.Description
+			var assignees []string
+			for _, a := range t.TaskAssignments {
+				assignees = append(assignees, userName(a.User, a.UserID))
+			}
+			if len(assignees) > 0 {
+				description = strings.TrimSpace(description + "\nAssigned to: " + strings.Join(assignees, ", "))
+			}
+
+			writeICSLine(bw, "BEGIN:VEVENT")
+			writeICSLine(bw, fmt.Sprintf("UID:task-%d-home-%d@diploma-server", t.ID, homeID))
+			writeICSLine(bw, "DTSTAMP:"+stamp)
+			writeICSLine(bw, "DTSTART:"+t.DueDate.UTC().Format(icsTimeLayout))
+			writeICSLine(bw, "SUMMARY:"+icsText(t.Name))
+			if description != "" {
+				writeICSLine(bw, "DESCRIPTION:"+icsText(description))
+			}
+			if t.Room != nil {
+				writeICSLine(bw, "LOCATION:"+icsText(t.Room.Name))
+			}
+			writeICSLine(bw, "END:VEVENT")
+		}
+		return bw.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	writeICSLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+const icsTimeLayout = "20060102T150405Z"
+
+// writeICSLine ends a content line with CRLF, folding it at 75 octets as RFC 5545 requires
+func writeICSLine(bw *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		// never split a UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		bw.WriteString(line[:cut])
+		bw.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // the folding space counts too
+	}
+	bw.WriteString(line)
+	bw.WriteString("\r\n")
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icsText(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// csvText keeps spreadsheet apps from running user text that looks like a formula
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// userName falls back to the ID for users that were not loaded
+func userName(u *models.User, id int) string {
+	if u == nil || u.Name == "" {
+		return "user " + strconv.Itoa(id)
+	}
+	return u.Name
+}
@@ -17,10 +17,11 @@ type HomeService struct {
 	repo     repository.HomeRepository
 	cache    *redis.Client
 	notifSvc INotificationService
+	outbox   IOutboxService
 }
 
 type IHomeService interface {
-	CreateHome(ctx context.Context, name string, userID int) error
+	CreateHome(ctx context.Context, name, currency string, userID int) error
 	RegenerateInviteCode(ctx context.Context, homeID int) error
 	JoinHomeByCode(ctx context.Context, code string, userID int) error
 	GetHomeByID(ctx context.Context, id int) (*models.Home, error)
@@ -36,11 +37,18 @@ type IHomeService interface {
 	UpdateMemberRole(ctx context.Context, homeID int, userID int, role string) error
 }
 
-func NewHomeService(repo repository.HomeRepository, cache *redis.Client, notifSvc INotificationService) *HomeService {
-	return &HomeService{repo: repo, cache: cache, notifSvc: notifSvc}
+func NewHomeService(repo repository.HomeRepository, cache *redis.Client, notifSvc INotificationService, outbox IOutboxService) *HomeService {
+	return &HomeService{repo: repo, cache: cache, notifSvc: notifSvc, outbox: outbox}
 }
 
-func (s *HomeService) CreateHome(ctx context.Context, name string, userID int) error {
+func (s *HomeService) CreateHome(ctx context.Context, name, currency string, userID int) error {
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if !IsSupportedCurrency(currency) {
+		return ErrUnsupportedCurrency
+	}
+
 	inviteCode, err := s.repo.GenerateUniqueInviteCode(ctx)
 	if err != nil {
 		return err
@@ -49,13 +57,25 @@ func (s *HomeService) CreateHome(ctx context.Context, name string, userID int) e
 	home := &models.Home{
 		Name:       name,
 		InviteCode: inviteCode,
+		Currency:   currency,
 	}
 
-	if err := s.repo.Create(ctx, home); err != nil {
-		return err
-	}
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, home); err != nil {
+			return err
+		}
 
-	if err := s.repo.AddMember(ctx, home.ID, userID, "admin", "approved"); err != nil {
+		if err := s.repo.AddMember(ctx, home.ID, userID, "admin", "approved"); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.UserChannel(userID), &event.RealTimeEvent{
+			Module: event.ModuleHome,
+			Action: event.ActionCreated,
+			Data:   home,
+		})
+	})
+	if err != nil {
 		return err
 	}
 
@@ -67,12 +87,6 @@ func (s *HomeService) CreateHome(ctx context.Context, name string, userID int) e
 	metrics.HomesTotal.Inc()
 	metrics.HomeOperationsTotal.WithLabelValues("create").Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHome,
-		Action: event.ActionCreated,
-		Data:   home,
-	})
-
 	return nil
 }
 
@@ -87,17 +101,17 @@ func (s *HomeService) RegenerateInviteCode(ctx context.Context, homeID int) erro
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.RegenerateCode(ctx, inviteCode, homeID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.RegenerateCode(ctx, inviteCode, homeID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHome,
-		Action: event.ActionUpdated,
-		Data:   map[string]int{"homeID": homeID},
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleHome,
+			Action: event.ActionUpdated,
+			Data:   map[string]int{"homeID": homeID},
+		})
 	})
-
-	return nil
 }
 
 func (s *HomeService) JoinHomeByCode(ctx context.Context, code string, userID int) error {
@@ -127,7 +141,18 @@ func (s *HomeService) JoinHomeByCode(ctx context.Context, code string, userID in
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.AddMember(ctx, home.ID, userID, "member", "pending"); err != nil {
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddMember(ctx, home.ID, userID, "member", "pending"); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(home.ID), &event.RealTimeEvent{
+			Module: event.ModuleHome,
+			Action: event.ActionMemberJoined,
+			Data:   map[string]int{"homeID": home.ID, "userID": userID},
+		})
+	})
+	if err != nil {
 		return err
 	}
 
@@ -137,12 +162,6 @@ func (s *HomeService) JoinHomeByCode(ctx context.Context, code string, userID in
 	fromID := userID
 	_ = s.notifSvc.CreateHomeNotification(ctx, &fromID, home.ID, "A user has requested to join the home")
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHome,
-		Action: event.ActionMemberJoined,
-		Data:   map[string]int{"homeID": home.ID, "userID": userID},
-	})
-
 	return nil
 }
 
@@ -172,7 +191,18 @@ func (s *HomeService) GetHomeByID(ctx context.Context, id int) (*models.Home, er
 }
 
 func (s *HomeService) DeleteHome(ctx context.Context, id int) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(id), &event.RealTimeEvent{
+			Module: event.ModuleHome,
+			Action: event.ActionDeleted,
+			Data:   map[string]int{"id": id},
+		})
+	})
+	if err != nil {
 		return err
 	}
 
@@ -185,12 +215,6 @@ func (s *HomeService) DeleteHome(ctx context.Context, id int) error {
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHome,
-		Action: event.ActionDeleted,
-		Data:   map[string]int{"id": id},
-	})
-
 	return nil
 }
 
@@ -200,7 +224,23 @@ func (s *HomeService) LeaveHome(ctx context.Context, homeID int, userID int) err
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.DeleteMember(ctx, homeID, userID); err != nil {
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteMember(ctx, homeID, userID); err != nil {
+			return err
+		}
+
+		// the user's own channel lets their open sockets pick up the membership change
+		memberEvent := &event.RealTimeEvent{
+			Module: event.ModuleHome,
+			Action: event.ActionMemberLeft,
+			Data:   map[string]int{"homeID": homeID, "userID": userID},
+		}
+		if err := s.outbox.Add(ctx, event.HomeChannel(homeID), memberEvent); err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, event.UserChannel(userID), memberEvent)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -215,12 +255,6 @@ func (s *HomeService) LeaveHome(ctx context.Context, homeID int, userID int) err
 	fromID := userID
 	_ = s.notifSvc.CreateHomeNotification(ctx, &fromID, homeID, "A member has left the home")
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHome,
-		Action: event.ActionMemberLeft,
-		Data:   map[string]int{"homeID": homeID, "userID": userID},
-	})
-
 	return nil
 }
 
@@ -234,7 +268,22 @@ func (s *HomeService) RemoveMember(ctx context.Context, homeID int, userID int,
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.DeleteMember(ctx, homeID, userID); err != nil {
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteMember(ctx, homeID, userID); err != nil {
+			return err
+		}
+
+		memberEvent := &event.RealTimeEvent{
+			Module: event.ModuleHome,
+			Action: event.ActionMemberRemoved,
+			Data:   map[string]int{"homeID": homeID, "userID": userID},
+		}
+		if err := s.outbox.Add(ctx, event.HomeChannel(homeID), memberEvent); err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, event.UserChannel(userID), memberEvent)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -251,12 +300,6 @@ func (s *HomeService) RemoveMember(ctx context.Context, homeID int, userID int,
 	// Notify home that a member was removed
 	_ = s.notifSvc.CreateHomeNotification(ctx, &fromID, homeID, "A member has been removed from the home")
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHome,
-		Action: event.ActionMemberRemoved,
-		Data:   map[string]int{"homeID": homeID, "userID": userID},
-	})
-
 	return nil
 }
 
@@ -306,7 +349,22 @@ func (s *HomeService) GetUserHomes(ctx context.Context, userID int) ([]models.Ho
 }
 
 func (s *HomeService) ApproveMember(ctx context.Context, homeID int, userID int) error {
-	if err := s.repo.ApproveMember(ctx, homeID, userID); err != nil {
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.ApproveMember(ctx, homeID, userID); err != nil {
+			return err
+		}
+
+		memberEvent := &event.RealTimeEvent{
+			Module: event.ModuleHome,
+			Action: event.ActionMemberJoined,
+			Data:   map[string]int{"homeID": homeID, "userID": userID},
+		}
+		if err := s.outbox.Add(ctx, event.HomeChannel(homeID), memberEvent); err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, event.UserChannel(userID), memberEvent)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -328,12 +386,6 @@ func (s *HomeService) ApproveMember(ctx context.Context, homeID int, userID int)
 	_ = s.notifSvc.Create(ctx, nil, userID, "Your request to join the home has been approved")
 	_ = s.notifSvc.CreateHomeNotification(ctx, nil, homeID, "A new member has been approved")
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHome,
-		Action: event.ActionMemberJoined,
-		Data:   map[string]int{"homeID": homeID, "userID": userID},
-	})
-
 	return nil
 }
 
@@ -359,7 +411,18 @@ func (s *HomeService) GetPendingMembers(ctx context.Context, homeID int) ([]mode
 }
 
 func (s *HomeService) UpdateMemberRole(ctx context.Context, homeID int, userID int, role string) error {
-	if err := s.repo.UpdateMemberRole(ctx, homeID, userID, role); err != nil {
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateMemberRole(ctx, homeID, userID, role); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleHome,
+			Action: event.ActionUpdated,
+			Data:   map[string]interface{}{"homeID": homeID, "userID": userID, "role": role},
+		})
+	})
+	if err != nil {
 		return err
 	}
 
@@ -372,11 +435,5 @@ func (s *HomeService) UpdateMemberRole(ctx context.Context, homeID int, userID i
 
 	_ = s.notifSvc.Create(ctx, nil, userID, "Your role has been updated to "+role)
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHome,
-		Action: event.ActionUpdated,
-		Data:   map[string]interface{}{"homeID": homeID, "userID": userID, "role": role},
-	})
-
 	return nil
 }
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+)
+
+type LedgerService struct {
+	billRepo       repository.BillRepository
+	settlementRepo repository.SettlementRepository
+	homeRepo       repository.HomeRepository
+}
+
+type ILedgerService interface {
+	GetBalances(ctx context.Context, homeID int) (*models.HomeBalances, error)
+}
+
+func NewLedgerService(billRepo repository.BillRepository, settlementRepo repository.SettlementRepository, homeRepo repository.HomeRepository) *LedgerService {
+	return &LedgerService{billRepo: billRepo, settlementRepo: settlementRepo, homeRepo: homeRepo}
+}
+
+// GetBalances nets every split and settlement in the home and suggests the transfers that clear them.
+// Amounts are in the home currency.
+func (s *LedgerService) GetBalances(ctx context.Context, homeID int) (*models.HomeBalances, error) {
+	currency, err := homeCurrency(ctx, s.homeRepo, homeID)
+	if err != nil {
+		return nil, err
+	}
+
+	debts, err := s.billRepo.FindSplitDebts(ctx, homeID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.settlementRepo.SumByPair(ctx, homeID)
+	if err != nil {
+		return nil, err
+	}
+	debts = append(debts, payments...)
+
+	net := make(map[int]models.Money)
+	for _, d := range debts {
+		net[d.CreditorID] += d.Amount
+		net[d.DebtorID] -= d.Amount
+	}
+
+	balances := make([]models.MemberBalance, 0, len(net))
+	for userID, amount := range net {
+		balances = append(balances, models.MemberBalance{UserID: userID, Net: amount})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].UserID < balances[j].UserID })
+
+	return &models.HomeBalances{
+		Currency:  currency,
+		Balances:  balances,
+		Transfers: settleUp(net),
+	}, nil
+}
+
+type position struct {
+	userID int
+	amount models.Money
+}
+
+// settleUp pairs debtors with creditors. Exact matches are settled first, then the
+// largest debtor pays the largest creditor until everyone is even, which needs at
+// most one transfer fewer than the number of members involved.
+func settleUp(net map[int]models.Money) []models.Transfer {
+	var debtors, creditors []position
+	for userID, amount := range net {
+		switch {
+		case amount < 0:
+			debtors = append(debtors, position{userID, -amount})
+		case amount > 0:
+			creditors = append(creditors, position{userID, amount})
+		}
+	}
+
+	transfers := []models.Transfer{}
+	pay := func(d, c *position, amount models.Money) {
+		transfers = append(transfers, models.Transfer{From: d.userID, To: c.userID, Amount: amount})
+		d.amount -= amount
+		c.amount -= amount
+	}
+
+	sortPositions(debtors)
+	sortPositions(creditors)
+	for i := range debtors {
+		for j := range creditors {
+			if creditors[j].amount > 0 && debtors[i].amount == creditors[j].amount {
+				pay(&debtors[i], &creditors[j], debtors[i].amount)
+				break
+			}
+		}
+	}
+
+	for {
+		sortPositions(debtors)
+		sortPositions(creditors)
+		if len(debtors) == 0 || len(creditors) == 0 || debtors[0].amount == 0 || creditors[0].amount == 0 {
+			break
+		}
+		pay(&debtors[0], &creditors[0], min(debtors[0].amount, creditors[0].amount))
+	}
+
+	return transfers
+}
+
+// sortPositions orders by amount descending, then by user ID for stable output
+func sortPositions(p []position) {
+	sort.Slice(p, func(i, j int) bool {
+		if p[i].amount != p[j].amount {
+			return p[i].amount > p[j].amount
+		}
+		return p[i].userID < p[j].userID
+	})
+}
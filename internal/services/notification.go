@@ -12,8 +12,9 @@ import (
 )
 
 type NotificationService struct {
-	repo  repository.NotificationRepository
-	cache *redis.Client
+	repo   repository.NotificationRepository
+	cache  *redis.Client
+	outbox IOutboxService
 }
 
 type INotificationService interface {
@@ -28,8 +29,8 @@ type INotificationService interface {
 	MarkAsReadForHomeNotification(ctx context.Context, notificationID, homeID int) error
 }
 
-func NewNotificationService(repo repository.NotificationRepository, cache *redis.Client) *NotificationService {
-	return &NotificationService{repo: repo, cache: cache}
+func NewNotificationService(repo repository.NotificationRepository, cache *redis.Client, outbox IOutboxService) *NotificationService {
+	return &NotificationService{repo: repo, cache: cache, outbox: outbox}
 }
 
 func (s *NotificationService) Create(ctx context.Context, from *int, to int, description string) error {
@@ -44,17 +45,17 @@ func (s *NotificationService) Create(ctx context.Context, from *int, to int, des
 		To:          to,
 		Description: description,
 	}
-	if err := s.repo.Create(ctx, notification); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, notification); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleNotification,
-		Action: event.ActionCreated,
-		Data:   notification,
+		return s.outbox.Add(ctx, event.UserChannel(to), &event.RealTimeEvent{
+			Module: event.ModuleNotification,
+			Action: event.ActionCreated,
+			Data:   notification,
+		})
 	})
-
-	return nil
 }
 
 func (s *NotificationService) GetByUserID(ctx context.Context, userID int) ([]models.Notification, error) {
@@ -82,17 +83,17 @@ func (s *NotificationService) MarkAsRead(ctx context.Context, notificationID, us
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.MarkAsRead(ctx, notificationID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkAsRead(ctx, notificationID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleNotification,
-		Action: event.ActionMarkRead,
-		Data:   map[string]int{"id": notificationID},
+		return s.outbox.Add(ctx, event.UserChannel(userID), &event.RealTimeEvent{
+			Module: event.ModuleNotification,
+			Action: event.ActionMarkRead,
+			Data:   map[string]int{"id": notificationID},
+		})
 	})
-
-	return nil
 }
 
 func (s *NotificationService) CreateHomeNotification(ctx context.Context, from *int, homeID int, description string) error {
@@ -106,17 +107,17 @@ func (s *NotificationService) CreateHomeNotification(ctx context.Context, from *
 		HomeID:      homeID,
 		Description: description,
 	}
-	if err := s.repo.CreateHomeNotification(ctx, notification); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateHomeNotification(ctx, notification); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHomeNotification,
-		Action: event.ActionCreated,
-		Data:   notification,
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleHomeNotification,
+			Action: event.ActionCreated,
+			Data:   notification,
+		})
 	})
-
-	return nil
 }
 
 func (s *NotificationService) GetByHomeID(ctx context.Context, homeID int) ([]models.HomeNotification, error) {
@@ -144,15 +145,15 @@ func (s *NotificationService) MarkAsReadForHomeNotification(ctx context.Context,
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.MarkAsReadForHomeNotification(ctx, notificationID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkAsReadForHomeNotification(ctx, notificationID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleHomeNotification,
-		Action: event.ActionMarkRead,
-		Data:   map[string]int{"id": notificationID},
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleHomeNotification,
+			Action: event.ActionMarkRead,
+			Data:   map[string]int{"id": notificationID},
+		})
 	})
-
-	return nil
 }
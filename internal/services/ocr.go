@@ -1,209 +1,223 @@
 package services
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/Dragodui/diploma-server/internal/logger"
 	"github.com/Dragodui/diploma-server/internal/metrics"
 	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
 )
 
+// OCR providers selectable in config
 const (
-	geminiTimeout = 60 * time.Second
-	geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash-lite:generateContent"
+	OCRProviderGemini = "gemini"
+	OCRProviderText   = "text"
+	OCRProviderFake   = "fake"
+)
+
+var (
+	// ErrUnsupportedOCRFile is returned by providers that can't read a kind of file
+	ErrUnsupportedOCRFile = errors.New("file type not supported by the OCR provider")
+	ErrNoOCRFiles         = errors.New("no receipt files to read")
+)
+
+// OCRProvider extracts receipt data from the contents of a file
+type OCRProvider interface {
+	Name() string
+	Extract(ctx context.Context, data []byte, mimeType, language string) (*models.OCRResult, error)
+}
+
+// OCRPageProvider is a provider that can read the pages of a document one by one,
+// so each page gets its own confidence
+type OCRPageProvider interface {
+	ExtractPages(ctx context.Context, data []byte, mimeType, language string) ([]*models.OCRResult, error)
+}
+
+// NewOCRProvider builds the provider named in config
+func NewOCRProvider(name, geminiAPIKey string) (OCRProvider, error) {
+	switch name {
+	case OCRProviderGemini:
+		if geminiAPIKey == "" {
+			return nil, errors.New("the gemini OCR provider needs GEMINI_API_KEY")
+		}
+		return NewGeminiOCRProvider(geminiAPIKey), nil
+	case OCRProviderText:
+		return NewTextOCRProvider(), nil
+	case OCRProviderFake:
+		return NewFakeOCRProvider(), nil
+	}
+	return nil, fmt.Errorf("unknown OCR provider %q, expected gemini, text or fake", name)
+}
+
+const (
+	// results of files seen before are reused for this long
+	ocrCacheKeep = 90 * 24 * time.Hour
+	// how many of the home's latest categorised bills a category is suggested from
+	ocrSuggestFromBills = 500
 )
 
 type OCRService struct {
-	geminiAPIKey string
-	httpClient   *http.Client
+	provider OCRProvider
+	cache    repository.OCRCacheRepository
+	bills    repository.BillRepository
 }
 
 type IOCRService interface {
-	ProcessFile(ctx context.Context, filePath, language string) (*models.OCRResult, error)
+	Process(ctx context.Context, files []models.OCRFile, language string) (*models.OCRResult, error)
+	SuggestCategory(ctx context.Context, homeID int, result *models.OCRResult) error
 }
 
-func NewOCRService(geminiAPIKey string) *OCRService {
-	return &OCRService{
-		geminiAPIKey: geminiAPIKey,
-		httpClient: &http.Client{
-			Timeout: geminiTimeout,
-		},
-	}
+func NewOCRService(provider OCRProvider, cache repository.OCRCacheRepository, bills repository.BillRepository) *OCRService {
+	return &OCRService{provider: provider, cache: cache, bills: bills}
 }
 
-// ProcessFile processes a local file with Gemini Vision API
-func (s *OCRService) ProcessFile(ctx context.Context, filePath, language string) (*models.OCRResult, error) {
-	start := time.Now()
+// Process reads one receipt from its files: a single document, or photos of its parts in
+// order. The pages are merged into one normalized result. Results are cached by the
+// SHA-256 of the files, so uploading the same receipt again is free.
+func (s *OCRService) Process(ctx context.Context, files []models.OCRFile, language string) (*models.OCRResult, error) {
+	if len(files) == 0 {
+		return nil, ErrNoOCRFiles
+	}
+	hash := receiptHash(files)
 
-	imageData, err := os.ReadFile(filePath)
-	if err != nil {
-		metrics.OcrRequestsTotal.WithLabelValues("error").Inc()
-		metrics.OcrProcessingDuration.Observe(time.Since(start).Seconds())
-		return nil, fmt.Errorf("failed to read file: %w", err)
+	if result := s.cached(ctx, hash); result != nil {
+		metrics.OcrRequestsTotal.WithLabelValues("cached").Inc()
+		normalizeReceipt(result, language)
+		return result, nil
 	}
 
-	mimeType := detectMimeType(filePath)
-	result, err := s.analyzeWithGemini(ctx, imageData, mimeType, language)
-	duration := time.Since(start).Seconds()
-	metrics.OcrProcessingDuration.Observe(duration)
-	if err != nil {
-		metrics.OcrRequestsTotal.WithLabelValues("error").Inc()
-		return nil, fmt.Errorf("Gemini Vision failed: %w", err)
+	start := time.Now()
+
+	var pages []*models.OCRResult
+	var fileOf []int
+	for i, file := range files {
+		read, err := s.extract(ctx, file, language)
+		if err != nil {
+			metrics.OcrProcessingDuration.Observe(time.Since(start).Seconds())
+			metrics.OcrRequestsTotal.WithLabelValues("error").Inc()
+			if len(files) > 1 {
+				return nil, fmt.Errorf("%s OCR failed on file %d: %w", s.provider.Name(), i+1, err)
+			}
+			return nil, fmt.Errorf("%s OCR failed: %w", s.provider.Name(), err)
+		}
+		for range read {
+			fileOf = append(fileOf, i+1)
+		}
+		pages = append(pages, read...)
 	}
+	metrics.OcrProcessingDuration.Observe(time.Since(start).Seconds())
+
+	result := mergeReceiptPages(pages, fileOf)
 
 	metrics.OcrRequestsTotal.WithLabelValues("success").Inc()
+	result.ReceiptHash = hash
+	normalizeReceipt(result, language)
 
 	strResult, _ := json.Marshal(result)
 	logger.Info.Printf("OCR Result: %s", string(strResult))
 
-	return result, nil
-}
-
-// Gemini API request/response types
-type geminiRequest struct {
-	Contents         []geminiContent        `json:"contents"`
-	GenerationConfig geminiGenerationConfig `json:"generationConfig"`
-}
-
-type geminiContent struct {
-	Parts []geminiPart `json:"parts"`
-}
-
-type geminiPart struct {
-	Text       string        `json:"text,omitempty"`
-	InlineData *geminiInline `json:"inlineData,omitempty"`
-}
-
-type geminiInline struct {
-	MimeType string `json:"mimeType"`
-	Data     string `json:"data"`
-}
-
-type geminiGenerationConfig struct {
-	ResponseMimeType string `json:"responseMimeType"`
-}
+	// nothing recognised may be a one-off, so such results get another chance
+	if result.Total > 0 || len(result.Items) > 0 {
+		if err := s.cache.Save(ctx, &models.OCRCacheEntry{Hash: hash, Provider: s.provider.Name(), Result: strResult}); err != nil {
+			logger.Info.Printf("[OCR] Failed to cache result %s: %v", hash, err)
+		}
+	}
 
-type geminiResponse struct {
-	Candidates []struct {
-		Content struct {
-			Parts []struct {
-				Text string `json:"text"`
-			} `json:"parts"`
-		} `json:"content"`
-	} `json:"candidates"`
-	Error *struct {
-		Message string `json:"message"`
-	} `json:"error,omitempty"`
+	return result, nil
 }
 
-func (s *OCRService) analyzeWithGemini(ctx context.Context, imageData []byte, mimeType, language string) (*models.OCRResult, error) {
-	languageHint := "Detect the language automatically."
-	if language != "" {
-		languageHint = fmt.Sprintf("The text is likely in %s.", language)
+// extract reads a file page by page when the provider can, and whole otherwise
+func (s *OCRService) extract(ctx context.Context, file models.OCRFile, language string) ([]*models.OCRResult, error) {
+	if paged, ok := s.provider.(OCRPageProvider); ok {
+		return paged.ExtractPages(ctx, file.Data, file.MimeType, language)
 	}
-
-	prompt := "Analyze this receipt/bill image. " + languageHint + "\n" +
-		"Extract the following data and return ONLY valid JSON (no markdown, no code fences):\n" +
-		"{\n" +
-		"  \"vendor\": \"store or company name\",\n" +
-		"  \"date\": \"date from receipt in original format\",\n" +
-		"  \"total\": 0.00,\n" +
-		"  \"items\": [\n" +
-		"    {\"name\": \"item name\", \"quantity\": 1, \"price\": 0.00}\n" +
-		"  ],\n" +
-		"  \"raw_text\": \"all visible text from the image\"\n" +
-		"}\n\n" +
-		"Rules:\n" +
-		"- \"total\" must be a number (float), not a string\n" +
-		"- \"price\" is the total price for that line item (quantity * unit price)\n" +
-		"- If you cannot determine a field, use empty string for strings, 0 for numbers, [] for items\n" +
-		"- Do NOT wrap the response in markdown code blocks"
-
-	reqBody := geminiRequest{
-		Contents: []geminiContent{
-			{
-				Parts: []geminiPart{
-					{
-						InlineData: &geminiInline{
-							MimeType: mimeType,
-							Data:     base64.StdEncoding.EncodeToString(imageData),
-						},
-					},
-					{
-						Text: prompt,
-					},
-				},
-			},
-		},
-		GenerationConfig: geminiGenerationConfig{
-			ResponseMimeType: "application/json",
-		},
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
+	result, err := s.provider.Extract(ctx, file.Data, file.MimeType, language)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
+	return []*models.OCRResult{result}, nil
+}
 
-	apiURL := fmt.Sprintf("%s?key=%s", geminiBaseURL, s.geminiAPIKey)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// receiptHash is the SHA-256 of a single file, or of the hashes of several in order
+func receiptHash(files []models.OCRFile) string {
+	sums := make([]string, len(files))
+	for i, file := range files {
+		sum := sha256.Sum256(file.Data)
+		sums[i] = hex.EncodeToString(sum[:])
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("Gemini API request failed: %w", err)
+	if len(sums) == 1 {
+		return sums[0]
 	}
-	defer resp.Body.Close()
+	sum := sha256.Sum256([]byte(strings.Join(sums, "")))
+	return hex.EncodeToString(sum[:])
+}
 
-	body, err := io.ReadAll(resp.Body)
+// cached returns the stored result for a file, or nil when there is none
+func (s *OCRService) cached(ctx context.Context, hash string) *models.OCRResult {
+	entry, err := s.cache.Find(ctx, hash, s.provider.Name())
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		logger.Info.Printf("[OCR] Failed to read cached result %s: %v", hash, err)
+		return nil
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Gemini API returned status %d: %s", resp.StatusCode, string(body))
+	if entry == nil {
+		return nil
 	}
 
-	var geminiResp geminiResponse
-	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode Gemini response: %w", err)
+	var result models.OCRResult
+	if err := json.Unmarshal(entry.Result, &result); err != nil {
+		return nil
 	}
+	result.ReceiptHash = hash
+	return &result
+}
 
-	if geminiResp.Error != nil {
-		return nil, fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
+// SuggestCategory sets the category the home used most for bills from the same vendor,
+// the latest one on a tie. Bills are matched by the vendor in their OCR data or their description.
+func (s *OCRService) SuggestCategory(ctx context.Context, homeID int, result *models.OCRResult) error {
+	key := vendorKey(result.Vendor)
+	if key == "" {
+		return nil
 	}
 
-	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return nil, errors.New("empty response from Gemini API")
+	bills, err := s.bills.FindCategorized(ctx, homeID, ocrSuggestFromBills)
+	if err != nil {
+		return err
 	}
 
-	resultText := geminiResp.Candidates[0].Content.Parts[0].Text
-
-	var result models.OCRResult
-	if err := json.Unmarshal([]byte(resultText), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse Gemini JSON output: %w (raw: %s)", err, resultText)
+	counts := make(map[int]int)
+	best := 0
+	for _, bill := range bills {
+		vendor, _ := receiptVendor(bill.OCRData)
+		if vendorKey(vendor) != key && vendorKey(bill.Description) != key {
+			continue
+		}
+		id := *bill.BillCategoryID
+		counts[id]++
+		if counts[id] > counts[best] {
+			best = id
+		}
 	}
+	if best != 0 {
+		result.SuggestedCategoryID = &best
+	}
+	return nil
+}
 
-	result.Confidence = calculateGeminiConfidence(&result)
-
-	return &result, nil
+// Cleanup forgets results cached long enough ago.
+func (s *OCRService) Cleanup(ctx context.Context) error {
+	return s.cache.DeleteBefore(ctx, time.Now().Add(-ocrCacheKeep))
 }
 
-func calculateGeminiConfidence(result *models.OCRResult) float64 {
+// receiptConfidence scores how much of a receipt was recognised, from 0 to 1
+func receiptConfidence(result *models.OCRResult) float64 {
 	score := 0.0
 	checks := 4.0
 
@@ -221,7 +235,7 @@ func calculateGeminiConfidence(result *models.OCRResult) float64 {
 
 		// Bonus check: items total vs overall total
 		checks++
-		itemsTotal := 0.0
+		var itemsTotal models.Money
 		for _, item := range result.Items {
 			itemsTotal += item.Price
 		}
@@ -230,7 +244,7 @@ func calculateGeminiConfidence(result *models.OCRResult) float64 {
 			if diff < 0 {
 				diff = -diff
 			}
-			if diff/result.Total < 0.1 {
+			if float64(diff)/float64(result.Total) < 0.1 {
 				score++
 			}
 		}
@@ -238,20 +252,3 @@ func calculateGeminiConfidence(result *models.OCRResult) float64 {
 
 	return score / checks
 }
-
-func detectMimeType(filePath string) string {
-	switch strings.ToLower(filepath.Ext(filePath)) {
-	case ".png":
-		return "image/png"
-	case ".gif":
-		return "image/gif"
-	case ".webp":
-		return "image/webp"
-	case ".bmp":
-		return "image/bmp"
-	case ".pdf":
-		return "application/pdf"
-	default:
-		return "image/jpeg"
-	}
-}
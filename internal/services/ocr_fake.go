@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+)
+
+// FakeOCRProvider answers every file with the same receipt, for tests and local development
+type FakeOCRProvider struct {
+	mu     sync.Mutex
+	result models.OCRResult
+	err    error
+	calls  int
+}
+
+func NewFakeOCRProvider() *FakeOCRProvider {
+	return &FakeOCRProvider{result: models.OCRResult{
+		Vendor: "Fake Market",
+		Date:   "2025-01-15",
+		Total:  1250,
+		Items: []models.OCRItem{
+			{Name: "Milk", Quantity: 2, Price: 500},
+			{Name: "Bread", Quantity: 1, Price: 750},
+		},
+		RawText:    "Fake Market\n2025-01-15\nMilk 2 x 2.50 5.00\nBread 7.50\nTOTAL 12.50",
+		Confidence: 1,
+	}}
+}
+
+// SetResult replaces the receipt returned from now on
+func (p *FakeOCRProvider) SetResult(result models.OCRResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.result = result
+}
+
+// SetError makes every call fail with err until it's set back to nil
+func (p *FakeOCRProvider) SetError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Calls counts the files extracted so far, failed ones included
+func (p *FakeOCRProvider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func (p *FakeOCRProvider) Name() string {
+	return OCRProviderFake
+}
+
+func (p *FakeOCRProvider) Extract(ctx context.Context, data []byte, mimeType, language string) (*models.OCRResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	// a copy, so callers can't change what later calls return
+	result := p.result
+	result.Items = append([]models.OCRItem{}, p.result.Items...)
+	return &result, nil
+}
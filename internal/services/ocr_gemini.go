@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+)
+
+const (
+	geminiTimeout = 60 * time.Second
+	geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash-lite:generateContent"
+)
+
+// GeminiOCRProvider reads receipts with the Gemini vision model
+type GeminiOCRProvider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewGeminiOCRProvider(apiKey string) *GeminiOCRProvider {
+	return NewGeminiOCRProviderWithURL(apiKey, geminiBaseURL)
+}
+
+// NewGeminiOCRProviderWithURL talks to the generateContent endpoint at baseURL instead of Google's
+func NewGeminiOCRProviderWithURL(apiKey, baseURL string) *GeminiOCRProvider {
+	return &GeminiOCRProvider{
+		apiKey:  apiKey,
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: geminiTimeout,
+		},
+	}
+}
+
+func (p *GeminiOCRProvider) Name() string {
+	return OCRProviderGemini
+}
+
+// Gemini API request/response types
+type geminiRequest struct {
+	Contents         []geminiContent        `json:"contents"`
+	GenerationConfig geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiContent struct {
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text       string        `json:"text,omitempty"`
+	InlineData *geminiInline `json:"inlineData,omitempty"`
+}
+
+type geminiInline struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiGenerationConfig struct {
+	ResponseMimeType string `json:"responseMimeType"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// the fields asked for on every receipt or page, and how to fill them in
+const geminiReceiptFields = "{\n" +
+	"  \"vendor\": \"store or company name\",\n" +
+	"  \"date\": \"date from receipt in original format\",\n" +
+	"  \"currency\": \"ISO 4217 code of the currency, e.g. EUR\",\n" +
+	"  \"total\": 0.00,\n" +
+	"  \"items\": [\n" +
+	"    {\"name\": \"item name\", \"quantity\": 1, \"price\": 0.00, \"kind\": \"item\"}\n" +
+	"  ],\n" +
+	"  \"raw_text\": \"all visible text from the image\"\n" +
+	"}"
+
+const geminiReceiptRules = "Rules:\n" +
+	"- \"total\" must be a number (float), not a string\n" +
+	"- \"price\" is the total price for that line item (quantity * unit price)\n" +
+	"- \"kind\" is \"item\" for products, \"tax\" for tax lines, \"discount\" for discounts and coupons, \"deposit\" for bottle deposits\n" +
+	"- If you cannot determine a field, use empty string for strings, 0 for numbers, [] for items\n" +
+	"- Do NOT wrap the response in markdown code blocks"
+
+// Extract sends the file inline to Gemini and parses the JSON it answers with
+func (p *GeminiOCRProvider) Extract(ctx context.Context, imageData []byte, mimeType, language string) (*models.OCRResult, error) {
+	prompt := "Analyze this receipt/bill image. " + geminiLanguageHint(language) + "\n" +
+		"Extract the following data and return ONLY valid JSON (no markdown, no code fences):\n" +
+		geminiReceiptFields + "\n\n" + geminiReceiptRules
+
+	resultText, err := p.generate(ctx, imageData, mimeType, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var result models.OCRResult
+	if err := json.Unmarshal([]byte(resultText), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini JSON output: %w (raw: %s)", err, resultText)
+	}
+
+	result.Confidence = receiptConfidence(&result)
+
+	return &result, nil
+}
+
+// ExtractPages has Gemini read each page of a PDF on its own in one request, skipping
+// blank pages. Images are read whole as one page.
+func (p *GeminiOCRProvider) ExtractPages(ctx context.Context, data []byte, mimeType, language string) ([]*models.OCRResult, error) {
+	if mimeType != "application/pdf" {
+		result, err := p.Extract(ctx, data, mimeType, language)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.OCRResult{result}, nil
+	}
+
+	prompt := "Analyze this receipt/bill document page by page. " + geminiLanguageHint(language) + "\n" +
+		"Read every page on its own and return ONLY valid JSON (no markdown, no code fences) with one entry per page, in page order:\n" +
+		"{\"pages\": [" + geminiReceiptFields + "]}\n\n" + geminiReceiptRules + "\n" +
+		"- Put each field on the page it is printed on; leave it empty on the other pages"
+
+	resultText, err := p.generate(ctx, data, mimeType, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Pages []*models.OCRResult `json:"pages"`
+	}
+	if err := json.Unmarshal([]byte(resultText), &document); err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini JSON output: %w (raw: %s)", err, resultText)
+	}
+
+	var pages []*models.OCRResult
+	for _, page := range document.Pages {
+		if page == nil || (page.Vendor == "" && page.Total == 0 && len(page.Items) == 0 && strings.TrimSpace(page.RawText) == "") {
+			continue
+		}
+		if page.Items == nil {
+			page.Items = []models.OCRItem{}
+		}
+		page.Confidence = receiptConfidence(page)
+		pages = append(pages, page)
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("no pages in Gemini response")
+	}
+	return pages, nil
+}
+
+func geminiLanguageHint(language string) string {
+	if language == "" {
+		return "Detect the language automatically."
+	}
+	return fmt.Sprintf("The text is likely in %s.", language)
+}
+
+// generate sends the file and the prompt to Gemini and returns the text it answers with
+func (p *GeminiOCRProvider) generate(ctx context.Context, data []byte, mimeType, prompt string) (string, error) {
+	reqBody := geminiRequest{
+		Contents: []geminiContent{
+			{
+				Parts: []geminiPart{
+					{
+						InlineData: &geminiInline{
+							MimeType: mimeType,
+							Data:     base64.StdEncoding.EncodeToString(data),
+						},
+					},
+					{
+						Text: prompt,
+					},
+				},
+			},
+		},
+		GenerationConfig: geminiGenerationConfig{
+			ResponseMimeType: "application/json",
+		},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	apiURL := fmt.Sprintf("%s?key=%s", p.baseURL, p.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Gemini API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Gemini API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var geminiResp geminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return "", fmt.Errorf("failed to decode Gemini response: %w", err)
+	}
+
+	if geminiResp.Error != nil {
+		return "", fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
+	}
+
+	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
+		return "", errors.New("empty response from Gemini API")
+	}
+
+	return geminiResp.Candidates[0].Content.Parts[0].Text, nil
+}
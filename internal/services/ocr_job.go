@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/logger"
+	"github.com/Dragodui/diploma-server/internal/metrics"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	ocrJobMaxAttempts = 3
+	ocrJobMaxBackoff  = 5 * time.Minute
+	ocrJobTimeout     = 2 * time.Minute
+	// a job processing for longer than this is taken as abandoned and picked up again
+	ocrJobLease = 5 * time.Minute
+	// finished jobs stay pollable for a day
+	ocrJobKeepFinished = 24 * time.Hour
+)
+
+var ErrOCRJobNotFound = errors.New("OCR job not found")
+
+type IOCRJobService interface {
+	Submit(ctx context.Context, userID, homeID int, files []models.OCRFile, language string) (*models.OCRJob, error)
+	Get(ctx context.Context, userID int, jobID string) (*models.OCRJob, error)
+}
+
+// OCRJobService runs OCR in the background on a fixed number of workers. Jobs are
+// stored in the database, which is the queue: the dispatcher claims due jobs for
+// as many workers as are free, so restarts and other instances lose nothing.
+type OCRJobService struct {
+	repo   repository.OCRJobRepository
+	ocr    IOCRService
+	outbox IOutboxService
+
+	workers int
+	// jobs claimed and not yet finished by a worker
+	inFlight atomic.Int32
+	queue    chan string
+	// wakes the dispatcher after a job is submitted
+	wake chan struct{}
+}
+
+func NewOCRJobService(repo repository.OCRJobRepository, ocr IOCRService, outbox IOutboxService, workers int) *OCRJobService {
+	if workers < 1 {
+		workers = 1
+	}
+	return &OCRJobService{repo: repo, ocr: ocr, outbox: outbox, workers: workers, queue: make(chan string, workers), wake: make(chan struct{}, 1)}
+}
+
+// Submit stores the files of a receipt as a queued job and returns it at once. A homeID of 0
+// queues it for no home; otherwise the result gets a category suggested from the home's bills.
+func (s *OCRJobService) Submit(ctx context.Context, userID, homeID int, files []models.OCRFile, language string) (*models.OCRJob, error) {
+	if len(files) == 0 {
+		return nil, ErrNoOCRFiles
+	}
+
+	job := &models.OCRJob{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		Status:        models.OCRJobQueued,
+		MimeType:      files[0].MimeType,
+		Language:      language,
+		FileCount:     len(files),
+		NextAttemptAt: time.Now(),
+	}
+	if homeID != 0 {
+		job.HomeID = &homeID
+	}
+	for i, file := range files {
+		job.Files = append(job.Files, models.OCRJobFile{JobID: job.ID, Position: i, MimeType: file.MimeType, Data: file.Data})
+	}
+	if err := s.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	metrics.OcrJobsQueued.Inc()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Get returns a job of the user; other users' jobs are reported as not found
+func (s *OCRJobService) Get(ctx context.Context, userID int, jobID string) (*models.OCRJob, error) {
+	job, err := s.repo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.UserID != userID {
+		return nil, ErrOCRJobNotFound
+	}
+	return job, nil
+}
+
+// Wake returns a channel that receives a value whenever a job was submitted.
+func (s *OCRJobService) Wake() <-chan struct{} {
+	return s.wake
+}
+
+// Dispatch claims due jobs for the free workers and returns how many it claimed.
+func (s *OCRJobService) Dispatch(ctx context.Context) (int, error) {
+	free := s.workers - int(s.inFlight.Load())
+	if free <= 0 {
+		return 0, nil
+	}
+
+	var ids []string
+	now := time.Now()
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		jobs, err := s.repo.FindDue(ctx, now, now.Add(-ocrJobLease), free)
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return s.repo.MarkProcessing(ctx, ids, now)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.inFlight.Add(int32(len(ids)))
+	for _, id := range ids {
+		s.queue <- id
+	}
+	s.updateQueued(ctx)
+	return len(ids), nil
+}
+
+// Work processes claimed jobs until ctx is done; run one goroutine per worker.
+func (s *OCRJobService) Work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.queue:
+			if err := s.process(ctx, id); err != nil {
+				logger.Info.Printf("[OCR] Error processing job %s: %v", id, err)
+			}
+		}
+	}
+}
+
+// process runs OCR for a job claimed by Dispatch. Failures are retried with backoff
+// until the attempts run out; the user hears about the outcome over their event channel.
+func (s *OCRJobService) process(ctx context.Context, id string) error {
+	defer s.inFlight.Add(-1)
+
+	job, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job == nil || job.Status != models.OCRJobProcessing {
+		return nil
+	}
+
+	stored, err := s.repo.FindFiles(ctx, id)
+	if err != nil {
+		return err
+	}
+	files := make([]models.OCRFile, len(stored))
+	for i, file := range stored {
+		files[i] = models.OCRFile{Data: file.Data, MimeType: file.MimeType}
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, ocrJobTimeout)
+	result, ocrErr := s.ocr.Process(jobCtx, files, job.Language)
+	cancel()
+
+	now := time.Now()
+	if ocrErr != nil {
+		// another try won't make an unreadable file readable
+		if job.Attempts < ocrJobMaxAttempts && !errors.Is(ocrErr, ErrUnsupportedOCRFile) && !errors.Is(ocrErr, ErrNoOCRFiles) {
+			metrics.OcrJobsTotal.WithLabelValues("retried").Inc()
+			if err := s.repo.Retry(ctx, id, ocrErr.Error(), now.Add(ocrJobBackoff(job.Attempts))); err != nil {
+				return err
+			}
+			s.updateQueued(ctx)
+			return nil
+		}
+		return s.finish(ctx, job, func(ctx context.Context) (bool, error) {
+			job.Status, job.LastError = models.OCRJobFailed, ocrErr.Error()
+			return s.repo.Fail(ctx, id, ocrErr.Error(), now)
+		}, now)
+	}
+
+	if job.HomeID != nil {
+		// the receipt is read either way, a suggestion is a bonus
+		if err := s.ocr.SuggestCategory(ctx, *job.HomeID, result); err != nil {
+			logger.Info.Printf("[OCR] Error suggesting a category for job %s: %v", id, err)
+		}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return s.finish(ctx, job, func(ctx context.Context) (bool, error) {
+		job.Status, job.Result, job.LastError = models.OCRJobCompleted, data, ""
+		return s.repo.Complete(ctx, id, data, now)
+	}, now)
+}
+
+// finish saves the outcome and the event for the uploader in one transaction
+func (s *OCRJobService) finish(ctx context.Context, job *models.OCRJob, save func(ctx context.Context) (bool, error), now time.Time) error {
+	job.FinishedAt = &now
+
+	var saved bool
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		saved, err = save(ctx)
+		if err != nil || !saved {
+			return err
+		}
+
+		action := event.ActionCompleted
+		if job.Status == models.OCRJobFailed {
+			action = event.ActionFailed
+		}
+		return s.outbox.Add(ctx, event.UserChannel(job.UserID), &event.RealTimeEvent{
+			Module: event.ModuleOCR,
+			Action: action,
+			Data:   job,
+		})
+	})
+	if err != nil || !saved {
+		return err
+	}
+
+	metrics.OcrJobsTotal.WithLabelValues(job.Status).Inc()
+	metrics.OcrJobDuration.Observe(now.Sub(job.CreatedAt).Seconds())
+	return nil
+}
+
+// Cleanup removes jobs that finished long enough ago.
+func (s *OCRJobService) Cleanup(ctx context.Context) error {
+	return s.repo.DeleteFinishedBefore(ctx, time.Now().Add(-ocrJobKeepFinished))
+}
+
+func (s *OCRJobService) updateQueued(ctx context.Context) {
+	if queued, err := s.repo.CountQueued(ctx); err == nil {
+		metrics.OcrJobsQueued.Set(float64(queued))
+	}
+}
+
+// ocrJobBackoff returns the delay before the next attempt after the given number of attempts.
+func ocrJobBackoff(attempts int) time.Duration {
+	if attempts > 6 {
+		return ocrJobMaxBackoff
+	}
+	d := 5 * time.Second << attempts
+	if d > ocrJobMaxBackoff {
+		return ocrJobMaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+)
+
+// mergeReceiptPages joins the pages read from the files of one receipt; fileOf holds the
+// file of each page. Photos of a long receipt overlap, so lines repeated where one page
+// ends and the next begins are kept once.
+func mergeReceiptPages(pages []*models.OCRResult, fileOf []int) *models.OCRResult {
+	if len(pages) == 1 {
+		page := pages[0]
+		page.Pages = []models.OCRPage{{Page: 1, File: fileOf[0], Confidence: page.Confidence}}
+		return page
+	}
+
+	merged := &models.OCRResult{Items: []models.OCRItem{}}
+	var lines []string
+	for i, page := range pages {
+		merged.Pages = append(merged.Pages, models.OCRPage{Page: i + 1, File: fileOf[i], Confidence: page.Confidence})
+
+		// the header is on the first page that has it
+		if merged.Vendor == "" {
+			merged.Vendor = page.Vendor
+		}
+		if merged.Date == "" {
+			merged.Date = page.Date
+		}
+		if merged.Currency == "" {
+			merged.Currency = page.Currency
+		}
+		// no page adds up to more than the whole receipt, and pages without a total
+		// line make do with their largest amount
+		if page.Total > merged.Total {
+			merged.Total = page.Total
+		}
+
+		merged.Items = append(merged.Items, page.Items[overlap(merged.Items, page.Items, sameOCRItem):]...)
+		merged.Adjustments = append(merged.Adjustments, page.Adjustments[overlap(merged.Adjustments, page.Adjustments, sameOCRItem):]...)
+
+		pageLines := receiptTextLines(page.RawText)
+		lines = append(lines, pageLines[overlap(lines, pageLines, sameReceiptLine):]...)
+	}
+	merged.RawText = strings.Join(lines, "\n")
+	merged.Confidence = receiptConfidence(merged)
+	return merged
+}
+
+// overlap returns how many leading elements of next repeat the trailing ones of prev
+func overlap[T any](prev, next []T, same func(a, b T) bool) int {
+	for k := min(len(prev), len(next)); k > 0; k-- {
+		tail := prev[len(prev)-k:]
+		match := true
+		for j := 0; j < k && match; j++ {
+			match = same(tail[j], next[j])
+		}
+		if match {
+			return k
+		}
+	}
+	return 0
+}
+
+func sameOCRItem(a, b models.OCRItem) bool {
+	return a.Price == b.Price && a.Quantity == b.Quantity && sameReceiptLine(a.Name, b.Name)
+}
+
+func sameReceiptLine(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+func receiptTextLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+)
+
+var (
+	// 2025-03-14, 14.03.2025, 03/14/25
+	receiptNumericDate = regexp.MustCompile(`\b(\d{1,4})([-./])(\d{1,2})[-./](\d{1,4})\b`)
+	// 14 March 2025, 14. März 2025, 14 marca 2025r.
+	receiptDayMonthDate = regexp.MustCompile(`\b(\d{1,2})\.?\s+(\p{L}+)\.?,?\s+(\d{4})`)
+	// March 14, 2025
+	receiptMonthDayDate = regexp.MustCompile(`(\p{L}+)\.?\s+(\d{1,2}),?\s+(\d{4})\b`)
+)
+
+// month names and abbreviations in the languages receipts come in, lowercase
+var receiptMonths = map[string]time.Month{
+	"jan": 1, "january": 1, "januar": 1, "janvier": 1, "enero": 1, "gennaio": 1, "sty": 1, "styczeń": 1, "stycznia": 1, "січня": 1, "января": 1,
+	"feb": 2, "february": 2, "februar": 2, "février": 2, "fév": 2, "febrero": 2, "febbraio": 2, "lut": 2, "luty": 2, "lutego": 2, "лютого": 2, "февраля": 2,
+	"mar": 3, "march": 3, "märz": 3, "mär": 3, "mars": 3, "marzo": 3, "marzec": 3, "marca": 3, "березня": 3, "марта": 3,
+	"apr": 4, "april": 4, "avril": 4, "abril": 4, "aprile": 4, "kwi": 4, "kwiecień": 4, "kwietnia": 4, "квітня": 4, "апреля": 4,
+	"may": 5, "mai": 5, "mayo": 5, "maggio": 5, "maj": 5, "maja": 5, "травня": 5, "мая": 5,
+	"jun": 6, "june": 6, "juni": 6, "juin": 6, "junio": 6, "giugno": 6, "cze": 6, "czerwiec": 6, "czerwca": 6, "червня": 6, "июня": 6,
+	"jul": 7, "july": 7, "juli": 7, "juillet": 7, "julio": 7, "luglio": 7, "lip": 7, "lipiec": 7, "lipca": 7, "липня": 7, "июля": 7,
+	"aug": 8, "august": 8, "août": 8, "agosto": 8, "sie": 8, "sierpień": 8, "sierpnia": 8, "серпня": 8, "августа": 8,
+	"sep": 9, "sept": 9, "september": 9, "septembre": 9, "septiembre": 9, "settembre": 9, "wrz": 9, "wrzesień": 9, "września": 9, "вересня": 9, "сентября": 9,
+	"oct": 10, "october": 10, "okt": 10, "oktober": 10, "octobre": 10, "octubre": 10, "ottobre": 10, "paź": 10, "październik": 10, "października": 10, "жовтня": 10, "октября": 10,
+	"nov": 11, "november": 11, "novembre": 11, "noviembre": 11, "lis": 11, "listopad": 11, "listopada": 11, "листопада": 11, "ноября": 11,
+	"dec": 12, "december": 12, "dez": 12, "dezember": 12, "décembre": 12, "diciembre": 12, "dicembre": 12, "gru": 12, "grudzień": 12, "grudnia": 12, "грудня": 12, "декабря": 12,
+}
+
+// currency signs printed on receipts; ISO codes are recognised as they are
+var receiptCurrencySigns = []struct {
+	sign     string
+	currency string
+}{
+	{"zł", "PLN"}, {"€", "EUR"}, {"£", "GBP"}, {"₴", "UAH"}, {"грн", "UAH"}, {"kč", "CZK"}, {"lei", "RON"}, {"¥", "JPY"}, {"$", "USD"},
+}
+
+var (
+	receiptTaxWords      = []string{"tax", "vat", "ptu", "mwst", "ust", "tva", "iva", "gst", "hst", "пдв", "ндс"}
+	receiptDiscountWords = []string{
+		"discount", "rabat", "rabatt", "upust", "obniżka", "promocja", "promo", "coupon", "kupon", "gutschein",
+		"remise", "réduction", "descuento", "sconto", "savings", "you saved", "знижка", "скидка",
+	}
+	receiptDepositWords = []string{"deposit", "kaucja", "pfand", "consigne", "depósito", "vratná záloha", "застава"}
+)
+
+// normalizeReceipt fills in what can be worked out from a provider's result: the date as a
+// time, the currency, and tax, discount and deposit lines moved out of the items. Running it
+// again on its own output changes nothing.
+func normalizeReceipt(result *models.OCRResult, language string) {
+	items := make([]models.OCRItem, 0, len(result.Items))
+	for _, item := range result.Items {
+		item.Kind = receiptLineKind(item)
+		switch item.Kind {
+		case models.ReceiptLineItem:
+			items = append(items, item)
+		case models.ReceiptLineDiscount:
+			if item.Price > 0 {
+				item.Price = -item.Price
+			}
+			result.Adjustments = append(result.Adjustments, item)
+		default:
+			result.Adjustments = append(result.Adjustments, item)
+		}
+	}
+	result.Items = items
+
+	var subtotal models.Money
+	for _, item := range result.Items {
+		subtotal += item.Price
+	}
+	result.Tax, result.Discount, result.Deposit = 0, 0, 0
+	for _, adj := range result.Adjustments {
+		switch adj.Kind {
+		case models.ReceiptLineTax:
+			result.Tax += adj.Price
+		case models.ReceiptLineDiscount:
+			result.Discount -= adj.Price
+		case models.ReceiptLineDeposit:
+			result.Deposit += adj.Price
+		}
+	}
+	// VAT is printed for information only; sales tax comes on top of the prices
+	result.TaxIncluded = result.Tax == 0 || subtotal-result.Discount+result.Deposit == result.Total
+
+	result.Currency = strings.ToUpper(strings.TrimSpace(result.Currency))
+	if !IsSupportedCurrency(result.Currency) {
+		result.Currency = detectCurrency(result.RawText + "\n" + result.Vendor)
+	}
+
+	result.ParsedDate = nil
+	monthFirst := result.Currency == "USD" || strings.EqualFold(language, "en-US")
+	for _, text := range []string{result.Date, result.RawText} {
+		if date := parseReceiptDate(text, monthFirst); date != nil {
+			result.ParsedDate = date
+			break
+		}
+	}
+}
+
+// receiptLineKind keeps a kind the provider gave and tells the others by their name
+func receiptLineKind(item models.OCRItem) string {
+	switch item.Kind {
+	case models.ReceiptLineItem, models.ReceiptLineTax, models.ReceiptLineDiscount, models.ReceiptLineDeposit:
+		return item.Kind
+	}
+
+	name := strings.ToLower(item.Name)
+	switch {
+	case containsAnyWord(name, receiptDepositWords):
+		// a returned bottle is money back
+		if item.Price < 0 {
+			return models.ReceiptLineDiscount
+		}
+		return models.ReceiptLineDeposit
+	case item.Price < 0, containsAnyWord(name, receiptDiscountWords):
+		return models.ReceiptLineDiscount
+	case containsAnyWord(name, receiptTaxWords):
+		return models.ReceiptLineTax
+	}
+	return models.ReceiptLineItem
+}
+
+// detectCurrency returns the first currency code or sign found in the text, preferring codes
+func detectCurrency(text string) string {
+	lower := strings.ToLower(text)
+
+	codes := make([]string, 0, len(defaultRates))
+	for code := range defaultRates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	best, at := "", -1
+	for _, code := range codes {
+		if i := wordIndex(lower, strings.ToLower(code)); i >= 0 && (at < 0 || i < at) {
+			best, at = code, i
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	for _, sign := range receiptCurrencySigns {
+		if i := wordIndex(lower, sign.sign); i >= 0 && (at < 0 || i < at) {
+			best, at = sign.currency, i
+		}
+	}
+	return best
+}
+
+// parseReceiptDate reads the first date in the text. Dates with dots are day first, as
+// everywhere dots are used; slashes are month first only when monthFirst is set and the
+// numbers allow it.
+func parseReceiptDate(text string, monthFirst bool) *time.Time {
+	for _, m := range receiptNumericDate.FindAllStringSubmatch(text, -1) {
+		a, b, c := atoi(m[1]), atoi(m[3]), atoi(m[4])
+		var year, month, day int
+		switch {
+		case len(m[1]) == 4:
+			year, month, day = a, b, c
+		case len(m[4]) != 2 && len(m[4]) != 4:
+			continue
+		case m[2] == "/" && b > 12, m[2] == "/" && a <= 12 && monthFirst:
+			month, day, year = a, b, c
+		default:
+			day, month, year = a, b, c
+		}
+		if len(m[1]) != 4 && len(m[4]) == 2 {
+			year += 2000
+		}
+		if date := receiptDateOf(year, month, day); date != nil {
+			return date
+		}
+	}
+
+	if m := receiptDayMonthDate.FindStringSubmatch(text); m != nil {
+		if month, ok := receiptMonths[strings.ToLower(m[2])]; ok {
+			if date := receiptDateOf(atoi(m[3]), int(month), atoi(m[1])); date != nil {
+				return date
+			}
+		}
+	}
+	if m := receiptMonthDayDate.FindStringSubmatch(text); m != nil {
+		if month, ok := receiptMonths[strings.ToLower(m[1])]; ok {
+			return receiptDateOf(atoi(m[3]), int(month), atoi(m[2]))
+		}
+	}
+	return nil
+}
+
+// receiptDateOf returns the date at midnight UTC, or nil when there is no such day
+func receiptDateOf(year, month, day int) *time.Time {
+	if year < 2000 || year > 2099 || month < 1 || month > 12 || day < 1 {
+		return nil
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return nil
+	}
+	return &date
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// vendorKey reduces a vendor to the word that names it, so "BIEDRONKA Sklep 1234"
+// and "Biedronka" are the same shop
+func vendorKey(vendor string) string {
+	words := strings.FieldsFunc(strings.ToLower(vendor), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '&'
+	})
+	for _, w := range words {
+		if letterCount(w) >= 2 && !vendorStopWords[w] {
+			return w
+		}
+	}
+	return ""
+}
+
+var vendorStopWords = map[string]bool{
+	"the": true, "sklep": true, "shop": true, "store": true, "market": true, "supermarket": true,
+	"sp": true, "zoo": true, "ltd": true, "inc": true, "llc": true, "gmbh": true, "ag": true, "sa": true,
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxPDFText caps the inflated size of all content streams, against zip bombs
+const maxPDFText = 16 << 20
+
+var (
+	pdfStreamStart = regexp.MustCompile(`stream\r?\n`)
+	pdfObjectStart = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	pdfPageType    = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfContents    = regexp.MustCompile(`/Contents\s*(\[[^\]]*\]|\d+\s+\d+\s+R)`)
+	pdfReference   = regexp.MustCompile(`(\d+)\s+\d+\s+R`)
+)
+
+// pdfStream is the decoded content of a stream object
+type pdfStream struct {
+	object  int
+	content []byte
+}
+
+// pdfText returns the text drawn by the content streams of a PDF, one line per
+// baseline. Only uncompressed and FlateDecode streams with single-byte fonts are
+// read, which is what tills and web shops export; scans have no text layer
+func pdfText(data []byte) string {
+	t := &pdfTextState{}
+	for _, stream := range pdfStreams(data) {
+		if bytes.Contains(stream.content, []byte("BT")) {
+			t.run(stream.content)
+		}
+	}
+	return t.out.String()
+}
+
+// pdfPages returns the text of each page of a PDF, in the order the pages are stored.
+// It returns nil when the pages can't be told apart, e.g. when the page objects are
+// compressed into object streams; pdfText still reads such files whole.
+func pdfPages(data []byte) []string {
+	streams := make(map[int][]byte)
+	for _, stream := range pdfStreams(data) {
+		streams[stream.object] = stream.content
+	}
+
+	var pages []string
+	for _, loc := range pdfObjectStart.FindAllSubmatchIndex(data, -1) {
+		body := data[loc[1]:]
+		if end := bytes.Index(body, []byte("endobj")); end >= 0 {
+			body = body[:end]
+		}
+		if i := bytes.Index(body, []byte("stream")); i >= 0 {
+			body = body[:i]
+		}
+		if !pdfPageType.Match(body) {
+			continue
+		}
+
+		t := &pdfTextState{}
+		if m := pdfContents.FindSubmatch(body); m != nil {
+			for _, ref := range pdfReference.FindAllSubmatch(m[1], -1) {
+				num, _ := strconv.Atoi(string(ref[1]))
+				if content := streams[num]; bytes.Contains(content, []byte("BT")) {
+					t.run(content)
+				}
+			}
+		}
+		pages = append(pages, t.out.String())
+	}
+	return pages
+}
+
+// pdfStreams decodes the streams of a PDF in file order
+func pdfStreams(data []byte) []pdfStream {
+	var streams []pdfStream
+	budget := int64(maxPDFText)
+	for _, loc := range pdfStreamStart.FindAllIndex(data, -1) {
+		if loc[0] >= 3 && string(data[loc[0]-3:loc[0]]) == "end" {
+			continue
+		}
+		end := bytes.Index(data[loc[1]:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		raw := data[loc[1] : loc[1]+end]
+		dict := data[:loc[0]]
+		object := -1
+		if i := bytes.LastIndex(dict, []byte("obj")); i >= 0 {
+			// "12 0 obj" ends right before the dictionary
+			header := dict[max(0, i-24) : i+3]
+			if m := pdfObjectStart.FindAllSubmatch(header, -1); len(m) > 0 {
+				object, _ = strconv.Atoi(string(m[len(m)-1][1]))
+			}
+			dict = dict[i:]
+		}
+
+		var content []byte
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			// keep whatever inflated before a truncated end
+			content, _ = io.ReadAll(io.LimitReader(r, budget))
+		case !bytes.Contains(dict, []byte("/Filter")):
+			content = raw
+		}
+		budget -= int64(len(content))
+		if budget <= 0 {
+			break
+		}
+		streams = append(streams, pdfStream{object: object, content: content})
+	}
+	return streams
+}
+
+// pdfTextState follows the text position across the operators of content streams
+type pdfTextState struct {
+	out      strings.Builder
+	operands []float64
+	strs     []string
+	inArray  bool
+	y        float64 // baseline of the current line
+	leading  float64
+	moved    bool // the position changed since the last text
+	lastY    float64
+	written  bool
+}
+
+func (t *pdfTextState) run(content []byte) {
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0:
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			var s string
+			s, i = pdfLiteral(content, i+1)
+			t.strs = append(t.strs, s)
+		case c == '<' && i+1 < len(content) && content[i+1] == '<':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			t.strs = append(t.strs, pdfHex(content[i+1:i+end]))
+			i += end + 1
+		case c == '[':
+			t.inArray = true
+			i++
+		case c == ']':
+			t.inArray = false
+			i++
+		case c == '>' || c == '{' || c == '}' || c == ')':
+			i++
+		case c == '/':
+			i++
+			for i < len(content) && !pdfDelimiter(content[i]) {
+				i++
+			}
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			for i++; i < len(content) && (content[i] == '.' || (content[i] >= '0' && content[i] <= '9')); i++ {
+			}
+			v, _ := strconv.ParseFloat(string(content[start:i]), 64)
+			if t.inArray {
+				// a wide gap between glyphs in a TJ array is a space
+				if v < -250 {
+					t.strs = append(t.strs, " ")
+				}
+				continue
+			}
+			t.operands = append(t.operands, v)
+		default:
+			start := i
+			for i++; i < len(content) && !pdfDelimiter(content[i]); i++ {
+			}
+			t.operator(string(content[start:i]))
+			t.operands = t.operands[:0]
+			t.strs = t.strs[:0]
+		}
+	}
+}
+
+func (t *pdfTextState) operator(op string) {
+	n := len(t.operands)
+	switch op {
+	case "BT":
+		t.y = 0
+		t.moved = true
+	case "Td", "TD":
+		if n >= 2 {
+			ty := t.operands[n-1]
+			t.y += ty
+			if op == "TD" {
+				t.leading = -ty
+			}
+		}
+		t.moved = true
+	case "Tm":
+		if n >= 6 {
+			t.y = t.operands[n-1]
+		}
+		t.moved = true
+	case "TL":
+		if n >= 1 {
+			t.leading = t.operands[n-1]
+		}
+	case "T*":
+		t.y -= t.leading
+		t.moved = true
+	case "Tj", "TJ":
+		t.show()
+	case "'", `"`:
+		t.y -= t.leading
+		t.moved = true
+		t.show()
+	}
+}
+
+// show writes the pending strings, starting a new line when the baseline moved
+func (t *pdfTextState) show() {
+	text := strings.Join(t.strs, "")
+	if text == "" {
+		return
+	}
+	if t.written {
+		if math.Abs(t.y-t.lastY) > 1 {
+			t.out.WriteByte('\n')
+		} else if t.moved {
+			t.out.WriteByte(' ')
+		}
+	}
+	t.out.WriteString(text)
+	t.lastY = t.y
+	t.written = true
+	t.moved = false
+}
+
+func pdfDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n\f\x00()<>[]{}/%", c) >= 0
+}
+
+// pdfLiteral reads a (string) starting after its opening parenthesis
+func pdfLiteral(data []byte, i int) (string, int) {
+	var s []byte
+	depth := 1
+	for i < len(data) {
+		c := data[i]
+		i++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(s), i
+			}
+		case '\\':
+			if i >= len(data) {
+				continue
+			}
+			e := data[i]
+			i++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// a line continuation
+				if e == '\r' && i < len(data) && data[i] == '\n' {
+					i++
+				}
+				continue
+			default:
+				c = e
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for k := 0; k < 2 && i < len(data) && data[i] >= '0' && data[i] <= '7'; k++ {
+						v = v*8 + int(data[i]-'0')
+						i++
+					}
+					c = byte(v)
+				}
+			}
+		}
+		s = append(s, c)
+	}
+	return pdfString(s), i
+}
+
+func pdfHex(data []byte) string {
+	digits := bytes.Map(func(r rune) rune {
+		if strings.ContainsRune(" \t\r\n\f", r) {
+			return -1
+		}
+		return r
+	}, data)
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	s, err := hex.DecodeString(string(digits))
+	if err != nil {
+		return ""
+	}
+	return pdfString(s)
+}
+
+// pdfString decodes UTF-16 strings with a byte order mark and reads any other as Latin-1
+func pdfString(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(s))
+	for i, b := range s {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
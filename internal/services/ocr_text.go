@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+)
+
+// TextOCRProvider parses plain-text receipts and the text layer of PDFs with heuristics,
+// without calling any external service
+type TextOCRProvider struct{}
+
+func NewTextOCRProvider() *TextOCRProvider {
+	return &TextOCRProvider{}
+}
+
+func (p *TextOCRProvider) Name() string {
+	return OCRProviderText
+}
+
+// Extract reads the receipt text; images have no text to read and are refused
+func (p *TextOCRProvider) Extract(ctx context.Context, data []byte, mimeType, language string) (*models.OCRResult, error) {
+	var text string
+	switch {
+	case mimeType == "application/pdf":
+		text = pdfText(data)
+	case strings.HasPrefix(mimeType, "text/"):
+		if !utf8.Valid(data) {
+			return nil, ErrUnsupportedOCRFile
+		}
+		text = string(data)
+	default:
+		return nil, ErrUnsupportedOCRFile
+	}
+	return readReceiptText(text)
+}
+
+// ExtractPages reads each page of a PDF on its own, skipping blank ones. Other
+// files, and PDFs whose pages can't be told apart, are read whole as one page.
+func (p *TextOCRProvider) ExtractPages(ctx context.Context, data []byte, mimeType, language string) ([]*models.OCRResult, error) {
+	if mimeType == "application/pdf" {
+		var results []*models.OCRResult
+		for _, text := range pdfPages(data) {
+			if result, err := readReceiptText(text); err == nil {
+				results = append(results, result)
+			}
+		}
+		if len(results) > 0 {
+			return results, nil
+		}
+	}
+
+	result, err := p.Extract(ctx, data, mimeType, language)
+	if err != nil {
+		return nil, err
+	}
+	return []*models.OCRResult{result}, nil
+}
+
+func readReceiptText(text string) (*models.OCRResult, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrUnsupportedOCRFile
+	}
+
+	result := parseReceiptText(text)
+	result.Confidence = receiptConfidence(result)
+	return result, nil
+}
+
+var (
+	// an amount with two decimals at the end of a line, optionally with a currency or a tax class
+	receiptAmountLine = regexp.MustCompile(`(?i)^(.*?)[\s:=]*(?:[$€£]|pln|eur|usd|gbp|uah|zł)?\s*(-?\d{1,3}(?:[.,' ]\d{3})+[.,]\d{2}|-?\d+[.,]\d{2})\s*(?:[$€£]|pln|eur|usd|gbp|uah|zł|[a-d])?\s*$`)
+	// "2 x 1,50" or "0.5 kg x 4.00" inside an item name
+	receiptQuantity = regexp.MustCompile(`(?i)\s*(\d+(?:[.,]\d+)?)\s*(?:szt\.?|pcs|kg|l)?\s*[x*×]\s*\d+[.,]\d{2}\s*`)
+	// "2 x Milk" at the start of an item name
+	receiptLeadingQuantity = regexp.MustCompile(`(?i)^(\d+)\s*[x*×]\s+`)
+	receiptDate            = regexp.MustCompile(`\b(\d{4}[-./]\d{1,2}[-./]\d{1,2}|\d{1,2}[-./]\d{1,2}[-./]\d{2,4})\b`)
+)
+
+var (
+	receiptTotalWords    = []string{"total", "suma", "razem", "summe", "gesamt", "итого", "всього", "do zapłaty", "amount due", "balance due", "to pay"}
+	receiptSubtotalWords = []string{"subtotal", "sub-total", "sub total", "zwischensumme", "suma ptu", "total tax", "total vat"}
+	// lines with an amount that are not something bought
+	receiptSkipWords = []string{
+		"change", "cash", "card", "visa", "mastercard", "tender", "payment", "paid",
+		"reszta", "gotówka", "karta", "płatność", "rückgeld", "bar", "сдача", "решта",
+	}
+)
+
+// parseReceiptText finds the vendor, date, total and line items in the text of a receipt
+func parseReceiptText(text string) *models.OCRResult {
+	result := &models.OCRResult{Items: []models.OCRItem{}, RawText: strings.TrimSpace(text)}
+
+	var largest, total models.Money
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.Join(strings.Fields(raw), " ")
+		if line == "" {
+			continue
+		}
+		if result.Date == "" {
+			if m := receiptDate.FindStringSubmatch(line); m != nil {
+				result.Date = m[1]
+			}
+		}
+
+		name, amount, ok := receiptLineAmount(line)
+		if !ok {
+			// the vendor heads the receipt, before anything with a price
+			if result.Vendor == "" && largest == 0 && letterCount(line) >= 3 && !receiptDate.MatchString(line) {
+				result.Vendor = line
+			}
+			continue
+		}
+		if amount > largest {
+			largest = amount
+		}
+
+		lower := strings.ToLower(name)
+		switch {
+		case containsAnyWord(lower, receiptSubtotalWords):
+		case containsAnyWord(lower, receiptTotalWords):
+			// the last total wins; the first is often before a discount or deposit
+			total = amount
+		case containsAnyWord(lower, receiptTaxWords):
+			result.Adjustments = append(result.Adjustments, models.OCRItem{Name: name, Quantity: 1, Price: amount, Kind: models.ReceiptLineTax})
+		case containsAnyWord(lower, receiptSkipWords), letterCount(name) == 0, receiptDate.MatchString(name):
+		default:
+			result.Items = append(result.Items, receiptItem(name, amount))
+		}
+	}
+
+	result.Total = total
+	if result.Total == 0 {
+		result.Total = largest
+	}
+	return result
+}
+
+// receiptLineAmount splits a line into its text and the amount at its end
+func receiptLineAmount(line string) (string, models.Money, bool) {
+	m := receiptAmountLine.FindStringSubmatch(line)
+	if m == nil {
+		return "", 0, false
+	}
+	value := m[2]
+	amount, err := parseStatementAmount(value, value[len(value)-3:len(value)-2])
+	if err != nil {
+		return "", 0, false
+	}
+	return strings.TrimSpace(m[1]), amount, true
+}
+
+func receiptItem(name string, price models.Money) models.OCRItem {
+	item := models.OCRItem{Name: name, Quantity: 1, Price: price}
+	if m := receiptQuantity.FindStringSubmatchIndex(name); m != nil {
+		item.Quantity = parseQuantity(name[m[2]:m[3]])
+		item.Name = strings.TrimSpace(name[:m[0]] + " " + name[m[1]:])
+	} else if m := receiptLeadingQuantity.FindStringSubmatch(name); m != nil {
+		item.Quantity = parseQuantity(m[1])
+		item.Name = name[len(m[0]):]
+	}
+	item.Name = strings.Trim(item.Name, " .:-*")
+	if item.Quantity <= 0 {
+		item.Quantity = 1
+	}
+	return item
+}
+
+func parseQuantity(s string) float64 {
+	value, err := models.ParseMoney(strings.ReplaceAll(s, ",", "."))
+	if err != nil {
+		return 1
+	}
+	return float64(value) / 100
+}
+
+// containsAnyWord matches whole words, so "bar" doesn't match "barley"
+func containsAnyWord(s string, words []string) bool {
+	for _, w := range words {
+		if wordIndex(s, w) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// wordIndex returns where w first stands as a whole word in s, or -1
+func wordIndex(s, w string) int {
+	for start := 0; ; {
+		i := strings.Index(s[start:], w)
+		if i < 0 {
+			return -1
+		}
+		i += start
+		end := i + len(w)
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if (i == 0 || !unicode.IsLetter(before)) && (end == len(s) || !unicode.IsLetter(after)) {
+			return i
+		}
+		start = end
+	}
+}
+
+func letterCount(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n
+}
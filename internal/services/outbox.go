@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/logger"
+	"github.com/Dragodui/diploma-server/internal/metrics"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	outboxBatchSize  = 100
+	outboxMaxBackoff = 5 * time.Minute
+	// published rows are kept for a day to help debugging, then removed
+	outboxKeepPublished = 24 * time.Hour
+)
+
+type OutboxService struct {
+	tx    repository.Transactor
+	repo  repository.OutboxRepository
+	cache *redis.Client
+
+	// wakes the relay after a transaction with new events commits
+	wake chan struct{}
+}
+
+type IOutboxService interface {
+	// WithinTx runs fn in a transaction. Events added inside fn are saved with the
+	// domain change and published only after it commits.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Add(ctx context.Context, channel string, e *event.RealTimeEvent) error
+}
+
+func NewOutboxService(tx repository.Transactor, repo repository.OutboxRepository, cache *redis.Client) *OutboxService {
+	return &OutboxService{tx: tx, repo: repo, cache: cache, wake: make(chan struct{}, 1)}
+}
+
+func (s *OutboxService) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := s.tx.WithinTx(ctx, fn); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *OutboxService) Add(ctx context.Context, channel string, e *event.RealTimeEvent) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Create(ctx, &models.OutboxEvent{
+		Channel:       channel,
+		Module:        string(e.Module),
+		Action:        string(e.Action),
+		Data:          data,
+		NextAttemptAt: time.Now(),
+	})
+}
+
+// Wake returns a channel that receives a value whenever new events were committed.
+func (s *OutboxService) Wake() <-chan struct{} {
+	return s.wake
+}
+
+// PublishPending sends every due event to Redis. Failed events are retried later
+// with exponential backoff. It returns how many events were published.
+func (s *OutboxService) PublishPending(ctx context.Context) (int, error) {
+	published := 0
+
+	for {
+		n, err := s.publishBatch(ctx)
+		published += n
+		if err != nil || n < outboxBatchSize {
+			s.updateLag(ctx)
+			return published, err
+		}
+	}
+}
+
+func (s *OutboxService) publishBatch(ctx context.Context) (int, error) {
+	published := 0
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		events, err := s.repo.FindDue(ctx, time.Now(), outboxBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			rt := &event.RealTimeEvent{
+				Module: event.Module(e.Module),
+				Action: event.Action(e.Action),
+				Data:   json.RawMessage(e.Data),
+			}
+
+			if err := event.SendEvent(ctx, s.cache, e.Channel, rt); err != nil {
+				metrics.OutboxPublishTotal.WithLabelValues("failed").Inc()
+				logger.Info.Printf("Failed to publish outbox event %d (attempt %d): %v", e.ID, e.Attempts+1, err)
+
+				if err := s.repo.MarkFailed(ctx, e.ID, err.Error(), time.Now().Add(outboxBackoff(e.Attempts))); err != nil {
+					return err
+				}
+				continue
+			}
+
+			now := time.Now()
+			if err := s.repo.MarkPublished(ctx, e.ID, now); err != nil {
+				return err
+			}
+			metrics.OutboxPublishTotal.WithLabelValues("published").Inc()
+			metrics.OutboxPublishDelay.Observe(now.Sub(e.CreatedAt).Seconds())
+			published++
+		}
+		return nil
+	})
+
+	return published, err
+}
+
+// Cleanup removes events that were published long enough ago.
+func (s *OutboxService) Cleanup(ctx context.Context) error {
+	return s.repo.DeletePublishedBefore(ctx, time.Now().Add(-outboxKeepPublished))
+}
+
+func (s *OutboxService) updateLag(ctx context.Context) {
+	if pending, err := s.repo.CountPending(ctx); err == nil {
+		metrics.OutboxPendingEvents.Set(float64(pending))
+	}
+
+	oldest, err := s.repo.OldestPendingCreatedAt(ctx)
+	if err != nil {
+		return
+	}
+	if oldest == nil {
+		metrics.OutboxLagSeconds.Set(0)
+		return
+	}
+	metrics.OutboxLagSeconds.Set(time.Since(*oldest).Seconds())
+}
+
+// outboxBackoff returns the delay before the next attempt after the given number of failed attempts.
+func outboxBackoff(attempts int) time.Duration {
+	if attempts > 8 {
+		return outboxMaxBackoff
+	}
+	d := time.Second << attempts
+	if d > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return d
+}
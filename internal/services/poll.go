@@ -20,6 +20,7 @@ type PollService struct {
 	repo     repository.PollRepository
 	cache    *redis.Client
 	notifSvc INotificationService
+	outbox   IOutboxService
 }
 
 type IPollService interface {
@@ -35,11 +36,12 @@ type IPollService interface {
 	Unvote(ctx context.Context, userID, pollID, homeID int) error
 }
 
-func NewPollService(repo repository.PollRepository, cache *redis.Client, notifSvc INotificationService) *PollService {
+func NewPollService(repo repository.PollRepository, cache *redis.Client, notifSvc INotificationService, outbox IOutboxService) *PollService {
 	return &PollService{
 		repo:     repo,
 		cache:    cache,
 		notifSvc: notifSvc,
+		outbox:   outbox,
 	}
 }
 
@@ -66,7 +68,17 @@ func (s *PollService) Create(ctx context.Context, homeID int, question, pollType
 		EndsAt:      endsAt,
 	}
 
-	if err := s.repo.Create(ctx, poll, optionModels); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, poll, optionModels); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModulePoll,
+			Action: event.ActionCreated,
+			Data:   poll,
+		})
+	}); err != nil {
 		return err
 	}
 
@@ -76,12 +88,6 @@ func (s *PollService) Create(ctx context.Context, homeID int, question, pollType
 	fromID := createdBy
 	_ = s.notifSvc.CreateHomeNotification(ctx, &fromID, homeID, "New poll created: "+question)
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModulePoll,
-		Action: event.ActionCreated,
-		Data:   poll,
-	})
-
 	return nil
 }
 
@@ -122,17 +128,17 @@ func (s *PollService) ClosePoll(ctx context.Context, pollID, homeID int) error {
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", pollsForHomeKey, err)
 	}
 
-	if err := s.repo.ClosePoll(ctx, pollID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.ClosePoll(ctx, pollID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModulePoll,
-		Action: event.ActionClosed,
-		Data:   map[string]int{"id": pollID},
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModulePoll,
+			Action: event.ActionClosed,
+			Data:   map[string]int{"id": pollID},
+		})
 	})
-
-	return nil
 }
 
 func (s *PollService) Delete(ctx context.Context, pollID, homeID int) error {
@@ -147,18 +153,22 @@ func (s *PollService) Delete(ctx context.Context, pollID, homeID int) error {
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", pollsForHomeKey, err)
 	}
 
-	if err := s.repo.Delete(ctx, pollID); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, pollID); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModulePoll,
+			Action: event.ActionDeleted,
+			Data:   map[string]int{"id": pollID},
+		})
+	}); err != nil {
 		return err
 	}
 
 	metrics.PollsTotal.Dec()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModulePoll,
-		Action: event.ActionDeleted,
-		Data:   map[string]int{"id": pollID},
-	})
-
 	return nil
 }
 
@@ -192,18 +202,22 @@ func (s *PollService) Vote(ctx context.Context, userID, optionID, homeID int) er
 		UserID:   userID,
 		OptionID: optionID,
 	}
-	if err := s.repo.Vote(ctx, vote); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Vote(ctx, vote); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModulePoll,
+			Action: event.ActionVoted,
+			Data:   vote,
+		})
+	}); err != nil {
 		return err
 	}
 
 	metrics.PollVotesTotal.Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModulePoll,
-		Action: event.ActionVoted,
-		Data:   vote,
-	})
-
 	return nil
 }
 
@@ -236,15 +250,15 @@ func (s *PollService) Unvote(ctx context.Context, userID, pollID, homeID int) er
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", pollsForHomeKey, err)
 	}
 
-	if err := s.repo.Unvote(ctx, userID, pollID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Unvote(ctx, userID, pollID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModulePoll,
-		Action: event.ActionUnvoted,
-		Data:   map[string]int{"userID": userID, "pollID": pollID},
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModulePoll,
+			Action: event.ActionUnvoted,
+			Data:   map[string]int{"userID": userID, "pollID": pollID},
+		})
 	})
-
-	return nil
 }
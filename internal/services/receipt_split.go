@@ -0,0 +1,110 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+)
+
+// splitReceipt divides a receipt between the users of its items. Each item is shared
+// equally by its users; the receipt total, after tax and discounts, is then apportioned
+// by each user's item subtotal, so tax and discounts land proportionally.
+func splitReceipt(lines []models.ReceiptLine) (*models.ReceiptSplit, []models.SplitInput, error) {
+	receipt := &models.ReceiptSplit{Items: make([]models.ReceiptLine, len(lines))}
+	subtotals := make(map[int]models.Money)
+
+	for i, line := range lines {
+		if line.Kind == "" {
+			line.Kind = models.ReceiptLineItem
+		}
+		line.Shares = nil
+
+		switch line.Kind {
+		case models.ReceiptLineTax:
+			if line.Price < 0 {
+				return nil, nil, splitError("tax line %q cannot be negative", line.Name)
+			}
+			line.UserIDs = nil
+			receipt.Tax += line.Price
+
+		case models.ReceiptLineDiscount:
+			// receipts print discounts either way round
+			if line.Price < 0 {
+				line.Price = -line.Price
+			}
+			line.UserIDs = nil
+			receipt.Discount += line.Price
+
+		default:
+			if line.Price < 0 {
+				return nil, nil, splitError("item %q has a negative price, use a discount line", line.Name)
+			}
+			if len(line.UserIDs) == 0 {
+				return nil, nil, splitError("item %q is not assigned to anyone", line.Name)
+			}
+
+			users := make([]models.SplitInput, len(line.UserIDs))
+			seen := make(map[int]bool, len(line.UserIDs))
+			for j, id := range line.UserIDs {
+				if id <= 0 || seen[id] {
+					return nil, nil, splitError("item %q has an invalid or repeated user", line.Name)
+				}
+				seen[id] = true
+				users[j] = models.SplitInput{UserID: id}
+			}
+			weights := make([]int64, len(users))
+			for j := range weights {
+				weights[j] = 1
+			}
+
+			amounts := apportion(line.Price, weights, users)
+			line.Shares = make([]models.ItemShare, len(users))
+			for j, u := range users {
+				line.Shares[j] = models.ItemShare{UserID: u.UserID, Amount: amounts[j]}
+				subtotals[u.UserID] += amounts[j]
+			}
+			receipt.Subtotal += line.Price
+		}
+
+		receipt.Items[i] = line
+	}
+
+	receipt.Total = receipt.Subtotal + receipt.Tax - receipt.Discount
+	if receipt.Subtotal <= 0 || receipt.Total <= 0 {
+		return nil, nil, splitError("receipt total must be greater than 0")
+	}
+
+	// users whose items were all free pay nothing and are left out of the split
+	var splits []models.SplitInput
+	var weights []int64
+	for id, subtotal := range subtotals {
+		if subtotal > 0 {
+			splits = append(splits, models.SplitInput{UserID: id})
+			weights = append(weights, int64(subtotal))
+		}
+	}
+	sort.Sort(byUserID{splits, weights})
+
+	amounts := apportion(receipt.Total, weights, splits)
+	result := make([]models.SplitInput, 0, len(splits))
+	for i, sp := range splits {
+		// a large discount can round a tiny share down to nothing
+		if amounts[i] > 0 {
+			result = append(result, models.SplitInput{UserID: sp.UserID, Amount: amounts[i]})
+		}
+	}
+	return receipt, result, nil
+}
+
+// byUserID orders split participants together with their weights
+type byUserID struct {
+	splits  []models.SplitInput
+	weights []int64
+}
+
+func (b byUserID) Len() int           { return len(b.splits) }
+func (b byUserID) Less(i, j int) bool { return b.splits[i].UserID < b.splits[j].UserID }
+func (b byUserID) Swap(i, j int) {
+	b.splits[i], b.splits[j] = b.splits[j], b.splits[i]
+	b.weights[i], b.weights[j] = b.weights[j], b.weights[i]
+}
@@ -13,8 +13,9 @@ import (
 )
 
 type RoomService struct {
-	repo  repository.RoomRepository
-	cache *redis.Client
+	repo   repository.RoomRepository
+	cache  *redis.Client
+	outbox IOutboxService
 }
 
 type IRoomService interface {
@@ -24,8 +25,8 @@ type IRoomService interface {
 	DeleteRoom(ctx context.Context, roomID int) error
 }
 
-func NewRoomService(repo repository.RoomRepository, cache *redis.Client) *RoomService {
-	return &RoomService{repo: repo, cache: cache}
+func NewRoomService(repo repository.RoomRepository, cache *redis.Client, outbox IOutboxService) *RoomService {
+	return &RoomService{repo: repo, cache: cache, outbox: outbox}
 }
 
 func (s *RoomService) CreateRoom(ctx context.Context, name string, homeID, createdBy int) error {
@@ -40,17 +41,17 @@ func (s *RoomService) CreateRoom(ctx context.Context, name string, homeID, creat
 		HomeID:    homeID,
 		CreatedBy: createdBy,
 	}
-	if err := s.repo.Create(ctx, room); err != nil {
-		return err
-	}
-
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleRoom,
-		Action: event.ActionCreated,
-		Data:   room,
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, room); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleRoom,
+			Action: event.ActionCreated,
+			Data:   room,
+		})
 	})
-
-	return nil
 }
 
 func (s *RoomService) GetRoomByID(ctx context.Context, roomID int) (*models.Room, error) {
@@ -115,15 +116,15 @@ func (s *RoomService) DeleteRoom(ctx context.Context, roomID int) error {
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", roomsKey, err)
 	}
 
-	if err := s.repo.Delete(ctx, roomID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, roomID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleRoom,
-		Action: event.ActionDeleted,
-		Data:   room,
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleRoom,
+			Action: event.ActionDeleted,
+			Data:   room,
+		})
 	})
-
-	return nil
 }
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/logger"
+	"github.com/Dragodui/diploma-server/internal/metrics"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/repository"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrSelfSettlement = errors.New("cannot record a payment to yourself")
+
+type SettlementService struct {
+	repo     repository.SettlementRepository
+	billRepo repository.BillRepository
+	homeRepo repository.HomeRepository
+	cache    *redis.Client
+	notifSvc INotificationService
+	outbox   IOutboxService
+}
+
+type ISettlementService interface {
+	RecordSettlement(ctx context.Context, homeID, fromID, toID int, amount models.Money, note string) (*models.Settlement, error)
+	GetSettlementsByHomeID(ctx context.Context, homeID int) ([]models.Settlement, error)
+}
+
+func NewSettlementService(repo repository.SettlementRepository, billRepo repository.BillRepository, homeRepo repository.HomeRepository, cache *redis.Client, notifSvc INotificationService, outbox IOutboxService) *SettlementService {
+	return &SettlementService{repo: repo, billRepo: billRepo, homeRepo: homeRepo, cache: cache, notifSvc: notifSvc, outbox: outbox}
+}
+
+func (s *SettlementService) RecordSettlement(ctx context.Context, homeID, fromID, toID int, amount models.Money, note string) (*models.Settlement, error) {
+	if fromID == toID {
+		return nil, ErrSelfSettlement
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+
+	// payments are always recorded in the home currency
+	currency, err := homeCurrency(ctx, s.homeRepo, homeID)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement := &models.Settlement{
+		HomeID:     homeID,
+		FromUserID: fromID,
+		ToUserID:   toID,
+		Amount:     amount,
+		Currency:   currency,
+		Note:       note,
+	}
+
+	var billIDs []int
+	err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, settlement); err != nil {
+			return err
+		}
+
+		var err error
+		billIDs, err = reconcileSplits(ctx, s.billRepo, s.repo, homeID, fromID, toID)
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleBill,
+			Action: event.ActionSettled,
+			Data:   settlement,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.BillOperationsTotal.WithLabelValues("settle").Inc()
+
+	invalidateBills(ctx, s.cache, homeID, billIDs)
+
+	// Let the receiver know the money is on its way
+	from := fromID
+	_ = s.notifSvc.Create(ctx, &from, toID, "You received a payment of "+formatMoney(settlement.Amount, settlement.Currency))
+
+	return settlement, nil
+}
+
+func (s *SettlementService) GetSettlementsByHomeID(ctx context.Context, homeID int) ([]models.Settlement, error) {
+	return s.repo.FindByHomeID(ctx, homeID)
+}
+
+// reconcileSplits derives the paid state of the debtor's splits towards the creditor
+// from the settlements between them. Payments made for a specific split cover that
+// split first, everything else pays off the oldest bills first. It returns the IDs
+// of bills whose splits changed.
+func reconcileSplits(ctx context.Context, billRepo repository.BillRepository, settlementRepo repository.SettlementRepository, homeID, debtorID, creditorID int) ([]int, error) {
+	splits, paid, err := allocatePayments(ctx, billRepo, settlementRepo, homeID, debtorID, creditorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var billIDs []int
+	for i, sp := range splits {
+		isPaid := paid[i] >= splitInBase(sp)
+
+		// paid amounts are shown in the bill's own currency
+		paidAmount := sp.Amount
+		if !isPaid {
+			paidAmount = models.MoneyFromFloat(paid[i].Float() / splitRate(sp))
+		}
+
+		if sp.PaidAmount == paidAmount && sp.Paid == isPaid {
+			continue
+		}
+		if err := billRepo.SetSplitPayment(ctx, sp.ID, paidAmount, isPaid); err != nil {
+			return nil, err
+		}
+		billIDs = append(billIDs, sp.BillID)
+	}
+
+	return billIDs, nil
+}
+
+// outstandingOnSplit returns how much is still owed on one split, in the home currency
+func outstandingOnSplit(ctx context.Context, billRepo repository.BillRepository, settlementRepo repository.SettlementRepository, homeID, debtorID, creditorID, splitID int) (models.Money, error) {
+	splits, paid, err := allocatePayments(ctx, billRepo, settlementRepo, homeID, debtorID, creditorID)
+	if err != nil {
+		return 0, err
+	}
+	for i, sp := range splits {
+		if sp.ID == splitID {
+			return max(splitInBase(sp)-paid[i], 0), nil
+		}
+	}
+	return 0, nil
+}
+
+// allocatePayments spreads the settlements from debtor to creditor over the debtor's
+// splits and returns the splits with the home currency amount paid on each.
+func allocatePayments(ctx context.Context, billRepo repository.BillRepository, settlementRepo repository.SettlementRepository, homeID, debtorID, creditorID int) ([]models.BillSplit, []models.Money, error) {
+	if debtorID == creditorID {
+		return nil, nil, nil
+	}
+
+	splits, err := billRepo.FindSplitsBetween(ctx, homeID, debtorID, creditorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	settlements, err := settlementRepo.FindBetween(ctx, homeID, debtorID, creditorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pinned := make(map[int]models.Money)
+	for _, sp := range splits {
+		pinned[sp.ID] = 0
+	}
+	var pool models.Money
+	for _, st := range settlements {
+		if st.SplitID != nil {
+			if _, ok := pinned[*st.SplitID]; ok {
+				pinned[*st.SplitID] += st.Amount
+				continue
+			}
+		}
+		pool += st.Amount
+	}
+
+	paid := make([]models.Money, len(splits))
+	for i, sp := range splits {
+		paid[i] = min(pinned[sp.ID], splitInBase(sp))
+		pool += pinned[sp.ID] - paid[i]
+	}
+	for i, sp := range splits {
+		extra := min(splitInBase(sp)-paid[i], pool)
+		paid[i] += extra
+		pool -= extra
+	}
+
+	return splits, paid, nil
+}
+
+// splitInBase is the split's amount converted to the home currency
+func splitInBase(sp models.BillSplit) models.Money {
+	return models.MoneyFromFloat(sp.Amount.Float() * splitRate(sp))
+}
+
+func splitRate(sp models.BillSplit) float64 {
+	if sp.Bill == nil || sp.Bill.ExchangeRate <= 0 {
+		return 1
+	}
+	return sp.Bill.ExchangeRate
+}
+
+// invalidateBills drops the cached bills and every cached bill list of the home
+func invalidateBills(ctx context.Context, cache *redis.Client, homeID int, billIDs []int) {
+	for _, id := range billIDs {
+		key := utils.GetBillKey(id)
+		if err := utils.DeleteFromCache(ctx, key, cache); err != nil {
+			logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
+		}
+	}
+
+	key := utils.GetBillsVersionKey(homeID)
+	if err := cache.Incr(ctx, key).Err(); err != nil {
+		logger.Info.Printf("Failed to bump bill list version [%s]: %v", key, err)
+	}
+}
@@ -17,8 +17,9 @@ import (
 var errCategoryNotBelongsToHome error = errors.New("this category does not belongs to this home")
 
 type ShoppingService struct {
-	repo  repository.ShoppingRepository
-	cache *redis.Client
+	repo   repository.ShoppingRepository
+	cache  *redis.Client
+	outbox IOutboxService
 }
 
 type IShoppingService interface {
@@ -36,12 +37,17 @@ type IShoppingService interface {
 	DeleteItem(ctx context.Context, itemID int) error
 	MarkIsBought(ctx context.Context, itemID int) error
 	EditItem(ctx context.Context, itemID int, name, image, link *string, isBought *bool, boughtAt *time.Time) error
+
+	// receipts
+	ReconcileReceipt(ctx context.Context, homeID int, items []models.OCRItem) (*models.ShoppingReconciliation, error)
+	ConfirmPurchases(ctx context.Context, homeID int, purchases []models.ShoppingPurchase) error
 }
 
-func NewShoppingService(repo repository.ShoppingRepository, cache *redis.Client) *ShoppingService {
+func NewShoppingService(repo repository.ShoppingRepository, cache *redis.Client, outbox IOutboxService) *ShoppingService {
 	return &ShoppingService{
 		repo,
 		cache,
+		outbox,
 	}
 }
 
@@ -58,18 +64,22 @@ func (s *ShoppingService) CreateCategory(ctx context.Context, name string, icon
 		HomeID:    homeID,
 		CreatedBy: createdBy,
 	}
-	if err := s.repo.CreateCategory(ctx, category); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateCategory(ctx, category); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleShoppingCategory,
+			Action: event.ActionCreated,
+			Data:   category,
+		})
+	}); err != nil {
 		return err
 	}
 
 	metrics.ShoppingOperationsTotal.WithLabelValues("create_category").Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleShoppingCategory,
-		Action: event.ActionCreated,
-		Data:   category,
-	})
-
 	return nil
 }
 
@@ -135,18 +145,22 @@ func (s *ShoppingService) DeleteCategory(ctx context.Context, categoryID, homeID
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", categoryKey, err)
 	}
 
-	if err := s.repo.DeleteCategory(ctx, categoryID); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteCategory(ctx, categoryID); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleShoppingCategory,
+			Action: event.ActionDeleted,
+			Data:   map[string]int{"id": categoryID},
+		})
+	}); err != nil {
 		return err
 	}
 
 	metrics.ShoppingOperationsTotal.WithLabelValues("delete_category").Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleShoppingCategory,
-		Action: event.ActionDeleted,
-		Data:   map[string]int{"id": categoryID},
-	})
-
 	return nil
 }
 
@@ -183,17 +197,17 @@ func (s *ShoppingService) EditCategory(ctx context.Context, categoryID, homeID i
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", categoryKey, err)
 	}
 
-	if err := s.repo.EditCategory(ctx, category, updates); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.EditCategory(ctx, category, updates); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleShoppingCategory,
-		Action: event.ActionUpdated,
-		Data:   category,
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleShoppingCategory,
+			Action: event.ActionUpdated,
+			Data:   category,
+		})
 	})
-
-	return nil
 }
 
 // items
@@ -211,19 +225,23 @@ func (s *ShoppingService) CreateItem(ctx context.Context, categoryID int, userID
 		Link:       link,
 		UploadedBy: userID,
 	}
-	if err := s.repo.CreateItem(ctx, item); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateItem(ctx, item); err != nil {
+			return err
+		}
+
+		return s.addItemEvent(ctx, categoryID, &event.RealTimeEvent{
+			Module: event.ModuleShoppingItem,
+			Action: event.ActionCreated,
+			Data:   item,
+		})
+	}); err != nil {
 		return err
 	}
 
 	metrics.ShoppingItemsTotal.Inc()
 	metrics.ShoppingOperationsTotal.WithLabelValues("create_item").Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleShoppingItem,
-		Action: event.ActionCreated,
-		Data:   item,
-	})
-
 	return nil
 }
 
@@ -251,19 +269,23 @@ func (s *ShoppingService) DeleteItem(ctx context.Context, itemID int) error {
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.DeleteItem(ctx, itemID); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteItem(ctx, itemID); err != nil {
+			return err
+		}
+
+		return s.addItemEvent(ctx, categoryID, &event.RealTimeEvent{
+			Module: event.ModuleShoppingItem,
+			Action: event.ActionDeleted,
+			Data:   item,
+		})
+	}); err != nil {
 		return err
 	}
 
 	metrics.ShoppingItemsTotal.Dec()
 	metrics.ShoppingOperationsTotal.WithLabelValues("delete_item").Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleShoppingItem,
-		Action: event.ActionDeleted,
-		Data:   item,
-	})
-
 	return nil
 }
 
@@ -283,22 +305,22 @@ func (s *ShoppingService) MarkIsBought(ctx context.Context, itemID int) error {
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.MarkIsBought(ctx, itemID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkIsBought(ctx, itemID); err != nil {
+			return err
+		}
 
-	updatedItem, err := s.repo.FindItemByID(ctx, itemID)
-	if err != nil {
-		logger.Info.Printf("Failed to fetch updated item %d for event: %v", itemID, err)
-	}
+		updatedItem, err := s.repo.FindItemByID(ctx, itemID)
+		if err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleShoppingItem,
-		Action: event.ActionUpdated,
-		Data:   updatedItem,
+		return s.addItemEvent(ctx, categoryID, &event.RealTimeEvent{
+			Module: event.ModuleShoppingItem,
+			Action: event.ActionUpdated,
+			Data:   updatedItem,
+		})
 	})
-
-	return nil
 }
 
 func (s *ShoppingService) EditItem(ctx context.Context, itemID int, name, image, link *string, isBought *bool, boughtAt *time.Time) error {
@@ -341,15 +363,29 @@ func (s *ShoppingService) EditItem(ctx context.Context, itemID int, name, image,
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.EditItem(ctx, item, updates); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.EditItem(ctx, item, updates); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleShoppingItem,
-		Action: event.ActionUpdated,
-		Data:   item,
+		return s.addItemEvent(ctx, categoryID, &event.RealTimeEvent{
+			Module: event.ModuleShoppingItem,
+			Action: event.ActionUpdated,
+			Data:   item,
+		})
 	})
+}
 
-	return nil
+// addItemEvent adds an item event for the home that owns the item's category to the outbox.
+func (s *ShoppingService) addItemEvent(ctx context.Context, categoryID int, e *event.RealTimeEvent) error {
+	category, err := s.repo.FindCategoryByID(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		logger.Info.Printf("Failed to resolve home for shopping category %d", categoryID)
+		return nil
+	}
+
+	return s.outbox.Add(ctx, event.HomeChannel(category.HomeID), e)
 }
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/logger"
+	"github.com/Dragodui/diploma-server/internal/metrics"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/utils"
+)
+
+// names at least this alike are proposed as the same thing
+const shoppingMatchThreshold = 0.75
+
+var ErrShoppingItemNotOpen = errors.New("shopping item is not open in this home")
+
+// ReconcileReceipt proposes which of the home's open shopping items a receipt covers. The most
+// alike names are paired first, and each list item and receipt line is used at most once.
+func (s *ShoppingService) ReconcileReceipt(ctx context.Context, homeID int, items []models.OCRItem) (*models.ShoppingReconciliation, error) {
+	open, err := s.repo.FindOpenItemsForHome(ctx, homeID)
+	if err != nil {
+		return nil, err
+	}
+
+	type pair struct {
+		item, line int
+		score      float64
+	}
+	var pairs []pair
+	lines := make([][]string, len(items))
+	for j, line := range items {
+		if isProductLine(line) {
+			lines[j] = shoppingWords(line.Name)
+		}
+	}
+	for i, item := range open {
+		words := shoppingWords(item.Name)
+		for j := range items {
+			if score := shoppingNameSimilarity(words, lines[j]); score >= shoppingMatchThreshold {
+				pairs = append(pairs, pair{item: i, line: j, score: score})
+			}
+		}
+	}
+	// on a tie the item longest on the list wins
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].score > pairs[b].score })
+
+	matched := make(map[int]models.ShoppingMatch)
+	usedItems := make(map[int]bool)
+	for _, p := range pairs {
+		if _, ok := matched[p.line]; ok || usedItems[p.item] {
+			continue
+		}
+		usedItems[p.item] = true
+		matched[p.line] = models.ShoppingMatch{Item: open[p.item], ReceiptItem: items[p.line], Score: math.Round(p.score*100) / 100}
+	}
+
+	result := &models.ShoppingReconciliation{Matches: []models.ShoppingMatch{}, Unmatched: []models.OCRItem{}}
+	for j, line := range items {
+		if match, ok := matched[j]; ok {
+			result.Matches = append(result.Matches, match)
+		} else if isProductLine(line) {
+			result.Unmatched = append(result.Unmatched, line)
+		}
+	}
+	return result, nil
+}
+
+// ConfirmPurchases marks the accepted matches bought and records the prices paid. The items
+// are locked while they are checked, so when two people confirm the same receipt the second
+// finds them bought and nothing is marked twice.
+func (s *ShoppingService) ConfirmPurchases(ctx context.Context, homeID int, purchases []models.ShoppingPurchase) error {
+	ids := make([]int, 0, len(purchases))
+	seen := make(map[int]bool, len(purchases))
+	for _, purchase := range purchases {
+		if purchase.Price != nil && *purchase.Price < 0 {
+			return models.ErrInvalidMoney
+		}
+		// the second of the two would find the item bought already
+		if seen[purchase.ItemID] {
+			return ErrShoppingItemNotOpen
+		}
+		seen[purchase.ItemID] = true
+		ids = append(ids, purchase.ItemID)
+	}
+
+	var bought []models.ShoppingItem
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		items, err := s.repo.FindItemsForUpdate(ctx, homeID, ids)
+		if err != nil {
+			return err
+		}
+		open := make(map[int]models.ShoppingItem, len(items))
+		for _, item := range items {
+			if !item.IsBought {
+				open[item.ID] = item
+			}
+		}
+
+		now := time.Now()
+		for _, purchase := range purchases {
+			item, ok := open[purchase.ItemID]
+			if !ok {
+				return ErrShoppingItemNotOpen
+			}
+
+			updates := map[string]interface{}{"is_bought": true, "bought_date": &now}
+			item.IsBought, item.BoughtDate = true, &now
+			if purchase.Price != nil {
+				updates["price"] = *purchase.Price
+				item.Price = purchase.Price
+			}
+			if err := s.repo.EditItem(ctx, &item, updates); err != nil {
+				return err
+			}
+			if err := s.addItemEvent(ctx, item.CategoryID, &event.RealTimeEvent{
+				Module: event.ModuleShoppingItem,
+				Action: event.ActionUpdated,
+				Data:   item,
+			}); err != nil {
+				return err
+			}
+			bought = append(bought, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range bought {
+		key := utils.GetCategoryKey(item.CategoryID)
+		if err := utils.DeleteFromCache(ctx, key, s.cache); err != nil {
+			logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
+		}
+	}
+	metrics.ShoppingOperationsTotal.WithLabelValues("confirm_purchases").Inc()
+	return nil
+}
+
+// isProductLine tells goods from tax, discount and deposit lines, which nobody puts on a list
+func isProductLine(line models.OCRItem) bool {
+	return line.Kind == "" || line.Kind == models.ReceiptLineItem
+}
+
+// shoppingNameSimilarity scores from 0 to 1 how well a receipt line names a list item. Mostly
+// it is how well the list words are found on the line; extra words on the line count a little
+// against it, so "milk" prefers "MILK 1L" to "MILK CHOCOLATE".
+func shoppingNameSimilarity(item, line []string) float64 {
+	if len(item) == 0 || len(line) == 0 {
+		return 0
+	}
+	return 0.85*wordCoverage(item, line) + 0.15*wordCoverage(line, item)
+}
+
+// wordCoverage is the mean over words of the best match each has in other
+func wordCoverage(words, other []string) float64 {
+	total := 0.0
+	for _, w := range words {
+		best := 0.0
+		for _, o := range other {
+			best = math.Max(best, wordSimilarity(w, o))
+		}
+		total += best
+	}
+	return total / float64(len(words))
+}
+
+// wordSimilarity compares two words, taking a word cut short on the receipt ("POMID") or a
+// plural ("apples") as nearly the same
+func wordSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(ra) >= 3 && strings.HasPrefix(string(rb), string(ra)) {
+		return 0.9
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(len(rb))
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// shoppingWords splits a product name into the words that say what it is: lowercase, without
+// accents, sizes or units, as receipts often print neither
+func shoppingWords(name string) []string {
+	fields := strings.FieldsFunc(shoppingAccents.Replace(strings.ToLower(name)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := []string{}
+	for _, w := range fields {
+		if letterCount(w) < 2 || strings.IndexFunc(w, unicode.IsDigit) >= 0 || shoppingUnitWords[w] {
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+var shoppingUnitWords = map[string]bool{
+	"kg": true, "ml": true, "szt": true, "pcs": true, "pc": true, "stk": true, "st": true, "op": true, "opak": true, "pack": true, "шт": true,
+}
+
+var shoppingAccents = strings.NewReplacer(
+	"ą", "a", "ć", "c", "ę", "e", "ł", "l", "ń", "n", "ó", "o", "ś", "s", "ź", "z", "ż", "z",
+	"ä", "a", "ö", "o", "ü", "u", "ß", "ss", "à", "a", "â", "a", "á", "a", "ç", "c", "é", "e", "è", "e", "ê", "e",
+	"í", "i", "î", "i", "ï", "i", "ñ", "n", "ô", "o", "ú", "u", "ù", "u", "û", "u", "č", "c", "ě", "e", "ř", "r",
+	"š", "s", "ů", "u", "ý", "y", "ž", "z", "ø", "o", "å", "a",
+)
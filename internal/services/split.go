@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+)
+
+// ErrInvalidSplit is wrapped by every split validation error so handlers can report it as bad input
+var ErrInvalidSplit = errors.New("invalid split")
+
+func splitError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSplit, fmt.Sprintf(format, args...))
+}
+
+// computeSplits turns the requested split into per-user amounts that add up to the
+// bill total exactly. Remainder cents go to the largest fractional shares, ties
+// broken by the lowest user ID, so the same input always gives the same result.
+func computeSplits(mode string, total models.Money, splits []models.SplitInput) ([]models.BillSplit, error) {
+	if mode == "" {
+		mode = models.SplitModeExact
+	}
+	if len(splits) == 0 {
+		return nil, splitError("at least one participant is required")
+	}
+
+	seen := make(map[int]bool)
+	for _, sp := range splits {
+		if sp.UserID <= 0 {
+			return nil, splitError("user_id is required for every participant")
+		}
+		if seen[sp.UserID] {
+			return nil, splitError("user %d appears more than once", sp.UserID)
+		}
+		seen[sp.UserID] = true
+	}
+
+	weights := make([]int64, len(splits))
+	switch mode {
+	case models.SplitModeEqual:
+		for i := range splits {
+			weights[i] = 1
+		}
+
+	case models.SplitModePercent:
+		// percentages are compared in hundredths so 33.33 + 33.33 + 33.34 is exactly 100
+		var sum int64
+		for i, sp := range splits {
+			weights[i] = int64(math.Round(sp.Percent * 100))
+			if weights[i] <= 0 {
+				return nil, splitError("percent must be greater than 0")
+			}
+			sum += weights[i]
+		}
+		if sum != 100*100 {
+			return nil, splitError("percentages add up to %.2f, not 100", float64(sum)/100)
+		}
+
+	case models.SplitModeShares:
+		for i, sp := range splits {
+			if sp.Shares <= 0 {
+				return nil, splitError("shares must be greater than 0")
+			}
+			if sp.Shares > models.MaxSplitShares {
+				return nil, splitError("shares must not exceed %d", models.MaxSplitShares)
+			}
+			weights[i] = int64(sp.Shares)
+		}
+
+	case models.SplitModeExact:
+		var sum models.Money
+		result := make([]models.BillSplit, len(splits))
+		for i, sp := range splits {
+			if sp.Amount <= 0 {
+				return nil, splitError("split amount must be greater than 0")
+			}
+			sum += sp.Amount
+			result[i] = models.BillSplit{UserID: sp.UserID, Amount: sp.Amount}
+		}
+		if sum != total {
+			return nil, splitError("split amounts add up to %s, bill total is %s", sum, total)
+		}
+		return result, nil
+
+	default:
+		return nil, splitError("unknown split mode %q", mode)
+	}
+
+	if total < 0 {
+		return nil, splitError("bill total must not be negative")
+	}
+	amounts := apportion(total, weights, splits)
+	result := make([]models.BillSplit, len(splits))
+	for i, sp := range splits {
+		result[i] = models.BillSplit{UserID: sp.UserID, Amount: amounts[i]}
+	}
+	return result, nil
+}
+
+// apportion divides the total by weight using the largest remainder method. The products
+// are taken in 128 bits, so a large total times a large weight can't overflow.
+func apportion(total models.Money, weights []int64, splits []models.SplitInput) []models.Money {
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+
+	amounts := make([]models.Money, len(weights))
+	remainders := make([]int64, len(weights))
+	left := int64(total)
+	for i, w := range weights {
+		// w <= sum, so the quotient fits in the total
+		hi, lo := bits.Mul64(uint64(total), uint64(w))
+		quo, rem := bits.Div64(hi, lo, uint64(sum))
+		amounts[i] = models.Money(quo)
+		remainders[i] = int64(rem)
+		left -= int64(amounts[i])
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		ia, ib := order[a], order[b]
+		if remainders[ia] != remainders[ib] {
+			return remainders[ia] > remainders[ib]
+		}
+		return splits[ia].UserID < splits[ib].UserID
+	})
+	for i := int64(0); i < left; i++ {
+		amounts[order[i]]++
+	}
+
+	return amounts
+}
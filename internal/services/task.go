@@ -19,6 +19,7 @@ type TaskService struct {
 	repo     repository.TaskRepository
 	cache    *redis.Client
 	notifSvc INotificationService
+	outbox   IOutboxService
 }
 
 type ITaskService interface {
@@ -37,8 +38,8 @@ type ITaskService interface {
 	GetAssignmentUser(ctx context.Context, assignmentID int) (*models.User, error)
 }
 
-func NewTaskService(repo repository.TaskRepository, cache *redis.Client, notifSvc INotificationService) *TaskService {
-	return &TaskService{repo: repo, cache: cache, notifSvc: notifSvc}
+func NewTaskService(repo repository.TaskRepository, cache *redis.Client, notifSvc INotificationService, outbox IOutboxService) *TaskService {
+	return &TaskService{repo: repo, cache: cache, notifSvc: notifSvc, outbox: outbox}
 }
 
 func (s *TaskService) CreateTask(ctx context.Context, homeID int, roomID *int, name, description, scheduleType string, dueDate *time.Time, createdBy int, userIDs []int) error {
@@ -56,16 +57,31 @@ func (s *TaskService) CreateTask(ctx context.Context, homeID int, roomID *int, n
 		ScheduleType: scheduleType,
 		DueDate:      dueDate,
 	}
-	if err := s.repo.Create(ctx, task); err != nil {
-		return err
-	}
 
-	// Assign to users
 	now := time.Now()
-	for _, uid := range userIDs {
-		if err := s.repo.AssignUser(ctx, task.ID, uid, now); err != nil {
+	err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, task); err != nil {
 			return err
 		}
+
+		// Assign to users
+		for _, uid := range userIDs {
+			if err := s.repo.AssignUser(ctx, task.ID, uid, now); err != nil {
+				return err
+			}
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleTask,
+			Action: event.ActionCreated,
+			Data:   task,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range userIDs {
 		// Invalidate user assignments cache
 		userAssignmentsKey := utils.GetAssignmentsForUserKey(uid, homeID)
 		if err := utils.DeleteFromCache(ctx, userAssignmentsKey, s.cache); err != nil {
@@ -85,12 +101,6 @@ func (s *TaskService) CreateTask(ctx context.Context, homeID int, roomID *int, n
 	metrics.TasksTotal.WithLabelValues("active").Inc()
 	metrics.TaskOperationsTotal.WithLabelValues("create").Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleTask,
-		Action: event.ActionCreated,
-		Data:   task,
-	})
-
 	return nil
 }
 
@@ -151,7 +161,17 @@ func (s *TaskService) DeleteTask(ctx context.Context, taskID int) error {
 		return errors.New("task not found")
 	}
 
-	if err := s.repo.Delete(ctx, taskID); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, taskID); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(task.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleTask,
+			Action: event.ActionDeleted,
+			Data:   task,
+		})
+	}); err != nil {
 		return err
 	}
 
@@ -170,12 +190,6 @@ func (s *TaskService) DeleteTask(ctx context.Context, taskID int) error {
 	metrics.TasksTotal.WithLabelValues("active").Dec()
 	metrics.TaskOperationsTotal.WithLabelValues("delete").Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleTask,
-		Action: event.ActionDeleted,
-		Data:   task,
-	})
-
 	return nil
 }
 
@@ -192,7 +206,17 @@ func (s *TaskService) AssignUser(ctx context.Context, taskID, userID, homeID int
 		logger.Info.Printf("Failed to delete redis cache for home %d: %v", homeID, err)
 	}
 
-	if err := s.repo.AssignUser(ctx, taskID, userID, date); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.AssignUser(ctx, taskID, userID, date); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleTask,
+			Action: event.ActionAssigned,
+			Data:   map[string]int{"taskID": taskID, "userID": userID},
+		})
+	}); err != nil {
 		return err
 	}
 
@@ -205,12 +229,6 @@ func (s *TaskService) AssignUser(ctx context.Context, taskID, userID, homeID int
 
 	metrics.TaskOperationsTotal.WithLabelValues("assign").Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleTask,
-		Action: event.ActionAssigned,
-		Data:   map[string]int{"taskID": taskID, "userID": userID},
-	})
-
 	return nil
 }
 
@@ -294,18 +312,22 @@ func (s *TaskService) MarkAssignmentCompleted(ctx context.Context, assignmentID
 		logger.Info.Printf("Failed to delete redis cache for home %d: %v", assignment.Task.HomeID, err)
 	}
 
-	if err := s.repo.MarkCompleted(ctx, assignmentID); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkCompleted(ctx, assignmentID); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(assignment.Task.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleTask,
+			Action: event.ActionCompleted,
+			Data:   assignment,
+		})
+	}); err != nil {
 		return err
 	}
 
 	metrics.TaskOperationsTotal.WithLabelValues("complete").Inc()
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleTask,
-		Action: event.ActionCompleted,
-		Data:   assignment,
-	})
-
 	return nil
 }
 
@@ -342,17 +364,17 @@ func (s *TaskService) MarkAssignmentUncompleted(ctx context.Context, assignmentI
 		logger.Info.Printf("Failed to delete redis cache for home %d: %v", assignment.Task.HomeID, err)
 	}
 
-	if err := s.repo.MarkUncompleted(ctx, assignmentID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkUncompleted(ctx, assignmentID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleTask,
-		Action: event.ActionUncompleted,
-		Data:   assignment,
+		return s.outbox.Add(ctx, event.HomeChannel(assignment.Task.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleTask,
+			Action: event.ActionUncompleted,
+			Data:   assignment,
+		})
 	})
-
-	return nil
 }
 
 func (s *TaskService) MarkTaskCompletedForUser(ctx context.Context, taskID, userID, homeID int) error {
@@ -398,17 +420,17 @@ func (s *TaskService) MarkTaskCompletedForUser(ctx context.Context, taskID, user
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", key, err)
 	}
 
-	if err := s.repo.MarkCompleted(ctx, assignment.ID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkCompleted(ctx, assignment.ID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleTask,
-		Action: event.ActionCompleted,
-		Data:   assignment,
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleTask,
+			Action: event.ActionCompleted,
+			Data:   assignment,
+		})
 	})
-
-	return nil
 }
 
 func (s *TaskService) DeleteAssignment(ctx context.Context, assignmentID int) error {
@@ -437,17 +459,17 @@ func (s *TaskService) DeleteAssignment(ctx context.Context, assignmentID int) er
 	if err := utils.DeleteFromCache(ctx, userClosestAssignmentsKey, s.cache); err != nil {
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", userClosestAssignmentsKey, err)
 	}
-	if err := s.repo.DeleteAssignment(ctx, assignmentID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteAssignment(ctx, assignmentID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleTask,
-		Action: event.ActionDeleted,
-		Data:   map[string]int{"assignmentID": assignmentID},
+		return s.outbox.Add(ctx, event.HomeChannel(assignment.Task.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleTask,
+			Action: event.ActionDeleted,
+			Data:   map[string]int{"assignmentID": assignmentID},
+		})
 	})
-
-	return nil
 }
 
 func (s *TaskService) GetAssignmentUser(ctx context.Context, assignmentID int) (*models.User, error) {
@@ -478,15 +500,15 @@ func (s *TaskService) ReassignRoom(ctx context.Context, taskID, roomID int) erro
 		logger.Info.Printf("Failed to delete redis cache for key %s: %v", homeTasksKey, err)
 	}
 
-	if err := s.repo.ReassignRoom(ctx, taskID, roomID); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.ReassignRoom(ctx, taskID, roomID); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleTask,
-		Action: event.ActionUpdated,
-		Data:   task,
+		return s.outbox.Add(ctx, event.HomeChannel(task.HomeID), &event.RealTimeEvent{
+			Module: event.ModuleTask,
+			Action: event.ActionUpdated,
+			Data:   task,
+		})
 	})
-
-	return nil
 }
@@ -27,10 +27,11 @@ type TaskScheduleService struct {
 	taskRepo repository.TaskRepository
 	cache    *redis.Client
 	notifSvc INotificationService
+	outbox   IOutboxService
 }
 
-func NewTaskScheduleService(repo repository.TaskScheduleRepository, taskRepo repository.TaskRepository, cache *redis.Client, notifSvc INotificationService) *TaskScheduleService {
-	return &TaskScheduleService{repo: repo, taskRepo: taskRepo, cache: cache, notifSvc: notifSvc}
+func NewTaskScheduleService(repo repository.TaskScheduleRepository, taskRepo repository.TaskRepository, cache *redis.Client, notifSvc INotificationService, outbox IOutboxService) *TaskScheduleService {
+	return &TaskScheduleService{repo: repo, taskRepo: taskRepo, cache: cache, notifSvc: notifSvc, outbox: outbox}
 }
 
 func (s *TaskScheduleService) CreateSchedule(ctx context.Context, taskID, homeID int, recurrenceType string, userIDs []int) (*models.TaskSchedule, error) {
@@ -74,7 +75,17 @@ func (s *TaskScheduleService) CreateSchedule(ctx context.Context, taskID, homeID
 		IsActive:             true,
 	}
 
-	if err := s.repo.Create(ctx, schedule); err != nil {
+	if err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, schedule); err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+			Module: event.ModuleTask,
+			Action: event.ActionUpdated,
+			Data:   map[string]interface{}{"schedule": schedule, "task_id": taskID},
+		})
+	}); err != nil {
 		return nil, err
 	}
 
@@ -90,12 +101,6 @@ func (s *TaskScheduleService) CreateSchedule(ctx context.Context, taskID, homeID
 	// Invalidate caches
 	s.invalidateTaskCaches(ctx, taskID, homeID)
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleTask,
-		Action: event.ActionUpdated,
-		Data:   map[string]interface{}{"schedule": schedule, "task_id": taskID},
-	})
-
 	return schedule, nil
 }
 
@@ -156,44 +161,51 @@ func (s *TaskScheduleService) ProcessDueSchedules(ctx context.Context) error {
 		// Get the next user in rotation
 		nextUserID := userIDs[schedule.CurrentRotationIndex%len(userIDs)]
 
-		// Create assignment for this user
-		if err := s.taskRepo.AssignUser(ctx, schedule.TaskID, nextUserID, now); err != nil {
-			logger.Info.Printf("[Scheduler] Failed to assign user %d to task %d: %v", nextUserID, schedule.TaskID, err)
-			continue
-		}
-
-		// Notify the user about their rotation assignment
-		taskName := ""
+		homeID := 0
 		if schedule.Task != nil {
-			taskName = schedule.Task.Name
+			homeID = schedule.Task.HomeID
 		}
-		_ = s.notifSvc.Create(ctx, nil, nextUserID, "It's your turn! You've been assigned to task: "+taskName)
 
 		// Update rotation index and next run date
 		schedule.CurrentRotationIndex = (schedule.CurrentRotationIndex + 1) % len(userIDs)
 		schedule.NextRunDate = calcNextRunDate(now, schedule.RecurrenceType)
 
-		if err := s.repo.Update(ctx, schedule); err != nil {
-			logger.Info.Printf("[Scheduler] Failed to update schedule %d: %v", schedule.ID, err)
+		// Create assignment for this user and advance the rotation together
+		err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.taskRepo.AssignUser(ctx, schedule.TaskID, nextUserID, now); err != nil {
+				return err
+			}
+
+			if err := s.repo.Update(ctx, schedule); err != nil {
+				return err
+			}
+
+			if homeID == 0 {
+				return nil
+			}
+			return s.outbox.Add(ctx, event.HomeChannel(homeID), &event.RealTimeEvent{
+				Module: event.ModuleTask,
+				Action: event.ActionAssigned,
+				Data:   map[string]interface{}{"task_id": schedule.TaskID, "user_id": nextUserID, "scheduled": true},
+			})
+		})
+		if err != nil {
+			logger.Info.Printf("[Scheduler] Failed to assign user %d to task %d: %v", nextUserID, schedule.TaskID, err)
 			continue
 		}
 
-		// Invalidate caches
-		homeID := 0
+		// Notify the user about their rotation assignment
+		taskName := ""
 		if schedule.Task != nil {
-			homeID = schedule.Task.HomeID
+			taskName = schedule.Task.Name
 		}
+		_ = s.notifSvc.Create(ctx, nil, nextUserID, "It's your turn! You've been assigned to task: "+taskName)
+
+		// Invalidate caches
 		if homeID > 0 {
 			s.invalidateTaskCaches(ctx, schedule.TaskID, homeID)
 		}
 
-		// Send real-time event
-		event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-			Module: event.ModuleTask,
-			Action: event.ActionAssigned,
-			Data:   map[string]interface{}{"task_id": schedule.TaskID, "user_id": nextUserID, "scheduled": true},
-		})
-
 		logger.Info.Printf("[Scheduler] Assigned user %d to task %d (rotation %d/%d)", nextUserID, schedule.TaskID, schedule.CurrentRotationIndex, len(userIDs))
 	}
 
@@ -11,8 +11,9 @@ import (
 )
 
 type UserService struct {
-	repo  repository.UserRepository
-	cache *redis.Client
+	repo   repository.UserRepository
+	cache  *redis.Client
+	outbox IOutboxService
 }
 
 type IUserService interface {
@@ -21,8 +22,8 @@ type IUserService interface {
 	UpdateUserAvatar(ctx context.Context, userID int, imagePath string) error
 }
 
-func NewUserService(repo repository.UserRepository, redis *redis.Client) *UserService {
-	return &UserService{repo: repo, cache: redis}
+func NewUserService(repo repository.UserRepository, redis *redis.Client, outbox IOutboxService) *UserService {
+	return &UserService{repo: repo, cache: redis, outbox: outbox}
 }
 
 func (s *UserService) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
@@ -51,17 +52,17 @@ func (s *UserService) UpdateUser(ctx context.Context, userID int, name string) e
 	updates := map[string]interface{}{}
 	updates["name"] = name
 
-	if err := s.repo.Update(ctx, user, updates); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, user, updates); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleUser,
-		Action: event.ActionUpdated,
-		Data:   user,
+		return s.outbox.Add(ctx, event.UserChannel(userID), &event.RealTimeEvent{
+			Module: event.ModuleUser,
+			Action: event.ActionUpdated,
+			Data:   user,
+		})
 	})
-
-	return nil
 }
 
 func (s *UserService) UpdateUserAvatar(ctx context.Context, userID int, imagePath string) error {
@@ -76,15 +77,15 @@ func (s *UserService) UpdateUserAvatar(ctx context.Context, userID int, imagePat
 	updates := map[string]interface{}{}
 	updates["avatar"] = imagePath
 
-	if err := s.repo.Update(ctx, user, updates); err != nil {
-		return err
-	}
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, user, updates); err != nil {
+			return err
+		}
 
-	event.SendEvent(ctx, s.cache, "updates", &event.RealTimeEvent{
-		Module: event.ModuleUser,
-		Action: event.ActionUpdated,
-		Data:   user,
+		return s.outbox.Add(ctx, event.UserChannel(userID), &event.RealTimeEvent{
+			Module: event.ModuleUser,
+			Action: event.ActionUpdated,
+			Data:   user,
+		})
 	})
-
-	return nil
 }
@@ -0,0 +1,67 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannels(t *testing.T) {
+	assert.Equal(t, "events:home:7", event.HomeChannel(7))
+	assert.Equal(t, "events:user:7", event.UserChannel(7))
+	assert.NotEqual(t, event.HomeChannel(7), event.UserChannel(7))
+}
+
+func TestIsMembershipChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    event.RealTimeEvent
+		expected bool
+	}{
+		{"Member joined", event.RealTimeEvent{Module: event.ModuleHome, Action: event.ActionMemberJoined}, true},
+		{"Member left", event.RealTimeEvent{Module: event.ModuleHome, Action: event.ActionMemberLeft}, true},
+		{"Member removed", event.RealTimeEvent{Module: event.ModuleHome, Action: event.ActionMemberRemoved}, true},
+		{"Home created", event.RealTimeEvent{Module: event.ModuleHome, Action: event.ActionCreated}, true},
+		{"Home deleted", event.RealTimeEvent{Module: event.ModuleHome, Action: event.ActionDeleted}, true},
+		{"Home updated", event.RealTimeEvent{Module: event.ModuleHome, Action: event.ActionUpdated}, false},
+		{"Bill created", event.RealTimeEvent{Module: event.ModuleBill, Action: event.ActionCreated}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, event.IsMembershipChange(&tt.event))
+		})
+	}
+}
+
+func TestValidCursor(t *testing.T) {
+	tests := []struct {
+		cursor   string
+		expected bool
+	}{
+		{"1700000000000-0", true},
+		{"1700000000000-12", true},
+		{"1700000000000", true},
+		{"", false},
+		{"abc", false},
+		{"1700000000000-", false},
+		{"-5", false},
+		{"1700000000000-x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cursor, func(t *testing.T) {
+			assert.Equal(t, tt.expected, event.ValidCursor(tt.cursor))
+		})
+	}
+}
+
+func TestCompareIDs(t *testing.T) {
+	assert.Equal(t, 0, event.CompareIDs("1700000000000-1", "1700000000000-1"))
+	assert.Equal(t, 0, event.CompareIDs("1700000000000", "1700000000000-0"))
+	assert.Equal(t, -1, event.CompareIDs("1700000000000-1", "1700000000000-2"))
+	assert.Equal(t, 1, event.CompareIDs("1700000000001-0", "1700000000000-9"))
+	// sequence numbers compare numerically, not lexically
+	assert.Equal(t, -1, event.CompareIDs("1700000000000-9", "1700000000000-10"))
+}
@@ -0,0 +1,255 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBillImportService struct {
+	CreateProfileFunc func(ctx context.Context, homeID int, req models.CreateImportProfileRequest) (*models.ImportProfile, error)
+	PreviewFunc       func(ctx context.Context, homeID, userID, profileID int, fileName string, file io.Reader) (*models.BillImport, error)
+	GetImportFunc     func(ctx context.Context, homeID, importID int) (*models.BillImport, error)
+	CommitFunc        func(ctx context.Context, homeID, userID, importID int, req models.CommitImportRequest) (*models.ImportResult, error)
+}
+
+func (m *mockBillImportService) CreateProfile(ctx context.Context, homeID int, req models.CreateImportProfileRequest) (*models.ImportProfile, error) {
+	if m.CreateProfileFunc != nil {
+		return m.CreateProfileFunc(ctx, homeID, req)
+	}
+	return &models.ImportProfile{}, nil
+}
+
+func (m *mockBillImportService) GetProfiles(ctx context.Context, homeID int) ([]models.ImportProfile, error) {
+	return nil, nil
+}
+
+func (m *mockBillImportService) DeleteProfile(ctx context.Context, homeID, profileID int) error {
+	return nil
+}
+
+func (m *mockBillImportService) CreateRule(ctx context.Context, homeID int, req models.CreateVendorRuleRequest) (*models.VendorRule, error) {
+	return &models.VendorRule{}, nil
+}
+
+func (m *mockBillImportService) GetRules(ctx context.Context, homeID int) ([]models.VendorRule, error) {
+	return nil, nil
+}
+
+func (m *mockBillImportService) DeleteRule(ctx context.Context, homeID, ruleID int) error {
+	return nil
+}
+
+func (m *mockBillImportService) Preview(ctx context.Context, homeID, userID, profileID int, fileName string, file io.Reader) (*models.BillImport, error) {
+	if m.PreviewFunc != nil {
+		return m.PreviewFunc(ctx, homeID, userID, profileID, fileName, file)
+	}
+	return &models.BillImport{}, nil
+}
+
+func (m *mockBillImportService) GetImport(ctx context.Context, homeID, importID int) (*models.BillImport, error) {
+	if m.GetImportFunc != nil {
+		return m.GetImportFunc(ctx, homeID, importID)
+	}
+	return &models.BillImport{ID: importID, HomeID: homeID, UserID: 123}, nil
+}
+
+func (m *mockBillImportService) Commit(ctx context.Context, homeID, userID, importID int, req models.CommitImportRequest) (*models.ImportResult, error) {
+	if m.CommitFunc != nil {
+		return m.CommitFunc(ctx, homeID, userID, importID, req)
+	}
+	return &models.ImportResult{}, nil
+}
+
+func setupBillImportRouter(svc *mockBillImportService, homeRepo *mockHomeRepo) *chi.Mux {
+	h := handlers.NewBillImportHandler(svc, homeRepo)
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(utils.WithUserID(r.Context(), 123))
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Post("/homes/{home_id}/bills/import", h.Preview)
+	r.Post("/homes/{home_id}/bills/import/profiles", h.CreateProfile)
+	r.Post("/homes/{home_id}/bills/import/{import_id}/commit", h.Commit)
+	return r
+}
+
+func statementUpload(t *testing.T, profileID string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("profile_id", profileID))
+	part, err := writer.CreateFormFile("file", "march.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("Date,Description,Amount\n2025-03-01,Lidl,-12.50\n"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/homes/1/bills/import", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestBillImportHandler_Preview(t *testing.T) {
+	tests := []struct {
+		name           string
+		profileID      string
+		mockFunc       func(ctx context.Context, homeID, userID, profileID int, fileName string, file io.Reader) (*models.BillImport, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Success",
+			profileID: "4",
+			mockFunc: func(ctx context.Context, homeID, userID, profileID int, fileName string, file io.Reader) (*models.BillImport, error) {
+				require.Equal(t, 1, homeID)
+				require.Equal(t, 123, userID)
+				require.Equal(t, 4, profileID)
+				require.Equal(t, "march.csv", fileName)
+				data, err := io.ReadAll(file)
+				require.NoError(t, err)
+				require.Contains(t, string(data), "Lidl")
+				return &models.BillImport{ID: 9, Status: models.ImportStatusPreview}, nil
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"status":"preview"`,
+		},
+		{
+			name:           "Invalid Profile ID",
+			profileID:      "bank",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid profile ID",
+		},
+		{
+			name:      "Bad File",
+			profileID: "4",
+			mockFunc: func(ctx context.Context, homeID, userID, profileID int, fileName string, file io.Reader) (*models.BillImport, error) {
+				return nil, services.ErrInvalidImportFile
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid statement file",
+		},
+		{
+			name:      "Unknown Profile",
+			profileID: "4",
+			mockFunc: func(ctx context.Context, homeID, userID, profileID int, fileName string, file io.Reader) (*models.BillImport, error) {
+				return nil, services.ErrImportProfileNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupBillImportRouter(&mockBillImportService{PreviewFunc: tt.mockFunc}, &mockHomeRepo{})
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, statementUpload(t, tt.profileID))
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
+func TestBillImportHandler_Commit(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           interface{}
+		uploader       int
+		isAdmin        bool
+		commitErr      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Success",
+			body:           map[string]interface{}{"lines": []int{3}},
+			uploader:       123,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"created":1`,
+		},
+		{
+			name:           "Admin Commits Someone Else's Import",
+			uploader:       7,
+			isAdmin:        true,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Forbidden",
+			uploader:       7,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Already Committed",
+			uploader:       123,
+			commitErr:      services.ErrImportCommitted,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockBillImportService{
+				GetImportFunc: func(ctx context.Context, homeID, importID int) (*models.BillImport, error) {
+					return &models.BillImport{ID: importID, HomeID: homeID, UserID: tt.uploader}, nil
+				},
+				CommitFunc: func(ctx context.Context, homeID, userID, importID int, req models.CommitImportRequest) (*models.ImportResult, error) {
+					if tt.commitErr != nil {
+						return nil, tt.commitErr
+					}
+					require.Equal(t, 9, importID)
+					return &models.ImportResult{Created: len(req.Lines)}, nil
+				},
+			}
+			homeRepo := &mockHomeRepo{
+				IsAdminFunc: func(ctx context.Context, homeID, userID int) (bool, error) {
+					return tt.isAdmin, nil
+				},
+			}
+			r := setupBillImportRouter(svc, homeRepo)
+
+			var req *http.Request
+			if tt.body != nil {
+				req = makeJSONRequest(http.MethodPost, "/homes/1/bills/import/9/commit", tt.body)
+			} else {
+				req = httptest.NewRequest(http.MethodPost, "/homes/1/bills/import/9/commit", nil)
+			}
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
+func TestBillImportHandler_CreateProfile_Validation(t *testing.T) {
+	r := setupBillImportRouter(&mockBillImportService{}, &mockHomeRepo{})
+
+	req := makeJSONRequest(http.MethodPost, "/homes/1/bills/import/profiles", map[string]interface{}{
+		"name":               "Bank",
+		"date_column":        "Date",
+		"date_format":        "DD.MM.YYYY",
+		"amount_column":      "Amount",
+		"amount_sign":        "sideways",
+		"description_column": "Description",
+	})
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
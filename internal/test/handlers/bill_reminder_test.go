@@ -0,0 +1,99 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBillReminderService struct {
+	SetSettingsFunc func(ctx context.Context, homeID, leadDays int) (*models.BillReminderSettings, error)
+}
+
+func (m *mockBillReminderService) GetSettings(ctx context.Context, homeID int) (*models.BillReminderSettings, error) {
+	return &models.BillReminderSettings{HomeID: homeID, LeadDays: models.DefaultReminderLeadDays}, nil
+}
+
+func (m *mockBillReminderService) SetSettings(ctx context.Context, homeID, leadDays int) (*models.BillReminderSettings, error) {
+	if m.SetSettingsFunc != nil {
+		return m.SetSettingsFunc(ctx, homeID, leadDays)
+	}
+	return &models.BillReminderSettings{HomeID: homeID, LeadDays: leadDays}, nil
+}
+
+func (m *mockBillReminderService) SendReminders(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func setupBillReminderRouter(svc *mockBillReminderService) *chi.Mux {
+	h := handlers.NewBillReminderHandler(svc)
+	r := chi.NewRouter()
+	r.Get("/homes/{home_id}/bills/reminders", h.GetSettings)
+	r.Put("/homes/{home_id}/bills/reminders", h.SetSettings)
+	return r
+}
+
+func TestBillReminderHandler_GetSettings(t *testing.T) {
+	r := setupBillReminderRouter(&mockBillReminderService{})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/homes/1/bills/reminders", nil))
+
+	assertJSONResponse(t, rr, http.StatusOK, `"lead_days":3`)
+}
+
+func TestBillReminderHandler_SetSettings(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           interface{}
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Success",
+			body:           map[string]interface{}{"lead_days": 7},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"lead_days":7`,
+		},
+		{
+			name:           "Zero Days",
+			body:           map[string]interface{}{"lead_days": 0},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"lead_days":0`,
+		},
+		{
+			name:           "Missing Lead Days",
+			body:           map[string]interface{}{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Too Far Ahead",
+			body:           map[string]interface{}{"lead_days": 60},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockBillReminderService{
+				SetSettingsFunc: func(ctx context.Context, homeID, leadDays int) (*models.BillReminderSettings, error) {
+					require.Equal(t, 1, homeID)
+					return &models.BillReminderSettings{HomeID: homeID, LeadDays: leadDays}, nil
+				},
+			}
+			r := setupBillReminderRouter(svc)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, makeJSONRequest(http.MethodPut, "/homes/1/bills/reminders", tt.body))
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock bill template service
+type mockBillTemplateService struct {
+	CreateTemplateFunc  func(ctx context.Context, homeID, createdBy int, billType string, billCategoryID *int, description string, amount models.Money, currency string, recurrenceType string, interval int, startDate time.Time, splitMode string, splits []models.SplitInput) (*models.BillTemplate, error)
+	GetTemplateByIDFunc func(ctx context.Context, id int) (*models.BillTemplate, error)
+	deleted             []int
+}
+
+func (m *mockBillTemplateService) CreateTemplate(ctx context.Context, homeID, createdBy int, billType string, billCategoryID *int, description string, amount models.Money, currency string, recurrenceType string, interval int, startDate time.Time, splitMode string, splits []models.SplitInput) (*models.BillTemplate, error) {
+	if m.CreateTemplateFunc != nil {
+		return m.CreateTemplateFunc(ctx, homeID, createdBy, billType, billCategoryID, description, amount, currency, recurrenceType, interval, startDate, splitMode, splits)
+	}
+	return &models.BillTemplate{ID: 1, HomeID: homeID, CreatedBy: createdBy}, nil
+}
+
+func (m *mockBillTemplateService) GetTemplateByID(ctx context.Context, id int) (*models.BillTemplate, error) {
+	if m.GetTemplateByIDFunc != nil {
+		return m.GetTemplateByIDFunc(ctx, id)
+	}
+	return nil, errors.New("template not found")
+}
+
+func (m *mockBillTemplateService) GetTemplatesByHomeID(ctx context.Context, homeID int) ([]models.BillTemplate, error) {
+	return []models.BillTemplate{{ID: 1, HomeID: homeID, RecurrenceType: models.RecurrenceMonthly}}, nil
+}
+
+func (m *mockBillTemplateService) DeleteTemplate(ctx context.Context, id int) error {
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func (m *mockBillTemplateService) ProcessDueTemplates(ctx context.Context) error {
+	return nil
+}
+
+func setupBillTemplateRouter(h *handlers.BillTemplateHandler) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(utils.WithUserID(r.Context(), 123))
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/homes/{home_id}/bills/templates", h.GetByHomeID)
+	r.Post("/homes/{home_id}/bills/templates", h.Create)
+	r.Delete("/homes/{home_id}/bills/templates/{template_id}", h.Delete)
+	return r
+}
+
+func TestBillTemplateHandler_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockFunc       func(ctx context.Context, homeID, createdBy int, billType string, billCategoryID *int, description string, amount models.Money, currency string, recurrenceType string, interval int, startDate time.Time, splitMode string, splits []models.SplitInput) (*models.BillTemplate, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			body: `{"type":"rent","amount":1200.50,"recurrence_type":"monthly","start_date":"2026-01-01T00:00:00Z","split_mode":"equal","splits":[{"user_id":1},{"user_id":2}]}`,
+			mockFunc: func(ctx context.Context, homeID, createdBy int, billType string, billCategoryID *int, description string, amount models.Money, currency string, recurrenceType string, interval int, startDate time.Time, splitMode string, splits []models.SplitInput) (*models.BillTemplate, error) {
+				assert.Equal(t, 1, homeID)
+				assert.Equal(t, 123, createdBy)
+				assert.Equal(t, models.Money(120050), amount)
+				assert.Equal(t, models.RecurrenceMonthly, recurrenceType)
+				assert.Len(t, splits, 2)
+				return &models.BillTemplate{ID: 4, HomeID: homeID, Amount: amount}, nil
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"amount":1200.50`,
+		},
+		{
+			name:           "Unknown recurrence",
+			body:           `{"type":"rent","amount":10,"recurrence_type":"daily","start_date":"2026-01-01T00:00:00Z"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing start date",
+			body:           `{"type":"rent","amount":10,"recurrence_type":"weekly"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid split",
+			body: `{"type":"rent","amount":10,"recurrence_type":"weekly","start_date":"2026-01-01T00:00:00Z","splits":[{"user_id":1,"amount":5}]}`,
+			mockFunc: func(ctx context.Context, homeID, createdBy int, billType string, billCategoryID *int, description string, amount models.Money, currency string, recurrenceType string, interval int, startDate time.Time, splitMode string, splits []models.SplitInput) (*models.BillTemplate, error) {
+				return nil, services.ErrInvalidSplit
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   services.ErrInvalidSplit.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupBillTemplateRouter(handlers.NewBillTemplateHandler(&mockBillTemplateService{CreateTemplateFunc: tt.mockFunc}, &mockHomeRepo{}))
+
+			req := httptest.NewRequest(http.MethodPost, "/homes/1/bills/templates", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
+func TestBillTemplateHandler_GetByHomeID(t *testing.T) {
+	r := setupBillTemplateRouter(handlers.NewBillTemplateHandler(&mockBillTemplateService{}, &mockHomeRepo{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/homes/1/bills/templates", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assertJSONResponse(t, rr, http.StatusOK, `"recurrence_type":"monthly"`)
+}
+
+func TestBillTemplateHandler_Delete(t *testing.T) {
+	tests := []struct {
+		name           string
+		template       *models.BillTemplate
+		isAdmin        bool
+		expectedStatus int
+		expectDeleted  bool
+	}{
+		{"Creator", &models.BillTemplate{ID: 5, HomeID: 1, CreatedBy: 123}, false, http.StatusOK, true},
+		{"Admin", &models.BillTemplate{ID: 5, HomeID: 1, CreatedBy: 7}, true, http.StatusOK, true},
+		{"Other member", &models.BillTemplate{ID: 5, HomeID: 1, CreatedBy: 7}, false, http.StatusForbidden, false},
+		{"Other home", &models.BillTemplate{ID: 5, HomeID: 2, CreatedBy: 123}, false, http.StatusNotFound, false},
+		{"Not found", nil, false, http.StatusNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockBillTemplateService{
+				GetTemplateByIDFunc: func(ctx context.Context, id int) (*models.BillTemplate, error) {
+					if tt.template == nil {
+						return nil, errors.New("template not found")
+					}
+					return tt.template, nil
+				},
+			}
+			homeRepo := &mockHomeRepo{
+				IsAdminFunc: func(ctx context.Context, homeID, userID int) (bool, error) {
+					return tt.isAdmin, nil
+				},
+			}
+			r := setupBillTemplateRouter(handlers.NewBillTemplateHandler(svc, homeRepo))
+
+			req := httptest.NewRequest(http.MethodDelete, "/homes/1/bills/templates/5", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectDeleted {
+				assert.Equal(t, []int{5}, svc.deleted)
+			} else {
+				assert.Empty(t, svc.deleted)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/Dragodui/diploma-server/internal/http/handlers"
 	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
 	"github.com/Dragodui/diploma-server/internal/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -21,20 +23,32 @@ import (
 
 // Mock service
 type mockBillService struct {
-	CreateBillFunc       func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount float64, start, end time.Time, ocrData datatypes.JSON, homeID, userID int, splits []models.SplitInput) error
-	GetBillByIDFunc      func(ctx context.Context, billID int) (*models.Bill, error)
-	GetBillsByHomeIDFunc func(ctx context.Context, homeID int, categoryID *int) ([]models.Bill, error)
-	DeleteFunc           func(ctx context.Context, billID int) error
-	MarkBillPayedFunc    func(ctx context.Context, billID int) error
-	UpdateSplitsFunc     func(ctx context.Context, billID int, splits []models.SplitInput) error
-	MarkSplitPaidFunc    func(ctx context.Context, splitID int) error
+	CreateBillFunc        func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount models.Money, currency string, start, end time.Time, dueDate *time.Time, ocrData datatypes.JSON, receiptHash string, homeID, userID int, splitMode string, splits []models.SplitInput) (*models.Bill, error)
+	CreateFromReceiptFunc func(ctx context.Context, homeID, userID int, req models.CreateReceiptBillRequest) (*models.Bill, error)
+	GetBillByIDFunc       func(ctx context.Context, billID int) (*models.Bill, error)
+	GetBillsByHomeIDFunc  func(ctx context.Context, f models.BillFilter) (*models.BillPage, error)
+	DeleteFunc            func(ctx context.Context, billID int) error
+	UpdateBillFunc        func(ctx context.Context, billID, userID int, req models.UpdateBillRequest) (*models.Bill, error)
+	GetRevisionsFunc      func(ctx context.Context, billID int) ([]models.BillRevision, error)
+	GetStatsFunc          func(ctx context.Context, homeID int, from, to *time.Time, groupBy string) (*models.SpendingStats, error)
+	MarkBillPayedFunc     func(ctx context.Context, billID, userID int) error
+	MarkBillUnpayedFunc   func(ctx context.Context, billID, userID int) error
+	UpdateSplitsFunc      func(ctx context.Context, billID, userID int, splitMode string, splits []models.SplitInput) error
+	MarkSplitPaidFunc     func(ctx context.Context, splitID int) error
 }
 
-func (m *mockBillService) CreateBill(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount float64, start, end time.Time, ocrData datatypes.JSON, homeID, userID int, splits []models.SplitInput) error {
+func (m *mockBillService) CreateBill(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount models.Money, currency string, start, end time.Time, dueDate *time.Time, ocrData datatypes.JSON, receiptHash string, homeID, userID int, splitMode string, splits []models.SplitInput) (*models.Bill, error) {
 	if m.CreateBillFunc != nil {
-		return m.CreateBillFunc(ctx, billType, billCategoryID, description, receiptImage, totalAmount, start, end, ocrData, homeID, userID, splits)
+		return m.CreateBillFunc(ctx, billType, billCategoryID, description, receiptImage, totalAmount, currency, start, end, dueDate, ocrData, receiptHash, homeID, userID, splitMode, splits)
 	}
-	return nil
+	return &models.Bill{}, nil
+}
+
+func (m *mockBillService) CreateBillFromReceipt(ctx context.Context, homeID, userID int, req models.CreateReceiptBillRequest) (*models.Bill, error) {
+	if m.CreateFromReceiptFunc != nil {
+		return m.CreateFromReceiptFunc(ctx, homeID, userID, req)
+	}
+	return &models.Bill{}, nil
 }
 
 func (m *mockBillService) GetBillByID(ctx context.Context, billID int) (*models.Bill, error) {
@@ -44,11 +58,11 @@ func (m *mockBillService) GetBillByID(ctx context.Context, billID int) (*models.
 	return nil, nil
 }
 
-func (m *mockBillService) GetBillsByHomeID(ctx context.Context, homeId int, categoryID *int) ([]models.Bill, error) {
+func (m *mockBillService) GetBillsByHomeID(ctx context.Context, f models.BillFilter) (*models.BillPage, error) {
 	if m.GetBillsByHomeIDFunc != nil {
-		return m.GetBillsByHomeIDFunc(ctx, homeId, categoryID)
+		return m.GetBillsByHomeIDFunc(ctx, f)
 	}
-	return nil, nil
+	return &models.BillPage{}, nil
 }
 
 func (m *mockBillService) Delete(ctx context.Context, billID int) error {
@@ -58,16 +72,44 @@ func (m *mockBillService) Delete(ctx context.Context, billID int) error {
 	return nil
 }
 
-func (m *mockBillService) MarkBillPayed(ctx context.Context, billID int) error {
+func (m *mockBillService) UpdateBill(ctx context.Context, billID, userID int, req models.UpdateBillRequest) (*models.Bill, error) {
+	if m.UpdateBillFunc != nil {
+		return m.UpdateBillFunc(ctx, billID, userID, req)
+	}
+	return &models.Bill{ID: billID}, nil
+}
+
+func (m *mockBillService) GetRevisions(ctx context.Context, billID int) ([]models.BillRevision, error) {
+	if m.GetRevisionsFunc != nil {
+		return m.GetRevisionsFunc(ctx, billID)
+	}
+	return nil, nil
+}
+
+func (m *mockBillService) GetStats(ctx context.Context, homeID int, from, to *time.Time, groupBy string) (*models.SpendingStats, error) {
+	if m.GetStatsFunc != nil {
+		return m.GetStatsFunc(ctx, homeID, from, to, groupBy)
+	}
+	return &models.SpendingStats{}, nil
+}
+
+func (m *mockBillService) MarkBillPayed(ctx context.Context, billID, userID int) error {
 	if m.MarkBillPayedFunc != nil {
-		return m.MarkBillPayedFunc(ctx, billID)
+		return m.MarkBillPayedFunc(ctx, billID, userID)
 	}
 	return nil
 }
 
-func (m *mockBillService) UpdateSplits(ctx context.Context, billID int, splits []models.SplitInput) error {
+func (m *mockBillService) MarkBillUnpayed(ctx context.Context, billID, userID int) error {
+	if m.MarkBillUnpayedFunc != nil {
+		return m.MarkBillUnpayedFunc(ctx, billID, userID)
+	}
+	return nil
+}
+
+func (m *mockBillService) UpdateSplits(ctx context.Context, billID, userID int, splitMode string, splits []models.SplitInput) error {
 	if m.UpdateSplitsFunc != nil {
-		return m.UpdateSplitsFunc(ctx, billID, splits)
+		return m.UpdateSplitsFunc(ctx, billID, userID, splitMode, splits)
 	}
 	return nil
 }
@@ -80,7 +122,7 @@ func (m *mockBillService) MarkSplitPaid(ctx context.Context, splitID int) error
 }
 
 func (m *mockBillService) GetSplitByID(ctx context.Context, splitID int) (*models.BillSplit, error) {
-	return &models.BillSplit{ID: splitID, UserID: 123, BillID: 1, Amount: 50.0}, nil
+	return &models.BillSplit{ID: splitID, UserID: 123, BillID: 1, Amount: 5000}, nil
 }
 
 // Test fixtures
@@ -90,7 +132,7 @@ var (
 	testOCRData, _   = json.Marshal([]byte("{" + "test ocr data" + "}"))
 	validBillRequest = models.CreateBillRequest{
 		BillType:    "electricity",
-		TotalAmount: 100.50,
+		TotalAmount: 10050,
 		Start:       testStartTime,
 		End:         testEndTime,
 		OCRData:     testOCRData,
@@ -109,20 +151,99 @@ func setupBillRouter(h *handlers.BillHandler) *chi.Mux {
 			next.ServeHTTP(w, r)
 		})
 	})
-	r.Get("/bills/{bill_id}", h.GetByID)
+	r.Get("/homes/{home_id}/bills/{bill_id}", h.GetByID)
 	r.Delete("/homes/{home_id}/bills/{bill_id}", h.Delete)
-	r.Put("/bills/{bill_id}/mark-payed", h.MarkPayed)
+	r.Put("/homes/{home_id}/bills/{bill_id}", h.Update)
+	r.Get("/homes/{home_id}/bills/{bill_id}/revisions", h.GetRevisions)
+	r.Get("/homes/{home_id}/bills/stats", h.GetStats)
+	r.Put("/homes/{home_id}/bills/{bill_id}/mark-payed", h.MarkPayed)
+	r.Put("/homes/{home_id}/bills/{bill_id}/mark-unpayed", h.MarkUnpayed)
 	r.Put("/homes/{home_id}/bills/{bill_id}/splits", h.UpdateSplits)
 	r.Patch("/homes/{home_id}/bills/{bill_id}/splits/{split_id}/paid", h.MarkSplitPaid)
 	return r
 }
 
+func TestBillHandler_GetByHomeID(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockFunc       func(ctx context.Context, f models.BillFilter) (*models.BillPage, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Filters",
+			query: "?status=unpaid&date_field=created&from=2025-01-01&to=2025-01-31&uploaded_by=2&participant_id=3&min_amount=10.50&max_amount=99&search=rent&sort=total_amount&order=asc&limit=20&cursor=abc",
+			mockFunc: func(ctx context.Context, f models.BillFilter) (*models.BillPage, error) {
+				assert.Equal(t, 1, f.HomeID)
+				assert.Equal(t, models.BillStatusUnpaid, f.Status)
+				assert.Equal(t, models.BillDateCreated, f.DateField)
+				assert.Equal(t, "2025-01-31", f.To.Format(time.DateOnly))
+				assert.Equal(t, 2, *f.UploadedBy)
+				assert.Equal(t, 3, *f.ParticipantID)
+				assert.Equal(t, models.Money(1050), *f.MinAmount)
+				assert.Equal(t, models.Money(9900), *f.MaxAmount)
+				assert.Equal(t, "rent", f.Search)
+				assert.Equal(t, models.BillSortAmount, f.Sort)
+				assert.True(t, f.Ascending)
+				assert.Equal(t, 20, f.Limit)
+				assert.Equal(t, "abc", f.Cursor)
+				return &models.BillPage{Bills: []models.Bill{{ID: 7}}, NextCursor: "next"}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"next_cursor":"next"`,
+		},
+		{
+			name:           "Invalid Date",
+			query:          "?from=01.01.2025",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid from date",
+		},
+		{
+			name:           "Invalid Amount",
+			query:          "?min_amount=lots",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid min_amount",
+		},
+		{
+			name:           "Invalid Order",
+			query:          "?order=up",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Invalid Filter",
+			query: "?sort=type",
+			mockFunc: func(ctx context.Context, f models.BillFilter) (*models.BillPage, error) {
+				return nil, fmt.Errorf("%w: sort must be created_at, period_start or total_amount", services.ErrInvalidBillFilter)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "sort must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := setupBillHandler(&mockBillService{GetBillsByHomeIDFunc: tt.mockFunc})
+
+			req := httptest.NewRequest(http.MethodGet, "/bills"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("home_id", "1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			h.GetByHomeID(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
 func TestBillHandler_Create(t *testing.T) {
 	tests := []struct {
 		name           string
 		body           interface{}
 		userID         int
-		mockFunc       func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount float64, start, end time.Time, ocrData datatypes.JSON, homeID, userID int, splits []models.SplitInput) error
+		mockFunc       func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount models.Money, currency string, start, end time.Time, dueDate *time.Time, ocrData datatypes.JSON, receiptHash string, homeID, userID int, splitMode string, splits []models.SplitInput) (*models.Bill, error)
 		expectedStatus int
 		expectedBody   string
 	}{
@@ -130,17 +251,27 @@ func TestBillHandler_Create(t *testing.T) {
 			name:   "Success",
 			body:   validBillRequest,
 			userID: 123,
-			mockFunc: func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount float64, start, end time.Time, ocrData datatypes.JSON, homeID, userID int, splits []models.SplitInput) error {
+			mockFunc: func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount models.Money, currency string, start, end time.Time, dueDate *time.Time, ocrData datatypes.JSON, receiptHash string, homeID, userID int, splitMode string, splits []models.SplitInput) (*models.Bill, error) {
 				assert.Equal(t, "electricity", billType)
 				assert.Nil(t, billCategoryID)
-				assert.Equal(t, 100.50, totalAmount)
+				assert.Equal(t, models.Money(10050), totalAmount)
 				assert.Equal(t, 1, homeID)
 				assert.Equal(t, 123, userID)
-				return nil
+				return &models.Bill{ID: 7}, nil
 			},
 			expectedStatus: http.StatusCreated,
 			expectedBody:   "Created successfully",
 		},
+		{
+			name:   "Duplicate Receipt",
+			body:   validBillRequest,
+			userID: 123,
+			mockFunc: func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount models.Money, currency string, start, end time.Time, dueDate *time.Time, ocrData datatypes.JSON, receiptHash string, homeID, userID int, splitMode string, splits []models.SplitInput) (*models.Bill, error) {
+				return &models.Bill{ID: 8, Warnings: []models.BillWarning{{Code: models.BillWarningDuplicateReceipt, BillID: 7}}}, nil
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"code":"duplicate_receipt","bill_id":7`,
+		},
 		{
 			name:           "Invalid JSON",
 			body:           "{bad json}",
@@ -157,12 +288,22 @@ func TestBillHandler_Create(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   "Unauthorized",
 		},
+		{
+			name:   "Invalid Split",
+			body:   validBillRequest,
+			userID: 123,
+			mockFunc: func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount models.Money, currency string, start, end time.Time, dueDate *time.Time, ocrData datatypes.JSON, receiptHash string, homeID, userID int, splitMode string, splits []models.SplitInput) (*models.Bill, error) {
+				return nil, fmt.Errorf("%w: percentages add up to 90.00, not 100", services.ErrInvalidSplit)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "percentages add up to 90.00",
+		},
 		{
 			name:   "Service Error",
 			body:   validBillRequest,
 			userID: 123,
-			mockFunc: func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount float64, start, end time.Time, ocrData datatypes.JSON, homeID, userID int, splits []models.SplitInput) error {
-				return errors.New("service error")
+			mockFunc: func(ctx context.Context, billType string, billCategoryID *int, description string, receiptImage *string, totalAmount models.Money, currency string, start, end time.Time, dueDate *time.Time, ocrData datatypes.JSON, receiptHash string, homeID, userID int, splitMode string, splits []models.SplitInput) (*models.Bill, error) {
+				return nil, errors.New("service error")
 			},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "Invalid data",
@@ -200,6 +341,74 @@ func TestBillHandler_Create(t *testing.T) {
 	}
 }
 
+func TestBillHandler_CreateFromReceipt(t *testing.T) {
+	validReceipt := map[string]interface{}{
+		"bill_type":    "groceries",
+		"vendor":       "Lidl",
+		"period_start": "2025-03-01T00:00:00Z",
+		"period_end":   "2025-03-01T00:00:00Z",
+		"items": []map[string]interface{}{
+			{"name": "Milk", "price": "3.00", "user_ids": []int{1, 2}},
+			{"name": "VAT", "price": "0.30", "kind": "tax"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		body           interface{}
+		mockFunc       func(ctx context.Context, homeID, userID int, req models.CreateReceiptBillRequest) (*models.Bill, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			body: validReceipt,
+			mockFunc: func(ctx context.Context, homeID, userID int, req models.CreateReceiptBillRequest) (*models.Bill, error) {
+				assert.Equal(t, 1, homeID)
+				assert.Equal(t, 123, userID)
+				require.Len(t, req.Items, 2)
+				assert.Equal(t, models.Money(300), req.Items[0].Price)
+				assert.Equal(t, []int{1, 2}, req.Items[0].UserIDs)
+				return &models.Bill{ID: 5, TotalAmount: 330}, nil
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"total_amount":3.30`,
+		},
+		{
+			name:           "No Items",
+			body:           map[string]interface{}{"bill_type": "groceries", "period_start": "2025-03-01T00:00:00Z", "period_end": "2025-03-01T00:00:00Z"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unassigned Item",
+			body: validReceipt,
+			mockFunc: func(ctx context.Context, homeID, userID int, req models.CreateReceiptBillRequest) (*models.Bill, error) {
+				return nil, fmt.Errorf("%w: item \"Milk\" is not assigned to anyone", services.ErrInvalidSplit)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "not assigned to anyone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := setupBillHandler(&mockBillService{CreateFromReceiptFunc: tt.mockFunc})
+
+			req := makeJSONRequest(http.MethodPost, "/bills/receipt", tt.body)
+			req = req.WithContext(utils.WithUserID(req.Context(), 123))
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("home_id", "1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			h.CreateFromReceipt(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
 func TestBillHandler_GetByID(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -213,7 +422,7 @@ func TestBillHandler_GetByID(t *testing.T) {
 			billID: "1",
 			mockFunc: func(ctx context.Context, billID int) (*models.Bill, error) {
 				require.Equal(t, 1, billID)
-				return &models.Bill{ID: 1, Type: "electricity", TotalAmount: 100.50}, nil
+				return &models.Bill{ID: 1, Type: "electricity", TotalAmount: 10050, HomeID: 1}, nil
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   "electricity",
@@ -232,7 +441,7 @@ func TestBillHandler_GetByID(t *testing.T) {
 				return nil, errors.New("service error")
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   "Failed to retrieve bill",
+			expectedBody:   "Failed to find bill",
 		},
 	}
 
@@ -245,7 +454,7 @@ func TestBillHandler_GetByID(t *testing.T) {
 			h := setupBillHandler(svc)
 			r := setupBillRouter(h)
 
-			req := httptest.NewRequest(http.MethodGet, "/bills/"+tt.billID, nil)
+			req := httptest.NewRequest(http.MethodGet, "/homes/1/bills/"+tt.billID, nil)
 			rr := httptest.NewRecorder()
 
 			r.ServeHTTP(rr, req)
@@ -317,15 +526,16 @@ func TestBillHandler_MarkPayed(t *testing.T) {
 	tests := []struct {
 		name           string
 		billID         string
-		mockFunc       func(ctx context.Context, billID int) error
+		mockFunc       func(ctx context.Context, billID, userID int) error
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
 			name:   "Success",
 			billID: "1",
-			mockFunc: func(ctx context.Context, billID int) error {
+			mockFunc: func(ctx context.Context, billID, userID int) error {
 				require.Equal(t, 1, billID)
+				require.Equal(t, 123, userID)
 				return nil
 			},
 			expectedStatus: http.StatusOK,
@@ -341,7 +551,7 @@ func TestBillHandler_MarkPayed(t *testing.T) {
 		{
 			name:   "Service Error",
 			billID: "1",
-			mockFunc: func(ctx context.Context, billID int) error {
+			mockFunc: func(ctx context.Context, billID, userID int) error {
 				return errors.New("update failed")
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -353,12 +563,119 @@ func TestBillHandler_MarkPayed(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := &mockBillService{
 				MarkBillPayedFunc: tt.mockFunc,
+				GetBillByIDFunc: func(ctx context.Context, billID int) (*models.Bill, error) {
+					return &models.Bill{ID: billID, UploadedBy: 123, HomeID: 1}, nil
+				},
+			}
+
+			h := setupBillHandler(svc)
+			r := setupBillRouter(h)
+
+			req := httptest.NewRequest(http.MethodPut, "/homes/1/bills/"+tt.billID+"/mark-payed", nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
+func TestBillHandler_MarkUnpayed(t *testing.T) {
+	svc := &mockBillService{
+		MarkBillUnpayedFunc: func(ctx context.Context, billID, userID int) error {
+			require.Equal(t, 1, billID)
+			require.Equal(t, 123, userID)
+			return nil
+		},
+		GetBillByIDFunc: func(ctx context.Context, billID int) (*models.Bill, error) {
+			return &models.Bill{ID: billID, UploadedBy: 123, HomeID: 1}, nil
+		},
+	}
+
+	h := setupBillHandler(svc)
+	r := setupBillRouter(h)
+
+	req := httptest.NewRequest(http.MethodPut, "/homes/1/bills/1/mark-unpayed", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	assertJSONResponse(t, rr, http.StatusOK, "Updated successfully")
+}
+
+func TestBillHandler_Update(t *testing.T) {
+	description := "Electricity, March"
+	tests := []struct {
+		name           string
+		billID         string
+		body           interface{}
+		mockFunc       func(ctx context.Context, billID, userID int, req models.UpdateBillRequest) (*models.Bill, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Success",
+			billID: "1",
+			body:   models.UpdateBillRequest{Description: &description},
+			mockFunc: func(ctx context.Context, billID, userID int, req models.UpdateBillRequest) (*models.Bill, error) {
+				require.Equal(t, 1, billID)
+				require.Equal(t, 123, userID)
+				require.NotNil(t, req.Description)
+				assert.Nil(t, req.TotalAmount)
+				return &models.Bill{ID: billID, Description: *req.Description}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   description,
+		},
+		{
+			name:           "Non-positive Total",
+			billID:         "1",
+			body:           map[string]interface{}{"total_amount": -5},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Splits Don't Match Total",
+			billID: "1",
+			body:   map[string]interface{}{"total_amount": 120},
+			mockFunc: func(ctx context.Context, billID, userID int, req models.UpdateBillRequest) (*models.Bill, error) {
+				return nil, fmt.Errorf("%w: splits add up to 90.00, new total is 120.00", services.ErrInvalidSplit)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "splits add up to 90.00",
+		},
+		{
+			name:   "Service Error",
+			billID: "1",
+			body:   models.UpdateBillRequest{Description: &description},
+			mockFunc: func(ctx context.Context, billID, userID int, req models.UpdateBillRequest) (*models.Bill, error) {
+				return nil, errors.New("db down")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to update bill",
+		},
+		{
+			name:           "Invalid Bill ID",
+			billID:         "invalid",
+			body:           models.UpdateBillRequest{},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid bill ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockBillService{
+				UpdateBillFunc: tt.mockFunc,
+				GetBillByIDFunc: func(ctx context.Context, billID int) (*models.Bill, error) {
+					return &models.Bill{ID: billID, UploadedBy: 123, HomeID: 1}, nil
+				},
 			}
 
 			h := setupBillHandler(svc)
 			r := setupBillRouter(h)
 
-			req := httptest.NewRequest(http.MethodPut, "/bills/"+tt.billID+"/mark-payed", nil)
+			req := makeJSONRequest(http.MethodPut, "/homes/1/bills/"+tt.billID, tt.body)
 			rr := httptest.NewRecorder()
 
 			r.ServeHTTP(rr, req)
@@ -368,12 +685,75 @@ func TestBillHandler_MarkPayed(t *testing.T) {
 	}
 }
 
+func TestBillHandler_GetRevisions(t *testing.T) {
+	svc := &mockBillService{
+		GetRevisionsFunc: func(ctx context.Context, billID int) ([]models.BillRevision, error) {
+			require.Equal(t, 1, billID)
+			return []models.BillRevision{{ID: 1, BillID: 1, ChangedBy: 123, Changes: []models.FieldChange{{Field: "description", Old: "a", New: "b"}}}}, nil
+		},
+		GetBillByIDFunc: func(ctx context.Context, billID int) (*models.Bill, error) {
+			return &models.Bill{ID: billID, UploadedBy: 7, HomeID: 1}, nil
+		},
+	}
+
+	h := setupBillHandler(svc)
+	r := setupBillRouter(h)
+
+	req := httptest.NewRequest(http.MethodGet, "/homes/1/bills/1/revisions", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	assertJSONResponse(t, rr, http.StatusOK, `"field":"description"`)
+}
+
+func TestBillHandler_OtherHomesBill(t *testing.T) {
+	// the caller is a member of home 1, the bill is in home 2
+	svc := &mockBillService{
+		GetBillByIDFunc: func(ctx context.Context, billID int) (*models.Bill, error) {
+			return &models.Bill{ID: billID, UploadedBy: 123, HomeID: 2}, nil
+		},
+		GetRevisionsFunc: func(ctx context.Context, billID int) ([]models.BillRevision, error) {
+			t.Fatal("revisions of another home's bill were read")
+			return nil, nil
+		},
+		UpdateBillFunc: func(ctx context.Context, billID, userID int, req models.UpdateBillRequest) (*models.Bill, error) {
+			t.Fatal("another home's bill was updated")
+			return nil, nil
+		},
+		MarkBillPayedFunc: func(ctx context.Context, billID, userID int) error {
+			t.Fatal("another home's bill was marked paid")
+			return nil
+		},
+	}
+	r := setupBillRouter(setupBillHandler(svc))
+
+	description := "mine now"
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/homes/1/bills/9", nil),
+		httptest.NewRequest(http.MethodGet, "/homes/1/bills/9/revisions", nil),
+		makeJSONRequest(http.MethodPut, "/homes/1/bills/9", models.UpdateBillRequest{Description: &description}),
+		httptest.NewRequest(http.MethodPut, "/homes/1/bills/9/mark-payed", nil),
+		httptest.NewRequest(http.MethodPut, "/homes/1/bills/9/mark-unpayed", nil),
+		makeJSONRequest(http.MethodPut, "/homes/1/bills/9/splits", models.UpdateSplitsRequest{Splits: []models.SplitInput{{UserID: 2, Amount: 100}}}),
+		httptest.NewRequest(http.MethodPatch, "/homes/1/bills/9/splits/5/paid", nil),
+		httptest.NewRequest(http.MethodDelete, "/homes/1/bills/9", nil),
+	}
+	for _, req := range requests {
+		t.Run(req.Method+" "+req.URL.Path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			assertJSONResponse(t, rr, http.StatusNotFound, "bill not found")
+		})
+	}
+}
+
 func TestBillHandler_UpdateSplits(t *testing.T) {
 	tests := []struct {
 		name           string
 		billID         string
 		body           interface{}
-		mockFunc       func(ctx context.Context, billID int, splits []models.SplitInput) error
+		mockFunc       func(ctx context.Context, billID, userID int, splitMode string, splits []models.SplitInput) error
 		expectedStatus int
 		expectedBody   string
 	}{
@@ -382,11 +762,11 @@ func TestBillHandler_UpdateSplits(t *testing.T) {
 			billID: "1",
 			body: models.UpdateSplitsRequest{
 				Splits: []models.SplitInput{
-					{UserID: 2, Amount: 50.0},
-					{UserID: 3, Amount: 50.0},
+					{UserID: 2, Amount: 5000},
+					{UserID: 3, Amount: 5000},
 				},
 			},
-			mockFunc: func(ctx context.Context, billID int, splits []models.SplitInput) error {
+			mockFunc: func(ctx context.Context, billID, userID int, splitMode string, splits []models.SplitInput) error {
 				require.Equal(t, 1, billID)
 				require.Len(t, splits, 2)
 				return nil
@@ -394,6 +774,28 @@ func TestBillHandler_UpdateSplits(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   "Splits updated",
 		},
+		{
+			name:   "Unknown Split Mode",
+			billID: "1",
+			body: models.UpdateSplitsRequest{
+				SplitMode: "weighted",
+				Splits:    []models.SplitInput{{UserID: 2}},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid Split",
+			billID: "1",
+			body: models.UpdateSplitsRequest{
+				SplitMode: models.SplitModeExact,
+				Splits:    []models.SplitInput{{UserID: 2, Amount: 1000}},
+			},
+			mockFunc: func(ctx context.Context, billID, userID int, splitMode string, splits []models.SplitInput) error {
+				return fmt.Errorf("%w: split amounts add up to 10.00, bill total is 100.00", services.ErrInvalidSplit)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "split amounts add up to 10.00",
+		},
 		{
 			name:           "Invalid Bill ID",
 			billID:         "invalid",
@@ -457,6 +859,9 @@ func TestBillHandler_MarkSplitPaid(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := &mockBillService{
 				MarkSplitPaidFunc: tt.mockFunc,
+				GetBillByIDFunc: func(ctx context.Context, billID int) (*models.Bill, error) {
+					return &models.Bill{ID: billID, UploadedBy: 123, HomeID: 1}, nil
+				},
 			}
 
 			h := setupBillHandler(svc)
@@ -471,3 +876,76 @@ func TestBillHandler_MarkSplitPaid(t *testing.T) {
 		})
 	}
 }
+
+func TestBillHandler_GetStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockFunc       func(ctx context.Context, homeID int, from, to *time.Time, groupBy string) (*models.SpendingStats, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Success",
+			query: "?from=2026-01-01&to=2026-03-31&group_by=uploader",
+			mockFunc: func(ctx context.Context, homeID int, from, to *time.Time, groupBy string) (*models.SpendingStats, error) {
+				require.Equal(t, 1, homeID)
+				require.NotNil(t, from)
+				require.NotNil(t, to)
+				assert.Equal(t, "2026-01-01", from.Format(time.DateOnly))
+				assert.Equal(t, "2026-03-31", to.Format(time.DateOnly))
+				assert.Equal(t, models.StatsGroupUploader, groupBy)
+				return &models.SpendingStats{GroupBy: groupBy, Total: 12345}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"total":123.45`,
+		},
+		{
+			name:  "No Range",
+			query: "",
+			mockFunc: func(ctx context.Context, homeID int, from, to *time.Time, groupBy string) (*models.SpendingStats, error) {
+				assert.Nil(t, from)
+				assert.Nil(t, to)
+				return &models.SpendingStats{}, nil
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid Date",
+			query:          "?from=01/02/2026",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid from date",
+		},
+		{
+			name:           "Reversed Range",
+			query:          "?from=2026-03-01&to=2026-02-01",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "to must not be before from",
+		},
+		{
+			name:  "Unknown Grouping",
+			query: "?group_by=weekday",
+			mockFunc: func(ctx context.Context, homeID int, from, to *time.Time, groupBy string) (*models.SpendingStats, error) {
+				return nil, services.ErrInvalidStatsGroup
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "group_by must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockBillService{GetStatsFunc: tt.mockFunc}
+
+			h := setupBillHandler(svc)
+			r := setupBillRouter(h)
+
+			req := httptest.NewRequest(http.MethodGet, "/homes/1/bills/stats"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
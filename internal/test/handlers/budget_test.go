@@ -0,0 +1,168 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBudgetService struct {
+	SetBudgetFunc    func(ctx context.Context, homeID, categoryID, userID int, amount models.Money) (*models.BillCategoryBudget, error)
+	GetBudgetFunc    func(ctx context.Context, homeID, categoryID int) (*models.BillCategoryBudget, error)
+	DeleteBudgetFunc func(ctx context.Context, homeID, categoryID int) error
+	GetStatusFunc    func(ctx context.Context, homeID, categoryID int, month time.Time) (*models.BudgetStatus, error)
+}
+
+func (m *mockBudgetService) SetBudget(ctx context.Context, homeID, categoryID, userID int, amount models.Money) (*models.BillCategoryBudget, error) {
+	if m.SetBudgetFunc != nil {
+		return m.SetBudgetFunc(ctx, homeID, categoryID, userID, amount)
+	}
+	return &models.BillCategoryBudget{}, nil
+}
+
+func (m *mockBudgetService) GetBudget(ctx context.Context, homeID, categoryID int) (*models.BillCategoryBudget, error) {
+	if m.GetBudgetFunc != nil {
+		return m.GetBudgetFunc(ctx, homeID, categoryID)
+	}
+	return &models.BillCategoryBudget{}, nil
+}
+
+func (m *mockBudgetService) DeleteBudget(ctx context.Context, homeID, categoryID int) error {
+	if m.DeleteBudgetFunc != nil {
+		return m.DeleteBudgetFunc(ctx, homeID, categoryID)
+	}
+	return nil
+}
+
+func (m *mockBudgetService) GetStatus(ctx context.Context, homeID, categoryID int, month time.Time) (*models.BudgetStatus, error) {
+	if m.GetStatusFunc != nil {
+		return m.GetStatusFunc(ctx, homeID, categoryID, month)
+	}
+	return &models.BudgetStatus{}, nil
+}
+
+func (m *mockBudgetService) GetStatuses(ctx context.Context, homeID int, month time.Time) ([]models.BudgetStatus, error) {
+	return nil, nil
+}
+
+func setupBudgetRouter(svc *mockBudgetService) *chi.Mux {
+	h := handlers.NewBudgetHandler(svc)
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(utils.WithUserID(r.Context(), 123))
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/homes/{home_id}/bill_categories/{category_id}/budget", h.Get)
+	r.Put("/homes/{home_id}/bill_categories/{category_id}/budget", h.Set)
+	r.Delete("/homes/{home_id}/bill_categories/{category_id}/budget", h.Delete)
+	r.Get("/homes/{home_id}/bill_categories/{category_id}/budget/status", h.GetStatus)
+	return r
+}
+
+func TestBudgetHandler_Set(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           interface{}
+		mockFunc       func(ctx context.Context, homeID, categoryID, userID int, amount models.Money) (*models.BillCategoryBudget, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			body: map[string]interface{}{"amount": 600},
+			mockFunc: func(ctx context.Context, homeID, categoryID, userID int, amount models.Money) (*models.BillCategoryBudget, error) {
+				require.Equal(t, 1, homeID)
+				require.Equal(t, 5, categoryID)
+				require.Equal(t, 123, userID)
+				return &models.BillCategoryBudget{BillCategoryID: categoryID, Amount: amount}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"amount":600.00`,
+		},
+		{
+			name:           "Zero Amount",
+			body:           map[string]interface{}{"amount": 0},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unknown Category",
+			body: map[string]interface{}{"amount": 600},
+			mockFunc: func(ctx context.Context, homeID, categoryID, userID int, amount models.Money) (*models.BillCategoryBudget, error) {
+				return nil, services.ErrCategoryNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "category not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupBudgetRouter(&mockBudgetService{SetBudgetFunc: tt.mockFunc})
+
+			req := makeJSONRequest(http.MethodPut, "/homes/1/bill_categories/5/budget", tt.body)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
+func TestBudgetHandler_Delete_NotFound(t *testing.T) {
+	r := setupBudgetRouter(&mockBudgetService{
+		DeleteBudgetFunc: func(ctx context.Context, homeID, categoryID int) error {
+			return services.ErrBudgetNotFound
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/homes/1/bill_categories/5/budget", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	assertJSONResponse(t, rr, http.StatusNotFound, "budget not found")
+}
+
+func TestBudgetHandler_GetStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Given Month", "?month=2026-02", http.StatusOK, `"period":"2026-02"`},
+		{"Current Month", "", http.StatusOK, `"remaining":150.00`},
+		{"Invalid Month", "?month=February", http.StatusBadRequest, "invalid month"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupBudgetRouter(&mockBudgetService{
+				GetStatusFunc: func(ctx context.Context, homeID, categoryID int, month time.Time) (*models.BudgetStatus, error) {
+					assert.Equal(t, 5, categoryID)
+					return &models.BudgetStatus{BillCategoryID: categoryID, Period: month.Format("2006-01"), Budget: 60000, Spent: 45000, Remaining: 15000}, nil
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/homes/1/bill_categories/5/budget/status"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
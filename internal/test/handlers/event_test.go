@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock event service
+type mockEventService struct {
+	GetHomeEventsFunc func(ctx context.Context, homeID int, since string, limit int64) ([]event.RealTimeEvent, error)
+}
+
+func (m *mockEventService) GetHomeEvents(ctx context.Context, homeID int, since string, limit int64) ([]event.RealTimeEvent, error) {
+	if m.GetHomeEventsFunc != nil {
+		return m.GetHomeEventsFunc(ctx, homeID, since, limit)
+	}
+	return nil, nil
+}
+
+func setupEventRouter(h *handlers.EventHandler) *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/homes/{home_id}/events", h.GetByHomeID)
+	return r
+}
+
+func TestEventHandler_GetByHomeID(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		mockFunc       func(ctx context.Context, homeID int, since string, limit int64) ([]event.RealTimeEvent, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			url:  "/homes/1/events?since=1700000000000-0",
+			mockFunc: func(ctx context.Context, homeID int, since string, limit int64) ([]event.RealTimeEvent, error) {
+				require.Equal(t, 1, homeID)
+				require.Equal(t, "1700000000000-0", since)
+				require.Equal(t, int64(100), limit)
+				return []event.RealTimeEvent{
+					{ID: "1700000000001-0", Module: event.ModuleBill, Action: event.ActionCreated},
+				}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "1700000000001-0",
+		},
+		{
+			name: "Limit Capped",
+			url:  "/homes/1/events?limit=10000",
+			mockFunc: func(ctx context.Context, homeID int, since string, limit int64) ([]event.RealTimeEvent, error) {
+				require.Equal(t, "", since)
+				require.Equal(t, int64(500), limit)
+				return []event.RealTimeEvent{}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "events",
+		},
+		{
+			name:           "Invalid Home ID",
+			url:            "/homes/abc/events",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid home ID",
+		},
+		{
+			name:           "Invalid Limit",
+			url:            "/homes/1/events?limit=-5",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid limit",
+		},
+		{
+			name: "Invalid Cursor",
+			url:  "/homes/1/events?since=yesterday",
+			mockFunc: func(ctx context.Context, homeID int, since string, limit int64) ([]event.RealTimeEvent, error) {
+				return nil, event.ErrInvalidCursor
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid since cursor",
+		},
+		{
+			name: "Service Error",
+			url:  "/homes/1/events",
+			mockFunc: func(ctx context.Context, homeID int, since string, limit int64) ([]event.RealTimeEvent, error) {
+				return nil, errors.New("redis error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to retrieve events",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockEventService{GetHomeEventsFunc: tt.mockFunc}
+			r := setupEventRouter(handlers.NewEventHandler(svc))
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
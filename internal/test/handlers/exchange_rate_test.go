@@ -0,0 +1,147 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock exchange rate service
+type mockExchangeRateService struct {
+	GetRatesFunc   func(ctx context.Context, homeID int) ([]models.EffectiveRate, error)
+	SetRateFunc    func(ctx context.Context, homeID int, currency string, rate float64) error
+	DeleteRateFunc func(ctx context.Context, homeID int, currency string) error
+}
+
+func (m *mockExchangeRateService) GetRates(ctx context.Context, homeID int) ([]models.EffectiveRate, error) {
+	if m.GetRatesFunc != nil {
+		return m.GetRatesFunc(ctx, homeID)
+	}
+	return nil, nil
+}
+
+func (m *mockExchangeRateService) SetRate(ctx context.Context, homeID int, currency string, rate float64) error {
+	if m.SetRateFunc != nil {
+		return m.SetRateFunc(ctx, homeID, currency, rate)
+	}
+	return nil
+}
+
+func (m *mockExchangeRateService) DeleteRate(ctx context.Context, homeID int, currency string) error {
+	if m.DeleteRateFunc != nil {
+		return m.DeleteRateFunc(ctx, homeID, currency)
+	}
+	return nil
+}
+
+func setupExchangeRateRouter(h *handlers.ExchangeRateHandler) *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/homes/{home_id}/exchange_rates", h.GetAll)
+	r.Put("/homes/{home_id}/exchange_rates/{currency}", h.Set)
+	r.Delete("/homes/{home_id}/exchange_rates/{currency}", h.Delete)
+	return r
+}
+
+func TestExchangeRateHandler_GetAll(t *testing.T) {
+	svc := &mockExchangeRateService{
+		GetRatesFunc: func(ctx context.Context, homeID int) ([]models.EffectiveRate, error) {
+			return []models.EffectiveRate{{Currency: "EUR", BaseCurrency: "PLN", Rate: 4.3, Custom: true}}, nil
+		},
+	}
+	r := setupExchangeRateRouter(handlers.NewExchangeRateHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/homes/1/exchange_rates", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assertJSONResponse(t, rr, http.StatusOK, `"base_currency":"PLN"`)
+}
+
+func TestExchangeRateHandler_Set(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		body           string
+		mockFunc       func(ctx context.Context, homeID int, currency string, rate float64) error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			url:  "/homes/1/exchange_rates/eur",
+			body: `{"rate":4.31}`,
+			mockFunc: func(ctx context.Context, homeID int, currency string, rate float64) error {
+				require.Equal(t, "EUR", currency)
+				require.Equal(t, 4.31, rate)
+				return nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Exchange rate updated",
+		},
+		{
+			name:           "Non-positive rate",
+			url:            "/homes/1/exchange_rates/EUR",
+			body:           `{"rate":0}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unsupported currency",
+			url:  "/homes/1/exchange_rates/XYZ",
+			body: `{"rate":2}`,
+			mockFunc: func(ctx context.Context, homeID int, currency string, rate float64) error {
+				return services.ErrUnsupportedCurrency
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   services.ErrUnsupportedCurrency.Error(),
+		},
+		{
+			name: "Service Error",
+			url:  "/homes/1/exchange_rates/EUR",
+			body: `{"rate":2}`,
+			mockFunc: func(ctx context.Context, homeID int, currency string, rate float64) error {
+				return errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to set exchange rate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupExchangeRateRouter(handlers.NewExchangeRateHandler(&mockExchangeRateService{SetRateFunc: tt.mockFunc}))
+
+			req := httptest.NewRequest(http.MethodPut, tt.url, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
+func TestExchangeRateHandler_Delete(t *testing.T) {
+	var deleted string
+	svc := &mockExchangeRateService{
+		DeleteRateFunc: func(ctx context.Context, homeID int, currency string) error {
+			deleted = currency
+			return nil
+		},
+	}
+	r := setupExchangeRateRouter(handlers.NewExchangeRateHandler(svc))
+
+	req := httptest.NewRequest(http.MethodDelete, "/homes/1/exchange_rates/gbp", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assertJSONResponse(t, rr, http.StatusOK, "Exchange rate reset")
+	require.Equal(t, "GBP", deleted)
+}
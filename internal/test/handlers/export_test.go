@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockExportService struct {
+	WriteCSVFunc func(ctx context.Context, homeID int, dataset string, w io.Writer) error
+}
+
+func (m *mockExportService) WriteCSV(ctx context.Context, homeID int, dataset string, w io.Writer) error {
+	if m.WriteCSVFunc != nil {
+		return m.WriteCSVFunc(ctx, homeID, dataset, w)
+	}
+	return nil
+}
+
+func (m *mockExportService) WriteJSON(ctx context.Context, homeID int, w io.Writer) error {
+	_, err := io.WriteString(w, `{"home":{}}`)
+	return err
+}
+
+func (m *mockExportService) WriteCalendar(ctx context.Context, homeID int, w io.Writer) error {
+	_, err := io.WriteString(w, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+	return err
+}
+
+func setupExportRouter(svc *mockExportService) *chi.Mux {
+	h := handlers.NewExportHandler(svc)
+	r := chi.NewRouter()
+	r.Get("/homes/{home_id}/export/archive.json", h.JSON)
+	r.Get("/homes/{home_id}/export/tasks.ics", h.Calendar)
+	r.Get("/homes/{home_id}/export/{dataset}.csv", h.CSV)
+	return r
+}
+
+func TestExportHandler_CSV(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		mockFunc       func(ctx context.Context, homeID int, dataset string, w io.Writer) error
+		expectedStatus int
+		expectedBody   string
+		expectedType   string
+	}{
+		{
+			name: "Success",
+			path: "/homes/1/export/bills.csv",
+			mockFunc: func(ctx context.Context, homeID int, dataset string, w io.Writer) error {
+				require.Equal(t, 1, homeID)
+				require.Equal(t, "bills", dataset)
+				_, err := io.WriteString(w, "bill_id\n1\n")
+				return err
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "bill_id\n1\n",
+			expectedType:   "text/csv; charset=utf-8",
+		},
+		{
+			name: "Unknown Dataset",
+			path: "/homes/1/export/users.csv",
+			mockFunc: func(ctx context.Context, homeID int, dataset string, w io.Writer) error {
+				return services.ErrUnknownExport
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "unknown export",
+			expectedType:   "application/json",
+		},
+		{
+			name: "Failure Before Streaming",
+			path: "/homes/1/export/tasks.csv",
+			mockFunc: func(ctx context.Context, homeID int, dataset string, w io.Writer) error {
+				return errors.New("connection refused")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to export home data",
+			expectedType:   "application/json",
+		},
+		{
+			name: "Failure While Streaming",
+			path: "/homes/1/export/tasks.csv",
+			mockFunc: func(ctx context.Context, homeID int, dataset string, w io.Writer) error {
+				io.WriteString(w, "task_id\n")
+				return errors.New("connection reset")
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "task_id\n",
+			expectedType:   "text/csv; charset=utf-8",
+		},
+		{
+			name:           "Invalid Home ID",
+			path:           "/homes/abc/export/bills.csv",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid home ID",
+			expectedType:   "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupExportRouter(&mockExportService{WriteCSVFunc: tt.mockFunc})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedType, rr.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestExportHandler_Downloads(t *testing.T) {
+	r := setupExportRouter(&mockExportService{})
+
+	req := httptest.NewRequest(http.MethodGet, "/homes/1/export/tasks.ics", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="home-1-tasks.ics"`, rr.Header().Get("Content-Disposition"))
+	assert.Contains(t, rr.Body.String(), "BEGIN:VCALENDAR")
+
+	req = httptest.NewRequest(http.MethodGet, "/homes/1/export/archive.json", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), `filename="home-1-archive-`)
+	assert.JSONEq(t, `{"home":{}}`, rr.Body.String())
+}
@@ -59,6 +59,9 @@ func (m *mockHomeRepo) GetUserHome(ctx context.Context, userID int) (*models.Hom
 func (m *mockHomeRepo) GetUserHomes(ctx context.Context, userID int) ([]models.Home, error) {
 	return nil, nil
 }
+func (m *mockHomeRepo) GetUserHomeIDs(ctx context.Context, userID int) ([]int, error) {
+	return nil, nil
+}
 func (m *mockHomeRepo) RegenerateCode(ctx context.Context, code string, id int) error { return nil }
 
 func (m *mockHomeRepo) IsMember(ctx context.Context, homeID, userID int) (bool, error) {
@@ -81,7 +84,7 @@ func (m *mockHomeRepo) UpdateMemberRole(ctx context.Context, homeID int, userID
 
 // Mock service
 type mockHomeService struct {
-	CreateHomeFunc           func(ctx context.Context, name string, userID int) error
+	CreateHomeFunc           func(ctx context.Context, name, currency string, userID int) error
 	RegenerateInviteCodeFunc func(ctx context.Context, homeID int) error
 	JoinHomeByCodeFunc       func(ctx context.Context, code string, userID int) error
 	GetUserHomeFunc          func(ctx context.Context, userID int) (*models.Home, error)
@@ -93,8 +96,8 @@ type mockHomeService struct {
 	GetUserHomesFunc         func(ctx context.Context, userID int) ([]models.Home, error)
 }
 
-func (m *mockHomeService) CreateHome(ctx context.Context, name string, userID int) error {
-	return m.CreateHomeFunc(ctx, name, userID)
+func (m *mockHomeService) CreateHome(ctx context.Context, name, currency string, userID int) error {
+	return m.CreateHomeFunc(ctx, name, currency, userID)
 }
 
 func (m *mockHomeService) JoinHomeByCode(ctx context.Context, code string, userID int) error {
@@ -166,7 +169,7 @@ func TestHomeHandler_Create(t *testing.T) {
 		name           string
 		body           interface{}
 		userID         int
-		mockFunc       func(ctx context.Context, name string, userID int) error
+		mockFunc       func(ctx context.Context, name, currency string, userID int) error
 		expectedStatus int
 		expectedBody   string
 	}{
@@ -174,7 +177,7 @@ func TestHomeHandler_Create(t *testing.T) {
 			name:   "Success",
 			body:   validCreateHomeRequest,
 			userID: 123,
-			mockFunc: func(ctx context.Context, name string, userID int) error {
+			mockFunc: func(ctx context.Context, name, currency string, userID int) error {
 				assert.Equal(t, "Test Home", name)
 				assert.Equal(t, 123, userID)
 				return nil
@@ -186,7 +189,7 @@ func TestHomeHandler_Create(t *testing.T) {
 			name:           "Invalid JSON",
 			body:           "{bad json}",
 			userID:         123,
-			mockFunc:       func(ctx context.Context, name string, userID int) error { return nil },
+			mockFunc:       func(ctx context.Context, name, currency string, userID int) error { return nil },
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "Invalid JSON",
 		},
@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock ledger service
+type mockLedgerService struct {
+	GetBalancesFunc func(ctx context.Context, homeID int) (*models.HomeBalances, error)
+}
+
+func (m *mockLedgerService) GetBalances(ctx context.Context, homeID int) (*models.HomeBalances, error) {
+	if m.GetBalancesFunc != nil {
+		return m.GetBalancesFunc(ctx, homeID)
+	}
+	return &models.HomeBalances{}, nil
+}
+
+func setupLedgerRouter(h *handlers.LedgerHandler) *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/homes/{home_id}/balances", h.GetBalances)
+	return r
+}
+
+func TestLedgerHandler_GetBalances(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		mockFunc       func(ctx context.Context, homeID int) (*models.HomeBalances, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			url:  "/homes/1/balances",
+			mockFunc: func(ctx context.Context, homeID int) (*models.HomeBalances, error) {
+				require.Equal(t, 1, homeID)
+				return &models.HomeBalances{
+					Balances:  []models.MemberBalance{{UserID: 1, Net: 2000}, {UserID: 2, Net: -2000}},
+					Transfers: []models.Transfer{{From: 2, To: 1, Amount: 2000}},
+				}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"from_user_id":2,"to_user_id":1,"amount":20.00}`,
+		},
+		{
+			name:           "Invalid Home ID",
+			url:            "/homes/abc/balances",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid home ID",
+		},
+		{
+			name: "Service Error",
+			url:  "/homes/1/balances",
+			mockFunc: func(ctx context.Context, homeID int) (*models.HomeBalances, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to retrieve balances",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handlers.NewLedgerHandler(&mockLedgerService{GetBalancesFunc: tt.mockFunc})
+			r := setupLedgerRouter(h)
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
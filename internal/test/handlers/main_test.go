@@ -0,0 +1,13 @@
+package handlers_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init(os.DevNull)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,275 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type mockOCRService struct {
+	ProcessFunc         func(ctx context.Context, files []models.OCRFile, language string) (*models.OCRResult, error)
+	SuggestCategoryFunc func(ctx context.Context, homeID int, result *models.OCRResult) error
+}
+
+func (m *mockOCRService) Process(ctx context.Context, files []models.OCRFile, language string) (*models.OCRResult, error) {
+	if m.ProcessFunc != nil {
+		return m.ProcessFunc(ctx, files, language)
+	}
+	return &models.OCRResult{}, nil
+}
+
+func (m *mockOCRService) SuggestCategory(ctx context.Context, homeID int, result *models.OCRResult) error {
+	if m.SuggestCategoryFunc != nil {
+		return m.SuggestCategoryFunc(ctx, homeID, result)
+	}
+	return nil
+}
+
+type mockOCRJobService struct {
+	SubmitFunc func(ctx context.Context, userID, homeID int, files []models.OCRFile, language string) (*models.OCRJob, error)
+	GetFunc    func(ctx context.Context, userID int, jobID string) (*models.OCRJob, error)
+}
+
+func (m *mockOCRJobService) Submit(ctx context.Context, userID, homeID int, files []models.OCRFile, language string) (*models.OCRJob, error) {
+	if m.SubmitFunc != nil {
+		return m.SubmitFunc(ctx, userID, homeID, files, language)
+	}
+	return &models.OCRJob{ID: "job", UserID: userID, Status: models.OCRJobQueued}, nil
+}
+
+func (m *mockOCRJobService) Get(ctx context.Context, userID int, jobID string) (*models.OCRJob, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, userID, jobID)
+	}
+	return nil, services.ErrOCRJobNotFound
+}
+
+func setupOCRRouter(svc *mockOCRService, jobs *mockOCRJobService) *chi.Mux {
+	h := handlers.NewOCRHandler(svc, jobs)
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(utils.WithUserID(r.Context(), 123))
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Post("/ocr/process", h.Process)
+	r.Post("/ocr/jobs", h.CreateJob)
+	r.Get("/ocr/jobs/{job_id}", h.GetJob)
+	r.Post("/homes/{home_id}/ocr/process", h.Process)
+	r.Post("/homes/{home_id}/ocr/jobs", h.CreateJob)
+	return r
+}
+
+func receiptUpload(t *testing.T, target, name string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("language", "en"))
+	part, err := writer.CreateFormFile("file", name)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, target, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestOCRHandler_Process_Files(t *testing.T) {
+	upload := func(n int) *http.Request {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for i := 0; i < n; i++ {
+			part, err := writer.CreateFormFile("file", fmt.Sprintf("page%d.txt", i+1))
+			require.NoError(t, err)
+			_, err = fmt.Fprintf(part, "Kiosk\nWater %d.50\n", i+1)
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/ocr/process", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req
+	}
+
+	svc := &mockOCRService{
+		ProcessFunc: func(ctx context.Context, files []models.OCRFile, language string) (*models.OCRResult, error) {
+			require.Len(t, files, 2)
+			require.Contains(t, string(files[1].Data), "Water 2.50")
+			return &models.OCRResult{Vendor: "Kiosk", Pages: []models.OCRPage{{Page: 1, File: 1}, {Page: 2, File: 2}}}, nil
+		},
+	}
+	r := setupOCRRouter(svc, &mockOCRJobService{})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, upload(2))
+	assertJSONResponse(t, rr, http.StatusOK, `"pages":[{"page":1,"file":1`)
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, upload(6))
+	assertJSONResponse(t, rr, http.StatusBadRequest, "At most 5 files per receipt")
+}
+
+func TestOCRHandler_Process(t *testing.T) {
+	tests := []struct {
+		name           string
+		file           string
+		content        []byte
+		mockFunc       func(ctx context.Context, files []models.OCRFile, language string) (*models.OCRResult, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "Text Receipt",
+			file:    "receipt.txt",
+			content: []byte("Kiosk\nWater 1.50\n"),
+			mockFunc: func(ctx context.Context, files []models.OCRFile, language string) (*models.OCRResult, error) {
+				require.Len(t, files, 1)
+				require.Equal(t, "text/plain", files[0].MimeType)
+				require.Equal(t, "en", language)
+				require.Contains(t, string(files[0].Data), "Water")
+				return &models.OCRResult{Vendor: "Kiosk", Total: 150}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"vendor":"Kiosk"`,
+		},
+		{
+			name:    "Unsupported By Provider",
+			file:    "receipt.png",
+			content: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"),
+			mockFunc: func(ctx context.Context, files []models.OCRFile, language string) (*models.OCRResult, error) {
+				return nil, services.ErrUnsupportedOCRFile
+			},
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   "not supported by the OCR provider",
+		},
+		{
+			name:           "Unsupported Type",
+			file:           "receipt.zip",
+			content:        []byte("PK\x03\x04\x14\x00\x00\x00"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Unsupported file type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupOCRRouter(&mockOCRService{ProcessFunc: tt.mockFunc}, &mockOCRJobService{})
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, receiptUpload(t, "/ocr/process", tt.file, tt.content))
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
+func TestOCRHandler_CreateJob(t *testing.T) {
+	jobs := &mockOCRJobService{
+		SubmitFunc: func(ctx context.Context, userID, homeID int, files []models.OCRFile, language string) (*models.OCRJob, error) {
+			require.Equal(t, 123, userID)
+			require.Equal(t, 0, homeID)
+			require.Equal(t, "application/pdf", files[0].MimeType)
+			require.Equal(t, "en", language)
+			return &models.OCRJob{ID: "3f1c", UserID: userID, Status: models.OCRJobQueued}, nil
+		},
+	}
+	r := setupOCRRouter(&mockOCRService{}, jobs)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, receiptUpload(t, "/ocr/jobs", "receipt.pdf", []byte("%PDF-1.4\n")))
+
+	assertJSONResponse(t, rr, http.StatusAccepted, `"id":"3f1c"`)
+}
+
+func TestOCRHandler_Process_ForHome(t *testing.T) {
+	svc := &mockOCRService{
+		ProcessFunc: func(ctx context.Context, files []models.OCRFile, language string) (*models.OCRResult, error) {
+			return &models.OCRResult{Vendor: "Kiosk", Total: 150}, nil
+		},
+		SuggestCategoryFunc: func(ctx context.Context, homeID int, result *models.OCRResult) error {
+			require.Equal(t, 5, homeID)
+			categoryID := 3
+			result.SuggestedCategoryID = &categoryID
+			return nil
+		},
+	}
+	r := setupOCRRouter(svc, &mockOCRJobService{})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, receiptUpload(t, "/homes/5/ocr/process", "receipt.txt", []byte("Kiosk\nWater 1.50\n")))
+	assertJSONResponse(t, rr, http.StatusOK, `"suggested_category_id":3`)
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, receiptUpload(t, "/homes/abc/ocr/process", "receipt.txt", []byte("Kiosk\nWater 1.50\n")))
+	assertJSONResponse(t, rr, http.StatusBadRequest, "Invalid home id")
+}
+
+func TestOCRHandler_CreateJob_ForHome(t *testing.T) {
+	jobs := &mockOCRJobService{
+		SubmitFunc: func(ctx context.Context, userID, homeID int, files []models.OCRFile, language string) (*models.OCRJob, error) {
+			require.Equal(t, 5, homeID)
+			return &models.OCRJob{ID: "3f1c", UserID: userID, HomeID: &homeID, Status: models.OCRJobQueued}, nil
+		},
+	}
+	r := setupOCRRouter(&mockOCRService{}, jobs)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, receiptUpload(t, "/homes/5/ocr/jobs", "receipt.pdf", []byte("%PDF-1.4\n")))
+
+	assertJSONResponse(t, rr, http.StatusAccepted, `"home_id":5`)
+}
+
+func TestOCRHandler_GetJob(t *testing.T) {
+	tests := []struct {
+		name           string
+		jobID          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Completed",
+			jobID:          "3f1c",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"result":{"vendor":"Kiosk"}`,
+		},
+		{
+			name:           "Not Found",
+			jobID:          "other",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "OCR job not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := &mockOCRJobService{
+				GetFunc: func(ctx context.Context, userID int, jobID string) (*models.OCRJob, error) {
+					require.Equal(t, 123, userID)
+					if jobID != "3f1c" {
+						return nil, services.ErrOCRJobNotFound
+					}
+					return &models.OCRJob{ID: jobID, Status: models.OCRJobCompleted, Result: []byte(`{"vendor":"Kiosk"}`)}, nil
+				},
+			}
+			r := setupOCRRouter(&mockOCRService{}, jobs)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ocr/jobs/"+tt.jobID, nil))
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/http/handlers"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/Dragodui/diploma-server/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// Mock settlement service
+type mockSettlementService struct {
+	RecordSettlementFunc       func(ctx context.Context, homeID, fromID, toID int, amount models.Money, note string) (*models.Settlement, error)
+	GetSettlementsByHomeIDFunc func(ctx context.Context, homeID int) ([]models.Settlement, error)
+}
+
+func (m *mockSettlementService) RecordSettlement(ctx context.Context, homeID, fromID, toID int, amount models.Money, note string) (*models.Settlement, error) {
+	if m.RecordSettlementFunc != nil {
+		return m.RecordSettlementFunc(ctx, homeID, fromID, toID, amount, note)
+	}
+	return &models.Settlement{HomeID: homeID, FromUserID: fromID, ToUserID: toID, Amount: amount}, nil
+}
+
+func (m *mockSettlementService) GetSettlementsByHomeID(ctx context.Context, homeID int) ([]models.Settlement, error) {
+	if m.GetSettlementsByHomeIDFunc != nil {
+		return m.GetSettlementsByHomeIDFunc(ctx, homeID)
+	}
+	return nil, nil
+}
+
+// allMembersRepo treats every user as a member of the home
+func allMembersRepo() *mockHomeRepo {
+	return &mockHomeRepo{
+		IsMemberFunc: func(ctx context.Context, homeID, userID int) (bool, error) {
+			return true, nil
+		},
+	}
+}
+
+func setupSettlementRouter(h *handlers.SettlementHandler) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(utils.WithUserID(r.Context(), 123))
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/homes/{home_id}/settlements", h.GetByHomeID)
+	r.Post("/homes/{home_id}/settlements", h.Create)
+	return r
+}
+
+func TestSettlementHandler_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		homeRepo       *mockHomeRepo
+		mockFunc       func(ctx context.Context, homeID, fromID, toID int, amount models.Money, note string) (*models.Settlement, error)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:     "Success",
+			body:     `{"to_user_id":7,"amount":12.5,"note":"groceries"}`,
+			homeRepo: allMembersRepo(),
+			mockFunc: func(ctx context.Context, homeID, fromID, toID int, amount models.Money, note string) (*models.Settlement, error) {
+				assert.Equal(t, 1, homeID)
+				assert.Equal(t, 123, fromID)
+				assert.Equal(t, 7, toID)
+				assert.Equal(t, models.Money(1250), amount)
+				return &models.Settlement{ID: 9, FromUserID: fromID, ToUserID: toID, Amount: amount}, nil
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"settlement"`,
+		},
+		{
+			name:           "Receiver Records Payment",
+			body:           `{"from_user_id":7,"to_user_id":123,"amount":5}`,
+			homeRepo:       allMembersRepo(),
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Third Party Forbidden",
+			body:           `{"from_user_id":7,"to_user_id":8,"amount":5}`,
+			homeRepo:       allMembersRepo(),
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "forbidden",
+		},
+		{
+			name: "Admin Records For Others",
+			body: `{"from_user_id":7,"to_user_id":8,"amount":5}`,
+			homeRepo: &mockHomeRepo{
+				IsMemberFunc: func(ctx context.Context, homeID, userID int) (bool, error) {
+					return true, nil
+				},
+				IsAdminFunc: func(ctx context.Context, homeID, userID int) (bool, error) {
+					return true, nil
+				},
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Receiver Not A Member",
+			body: `{"to_user_id":7,"amount":5}`,
+			homeRepo: &mockHomeRepo{
+				IsMemberFunc: func(ctx context.Context, homeID, userID int) (bool, error) {
+					return userID != 7, nil
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "both users must be members",
+		},
+		{
+			name:           "Invalid Amount",
+			body:           `{"to_user_id":7,"amount":-5}`,
+			homeRepo:       allMembersRepo(),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{bad json}`,
+			homeRepo:       allMembersRepo(),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid JSON",
+		},
+		{
+			name:     "Self Payment",
+			body:     `{"to_user_id":123,"amount":5}`,
+			homeRepo: allMembersRepo(),
+			mockFunc: func(ctx context.Context, homeID, fromID, toID int, amount models.Money, note string) (*models.Settlement, error) {
+				return nil, services.ErrSelfSettlement
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   services.ErrSelfSettlement.Error(),
+		},
+		{
+			name:     "Service Error",
+			body:     `{"to_user_id":7,"amount":5}`,
+			homeRepo: allMembersRepo(),
+			mockFunc: func(ctx context.Context, homeID, fromID, toID int, amount models.Money, note string) (*models.Settlement, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to record payment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handlers.NewSettlementHandler(&mockSettlementService{RecordSettlementFunc: tt.mockFunc}, tt.homeRepo)
+			r := setupSettlementRouter(h)
+
+			req := httptest.NewRequest(http.MethodPost, "/homes/1/settlements", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+		})
+	}
+}
+
+func TestSettlementHandler_GetByHomeID(t *testing.T) {
+	svc := &mockSettlementService{
+		GetSettlementsByHomeIDFunc: func(ctx context.Context, homeID int) ([]models.Settlement, error) {
+			return []models.Settlement{{ID: 3, HomeID: homeID, Amount: 1000}}, nil
+		},
+	}
+	r := setupSettlementRouter(handlers.NewSettlementHandler(svc, allMembersRepo()))
+
+	req := httptest.NewRequest(http.MethodGet, "/homes/1/settlements", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assertJSONResponse(t, rr, http.StatusOK, `"settlements"`)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/Dragodui/diploma-server/internal/http/handlers"
 	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
 	"github.com/Dragodui/diploma-server/internal/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -108,6 +109,10 @@ func setupShoppingRouter(h *handlers.ShoppingHandler) *chi.Mux {
 	r.Delete("/homes/{home_id}/items/{item_id}", h.DeleteItem)
 	r.Put("/items/{item_id}/mark-bought", h.MarkIsBought)
 
+	// Receipts
+	r.Post("/homes/{home_id}/shopping/reconcile", h.ReconcileReceipt)
+	r.Post("/homes/{home_id}/shopping/reconcile/confirm", h.ConfirmPurchases)
+
 	return r
 }
 
@@ -141,6 +146,10 @@ type mockShoppingService struct {
 	DeleteItemFunc            func(ctx context.Context, itemID int) error
 	MarkIsBoughtFunc          func(ctx context.Context, itemID int) error
 	EditItemFunc              func(ctx context.Context, itemID int, name, image, link *string, isBought *bool, boughtAt *time.Time) error
+
+	// Receipts
+	ReconcileReceiptFunc func(ctx context.Context, homeID int, items []models.OCRItem) (*models.ShoppingReconciliation, error)
+	ConfirmPurchasesFunc func(ctx context.Context, homeID int, purchases []models.ShoppingPurchase) error
 }
 
 // Category methods
@@ -222,6 +231,20 @@ func (m *mockShoppingService) EditItem(ctx context.Context, itemID int, name, im
 	return nil
 }
 
+func (m *mockShoppingService) ReconcileReceipt(ctx context.Context, homeID int, items []models.OCRItem) (*models.ShoppingReconciliation, error) {
+	if m.ReconcileReceiptFunc != nil {
+		return m.ReconcileReceiptFunc(ctx, homeID, items)
+	}
+	return &models.ShoppingReconciliation{}, nil
+}
+
+func (m *mockShoppingService) ConfirmPurchases(ctx context.Context, homeID int, purchases []models.ShoppingPurchase) error {
+	if m.ConfirmPurchasesFunc != nil {
+		return m.ConfirmPurchasesFunc(ctx, homeID, purchases)
+	}
+	return nil
+}
+
 // CATEGORY TESTS
 func TestShoppingHandler_Categories(t *testing.T) {
 	t.Run("CreateCategory", func(t *testing.T) {
@@ -955,3 +978,80 @@ func TestShoppingHandler_Items(t *testing.T) {
 	})
 
 }
+
+// RECEIPT TESTS
+func TestShoppingHandler_Receipts(t *testing.T) {
+	t.Run("ReconcileReceipt", func(t *testing.T) {
+		svc := &mockShoppingService{
+			ReconcileReceiptFunc: func(ctx context.Context, homeID int, items []models.OCRItem) (*models.ShoppingReconciliation, error) {
+				assert.Equal(t, 1, homeID)
+				require.Len(t, items, 2)
+				return &models.ShoppingReconciliation{
+					Matches:   []models.ShoppingMatch{{Item: *validItem, ReceiptItem: items[0], Score: 1}},
+					Unmatched: items[1:],
+				}, nil
+			},
+		}
+		r := setupShoppingRouter(setupShoppingHandler(svc))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, makeJSONRequest(http.MethodPost, "/homes/1/shopping/reconcile", models.ReconcileReceiptRequest{
+			Items: []models.OCRItem{{Name: "MILK 1L", Price: 99}, {Name: "Bananas", Price: 200}},
+		}))
+		assertJSONResponse(t, rr, http.StatusOK, `"unmatched":[{"name":"Bananas"`)
+
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, makeJSONRequest(http.MethodPost, "/homes/1/shopping/reconcile", models.ReconcileReceiptRequest{}))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("ConfirmPurchases", func(t *testing.T) {
+		price := models.Money(99)
+		tests := []struct {
+			name           string
+			mockFunc       func(ctx context.Context, homeID int, purchases []models.ShoppingPurchase) error
+			expectedStatus int
+			expectedBody   string
+		}{
+			{
+				name: "Success",
+				mockFunc: func(ctx context.Context, homeID int, purchases []models.ShoppingPurchase) error {
+					assert.Equal(t, 1, homeID)
+					assert.Equal(t, price, *purchases[0].Price)
+					return nil
+				},
+				expectedStatus: http.StatusOK,
+				expectedBody:   "Marked successfully",
+			},
+			{
+				name: "Not Open",
+				mockFunc: func(ctx context.Context, homeID int, purchases []models.ShoppingPurchase) error {
+					return services.ErrShoppingItemNotOpen
+				},
+				expectedStatus: http.StatusConflict,
+				expectedBody:   "not open",
+			},
+			{
+				name: "Service Error",
+				mockFunc: func(ctx context.Context, homeID int, purchases []models.ShoppingPurchase) error {
+					return errors.New("db down")
+				},
+				expectedStatus: http.StatusInternalServerError,
+				expectedBody:   "Failed to mark items as bought",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				r := setupShoppingRouter(setupShoppingHandler(&mockShoppingService{ConfirmPurchasesFunc: tt.mockFunc}))
+
+				rr := httptest.NewRecorder()
+				r.ServeHTTP(rr, makeJSONRequest(http.MethodPost, "/homes/1/shopping/reconcile/confirm", models.ConfirmPurchasesRequest{
+					Purchases: []models.ShoppingPurchase{{ItemID: 1, Price: &price}},
+				}))
+
+				assertJSONResponse(t, rr, tt.expectedStatus, tt.expectedBody)
+			})
+		}
+	})
+}
@@ -63,6 +63,9 @@ func (m *mockHomeRepo) GetUserHome(ctx context.Context, userID int) (*models.Hom
 func (m *mockHomeRepo) GetUserHomes(ctx context.Context, userID int) ([]models.Home, error) {
 	return nil, nil
 }
+func (m *mockHomeRepo) GetUserHomeIDs(ctx context.Context, userID int) ([]int, error) {
+	return nil, nil
+}
 func (m *mockHomeRepo) RegenerateCode(ctx context.Context, code string, id int) error { return nil }
 
 func (m *mockHomeRepo) IsMember(ctx context.Context, homeID, userID int) (bool, error) {
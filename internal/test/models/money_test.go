@@ -0,0 +1,80 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected models.Money
+	}{
+		{"12.34", 1234},
+		{"12.3", 1230},
+		{"12", 1200},
+		{".5", 50},
+		{"-0.07", -7},
+		{"+3", 300},
+		{"0.005", 1},
+		{"0.004", 0},
+		{"-2.675", -268},
+		{"1e2", 10000},
+		{"1.5E-1", 15},
+		{"0000.10", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := models.ParseMoney(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestParseMoney_Invalid(t *testing.T) {
+	for _, input := range []string{"", "-", ".", "abc", "1,50", "1.2.3", "1e", "99999999999999999999"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := models.ParseMoney(input)
+			assert.ErrorIs(t, err, models.ErrInvalidMoney)
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "12.34", models.Money(1234).String())
+	assert.Equal(t, "0.05", models.Money(5).String())
+	assert.Equal(t, "-0.05", models.Money(-5).String())
+	assert.Equal(t, "-120.00", models.Money(-12000).String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var req struct {
+		A models.Money `json:"a"`
+		B models.Money `json:"b"`
+		C models.Money `json:"c"`
+		D models.Money `json:"d"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"a":0.1,"b":0.2,"c":"0.30","d":null}`), &req))
+
+	// no binary floating point drift
+	assert.Equal(t, req.C, req.A+req.B)
+	assert.Zero(t, req.D)
+
+	out, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":0.10,"b":0.20,"c":0.30,"d":0.00}`, string(out))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"a":true}`), &req))
+}
+
+func TestMoneyFromFloat(t *testing.T) {
+	assert.Equal(t, models.Money(29), models.MoneyFromFloat(0.29))
+	assert.Equal(t, models.Money(4300), models.MoneyFromFloat(10*4.3))
+	assert.Equal(t, 12.34, models.Money(1234).Float())
+}
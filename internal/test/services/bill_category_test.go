@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCachedBillListing lists bill 1 of category 5 through a bill service that shares its
+// cache with the returned category service
+func setupCachedBillListing(t *testing.T) (*services.BillService, *services.BillCategoryService) {
+	categories := &mockBillCategoryRepo{categories: map[int]*models.BillCategory{5: groceries()}}
+	bills := &mockBillRepo{
+		FindByHomeIDFunc: func(ctx context.Context, f models.BillFilter) ([]models.Bill, error) {
+			bill := models.Bill{ID: 1, HomeID: 1}
+			if category, ok := categories.categories[5]; ok {
+				bill.BillCategoryID = &category.ID
+				bill.BillCategory = &models.BillCategory{ID: category.ID, HomeID: category.HomeID, Name: category.Name}
+			}
+			return []models.Bill{bill}, nil
+		},
+	}
+
+	cache := newFakeRedis(t)
+	billSvc := services.NewBillService(bills, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("USD"), &mockRateSource{rate: 1}, cache, &mockNotifSvc{}, &mockOutbox{})
+	return billSvc, services.NewBillCategoryService(categories, cache, &mockOutbox{})
+}
+
+func TestBillCategoryService_UpdateCategory_RefreshesBillList(t *testing.T) {
+	ctx := context.Background()
+	billSvc, svc := setupCachedBillListing(t)
+
+	page, err := billSvc.GetBillsByHomeID(ctx, models.BillFilter{HomeID: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Bills, 1)
+	assert.Equal(t, "Groceries", page.Bills[0].BillCategory.Name)
+
+	name := "Food"
+	_, err = svc.UpdateCategory(ctx, 5, &name, nil)
+	require.NoError(t, err)
+
+	page, err = billSvc.GetBillsByHomeID(ctx, models.BillFilter{HomeID: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Bills, 1)
+	require.NotNil(t, page.Bills[0].BillCategory)
+	assert.Equal(t, "Food", page.Bills[0].BillCategory.Name)
+}
+
+func TestBillCategoryService_DeleteCategory_RefreshesBillList(t *testing.T) {
+	ctx := context.Background()
+	billSvc, svc := setupCachedBillListing(t)
+
+	page, err := billSvc.GetBillsByHomeID(ctx, models.BillFilter{HomeID: 1})
+	require.NoError(t, err)
+	require.NotNil(t, page.Bills[0].BillCategory)
+
+	require.NoError(t, svc.DeleteCategory(ctx, 5, 1))
+
+	page, err = billSvc.GetBillsByHomeID(ctx, models.BillFilter{HomeID: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Bills, 1)
+	assert.Nil(t, page.Bills[0].BillCategoryID)
+	assert.Nil(t, page.Bills[0].BillCategory)
+}
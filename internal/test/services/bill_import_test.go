@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock BillImportRepository
+type mockBillImportRepo struct {
+	profiles  map[int]*models.ImportProfile
+	rules     []models.VendorRule
+	imports   map[int]*models.BillImport
+	committed bool
+}
+
+func (m *mockBillImportRepo) CreateProfile(ctx context.Context, p *models.ImportProfile) error {
+	p.ID = len(m.profiles) + 1
+	m.profiles[p.ID] = p
+	return nil
+}
+
+func (m *mockBillImportRepo) FindProfile(ctx context.Context, id int) (*models.ImportProfile, error) {
+	return m.profiles[id], nil
+}
+
+func (m *mockBillImportRepo) FindProfilesByHome(ctx context.Context, homeID int) ([]models.ImportProfile, error) {
+	return nil, nil
+}
+
+func (m *mockBillImportRepo) DeleteProfile(ctx context.Context, id int) error {
+	delete(m.profiles, id)
+	return nil
+}
+
+func (m *mockBillImportRepo) CreateRule(ctx context.Context, rule *models.VendorRule) error {
+	m.rules = append(m.rules, *rule)
+	return nil
+}
+
+func (m *mockBillImportRepo) FindRule(ctx context.Context, id int) (*models.VendorRule, error) {
+	return nil, nil
+}
+
+func (m *mockBillImportRepo) FindRulesByHome(ctx context.Context, homeID int) ([]models.VendorRule, error) {
+	return m.rules, nil
+}
+
+func (m *mockBillImportRepo) DeleteRule(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *mockBillImportRepo) Create(ctx context.Context, imp *models.BillImport) error {
+	imp.ID = len(m.imports) + 1
+	m.imports[imp.ID] = imp
+	return nil
+}
+
+func (m *mockBillImportRepo) FindByID(ctx context.Context, id int) (*models.BillImport, error) {
+	return m.imports[id], nil
+}
+
+func (m *mockBillImportRepo) MarkCommitted(ctx context.Context, id int, at time.Time) (bool, error) {
+	if m.committed {
+		return false, nil
+	}
+	m.committed = true
+	return true, nil
+}
+
+// mockBillImporter records the rows turned into bills
+type mockBillImporter struct {
+	rows []models.ImportRow
+}
+
+func (m *mockBillImporter) CreateImportedBill(ctx context.Context, homeID, uploadedBy int, row models.ImportRow, currency string) (*models.Bill, error) {
+	m.rows = append(m.rows, row)
+	return &models.Bill{ID: len(m.rows), HomeID: homeID, TotalAmount: row.Amount, Currency: currency, Description: row.Description}, nil
+}
+
+func bankProfile() *models.ImportProfile {
+	return &models.ImportProfile{
+		ID:                1,
+		HomeID:            1,
+		Name:              "Bank",
+		Delimiter:         ";",
+		HasHeader:         true,
+		SkipLines:         1,
+		DateColumn:        "Date",
+		DateFormat:        "DD.MM.YYYY",
+		AmountColumn:      "Amount",
+		AmountSign:        models.AmountSignNegative,
+		DecimalSeparator:  ",",
+		DescriptionColumn: "Description",
+	}
+}
+
+const bankStatement = "\ufeffAccount 123\n" +
+	"Date;Description;Amount\n" +
+	"01.03.2025;LIDL Store 42;-12,50\n" +
+	"01.03.2025;Salary;2.500,00\n" +
+	"02.03.2025;Coffee;-3,20\n" +
+	"02.03.2025;Coffee;-3,20\n" +
+	"31.02.2025;Broken;-1,00\n" +
+	"\n"
+
+func setupBillImportService(repo *mockBillImportRepo, billRepo *mockBillRepo, importer *mockBillImporter) *services.BillImportService {
+	categories := &mockBillCategoryRepo{categories: map[int]*models.BillCategory{5: groceries()}}
+	notif := &mockNotifSvc{CreateHomeNotificationFunc: func(ctx context.Context, from *int, homeID int, description string) error { return nil }}
+	return services.NewBillImportService(repo, billRepo, categories, importer, homeWithCurrency("PLN"), notif, &mockOutbox{})
+}
+
+func TestBillImportService_Preview(t *testing.T) {
+	repo := &mockBillImportRepo{
+		profiles: map[int]*models.ImportProfile{1: bankProfile()},
+		rules: []models.VendorRule{
+			{Pattern: "store", BillCategoryID: 7},
+			{Pattern: "lidl", BillCategoryID: 5},
+			{Pattern: "lidl store", BillCategoryID: 5},
+		},
+		imports: map[int]*models.BillImport{},
+	}
+	svc := setupBillImportService(repo, &mockBillRepo{}, &mockBillImporter{})
+
+	imp, err := svc.Preview(context.Background(), 1, 2, 1, "march.csv", strings.NewReader(bankStatement))
+	require.NoError(t, err)
+
+	assert.Equal(t, "PLN", imp.Currency)
+	assert.Equal(t, models.ImportStatusPreview, imp.Status)
+	require.Len(t, imp.Rows, 5)
+
+	lidl := imp.Rows[0]
+	assert.Equal(t, 3, lidl.Line)
+	assert.Equal(t, models.ImportRowNew, lidl.Status)
+	assert.Equal(t, models.Money(1250), lidl.Amount)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), lidl.Date)
+	require.NotNil(t, lidl.BillCategoryID)
+	assert.Equal(t, 5, *lidl.BillCategoryID) // longest pattern wins
+
+	assert.Equal(t, models.ImportRowIncome, imp.Rows[1].Status)
+	assert.Equal(t, models.Money(250000), imp.Rows[1].Amount)
+
+	// the same coffee twice on one day is two transactions
+	assert.Equal(t, models.ImportRowNew, imp.Rows[3].Status)
+	assert.NotEqual(t, imp.Rows[2].Hash, imp.Rows[3].Hash)
+	assert.Nil(t, imp.Rows[2].BillCategoryID)
+
+	assert.Equal(t, models.ImportRowInvalid, imp.Rows[4].Status)
+	assert.Equal(t, "invalid date", imp.Rows[4].Error)
+}
+
+func TestBillImportService_Preview_Duplicates(t *testing.T) {
+	repo := &mockBillImportRepo{profiles: map[int]*models.ImportProfile{1: bankProfile()}, imports: map[int]*models.BillImport{}}
+	svc := setupBillImportService(repo, &mockBillRepo{}, &mockBillImporter{})
+
+	first, err := svc.Preview(context.Background(), 1, 2, 1, "march.csv", strings.NewReader(bankStatement))
+	require.NoError(t, err)
+
+	// the first coffee was imported before
+	imported := first.Rows[2].Hash
+	billRepo := &mockBillRepo{
+		FindImportHashesFunc: func(ctx context.Context, homeID int, hashes []string) (map[string]bool, error) {
+			return map[string]bool{imported: true}, nil
+		},
+	}
+	svc = setupBillImportService(repo, billRepo, &mockBillImporter{})
+
+	second, err := svc.Preview(context.Background(), 1, 2, 1, "march.csv", strings.NewReader(bankStatement))
+	require.NoError(t, err)
+	assert.Equal(t, first.Rows[2].Hash, second.Rows[2].Hash)
+	assert.Equal(t, models.ImportRowDuplicate, second.Rows[2].Status)
+	assert.Equal(t, models.ImportRowNew, second.Rows[3].Status)
+}
+
+func TestBillImportService_Preview_HeaderMismatch(t *testing.T) {
+	profile := bankProfile()
+	profile.AmountColumn = "Betrag"
+	repo := &mockBillImportRepo{profiles: map[int]*models.ImportProfile{1: profile}, imports: map[int]*models.BillImport{}}
+	svc := setupBillImportService(repo, &mockBillRepo{}, &mockBillImporter{})
+
+	_, err := svc.Preview(context.Background(), 1, 2, 1, "march.csv", strings.NewReader(bankStatement))
+	assert.ErrorIs(t, err, services.ErrInvalidImportFile)
+}
+
+func TestBillImportService_Preview_OtherHome(t *testing.T) {
+	repo := &mockBillImportRepo{profiles: map[int]*models.ImportProfile{1: bankProfile()}, imports: map[int]*models.BillImport{}}
+	svc := setupBillImportService(repo, &mockBillRepo{}, &mockBillImporter{})
+
+	_, err := svc.Preview(context.Background(), 9, 2, 1, "march.csv", strings.NewReader(bankStatement))
+	assert.ErrorIs(t, err, services.ErrImportProfileNotFound)
+}
+
+func TestBillImportService_Commit(t *testing.T) {
+	repo := &mockBillImportRepo{profiles: map[int]*models.ImportProfile{1: bankProfile()}, imports: map[int]*models.BillImport{}}
+	importer := &mockBillImporter{}
+	svc := setupBillImportService(repo, &mockBillRepo{}, importer)
+
+	imp, err := svc.Preview(context.Background(), 1, 2, 1, "march.csv", strings.NewReader(bankStatement))
+	require.NoError(t, err)
+
+	result, err := svc.Commit(context.Background(), 1, 2, imp.ID, models.CommitImportRequest{
+		Categories: map[int]int{5: 5},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Created)
+	assert.Equal(t, 2, result.Skipped) // income and the invalid row
+	require.Len(t, importer.rows, 3)
+	assert.Equal(t, "LIDL Store 42", importer.rows[0].Description)
+	require.NotNil(t, importer.rows[1].BillCategoryID)
+	assert.Equal(t, 5, *importer.rows[1].BillCategoryID)
+
+	_, err = svc.Commit(context.Background(), 1, 2, imp.ID, models.CommitImportRequest{})
+	assert.ErrorIs(t, err, services.ErrImportCommitted)
+}
+
+func TestBillImportService_Commit_SelectedLines(t *testing.T) {
+	repo := &mockBillImportRepo{profiles: map[int]*models.ImportProfile{1: bankProfile()}, imports: map[int]*models.BillImport{}}
+	importer := &mockBillImporter{}
+	svc := setupBillImportService(repo, &mockBillRepo{}, importer)
+
+	imp, err := svc.Preview(context.Background(), 1, 2, 1, "march.csv", strings.NewReader(bankStatement))
+	require.NoError(t, err)
+
+	// income can't become a bill
+	_, err = svc.Commit(context.Background(), 1, 2, imp.ID, models.CommitImportRequest{Lines: []int{4}})
+	assert.ErrorIs(t, err, services.ErrInvalidImportLine)
+
+	// a duplicate is imported when picked explicitly
+	imp.Rows[0].Status = models.ImportRowDuplicate
+	result, err := svc.Commit(context.Background(), 1, 2, imp.ID, models.CommitImportRequest{Lines: []int{3}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 4, result.Skipped)
+	assert.Equal(t, 3, importer.rows[0].Line)
+}
+
+func TestBillImportService_CreateProfile(t *testing.T) {
+	repo := &mockBillImportRepo{profiles: map[int]*models.ImportProfile{}, imports: map[int]*models.BillImport{}}
+	svc := setupBillImportService(repo, &mockBillRepo{}, &mockBillImporter{})
+
+	profile, err := svc.CreateProfile(context.Background(), 1, models.CreateImportProfileRequest{
+		Name:              "Card",
+		DateColumn:        "Booked",
+		DateFormat:        "YYYY-MM-DD",
+		AmountColumn:      "Amount",
+		DescriptionColumn: "Payee",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ",", profile.Delimiter)
+	assert.Equal(t, ".", profile.DecimalSeparator)
+	assert.Equal(t, models.AmountSignNegative, profile.AmountSign)
+	assert.True(t, profile.HasHeader)
+
+	noHeader := false
+	_, err = svc.CreateProfile(context.Background(), 1, models.CreateImportProfileRequest{
+		Name:              "Card",
+		HasHeader:         &noHeader,
+		DateColumn:        "Booked",
+		DateFormat:        "YYYY-MM-DD",
+		AmountColumn:      "2",
+		DescriptionColumn: "3",
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidImportProfile)
+
+	_, err = svc.CreateProfile(context.Background(), 1, models.CreateImportProfileRequest{
+		Name:              "Card",
+		DateColumn:        "Booked",
+		DateFormat:        "MM/YYYY",
+		AmountColumn:      "Amount",
+		DescriptionColumn: "Payee",
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidImportProfile)
+}
+
+func TestBillImportService_CreateRule_ForeignCategory(t *testing.T) {
+	repo := &mockBillImportRepo{profiles: map[int]*models.ImportProfile{}, imports: map[int]*models.BillImport{}}
+	svc := setupBillImportService(repo, &mockBillRepo{}, &mockBillImporter{})
+
+	_, err := svc.CreateRule(context.Background(), 2, models.CreateVendorRuleRequest{Pattern: "lidl", BillCategoryID: 5})
+	assert.ErrorIs(t, err, services.ErrCategoryNotFound)
+
+	rule, err := svc.CreateRule(context.Background(), 1, models.CreateVendorRuleRequest{Pattern: " lidl ", BillCategoryID: 5})
+	require.NoError(t, err)
+	assert.Equal(t, "lidl", rule.Pattern)
+}
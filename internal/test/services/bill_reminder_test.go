@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock BillReminderRepository, enforcing the one-reminder-per-due-date key like the unique index
+type mockBillReminderRepo struct {
+	settings map[int]int
+	splits   []models.BillSplit
+	sent     map[string]bool
+}
+
+func reminderKey(billID, userID int, kind string, due time.Time) string {
+	return fmt.Sprintf("%d/%d/%s/%s", billID, userID, kind, due.UTC().Format(time.RFC3339))
+}
+
+func (m *mockBillReminderRepo) UpsertSettings(ctx context.Context, s *models.BillReminderSettings) error {
+	m.settings[s.HomeID] = s.LeadDays
+	return nil
+}
+
+func (m *mockBillReminderRepo) FindSettings(ctx context.Context, homeID int) (*models.BillReminderSettings, error) {
+	lead, ok := m.settings[homeID]
+	if !ok {
+		return nil, nil
+	}
+	return &models.BillReminderSettings{HomeID: homeID, LeadDays: lead}, nil
+}
+
+func (m *mockBillReminderRepo) LeadDays(ctx context.Context, homeIDs []int) (map[int]int, error) {
+	return m.settings, nil
+}
+
+func (m *mockBillReminderRepo) FindUnpaidDueSplits(ctx context.Context, dueBefore time.Time) ([]models.BillSplit, error) {
+	var due []models.BillSplit
+	for _, sp := range m.splits {
+		if sp.Bill.DueDate.Before(dueBefore) && !m.sent[reminderKey(sp.BillID, sp.UserID, models.ReminderOverdue, *sp.Bill.DueDate)] {
+			due = append(due, sp)
+		}
+	}
+	return due, nil
+}
+
+func (m *mockBillReminderRepo) MarkSent(ctx context.Context, r *models.BillReminder) (bool, error) {
+	key := reminderKey(r.BillID, r.UserID, r.Kind, r.DueDate)
+	if m.sent[key] {
+		return false, nil
+	}
+	m.sent[key] = true
+	return true, nil
+}
+
+type sentNotification struct {
+	to   int
+	text string
+}
+
+func setupReminderService(repo *mockBillReminderRepo) (*services.BillReminderService, *[]sentNotification) {
+	var notifications []sentNotification
+	notifSvc := &mockNotifSvc{
+		CreateFunc: func(ctx context.Context, from *int, to int, description string) error {
+			notifications = append(notifications, sentNotification{to, description})
+			return nil
+		},
+	}
+	return services.NewBillReminderService(repo, notifSvc, &mockOutbox{}), &notifications
+}
+
+func dueSplit(billID, homeID, userID int, due time.Time) models.BillSplit {
+	return models.BillSplit{
+		BillID: billID,
+		UserID: userID,
+		Amount: 4500,
+		Bill:   &models.Bill{ID: billID, HomeID: homeID, Type: "electricity", Currency: "EUR", DueDate: &due},
+	}
+}
+
+func TestBillReminderService_SendReminders(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := &mockBillReminderRepo{
+		settings: map[int]int{1: 5},
+		splits: []models.BillSplit{
+			dueSplit(1, 1, 2, now.AddDate(0, 0, 4)),   // within home 1's five days
+			dueSplit(2, 2, 2, now.AddDate(0, 0, 4)),   // outside the default three days
+			dueSplit(3, 2, 3, now.Add(8*time.Hour)),   // later today
+			dueSplit(4, 2, 4, now.AddDate(0, 0, -2)),  // two days late
+			dueSplit(5, 2, 5, now.AddDate(0, 0, 1)),   // tomorrow
+			dueSplit(6, 2, 6, now.AddDate(0, 0, 40)),  // far ahead
+			dueSplit(7, 2, 7, now.Add(-13*time.Hour)), // yesterday evening
+		},
+		sent: map[string]bool{},
+	}
+	svc, notifications := setupReminderService(repo)
+
+	sent, err := svc.SendReminders(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 5, sent)
+	assert.Equal(t, []sentNotification{
+		{2, `Your share of "electricity" (45.00 EUR) is due in 4 days`},
+		{3, `Your share of "electricity" (45.00 EUR) is due today`},
+		{4, `Your share of "electricity" (45.00 EUR) was due on 2025-03-08 and is overdue`},
+		{5, `Your share of "electricity" (45.00 EUR) is due tomorrow`},
+		{7, `Your share of "electricity" (45.00 EUR) was due on 2025-03-09 and is overdue`},
+	}, *notifications)
+
+	// an hour later nothing is sent again
+	sent, err = svc.SendReminders(context.Background(), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Len(t, *notifications, 5)
+}
+
+func TestBillReminderService_SendReminders_NextStage(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := &mockBillReminderRepo{
+		settings: map[int]int{},
+		splits:   []models.BillSplit{dueSplit(1, 1, 2, now.AddDate(0, 0, 2))},
+		sent:     map[string]bool{},
+	}
+	svc, notifications := setupReminderService(repo)
+
+	// upcoming, then due on the day and overdue the day after, each once
+	for day := 0; day <= 4; day++ {
+		_, err := svc.SendReminders(context.Background(), now.AddDate(0, 0, day))
+		require.NoError(t, err)
+	}
+	require.Len(t, *notifications, 3)
+	assert.Contains(t, (*notifications)[0].text, "is due in 2 days")
+	assert.Contains(t, (*notifications)[1].text, "is due today")
+	assert.Contains(t, (*notifications)[2].text, "is overdue")
+
+	// a new due date starts over
+	moved := now.AddDate(0, 0, 10)
+	repo.splits[0].Bill.DueDate = &moved
+	_, err := svc.SendReminders(context.Background(), now.AddDate(0, 0, 9))
+	require.NoError(t, err)
+	require.Len(t, *notifications, 4)
+	assert.Contains(t, (*notifications)[3].text, "is due tomorrow")
+}
+
+func TestBillReminderService_Settings(t *testing.T) {
+	repo := &mockBillReminderRepo{settings: map[int]int{}, sent: map[string]bool{}}
+	svc, _ := setupReminderService(repo)
+
+	settings, err := svc.GetSettings(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultReminderLeadDays, settings.LeadDays)
+
+	_, err = svc.SetSettings(context.Background(), 1, 31)
+	assert.Error(t, err)
+
+	_, err = svc.SetSettings(context.Background(), 1, 0)
+	require.NoError(t, err)
+	settings, err = svc.GetSettings(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, settings.LeadDays)
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock BillTemplateRepository
+type mockBillTemplateRepo struct {
+	templates map[int]*models.BillTemplate
+	created   []models.BillTemplate
+
+	AdvanceFunc func(ctx context.Context, id, occurrences int, nextRun time.Time) (bool, error)
+}
+
+func (m *mockBillTemplateRepo) Create(ctx context.Context, t *models.BillTemplate) error {
+	t.ID = len(m.created) + 1
+	m.created = append(m.created, *t)
+	return nil
+}
+
+func (m *mockBillTemplateRepo) FindByID(ctx context.Context, id int) (*models.BillTemplate, error) {
+	return m.templates[id], nil
+}
+
+func (m *mockBillTemplateRepo) FindByHomeID(ctx context.Context, homeID int) ([]models.BillTemplate, error) {
+	return nil, nil
+}
+
+func (m *mockBillTemplateRepo) FindDue(ctx context.Context, now time.Time) ([]models.BillTemplate, error) {
+	var due []models.BillTemplate
+	for _, t := range m.templates {
+		if t.IsActive && !t.NextRunDate.After(now) {
+			due = append(due, *t)
+		}
+	}
+	return due, nil
+}
+
+func (m *mockBillTemplateRepo) Advance(ctx context.Context, id, occurrences int, nextRun time.Time) (bool, error) {
+	if m.AdvanceFunc != nil {
+		return m.AdvanceFunc(ctx, id, occurrences, nextRun)
+	}
+	t := m.templates[id]
+	if t.Occurrences != occurrences-1 {
+		return false, nil
+	}
+	t.Occurrences = occurrences
+	t.NextRunDate = nextRun
+	return true, nil
+}
+
+func (m *mockBillTemplateRepo) Delete(ctx context.Context, id int) error {
+	delete(m.templates, id)
+	return nil
+}
+
+// billRepoRecorder returns a bill repository that keeps every created bill and split
+func billRepoRecorder(bills *[]models.Bill, splits *[][]models.BillSplit) *mockBillRepo {
+	return &mockBillRepo{
+		CreateFunc: func(ctx context.Context, b *models.Bill) error {
+			b.ID = len(*bills) + 1
+			*bills = append(*bills, *b)
+			return nil
+		},
+		CreateSplitsFunc: func(ctx context.Context, billID int, s []models.BillSplit) error {
+			*splits = append(*splits, s)
+			return nil
+		},
+	}
+}
+
+func setupBillTemplateService(templateRepo *mockBillTemplateRepo, billRepo *mockBillRepo, notifSvc *mockNotifSvc, outbox *mockOutbox) *services.BillTemplateService {
+	billSvc := services.NewBillService(billRepo, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("USD"), &mockRateSource{rate: 1},
+		redis.NewClient(&redis.Options{Addr: "localhost:6379"}), notifSvc, outbox)
+	return services.NewBillTemplateService(templateRepo, billSvc)
+}
+
+func TestBillTemplateService_ProcessDueTemplates_GeneratesBill(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	templateRepo := &mockBillTemplateRepo{templates: map[int]*models.BillTemplate{
+		1: {
+			ID: 1, HomeID: 1, CreatedBy: 1, BillType: "rent", Description: "Rent", Amount: 90000,
+			RecurrenceType: models.RecurrenceMonthly, Interval: 1, StartDate: start, NextRunDate: start, IsActive: true,
+			SplitMode: models.SplitModeEqual, Splits: []models.SplitInput{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+		},
+	}}
+	var bills []models.Bill
+	var splits [][]models.BillSplit
+	var notified string
+	notifSvc := &mockNotifSvc{
+		CreateHomeNotificationFunc: func(ctx context.Context, from *int, homeID int, description string) error {
+			notified = description
+			return nil
+		},
+	}
+	outbox := &mockOutbox{}
+	svc := setupBillTemplateService(templateRepo, billRepoRecorder(&bills, &splits), notifSvc, outbox)
+
+	require.NoError(t, svc.ProcessDueTemplates(context.Background()))
+
+	require.Len(t, bills, 1)
+	bill := bills[0]
+	assert.Equal(t, models.Money(90000), bill.TotalAmount)
+	assert.Equal(t, 1, bill.UploadedBy)
+	assert.Equal(t, "USD", bill.Currency)
+	require.NotNil(t, bill.TemplateID)
+	assert.Equal(t, 1, *bill.TemplateID)
+	assert.True(t, bill.Start.Equal(start))
+	assert.True(t, bill.End.Equal(start.AddDate(0, 1, -1)))
+
+	require.Len(t, splits, 1)
+	assert.Equal(t, []models.BillSplit{{UserID: 1, Amount: 30000}, {UserID: 2, Amount: 30000}, {UserID: 3, Amount: 30000}}, splits[0])
+
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, event.HomeChannel(1), outbox.channels[0])
+	assert.Equal(t, event.ModuleBill, outbox.events[0].Module)
+	assert.Equal(t, event.ActionCreated, outbox.events[0].Action)
+	assert.Equal(t, "Recurring expense added: Rent (900.00 USD)", notified)
+
+	tpl := templateRepo.templates[1]
+	assert.Equal(t, 1, tpl.Occurrences)
+	assert.True(t, tpl.NextRunDate.Equal(start.AddDate(0, 1, 0)))
+
+	// nothing is due until the next period starts
+	require.NoError(t, svc.ProcessDueTemplates(context.Background()))
+	assert.Len(t, bills, 1)
+}
+
+func TestBillTemplateService_ProcessDueTemplates_CatchesUp(t *testing.T) {
+	// started on the 31st three or four months ago, so that many periods are due
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month()-3, 31, 0, 0, 0, 0, time.UTC)
+	if start.Day() != 31 {
+		start = time.Date(now.Year(), now.Month()-4, 31, 0, 0, 0, 0, time.UTC)
+	}
+	templateRepo := &mockBillTemplateRepo{templates: map[int]*models.BillTemplate{
+		1: {ID: 1, HomeID: 1, CreatedBy: 1, Amount: 5000, RecurrenceType: models.RecurrenceMonthly, Interval: 1, StartDate: start, NextRunDate: start, IsActive: true},
+	}}
+	var bills []models.Bill
+	var splits [][]models.BillSplit
+	svc := setupBillTemplateService(templateRepo, billRepoRecorder(&bills, &splits), &mockNotifSvc{}, &mockOutbox{})
+
+	// at most three periods per tick
+	require.NoError(t, svc.ProcessDueTemplates(context.Background()))
+	require.Len(t, bills, 3)
+
+	require.NoError(t, svc.ProcessDueTemplates(context.Background()))
+	require.GreaterOrEqual(t, len(bills), 3)
+	for i, bill := range bills {
+		assert.Equal(t, start.Year()*12+int(start.Month())+i, bill.Start.Year()*12+int(bill.Start.Month()))
+		// short months are clamped to their last day, long ones return to the 31st
+		lastDay := time.Date(bill.Start.Year(), bill.Start.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		assert.Equal(t, lastDay, bill.Start.Day())
+		if i > 0 {
+			assert.True(t, bill.Start.Equal(bills[i-1].End.AddDate(0, 0, 1)))
+		}
+	}
+	assert.True(t, templateRepo.templates[1].NextRunDate.After(now))
+	assert.Equal(t, len(bills), templateRepo.templates[1].Occurrences)
+}
+
+func TestBillTemplateService_ProcessDueTemplates_CapsCatchUp(t *testing.T) {
+	// a year of weekly periods is due
+	start := time.Now().AddDate(-1, 0, 0)
+	templateRepo := &mockBillTemplateRepo{templates: map[int]*models.BillTemplate{
+		1: {ID: 1, HomeID: 1, CreatedBy: 1, Amount: 1000, RecurrenceType: models.RecurrenceWeekly, Interval: 1, StartDate: start, NextRunDate: start, IsActive: true},
+	}}
+	var bills []models.Bill
+	var splits [][]models.BillSplit
+	svc := setupBillTemplateService(templateRepo, billRepoRecorder(&bills, &splits), &mockNotifSvc{}, &mockOutbox{})
+
+	require.NoError(t, svc.ProcessDueTemplates(context.Background()))
+	require.Len(t, bills, 3)
+	assert.Equal(t, 3, templateRepo.templates[1].Occurrences)
+	assert.True(t, templateRepo.templates[1].NextRunDate.Equal(start.AddDate(0, 0, 21)))
+
+	// the next tick carries on from there
+	require.NoError(t, svc.ProcessDueTemplates(context.Background()))
+	require.Len(t, bills, 6)
+	assert.True(t, bills[3].Start.Equal(start.AddDate(0, 0, 21)))
+}
+
+func TestBillTemplateService_ProcessDueTemplates_Weekly(t *testing.T) {
+	start := time.Now().AddDate(0, 0, -8)
+	templateRepo := &mockBillTemplateRepo{templates: map[int]*models.BillTemplate{
+		1: {ID: 1, HomeID: 1, CreatedBy: 1, Amount: 1000, RecurrenceType: models.RecurrenceWeekly, Interval: 1, StartDate: start, NextRunDate: start, IsActive: true},
+	}}
+	var bills []models.Bill
+	var splits [][]models.BillSplit
+	svc := setupBillTemplateService(templateRepo, billRepoRecorder(&bills, &splits), &mockNotifSvc{}, &mockOutbox{})
+
+	require.NoError(t, svc.ProcessDueTemplates(context.Background()))
+
+	require.Len(t, bills, 2)
+	assert.True(t, bills[1].Start.Equal(start.AddDate(0, 0, 7)))
+	assert.True(t, templateRepo.templates[1].NextRunDate.Equal(start.AddDate(0, 0, 14)))
+}
+
+func TestBillTemplateService_ProcessDueTemplates_PeriodTaken(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	templateRepo := &mockBillTemplateRepo{
+		templates: map[int]*models.BillTemplate{
+			1: {ID: 1, HomeID: 1, CreatedBy: 1, Amount: 1000, RecurrenceType: models.RecurrenceMonthly, Interval: 1, StartDate: start, NextRunDate: start, IsActive: true},
+		},
+		// another instance generated the period first
+		AdvanceFunc: func(ctx context.Context, id, occurrences int, nextRun time.Time) (bool, error) {
+			return false, nil
+		},
+	}
+	var bills []models.Bill
+	var splits [][]models.BillSplit
+	outbox := &mockOutbox{}
+	svc := setupBillTemplateService(templateRepo, billRepoRecorder(&bills, &splits), &mockNotifSvc{}, outbox)
+
+	require.NoError(t, svc.ProcessDueTemplates(context.Background()))
+
+	// the mock outbox has no real transaction, but no event or notification may follow
+	assert.Empty(t, outbox.events)
+}
+
+func TestBillTemplateService_CreateTemplate(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		amount     models.Money
+		currency   string
+		recurrence string
+		splitMode  string
+		splits     []models.SplitInput
+		wantErr    error
+	}{
+		{"Success", 120000, "", models.RecurrenceMonthly, models.SplitModeEqual, []models.SplitInput{{UserID: 1}, {UserID: 2}}, nil},
+		{"Invalid split", 120000, "", models.RecurrenceMonthly, models.SplitModeExact, []models.SplitInput{{UserID: 1, Amount: 100}}, services.ErrInvalidSplit},
+		{"Unsupported currency", 120000, "XYZ", models.RecurrenceMonthly, "", nil, services.ErrUnsupportedCurrency},
+		{"Invalid recurrence", 120000, "", "daily", "", nil, errors.New("recurrence_type must be weekly, monthly, or yearly")},
+		{"Zero amount", 0, "", models.RecurrenceMonthly, "", nil, errors.New("amount must be greater than 0")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockBillTemplateRepo{}
+			svc := services.NewBillTemplateService(repo, nil)
+
+			template, err := svc.CreateTemplate(context.Background(), 1, 2, "rent", nil, "", tt.amount, tt.currency, tt.recurrence, 0, start, tt.splitMode, tt.splits)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				if errors.Is(tt.wantErr, services.ErrInvalidSplit) || errors.Is(tt.wantErr, services.ErrUnsupportedCurrency) {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					assert.EqualError(t, err, tt.wantErr.Error())
+				}
+				assert.Empty(t, repo.created)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, template.Interval)
+			assert.Equal(t, 2, template.CreatedBy)
+			assert.True(t, template.NextRunDate.Equal(start))
+			assert.True(t, template.IsActive)
+			assert.Len(t, repo.created, 1)
+		})
+	}
+}
@@ -0,0 +1,630 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBillService(repo *mockBillRepo) *services.BillService {
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	return services.NewBillService(repo, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("USD"), &mockRateSource{rate: 1}, redisClient, &mockNotifSvc{}, &mockOutbox{})
+}
+
+type splitAmount struct {
+	userID int
+	amount models.Money
+}
+
+func TestBillService_CreateBill_SplitModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    models.Money
+		mode     string
+		splits   []models.SplitInput
+		expected []splitAmount
+	}{
+		{
+			name:   "Equal gives the remainder to the lowest user IDs",
+			total:  10000,
+			mode:   models.SplitModeEqual,
+			splits: []models.SplitInput{{UserID: 3}, {UserID: 1}, {UserID: 2}},
+			expected: []splitAmount{
+				{3, 3333}, {1, 3334}, {2, 3333},
+			},
+		},
+		{
+			name:   "Percent",
+			total:  9999,
+			mode:   models.SplitModePercent,
+			splits: []models.SplitInput{{UserID: 1, Percent: 50}, {UserID: 2, Percent: 30}, {UserID: 3, Percent: 20}},
+			expected: []splitAmount{
+				{1, 4999}, {2, 3000}, {3, 2000},
+			},
+		},
+		{
+			name:   "Percent with fractional percentages",
+			total:  1000,
+			mode:   models.SplitModePercent,
+			splits: []models.SplitInput{{UserID: 1, Percent: 33.33}, {UserID: 2, Percent: 33.33}, {UserID: 3, Percent: 33.34}},
+			expected: []splitAmount{
+				{1, 333}, {2, 333}, {3, 334},
+			},
+		},
+		{
+			name:   "Shares",
+			total:  1000,
+			mode:   models.SplitModeShares,
+			splits: []models.SplitInput{{UserID: 1, Shares: 1}, {UserID: 2, Shares: 2}},
+			expected: []splitAmount{
+				{1, 333}, {2, 667},
+			},
+		},
+		{
+			name:   "Shares with extreme weights",
+			total:  90_000_000_000_001, // a total times a weight is past int64
+			mode:   models.SplitModeShares,
+			splits: []models.SplitInput{{UserID: 1, Shares: models.MaxSplitShares}, {UserID: 2, Shares: models.MaxSplitShares - 1}, {UserID: 3, Shares: 1}},
+			expected: []splitAmount{
+				{1, 45_000_000_000_001}, {2, 44_999_955_000_000}, {3, 45_000_000},
+			},
+		},
+		{
+			name:   "Exact",
+			total:  5050,
+			mode:   models.SplitModeExact,
+			splits: []models.SplitInput{{UserID: 1, Amount: 2025}, {UserID: 2, Amount: 3025}},
+			expected: []splitAmount{
+				{1, 2025}, {2, 3025},
+			},
+		},
+		{
+			name:   "Mode defaults to exact",
+			total:  1000,
+			splits: []models.SplitInput{{UserID: 1, Amount: 1000}},
+			expected: []splitAmount{
+				{1, 1000},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created []models.BillSplit
+			svc := setupBillService(&mockBillRepo{
+				CreateSplitsFunc: func(ctx context.Context, billID int, splits []models.BillSplit) error {
+					created = splits
+					return nil
+				},
+			})
+
+			_, err := svc.CreateBill(context.Background(), "other", nil, "", nil, tt.total, "", time.Now(), time.Now(), nil, nil, "", 1, 1, tt.mode, tt.splits)
+
+			require.NoError(t, err)
+			require.Len(t, created, len(tt.expected))
+			for i, exp := range tt.expected {
+				assert.Equal(t, exp.userID, created[i].UserID)
+				assert.Equal(t, exp.amount, created[i].Amount)
+			}
+		})
+	}
+}
+
+func TestBillService_CreateBill_InvalidSplits(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   string
+		splits []models.SplitInput
+	}{
+		{"Exact below total", models.SplitModeExact, []models.SplitInput{{UserID: 1, Amount: 4000}, {UserID: 2, Amount: 4000}}},
+		{"Exact above total", models.SplitModeExact, []models.SplitInput{{UserID: 1, Amount: 6000}, {UserID: 2, Amount: 6000}}},
+		{"Exact zero amount", models.SplitModeExact, []models.SplitInput{{UserID: 1, Amount: 10000}, {UserID: 2}}},
+		{"Percent not 100", models.SplitModePercent, []models.SplitInput{{UserID: 1, Percent: 50}, {UserID: 2, Percent: 40}}},
+		{"Zero shares", models.SplitModeShares, []models.SplitInput{{UserID: 1, Shares: 1}, {UserID: 2}}},
+		{"Too many shares", models.SplitModeShares, []models.SplitInput{{UserID: 1, Shares: 1}, {UserID: 2, Shares: models.MaxSplitShares + 1}}},
+		{"Duplicate user", models.SplitModeEqual, []models.SplitInput{{UserID: 1}, {UserID: 1}}},
+		{"Missing user", models.SplitModeEqual, []models.SplitInput{{UserID: 0}}},
+		{"Unknown mode", "weighted", []models.SplitInput{{UserID: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &mockOutbox{}
+			svc := services.NewBillService(&mockBillRepo{}, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("USD"), &mockRateSource{rate: 1}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+			_, err := svc.CreateBill(context.Background(), "other", nil, "", nil, 10000, "", time.Now(), time.Now(), nil, nil, "", 1, 1, tt.mode, tt.splits)
+
+			assert.ErrorIs(t, err, services.ErrInvalidSplit)
+			assert.Empty(t, outbox.events)
+		})
+	}
+}
+
+func TestBillService_UpdateSplits_UsesBillTotal(t *testing.T) {
+	var updated []models.BillSplit
+	svc := setupBillService(&mockBillRepo{
+		FindByIDFunc: func(ctx context.Context, id int) (*models.Bill, error) {
+			return &models.Bill{ID: id, HomeID: 1, UploadedBy: 1, TotalAmount: 10}, nil
+		},
+		UpdateSplitsFunc: func(ctx context.Context, billID int, splits []models.BillSplit) error {
+			updated = splits
+			return nil
+		},
+	})
+
+	err := svc.UpdateSplits(context.Background(), 7, 1, models.SplitModeEqual, []models.SplitInput{{UserID: 1}, {UserID: 2}, {UserID: 3}})
+
+	require.NoError(t, err)
+	require.Len(t, updated, 3)
+	assert.Equal(t, models.Money(4), updated[0].Amount)
+	assert.Equal(t, models.Money(3), updated[1].Amount)
+	assert.Equal(t, models.Money(3), updated[2].Amount)
+}
+
+func billWithSplits() *models.Bill {
+	return &models.Bill{
+		ID: 7, HomeID: 1, UploadedBy: 1, Type: "other", Description: "Groseries", TotalAmount: 9000, Currency: "USD", ExchangeRate: 1,
+		BillSplits: []models.BillSplit{{UserID: 1, Amount: 3000}, {UserID: 2, Amount: 3000}, {UserID: 3, Amount: 3000}},
+	}
+}
+
+func TestBillService_UpdateBill_RecordsRevision(t *testing.T) {
+	var saved *models.Bill
+	var revision *models.BillRevision
+	svc := setupBillService(&mockBillRepo{
+		FindByIDFunc: func(ctx context.Context, id int) (*models.Bill, error) {
+			return billWithSplits(), nil
+		},
+		UpdateFunc: func(ctx context.Context, b *models.Bill) error {
+			saved = b
+			return nil
+		},
+		CreateRevisionFunc: func(ctx context.Context, rev *models.BillRevision) error {
+			revision = rev
+			return nil
+		},
+	})
+
+	description := "Groceries"
+	billType := "other"
+	_, err := svc.UpdateBill(context.Background(), 7, 2, models.UpdateBillRequest{Description: &description, BillType: &billType})
+
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, "Groceries", saved.Description)
+	require.NotNil(t, revision)
+	assert.Equal(t, 7, revision.BillID)
+	assert.Equal(t, 2, revision.ChangedBy)
+	// unchanged fields are left out
+	assert.Equal(t, []models.FieldChange{{Field: "description", Old: "Groseries", New: "Groceries"}}, []models.FieldChange(revision.Changes))
+}
+
+func TestBillService_UpdateBill_DueDate(t *testing.T) {
+	due := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	var saved *models.Bill
+	var revision *models.BillRevision
+	svc := setupBillService(&mockBillRepo{
+		FindByIDFunc: func(ctx context.Context, id int) (*models.Bill, error) {
+			bill := billWithSplits()
+			bill.DueDate = &due
+			return bill, nil
+		},
+		UpdateFunc: func(ctx context.Context, b *models.Bill) error {
+			saved = b
+			return nil
+		},
+		CreateRevisionFunc: func(ctx context.Context, rev *models.BillRevision) error {
+			revision = rev
+			return nil
+		},
+	})
+
+	later := due.AddDate(0, 0, 7)
+	_, err := svc.UpdateBill(context.Background(), 7, 2, models.UpdateBillRequest{DueDate: &later})
+	require.NoError(t, err)
+	require.NotNil(t, saved.DueDate)
+	assert.True(t, later.Equal(*saved.DueDate))
+	require.Len(t, revision.Changes, 1)
+	assert.Equal(t, "due_date", revision.Changes[0].Field)
+
+	_, err = svc.UpdateBill(context.Background(), 7, 2, models.UpdateBillRequest{RemoveDueDate: true})
+	require.NoError(t, err)
+	assert.Nil(t, saved.DueDate)
+	assert.Nil(t, revision.Changes[0].New)
+}
+
+func TestBillService_UpdateBill_NothingChanged(t *testing.T) {
+	outbox := &mockOutbox{}
+	revisions := 0
+	repo := &mockBillRepo{
+		FindByIDFunc: func(ctx context.Context, id int) (*models.Bill, error) {
+			return billWithSplits(), nil
+		},
+		CreateRevisionFunc: func(ctx context.Context, rev *models.BillRevision) error {
+			revisions++
+			return nil
+		},
+	}
+	svc := services.NewBillService(repo, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("USD"), &mockRateSource{rate: 1}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+	description := "Groseries"
+	_, err := svc.UpdateBill(context.Background(), 7, 1, models.UpdateBillRequest{Description: &description})
+
+	require.NoError(t, err)
+	assert.Zero(t, revisions)
+	assert.Empty(t, outbox.events)
+}
+
+func TestBillService_UpdateBill_TotalMustMatchSplits(t *testing.T) {
+	var updated []models.BillSplit
+	svc := setupBillService(&mockBillRepo{
+		FindByIDFunc: func(ctx context.Context, id int) (*models.Bill, error) {
+			return billWithSplits(), nil
+		},
+		UpdateSplitsFunc: func(ctx context.Context, billID int, splits []models.BillSplit) error {
+			updated = splits
+			return nil
+		},
+	})
+	total := models.Money(12000)
+
+	// the existing splits only cover the old total
+	_, err := svc.UpdateBill(context.Background(), 7, 1, models.UpdateBillRequest{TotalAmount: &total})
+	assert.ErrorIs(t, err, services.ErrInvalidSplit)
+
+	_, err = svc.UpdateBill(context.Background(), 7, 1, models.UpdateBillRequest{
+		TotalAmount: &total,
+		SplitMode:   models.SplitModeEqual,
+		Splits:      []models.SplitInput{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+	})
+	require.NoError(t, err)
+	require.Len(t, updated, 3)
+	for _, sp := range updated {
+		assert.Equal(t, models.Money(4000), sp.Amount)
+	}
+}
+
+func TestBillService_UpdateBill_NewCurrencySnapshotsRate(t *testing.T) {
+	var saved *models.Bill
+	svc := services.NewBillService(&mockBillRepo{
+		FindByIDFunc: func(ctx context.Context, id int) (*models.Bill, error) {
+			return billWithSplits(), nil
+		},
+		UpdateFunc: func(ctx context.Context, b *models.Bill) error {
+			saved = b
+			return nil
+		},
+	}, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("USD"), &mockRateSource{rate: 1.1}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, &mockOutbox{})
+
+	currency := "EUR"
+	_, err := svc.UpdateBill(context.Background(), 7, 1, models.UpdateBillRequest{Currency: &currency})
+
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, "EUR", saved.Currency)
+	assert.Equal(t, 1.1, saved.ExchangeRate)
+}
+
+func TestBillService_MarkBillUnpayed(t *testing.T) {
+	paidAt := time.Now()
+	outbox := &mockOutbox{}
+	var saved *models.Bill
+	repo := &mockBillRepo{
+		FindByIDFunc: func(ctx context.Context, id int) (*models.Bill, error) {
+			return &models.Bill{ID: id, HomeID: 1, UploadedBy: 1, Payed: true, PaymentDate: &paidAt}, nil
+		},
+		UpdateFunc: func(ctx context.Context, b *models.Bill) error {
+			saved = b
+			return nil
+		},
+	}
+	svc := services.NewBillService(repo, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("USD"), &mockRateSource{rate: 1}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+	err := svc.MarkBillUnpayed(context.Background(), 7, 1)
+
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.False(t, saved.Payed)
+	assert.Nil(t, saved.PaymentDate)
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, event.ActionMarkedUnpayed, outbox.events[0].Action)
+}
+
+func TestBillService_GetStats(t *testing.T) {
+	change := models.Money(-500)
+	var filters []models.SpendingFilter
+	svc := setupBillService(&mockBillRepo{
+		SpendingSummaryFunc: func(ctx context.Context, f models.SpendingFilter) (*models.SpendingStat, error) {
+			filters = append(filters, f)
+			return &models.SpendingStat{Total: 15000, Count: 3, Average: 5000}, nil
+		},
+		SpendingByGroupFunc: func(ctx context.Context, f models.SpendingFilter) ([]models.SpendingStat, error) {
+			return []models.SpendingStat{
+				{Key: "2", Label: "Food", Total: 10000, Count: 2, Average: 5000},
+				{Key: "0", Label: "Uncategorized", Total: 5000, Count: 1, Average: 5000},
+			}, nil
+		},
+		SpendingByMonthFunc: func(ctx context.Context, f models.SpendingFilter) (map[string][]models.MonthlySpending, error) {
+			return map[string][]models.MonthlySpending{
+				"2": {{Month: "2026-01", Total: 7500, Count: 1}, {Month: "2026-02", Total: 2500, Count: 1, Change: &change}},
+			}, nil
+		},
+	})
+
+	stats, err := svc.GetStats(context.Background(), 1, nil, nil, "")
+
+	require.NoError(t, err)
+	require.Len(t, filters, 1)
+	assert.Equal(t, models.StatsGroupCategory, filters[0].GroupBy)
+	assert.Equal(t, "USD", stats.Currency)
+	assert.Equal(t, models.Money(15000), stats.Total)
+	require.Len(t, stats.Groups, 2)
+	require.Len(t, stats.Groups[0].Months, 2)
+	assert.Equal(t, &change, stats.Groups[0].Months[1].Change)
+	assert.Empty(t, stats.Groups[1].Months)
+}
+
+func TestBillService_GetStats_ByMonth(t *testing.T) {
+	change := models.Money(2500)
+	svc := setupBillService(&mockBillRepo{
+		SpendingByGroupFunc: func(ctx context.Context, f models.SpendingFilter) ([]models.SpendingStat, error) {
+			return []models.SpendingStat{{Key: "2026-01", Label: "2026-01", Total: 5000}, {Key: "2026-02", Label: "2026-02", Total: 7500}}, nil
+		},
+		SpendingByMonthFunc: func(ctx context.Context, f models.SpendingFilter) (map[string][]models.MonthlySpending, error) {
+			return map[string][]models.MonthlySpending{
+				"": {{Month: "2026-01", Total: 5000}, {Month: "2026-02", Total: 7500, Change: &change}},
+			}, nil
+		},
+	})
+
+	stats, err := svc.GetStats(context.Background(), 1, nil, nil, models.StatsGroupMonth)
+
+	require.NoError(t, err)
+	require.Len(t, stats.Groups, 2)
+	assert.Nil(t, stats.Groups[0].Change)
+	assert.Equal(t, &change, stats.Groups[1].Change)
+	assert.Empty(t, stats.Groups[1].Months)
+}
+
+func TestBillService_GetStats_InvalidGroup(t *testing.T) {
+	svc := setupBillService(&mockBillRepo{})
+
+	_, err := svc.GetStats(context.Background(), 1, nil, nil, "weekday")
+
+	assert.ErrorIs(t, err, services.ErrInvalidStatsGroup)
+}
+
+func receiptRequest() models.CreateReceiptBillRequest {
+	now := time.Now()
+	return models.CreateReceiptBillRequest{
+		BillType: "groceries",
+		Vendor:   "Lidl",
+		Start:    now,
+		End:      now,
+		Items: []models.ReceiptLine{
+			{Name: "Shampoo", Price: 500, UserIDs: []int{1}},
+			{Name: "Milk", Price: 300, UserIDs: []int{2, 1}},
+			{Name: "Bread", Price: 201, UserIDs: []int{1, 2, 3}},
+			{Name: "VAT", Price: 100, Kind: models.ReceiptLineTax},
+			{Name: "Coupon", Price: -50, Kind: models.ReceiptLineDiscount},
+		},
+	}
+}
+
+func TestBillService_CreateBillFromReceipt(t *testing.T) {
+	var created []models.BillSplit
+	repo := &mockBillRepo{
+		CreateSplitsFunc: func(ctx context.Context, billID int, splits []models.BillSplit) error {
+			created = splits
+			return nil
+		},
+	}
+	svc := setupBillService(repo)
+
+	req := receiptRequest()
+	req.Total = 1051
+	bill, err := svc.CreateBillFromReceipt(context.Background(), 1, 1, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.Money(1051), bill.TotalAmount)
+	assert.Equal(t, "Lidl", bill.Description)
+
+	// items 7.17 / 2.17 / 0.67 of 10.01; tax and the coupon follow the same proportions
+	var got []splitAmount
+	for _, sp := range created {
+		got = append(got, splitAmount{sp.UserID, sp.Amount})
+	}
+	assert.Equal(t, []splitAmount{{1, 753}, {2, 228}, {3, 70}}, got)
+
+	var receipt models.ReceiptSplit
+	require.NoError(t, json.Unmarshal(bill.OCRData, &receipt))
+	assert.Equal(t, models.Money(1001), receipt.Subtotal)
+	assert.Equal(t, models.Money(100), receipt.Tax)
+	assert.Equal(t, models.Money(50), receipt.Discount)
+	assert.Equal(t, []models.ItemShare{{UserID: 2, Amount: 150}, {UserID: 1, Amount: 150}}, receipt.Items[1].Shares)
+	assert.Equal(t, []models.ItemShare{{UserID: 1, Amount: 67}, {UserID: 2, Amount: 67}, {UserID: 3, Amount: 67}}, receipt.Items[2].Shares)
+	assert.Equal(t, models.ReceiptLineItem, receipt.Items[0].Kind)
+	assert.Empty(t, receipt.Items[3].Shares)
+}
+
+func TestBillService_CreateBillFromReceipt_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *models.CreateReceiptBillRequest)
+	}{
+		{"Total mismatch", func(req *models.CreateReceiptBillRequest) { req.Total = 1000 }},
+		{"Unassigned item", func(req *models.CreateReceiptBillRequest) { req.Items[0].UserIDs = nil }},
+		{"Repeated user", func(req *models.CreateReceiptBillRequest) { req.Items[1].UserIDs = []int{2, 2} }},
+		{"Negative item", func(req *models.CreateReceiptBillRequest) { req.Items[0].Price = -500 }},
+		{"Negative tax", func(req *models.CreateReceiptBillRequest) { req.Items[3].Price = -100 }},
+		{"Discount above total", func(req *models.CreateReceiptBillRequest) { req.Items[4].Price = 5000 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &mockOutbox{}
+			svc := services.NewBillService(&mockBillRepo{}, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("USD"), &mockRateSource{rate: 1}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+			req := receiptRequest()
+			tt.modify(&req)
+			_, err := svc.CreateBillFromReceipt(context.Background(), 1, 1, req)
+
+			assert.ErrorIs(t, err, services.ErrInvalidSplit)
+			assert.Empty(t, outbox.events)
+		})
+	}
+}
+
+func TestBillService_CreateBill_DuplicateWarnings(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	var created *models.Bill
+	repo := &mockBillRepo{
+		CreateFunc: func(ctx context.Context, b *models.Bill) error {
+			created = b
+			return nil
+		},
+		FindDuplicatesFunc: func(ctx context.Context, homeID int, receiptHash, vendor, date string, total models.Money) ([]models.Bill, error) {
+			assert.Equal(t, 1, homeID)
+			assert.Equal(t, hash, receiptHash)
+			assert.Equal(t, "Lidl", vendor)
+			assert.Equal(t, "2025-03-14", date)
+			assert.Equal(t, models.Money(1250), total)
+			return []models.Bill{{ID: 3, ReceiptHash: hash}, {ID: 5}}, nil
+		},
+	}
+	svc := setupBillService(repo)
+
+	ocrData := []byte(`{"vendor":" Lidl ","date":"2025-03-14","total":1250}`)
+	bill, err := svc.CreateBill(context.Background(), "groceries", nil, "", nil, 1250, "", time.Now(), time.Now(), nil, ocrData, hash, 1, 1, "", nil)
+	require.NoError(t, err)
+
+	// still created, with the reasons it may be a duplicate
+	require.NotNil(t, created)
+	assert.Equal(t, hash, created.ReceiptHash)
+	require.Len(t, bill.Warnings, 2)
+	assert.Equal(t, models.BillWarning{Code: models.BillWarningDuplicateReceipt, BillID: 3, Message: "This receipt was already added as bill #3"}, bill.Warnings[0])
+	assert.Equal(t, models.BillWarningPossibleDuplicate, bill.Warnings[1].Code)
+	assert.Equal(t, 5, bill.Warnings[1].BillID)
+}
+
+func TestBillService_CreateBill_NoReceipt(t *testing.T) {
+	repo := &mockBillRepo{
+		FindDuplicatesFunc: func(ctx context.Context, homeID int, receiptHash, vendor, date string, total models.Money) ([]models.Bill, error) {
+			t.Fatal("bills without a receipt are not compared")
+			return nil, nil
+		},
+	}
+	svc := setupBillService(repo)
+
+	bill, err := svc.CreateBill(context.Background(), "rent", nil, "", nil, 90000, "", time.Now(), time.Now(), nil, []byte(`{}`), "", 1, 1, "", nil)
+	require.NoError(t, err)
+	assert.Empty(t, bill.Warnings)
+}
+
+func TestBillService_GetBillsByHomeID_Pages(t *testing.T) {
+	base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	all := []models.Bill{
+		{ID: 5, HomeID: 1, CreatedAt: base.Add(3 * time.Hour)},
+		{ID: 4, HomeID: 1, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: 3, HomeID: 1, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: 2, HomeID: 1, CreatedAt: base},
+	}
+	var queries []models.BillFilter
+	repo := &mockBillRepo{
+		FindByHomeIDFunc: func(ctx context.Context, f models.BillFilter) ([]models.Bill, error) {
+			queries = append(queries, f)
+			var page []models.Bill
+			for _, b := range all {
+				// newest first, ties by ID
+				if f.After != nil && !(b.CreatedAt.Before(*f.After.Time) || b.CreatedAt.Equal(*f.After.Time) && b.ID < f.After.ID) {
+					continue
+				}
+				if len(page) < f.Limit {
+					page = append(page, b)
+				}
+			}
+			return page, nil
+		},
+	}
+	svc := setupBillService(repo)
+
+	first, err := svc.GetBillsByHomeID(context.Background(), models.BillFilter{HomeID: 1, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, queries[0].Limit) // one extra tells there is more
+	assert.Equal(t, models.BillSortCreated, queries[0].Sort)
+	assert.Equal(t, models.BillDatePeriod, queries[0].DateField)
+	require.Len(t, first.Bills, 2)
+	assert.Equal(t, 4, first.Bills[1].ID)
+	require.NotEmpty(t, first.NextCursor)
+
+	second, err := svc.GetBillsByHomeID(context.Background(), models.BillFilter{HomeID: 1, Limit: 2, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.NotNil(t, queries[1].After)
+	assert.Equal(t, 4, queries[1].After.ID)
+	require.Len(t, second.Bills, 2)
+	assert.Equal(t, []int{3, 2}, []int{second.Bills[0].ID, second.Bills[1].ID})
+	assert.Empty(t, second.NextCursor)
+}
+
+func TestBillService_GetBillsByHomeID_Defaults(t *testing.T) {
+	var query models.BillFilter
+	svc := setupBillService(&mockBillRepo{
+		FindByHomeIDFunc: func(ctx context.Context, f models.BillFilter) ([]models.Bill, error) {
+			query = f
+			return nil, nil
+		},
+	})
+
+	page, err := svc.GetBillsByHomeID(context.Background(), models.BillFilter{HomeID: 1, Limit: 1000, Search: "  rent "})
+	require.NoError(t, err)
+	assert.Equal(t, models.BillListMaxLimit+1, query.Limit)
+	assert.Equal(t, "rent", query.Search)
+	assert.NotNil(t, page.Bills) // an empty page is still a list
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestBillService_GetBillsByHomeID_Invalid(t *testing.T) {
+	from := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, -1)
+	low, high := models.Money(500), models.Money(100)
+
+	// a cursor of one sort can't continue another
+	svc := setupBillService(&mockBillRepo{
+		FindByHomeIDFunc: func(ctx context.Context, f models.BillFilter) ([]models.Bill, error) {
+			return []models.Bill{{ID: 2, TotalAmount: 100}, {ID: 1, TotalAmount: 50}}, nil
+		},
+	})
+	page, err := svc.GetBillsByHomeID(context.Background(), models.BillFilter{HomeID: 1, Limit: 1, Sort: models.BillSortAmount})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextCursor)
+
+	tests := []struct {
+		name   string
+		filter models.BillFilter
+	}{
+		{"Unknown status", models.BillFilter{Status: "late"}},
+		{"Unknown date field", models.BillFilter{DateField: "due"}},
+		{"Unknown sort", models.BillFilter{Sort: "type"}},
+		{"Reversed range", models.BillFilter{From: &from, To: &to}},
+		{"Reversed amounts", models.BillFilter{MinAmount: &low, MaxAmount: &high}},
+		{"Garbage cursor", models.BillFilter{Cursor: "not a cursor"}},
+		{"Cursor of another sort", models.BillFilter{Cursor: page.NextCursor}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.HomeID = 1
+			_, err := svc.GetBillsByHomeID(context.Background(), tt.filter)
+			assert.ErrorIs(t, err, services.ErrInvalidBillFilter)
+		})
+	}
+}
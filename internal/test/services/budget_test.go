@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock BudgetRepository
+type mockBudgetRepo struct {
+	budgets  map[int]*models.BillCategoryBudget
+	spent    map[int]models.Money
+	upserted *models.BillCategoryBudget
+	deleted  int
+}
+
+func (m *mockBudgetRepo) Upsert(ctx context.Context, b *models.BillCategoryBudget) error {
+	m.upserted = b
+	return nil
+}
+
+func (m *mockBudgetRepo) FindByCategoryID(ctx context.Context, categoryID int) (*models.BillCategoryBudget, error) {
+	return m.budgets[categoryID], nil
+}
+
+func (m *mockBudgetRepo) FindByHomeID(ctx context.Context, homeID int) ([]models.BillCategoryBudget, error) {
+	var budgets []models.BillCategoryBudget
+	for _, b := range m.budgets {
+		budgets = append(budgets, *b)
+	}
+	return budgets, nil
+}
+
+func (m *mockBudgetRepo) Delete(ctx context.Context, categoryID int) error {
+	m.deleted = categoryID
+	return nil
+}
+
+func (m *mockBudgetRepo) SpentByCategory(ctx context.Context, homeID int, from, to time.Time) (map[int]models.Money, error) {
+	return m.spent, nil
+}
+
+// Mock IBillCategoryRepository
+type mockBillCategoryRepo struct {
+	categories map[int]*models.BillCategory
+}
+
+func (m *mockBillCategoryRepo) Create(ctx context.Context, category *models.BillCategory) error {
+	return nil
+}
+
+func (m *mockBillCategoryRepo) GetByHomeID(ctx context.Context, homeID int) ([]models.BillCategory, error) {
+	return nil, nil
+}
+
+func (m *mockBillCategoryRepo) Update(ctx context.Context, category *models.BillCategory, updates map[string]interface{}) (*models.BillCategory, error) {
+	if name, ok := updates["name"].(string); ok {
+		category.Name = name
+	}
+	if color, ok := updates["color"].(string); ok {
+		category.Color = color
+	}
+	return category, nil
+}
+
+func (m *mockBillCategoryRepo) Delete(ctx context.Context, id int) error {
+	delete(m.categories, id)
+	return nil
+}
+
+func (m *mockBillCategoryRepo) GetByID(ctx context.Context, id int) (*models.BillCategory, error) {
+	if c, ok := m.categories[id]; ok {
+		return c, nil
+	}
+	return nil, errors.New("record not found")
+}
+
+func groceries() *models.BillCategory {
+	return &models.BillCategory{ID: 5, HomeID: 1, Name: "Groceries"}
+}
+
+func setupBudgetService(repo *mockBudgetRepo, outbox *mockOutbox) *services.BudgetService {
+	categories := &mockBillCategoryRepo{categories: map[int]*models.BillCategory{5: groceries()}}
+	return services.NewBudgetService(repo, categories, homeWithCurrency("USD"), outbox)
+}
+
+func TestBudgetService_SetBudget(t *testing.T) {
+	repo := &mockBudgetRepo{}
+	outbox := &mockOutbox{}
+	svc := setupBudgetService(repo, outbox)
+
+	budget, err := svc.SetBudget(context.Background(), 1, 5, 2, 60000)
+
+	require.NoError(t, err)
+	require.NotNil(t, repo.upserted)
+	assert.Equal(t, models.Money(60000), repo.upserted.Amount)
+	assert.Equal(t, 5, repo.upserted.BillCategoryID)
+	assert.Equal(t, "Groceries", budget.BillCategory.Name)
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, event.ModuleBudget, outbox.events[0].Module)
+}
+
+func TestBudgetService_SetBudget_OtherHomesCategory(t *testing.T) {
+	repo := &mockBudgetRepo{}
+	svc := setupBudgetService(repo, &mockOutbox{})
+
+	_, err := svc.SetBudget(context.Background(), 2, 5, 2, 60000)
+	assert.ErrorIs(t, err, services.ErrCategoryNotFound)
+
+	_, err = svc.SetBudget(context.Background(), 1, 99, 2, 60000)
+	assert.ErrorIs(t, err, services.ErrCategoryNotFound)
+	assert.Nil(t, repo.upserted)
+}
+
+func TestBudgetService_DeleteBudget_Missing(t *testing.T) {
+	repo := &mockBudgetRepo{}
+	svc := setupBudgetService(repo, &mockOutbox{})
+
+	err := svc.DeleteBudget(context.Background(), 1, 5)
+
+	assert.ErrorIs(t, err, services.ErrBudgetNotFound)
+	assert.Zero(t, repo.deleted)
+}
+
+func TestBudgetService_GetStatus(t *testing.T) {
+	svc := setupBudgetService(&mockBudgetRepo{
+		budgets: map[int]*models.BillCategoryBudget{5: {BillCategoryID: 5, Amount: 60000, BillCategory: groceries()}},
+		spent:   map[int]models.Money{5: 45000},
+	}, &mockOutbox{})
+
+	status, err := svc.GetStatus(context.Background(), 1, 5, time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03", status.Period)
+	assert.Equal(t, "Groceries", status.CategoryName)
+	assert.Equal(t, "USD", status.Currency)
+	assert.Equal(t, models.Money(45000), status.Spent)
+	assert.Equal(t, models.Money(15000), status.Remaining)
+	assert.Equal(t, 75.0, status.PercentUsed)
+}
+
+func TestBillService_CreateBill_BudgetAlerts(t *testing.T) {
+	tests := []struct {
+		name      string
+		spent     models.Money // including the new bill of 100.00
+		threshold int
+	}{
+		{"Below 80%", 47000, 0},
+		{"Crosses 80%", 50000, models.BudgetWarningPercent},
+		{"Already past 80%", 59000, 0},
+		{"Crosses 100%", 62000, models.BudgetExceededPercent},
+		{"Crosses both, reports 100%", 65000, models.BudgetExceededPercent},
+		{"Already over budget", 75000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &mockOutbox{}
+			var notifications []string
+			notif := &mockNotifSvc{
+				CreateHomeNotificationFunc: func(ctx context.Context, from *int, homeID int, description string) error {
+					notifications = append(notifications, description)
+					return nil
+				},
+			}
+			budgets := &mockBudgetRepo{
+				budgets: map[int]*models.BillCategoryBudget{5: {BillCategoryID: 5, Amount: 60000, BillCategory: groceries()}},
+				spent:   map[int]models.Money{5: tt.spent},
+			}
+			svc := services.NewBillService(&mockBillRepo{}, &mockSettlementRepo{}, budgets, homeWithCurrency("USD"), &mockRateSource{rate: 1}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), notif, outbox)
+
+			categoryID := 5
+			_, err := svc.CreateBill(context.Background(), "", &categoryID, "", nil, 10000, "", time.Now(), time.Now(), nil, nil, "", 1, 1, "", nil)
+			require.NoError(t, err)
+
+			var alerts []*models.BudgetAlert
+			for _, e := range outbox.events {
+				if e.Action == event.ActionThresholdReached {
+					alerts = append(alerts, e.Data.(*models.BudgetAlert))
+				}
+			}
+
+			if tt.threshold == 0 {
+				assert.Empty(t, alerts)
+				assert.Len(t, notifications, 1)
+				return
+			}
+			require.Len(t, alerts, 1)
+			assert.Equal(t, tt.threshold, alerts[0].Threshold)
+			assert.Equal(t, tt.spent, alerts[0].Spent)
+			require.Len(t, notifications, 2)
+			assert.Contains(t, notifications[1], "Groceries budget")
+		})
+	}
+}
+
+func TestBillService_CreateImportedBill_BudgetAlert(t *testing.T) {
+	var notifications []string
+	notif := &mockNotifSvc{
+		CreateHomeNotificationFunc: func(ctx context.Context, from *int, homeID int, description string) error {
+			notifications = append(notifications, description)
+			return nil
+		},
+	}
+	budgets := &mockBudgetRepo{
+		budgets: map[int]*models.BillCategoryBudget{5: {BillCategoryID: 5, Amount: 60000, BillCategory: groceries()}},
+		spent:   map[int]models.Money{5: 62000},
+	}
+	svc := services.NewBillService(&mockBillRepo{}, &mockSettlementRepo{}, budgets, homeWithCurrency("USD"), &mockRateSource{rate: 1}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), notif, &mockOutbox{})
+
+	categoryID := 5
+	_, err := svc.CreateImportedBill(context.Background(), 1, 1, models.ImportRow{Date: time.Now(), Amount: 10000, BillCategoryID: &categoryID, Hash: "abc"}, "USD")
+	require.NoError(t, err)
+
+	// no "New expense added" for an imported bill, but the budget alert still goes out
+	require.Len(t, notifications, 1)
+	assert.Contains(t, notifications[0], "Groceries budget")
+}
+
+func TestBillService_UpdateBill_BudgetAlerts(t *testing.T) {
+	groceriesID := 5
+	raised, lowered := models.Money(20000), models.Money(5000)
+	description := "Lidl"
+	tests := []struct {
+		name      string
+		category  *int // of the bill before the edit
+		req       models.UpdateBillRequest
+		spent     models.Money // after the edit
+		threshold int
+	}{
+		{"Raised total crosses 80%", &groceriesID, models.UpdateBillRequest{TotalAmount: &raised}, 50000, models.BudgetWarningPercent},
+		{"Lowered total", &groceriesID, models.UpdateBillRequest{TotalAmount: &lowered}, 62000, 0},
+		{"Moved into the category crosses 100%", nil, models.UpdateBillRequest{BillCategoryID: &groceriesID}, 62000, models.BudgetExceededPercent},
+		{"Description only", &groceriesID, models.UpdateBillRequest{Description: &description}, 62000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &mockOutbox{}
+			var notifications []string
+			notif := &mockNotifSvc{
+				CreateHomeNotificationFunc: func(ctx context.Context, from *int, homeID int, description string) error {
+					notifications = append(notifications, description)
+					return nil
+				},
+			}
+			budgets := &mockBudgetRepo{
+				budgets: map[int]*models.BillCategoryBudget{5: {BillCategoryID: 5, Amount: 60000, BillCategory: groceries()}},
+				spent:   map[int]models.Money{5: tt.spent},
+			}
+			repo := &mockBillRepo{
+				FindByIDFunc: func(ctx context.Context, id int) (*models.Bill, error) {
+					return &models.Bill{ID: 7, HomeID: 1, UploadedBy: 1, BillCategoryID: tt.category, TotalAmount: 10000, Currency: "USD", ExchangeRate: 1, Start: time.Now(), End: time.Now()}, nil
+				},
+			}
+			svc := services.NewBillService(repo, &mockSettlementRepo{}, budgets, homeWithCurrency("USD"), &mockRateSource{rate: 1}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), notif, outbox)
+
+			_, err := svc.UpdateBill(context.Background(), 7, 1, tt.req)
+			require.NoError(t, err)
+
+			var alerts []*models.BudgetAlert
+			for _, e := range outbox.events {
+				if e.Action == event.ActionThresholdReached {
+					alerts = append(alerts, e.Data.(*models.BudgetAlert))
+				}
+			}
+
+			if tt.threshold == 0 {
+				assert.Empty(t, alerts)
+				assert.Empty(t, notifications)
+				return
+			}
+			require.Len(t, alerts, 1)
+			assert.Equal(t, tt.threshold, alerts[0].Threshold)
+			require.Len(t, notifications, 1)
+			assert.Contains(t, notifications[0], "Groceries budget")
+		})
+	}
+}
+
+func TestBillService_CreateBill_BudgetIgnoresOtherMonths(t *testing.T) {
+	outbox := &mockOutbox{}
+	budgets := &mockBudgetRepo{
+		budgets: map[int]*models.BillCategoryBudget{5: {BillCategoryID: 5, Amount: 60000}},
+		spent:   map[int]models.Money{5: 70000},
+	}
+	svc := services.NewBillService(&mockBillRepo{}, &mockSettlementRepo{}, budgets, homeWithCurrency("USD"), &mockRateSource{rate: 1}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+	categoryID := 5
+	lastYear := time.Now().AddDate(-1, 0, 0)
+	_, err := svc.CreateBill(context.Background(), "", &categoryID, "", nil, 10000, "", lastYear, lastYear, nil, nil, "", 1, 1, "", nil)
+
+	require.NoError(t, err)
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, event.ActionCreated, outbox.events[0].Action)
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock ExchangeRateRepository
+type mockExchangeRateRepo struct {
+	rates map[string]float64 // keyed by base+currency, e.g. "PLNEUR"
+
+	upserted []models.ExchangeRate
+	deleted  []string
+}
+
+func (m *mockExchangeRateRepo) Find(ctx context.Context, homeID int, base, currency string) (*models.ExchangeRate, error) {
+	rate, ok := m.rates[base+currency]
+	if !ok {
+		return nil, nil
+	}
+	return &models.ExchangeRate{HomeID: homeID, BaseCurrency: base, Currency: currency, Rate: rate}, nil
+}
+
+func (m *mockExchangeRateRepo) FindByHome(ctx context.Context, homeID int, base string) ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	for key, rate := range m.rates {
+		if key[:3] == base {
+			rates = append(rates, models.ExchangeRate{HomeID: homeID, BaseCurrency: base, Currency: key[3:], Rate: rate})
+		}
+	}
+	return rates, nil
+}
+
+func (m *mockExchangeRateRepo) Upsert(ctx context.Context, rate *models.ExchangeRate) error {
+	m.upserted = append(m.upserted, *rate)
+	return nil
+}
+
+func (m *mockExchangeRateRepo) Delete(ctx context.Context, homeID int, base, currency string) error {
+	m.deleted = append(m.deleted, base+currency)
+	return nil
+}
+
+// Mock RateSource with a fixed rate for every conversion between different currencies
+type mockRateSource struct {
+	rate float64
+	err  error
+}
+
+func (m *mockRateSource) Rate(ctx context.Context, homeID int, from, to string) (float64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	if from == to {
+		return 1, nil
+	}
+	return m.rate, nil
+}
+
+// homeWithCurrency returns a home repository whose homes all keep balances in the given currency
+func homeWithCurrency(currency string) *mockHomeRepo {
+	return &mockHomeRepo{
+		FindByIDFunc: func(ctx context.Context, id int) (*models.Home, error) {
+			return &models.Home{ID: id, Currency: currency}, nil
+		},
+	}
+}
+
+func TestStaticRateSource_Rate(t *testing.T) {
+	repo := &mockExchangeRateRepo{rates: map[string]float64{"PLNEUR": 4.3}}
+	source := services.NewStaticRateSource(repo)
+	ctx := context.Background()
+
+	rate, err := source.Rate(ctx, 1, "PLN", "PLN")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+
+	// the home's override wins over the built-in table
+	rate, err = source.Rate(ctx, 1, "EUR", "PLN")
+	require.NoError(t, err)
+	assert.Equal(t, 4.3, rate)
+
+	rate, err = source.Rate(ctx, 1, "USD", "PLN")
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, rate)
+
+	_, err = source.Rate(ctx, 1, "XYZ", "PLN")
+	assert.ErrorIs(t, err, services.ErrUnsupportedCurrency)
+}
+
+func TestExchangeRateService_GetRates(t *testing.T) {
+	repo := &mockExchangeRateRepo{rates: map[string]float64{"PLNEUR": 4.3}}
+	svc := services.NewExchangeRateService(repo, homeWithCurrency("PLN"), services.NewStaticRateSource(repo))
+
+	rates, err := svc.GetRates(context.Background(), 1)
+
+	require.NoError(t, err)
+	byCode := make(map[string]models.EffectiveRate)
+	for _, r := range rates {
+		assert.Equal(t, "PLN", r.BaseCurrency)
+		byCode[r.Currency] = r
+	}
+	assert.NotContains(t, byCode, "PLN")
+	assert.Equal(t, models.EffectiveRate{Currency: "EUR", BaseCurrency: "PLN", Rate: 4.3, Custom: true}, byCode["EUR"])
+	assert.False(t, byCode["USD"].Custom)
+}
+
+func TestExchangeRateService_SetRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		rate     float64
+		wantErr  error
+	}{
+		{"Success", "EUR", 4.31, nil},
+		{"Unsupported currency", "XYZ", 1, services.ErrUnsupportedCurrency},
+		{"Home currency", "PLN", 2, services.ErrBaseCurrencyRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockExchangeRateRepo{}
+			svc := services.NewExchangeRateService(repo, homeWithCurrency("PLN"), services.NewStaticRateSource(repo))
+
+			err := svc.SetRate(context.Background(), 1, tt.currency, tt.rate)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, repo.upserted)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, repo.upserted, 1)
+			assert.Equal(t, models.ExchangeRate{HomeID: 1, BaseCurrency: "PLN", Currency: "EUR", Rate: 4.31}, repo.upserted[0])
+		})
+	}
+}
+
+func TestExchangeRateService_DeleteRate(t *testing.T) {
+	repo := &mockExchangeRateRepo{}
+	svc := services.NewExchangeRateService(repo, homeWithCurrency("PLN"), services.NewStaticRateSource(repo))
+
+	require.NoError(t, svc.DeleteRate(context.Background(), 1, "EUR"))
+	assert.Equal(t, []string{"PLNEUR"}, repo.deleted)
+}
+
+func TestBillService_CreateBill_SnapshotsRate(t *testing.T) {
+	var created *models.Bill
+	var splits []models.BillSplit
+	repo := &mockBillRepo{
+		CreateFunc: func(ctx context.Context, b *models.Bill) error {
+			created = b
+			return nil
+		},
+		CreateSplitsFunc: func(ctx context.Context, billID int, s []models.BillSplit) error {
+			splits = s
+			return nil
+		},
+	}
+	svc := services.NewBillService(repo, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("PLN"), &mockRateSource{rate: 4.3}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, &mockOutbox{})
+
+	_, err := svc.CreateBill(context.Background(), "other", nil, "", nil, 1000, "EUR", time.Now(), time.Now(), nil, nil, "", 1, 1, models.SplitModeEqual, []models.SplitInput{{UserID: 1}, {UserID: 2}})
+
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", created.Currency)
+	assert.Equal(t, 4.3, created.ExchangeRate)
+	// splits stay in the bill's currency
+	assert.Equal(t, models.Money(500), splits[0].Amount)
+}
+
+func TestBillService_CreateBill_DefaultsToHomeCurrency(t *testing.T) {
+	var created *models.Bill
+	repo := &mockBillRepo{
+		CreateFunc: func(ctx context.Context, b *models.Bill) error {
+			created = b
+			return nil
+		},
+	}
+	svc := services.NewBillService(repo, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("PLN"), &mockRateSource{rate: 4.3}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, &mockOutbox{})
+
+	_, err := svc.CreateBill(context.Background(), "other", nil, "", nil, 1000, "", time.Now(), time.Now(), nil, nil, "", 1, 1, "", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "PLN", created.Currency)
+	assert.Equal(t, 1.0, created.ExchangeRate)
+}
+
+func TestBillService_CreateBill_RateError(t *testing.T) {
+	outbox := &mockOutbox{}
+	svc := services.NewBillService(&mockBillRepo{}, &mockSettlementRepo{}, &mockBudgetRepo{}, homeWithCurrency("PLN"), &mockRateSource{err: services.ErrUnsupportedCurrency}, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+	_, err := svc.CreateBill(context.Background(), "other", nil, "", nil, 1000, "XYZ", time.Now(), time.Now(), nil, nil, "", 1, 1, "", nil)
+
+	assert.True(t, errors.Is(err, services.ErrUnsupportedCurrency))
+	assert.Empty(t, outbox.events)
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock ExportRepository, streaming every dataset in batches of one
+type mockExportRepo struct {
+	bills   []models.Bill
+	tasks   []models.Task
+	items   []models.ShoppingItem
+	polls   []models.Poll
+	batches int
+}
+
+func streamEach[T any](items []T, m *mockExportRepo, fn func([]T) error) error {
+	for i := range items {
+		m.batches++
+		if err := fn(items[i : i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockExportRepo) StreamBills(ctx context.Context, homeID int, fn func([]models.Bill) error) error {
+	return streamEach(m.bills, m, fn)
+}
+
+func (m *mockExportRepo) StreamTasks(ctx context.Context, homeID int, fn func([]models.Task) error) error {
+	return streamEach(m.tasks, m, fn)
+}
+
+func (m *mockExportRepo) StreamDueTasks(ctx context.Context, homeID int, fn func([]models.Task) error) error {
+	var due []models.Task
+	for _, t := range m.tasks {
+		if t.DueDate != nil {
+			due = append(due, t)
+		}
+	}
+	return streamEach(due, m, fn)
+}
+
+func (m *mockExportRepo) StreamShoppingItems(ctx context.Context, homeID int, fn func([]models.ShoppingItem) error) error {
+	return streamEach(m.items, m, fn)
+}
+
+func (m *mockExportRepo) ShoppingCategoryNames(ctx context.Context, homeID int) (map[int]string, error) {
+	return map[int]string{2: "Kitchen"}, nil
+}
+
+func (m *mockExportRepo) StreamPolls(ctx context.Context, homeID int, fn func([]models.Poll) error) error {
+	return streamEach(m.polls, m, fn)
+}
+
+func exportFixture() *mockExportRepo {
+	due := time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)
+	alice := &models.User{ID: 1, Name: "Alice"}
+	return &mockExportRepo{
+		bills: []models.Bill{
+			{
+				ID: 1, Type: "electricity", Description: "=HYPERLINK(\"x\")", TotalAmount: 9000, Currency: "EUR", ExchangeRate: 1,
+				Start: due, End: due, UploadedBy: 1, User: alice,
+				BillSplits: []models.BillSplit{
+					{UserID: 1, Amount: 4500, PaidAmount: 4500, Paid: true, User: alice},
+					{UserID: 2, Amount: 4500},
+				},
+			},
+			{ID: 2, Type: "other", TotalAmount: 1250, Currency: "EUR", ExchangeRate: 1, Start: due, End: due, UploadedBy: 2},
+		},
+		tasks: []models.Task{
+			{
+				ID: 7, Name: "Take out the trash", Description: "Bins; paper, glass", DueDate: &due,
+				Room:            &models.Room{Name: "Kitchen"},
+				TaskAssignments: []models.TaskAssignment{{UserID: 1, Status: "assigned", User: alice}},
+			},
+			{ID: 8, Name: "Someday"},
+		},
+		items: []models.ShoppingItem{{ID: 3, CategoryID: 2, Name: "Milk", UploadedBy: 1, User: alice}},
+		polls: []models.Poll{{
+			ID: 4, Question: "Pizza?", Type: "anonymous", Status: "closed",
+			Options: []models.Option{
+				{ID: 1, Title: "Yes", Votes: []models.Vote{{UserID: 1}, {UserID: 2}}},
+				{ID: 2, Title: "No"},
+			},
+		}},
+	}
+}
+
+func readCSV(t *testing.T, data string) [][]string {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestExportService_WriteCSV_Bills(t *testing.T) {
+	repo := exportFixture()
+	svc := services.NewExportService(repo, homeWithCurrency("EUR"))
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.WriteCSV(context.Background(), 1, models.ExportBills, &buf))
+
+	records := readCSV(t, buf.String())
+	require.Len(t, records, 4) // header, two splits, a bill without splits
+	assert.Equal(t, "bill_id", records[0][0])
+
+	// one row per split, bill columns repeated
+	assert.Equal(t, []string{"1", "2025-03-10", "2025-03-10", "electricity", "", `'=HYPERLINK("x")`, "90.00", "EUR", "1",
+		"false", "", "Alice", "Alice", "45.00", "45.00", "true"}, records[1])
+	assert.Equal(t, "user 2", records[2][12])
+	assert.Equal(t, "0.00", records[2][14])
+
+	assert.Equal(t, "12.50", records[3][6])
+	assert.Equal(t, "", records[3][12])
+}
+
+func TestExportService_WriteCSV_Others(t *testing.T) {
+	svc := services.NewExportService(exportFixture(), homeWithCurrency("EUR"))
+
+	var tasks bytes.Buffer
+	require.NoError(t, svc.WriteCSV(context.Background(), 1, models.ExportTasks, &tasks))
+	records := readCSV(t, tasks.String())
+	require.Len(t, records, 3)
+	assert.Equal(t, "Alice", records[1][8])
+	assert.Equal(t, "assigned", records[1][9])
+	assert.Equal(t, "", records[2][8])
+
+	var shopping bytes.Buffer
+	require.NoError(t, svc.WriteCSV(context.Background(), 1, models.ExportShopping, &shopping))
+	records = readCSV(t, shopping.String())
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"3", "Kitchen", "Milk", "Alice"}, records[1][:4])
+
+	var polls bytes.Buffer
+	require.NoError(t, svc.WriteCSV(context.Background(), 1, models.ExportPolls, &polls))
+	records = readCSV(t, polls.String())
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"Yes", "2"}, records[1][6:])
+	assert.Equal(t, []string{"No", "0"}, records[2][6:])
+}
+
+func TestExportService_WriteCSV_UnknownDataset(t *testing.T) {
+	svc := services.NewExportService(exportFixture(), homeWithCurrency("EUR"))
+
+	var buf bytes.Buffer
+	err := svc.WriteCSV(context.Background(), 1, "users", &buf)
+	assert.ErrorIs(t, err, services.ErrUnknownExport)
+	assert.Zero(t, buf.Len())
+}
+
+func TestExportService_WriteJSON(t *testing.T) {
+	repo := exportFixture()
+	svc := services.NewExportService(repo, homeWithCurrency("EUR"))
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.WriteJSON(context.Background(), 1, &buf))
+
+	var archive struct {
+		Home          map[string]interface{} `json:"home"`
+		Bills         []models.Bill          `json:"bills"`
+		Tasks         []models.Task          `json:"tasks"`
+		ShoppingItems []models.ShoppingItem  `json:"shopping_items"`
+		Polls         []json.RawMessage      `json:"polls"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &archive))
+
+	assert.Equal(t, "EUR", archive.Home["currency"])
+	require.Len(t, archive.Bills, 2)
+	assert.Len(t, archive.Bills[0].BillSplits, 2)
+	assert.Len(t, archive.Tasks, 2)
+	assert.Len(t, archive.ShoppingItems, 1)
+	require.Len(t, archive.Polls, 1)
+
+	// vote counts only, no voters
+	assert.Contains(t, string(archive.Polls[0]), `"votes":2`)
+	assert.NotContains(t, string(archive.Polls[0]), "user_id")
+
+	assert.Equal(t, 6, repo.batches)
+}
+
+func TestExportService_WriteCalendar(t *testing.T) {
+	repo := exportFixture()
+	repo.tasks[0].Description = strings.Repeat("Scrub the floor and wipe every shelf. ", 4)
+	svc := services.NewExportService(repo, homeWithCurrency("EUR"))
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.WriteCalendar(context.Background(), 1, &buf))
+	ics := buf.String()
+
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 1, strings.Count(ics, "BEGIN:VEVENT"))
+	assert.Contains(t, ics, "DTSTART:20250310T180000Z\r\n")
+	assert.Contains(t, ics, "SUMMARY:Take out the trash\r\n")
+	assert.Contains(t, ics, "LOCATION:Kitchen\r\n")
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+
+	// unfolded, the description keeps the assignees on an escaped new line
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	assert.Contains(t, unfolded, `\nAssigned to: Alice`)
+}
+
+func TestExportService_WriteCalendar_Escaping(t *testing.T) {
+	repo := exportFixture()
+	svc := services.NewExportService(repo, homeWithCurrency("EUR"))
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.WriteCalendar(context.Background(), 1, &buf))
+
+	assert.Contains(t, buf.String(), `DESCRIPTION:Bins\; paper\, glass\nAssigned to: Alice`)
+}
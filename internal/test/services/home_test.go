@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/Dragodui/diploma-server/internal/event"
 	"github.com/Dragodui/diploma-server/internal/logger"
 	"github.com/Dragodui/diploma-server/internal/models"
 	"github.com/Dragodui/diploma-server/internal/repository"
@@ -21,9 +22,15 @@ func TestMain(m *testing.M) {
 }
 
 // Mock NotificationService (no-op, shared across all test files in this package)
-type mockNotifSvc struct{}
+type mockNotifSvc struct {
+	CreateFunc                 func(ctx context.Context, from *int, to int, description string) error
+	CreateHomeNotificationFunc func(ctx context.Context, from *int, homeID int, description string) error
+}
 
 func (m *mockNotifSvc) Create(ctx context.Context, from *int, to int, description string) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, from, to, description)
+	}
 	return nil
 }
 func (m *mockNotifSvc) GetByUserID(ctx context.Context, userID int) ([]models.Notification, error) {
@@ -33,6 +40,9 @@ func (m *mockNotifSvc) MarkAsRead(ctx context.Context, notificationID, userID in
 	return nil
 }
 func (m *mockNotifSvc) CreateHomeNotification(ctx context.Context, from *int, homeID int, description string) error {
+	if m.CreateHomeNotificationFunc != nil {
+		return m.CreateHomeNotificationFunc(ctx, from, homeID, description)
+	}
 	return nil
 }
 func (m *mockNotifSvc) GetByHomeID(ctx context.Context, homeID int) ([]models.HomeNotification, error) {
@@ -42,6 +52,21 @@ func (m *mockNotifSvc) MarkAsReadForHomeNotification(ctx context.Context, notifi
 	return nil
 }
 
+// Mock OutboxService (runs fn without a transaction and records added events, shared across all test files in this package)
+type mockOutbox struct {
+	channels []string
+	events   []event.RealTimeEvent
+}
+
+func (m *mockOutbox) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+func (m *mockOutbox) Add(ctx context.Context, channel string, e *event.RealTimeEvent) error {
+	m.channels = append(m.channels, channel)
+	m.events = append(m.events, *e)
+	return nil
+}
+
 // Mock HomeRepository
 type mockHomeRepo struct {
 	CreateFunc                   func(ctx context.Context, h *models.Home) error
@@ -167,6 +192,10 @@ func (m *mockHomeRepo) GetUserHomes(ctx context.Context, userID int) ([]models.H
 	return nil, nil
 }
 
+func (m *mockHomeRepo) GetUserHomeIDs(ctx context.Context, userID int) ([]int, error) {
+	return nil, nil
+}
+
 func (m *mockHomeRepo) UpdateMemberRole(ctx context.Context, homeID int, userID int, role string) error {
 	return nil
 }
@@ -181,7 +210,7 @@ func (m *mockHomeRepo) GetUserHome(ctx context.Context, userID int) (*models.Hom
 // Test helpers
 func setupHomeService(t *testing.T, repo repository.HomeRepository) *services.HomeService {
 	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
-	return services.NewHomeService(repo, redisClient, &mockNotifSvc{})
+	return services.NewHomeService(repo, redisClient, &mockNotifSvc{}, &mockOutbox{})
 }
 
 // CreateHome Tests
@@ -193,6 +222,7 @@ func TestHomeService_CreateHome_Success(t *testing.T) {
 		CreateFunc: func(ctx context.Context, h *models.Home) error {
 			require.Equal(t, "My Home", h.Name)
 			require.Equal(t, "ABC123", h.InviteCode)
+			require.Equal(t, models.DefaultCurrency, h.Currency)
 			h.ID = 1
 			return nil
 		},
@@ -206,7 +236,7 @@ func TestHomeService_CreateHome_Success(t *testing.T) {
 	}
 
 	svc := setupHomeService(t, repo)
-	err := svc.CreateHome(context.Background(), "My Home", 5)
+	err := svc.CreateHome(context.Background(), "My Home", "", 5)
 
 	assert.NoError(t, err)
 }
@@ -219,7 +249,7 @@ func TestHomeService_CreateHome_InviteCodeGenerationFails(t *testing.T) {
 	}
 
 	svc := setupHomeService(t, repo)
-	err := svc.CreateHome(context.Background(), "My Home", 5)
+	err := svc.CreateHome(context.Background(), "My Home", "", 5)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to generate code")
@@ -236,12 +266,26 @@ func TestHomeService_CreateHome_RepositoryError(t *testing.T) {
 	}
 
 	svc := setupHomeService(t, repo)
-	err := svc.CreateHome(context.Background(), "My Home", 5)
+	err := svc.CreateHome(context.Background(), "My Home", "", 5)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "database error")
 }
 
+func TestHomeService_CreateHome_UnsupportedCurrency(t *testing.T) {
+	repo := &mockHomeRepo{
+		CreateFunc: func(ctx context.Context, h *models.Home) error {
+			t.Fatal("home must not be created")
+			return nil
+		},
+	}
+
+	svc := setupHomeService(t, repo)
+	err := svc.CreateHome(context.Background(), "My Home", "XYZ", 5)
+
+	assert.ErrorIs(t, err, services.ErrUnsupportedCurrency)
+}
+
 // JoinHome Tests
 func TestHomeService_JoinHome_Success(t *testing.T) {
 	home := &models.Home{
@@ -398,6 +442,39 @@ func TestHomeService_LeaveHome_Success(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestHomeService_LeaveHome_AddsMemberEvents(t *testing.T) {
+	repo := &mockHomeRepo{
+		DeleteMemberFunc: func(ctx context.Context, id int, userID int) error {
+			return nil
+		},
+	}
+	outbox := &mockOutbox{}
+	svc := services.NewHomeService(repo, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+	err := svc.LeaveHome(context.Background(), 1, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{event.HomeChannel(1), event.UserChannel(5)}, outbox.channels)
+	for _, e := range outbox.events {
+		assert.Equal(t, event.ActionMemberLeft, e.Action)
+	}
+}
+
+func TestHomeService_LeaveHome_NoEventOnError(t *testing.T) {
+	repo := &mockHomeRepo{
+		DeleteMemberFunc: func(ctx context.Context, id int, userID int) error {
+			return errors.New("db error")
+		},
+	}
+	outbox := &mockOutbox{}
+	svc := services.NewHomeService(repo, redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+	err := svc.LeaveHome(context.Background(), 1, 5)
+
+	assert.Error(t, err)
+	assert.Empty(t, outbox.events)
+}
+
 // RemoveMember Tests
 func TestHomeService_RemoveMember_Success(t *testing.T) {
 	repo := &mockHomeRepo{
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock BillRepository
+type mockBillRepo struct {
+	CreateFunc            func(ctx context.Context, b *models.Bill) error
+	FindByIDFunc          func(ctx context.Context, id int) (*models.Bill, error)
+	UpdateFunc            func(ctx context.Context, b *models.Bill) error
+	CreateSplitsFunc      func(ctx context.Context, billID int, splits []models.BillSplit) error
+	UpdateSplitsFunc      func(ctx context.Context, billID int, splits []models.BillSplit) error
+	FindSplitsBetweenFunc func(ctx context.Context, homeID, debtorID, creditorID int) ([]models.BillSplit, error)
+	SetSplitPaymentFunc   func(ctx context.Context, splitID int, paidAmount models.Money, paid bool) error
+	FindSplitDebtsFunc    func(ctx context.Context, homeID int) ([]models.Debt, error)
+	CreateRevisionFunc    func(ctx context.Context, rev *models.BillRevision) error
+	FindImportHashesFunc  func(ctx context.Context, homeID int, hashes []string) (map[string]bool, error)
+	FindDuplicatesFunc    func(ctx context.Context, homeID int, receiptHash, vendor, date string, total models.Money) ([]models.Bill, error)
+	FindCategorizedFunc   func(ctx context.Context, homeID, limit int) ([]models.Bill, error)
+	SpendingSummaryFunc   func(ctx context.Context, f models.SpendingFilter) (*models.SpendingStat, error)
+	SpendingByGroupFunc   func(ctx context.Context, f models.SpendingFilter) ([]models.SpendingStat, error)
+	SpendingByMonthFunc   func(ctx context.Context, f models.SpendingFilter) (map[string][]models.MonthlySpending, error)
+	FindByHomeIDFunc      func(ctx context.Context, f models.BillFilter) ([]models.Bill, error)
+}
+
+func (m *mockBillRepo) Create(ctx context.Context, b *models.Bill) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, b)
+	}
+	return nil
+}
+
+func (m *mockBillRepo) FindByID(ctx context.Context, id int) (*models.Bill, error) {
+	if m.FindByIDFunc != nil {
+		return m.FindByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *mockBillRepo) FindByHomeID(ctx context.Context, f models.BillFilter) ([]models.Bill, error) {
+	if m.FindByHomeIDFunc != nil {
+		return m.FindByHomeIDFunc(ctx, f)
+	}
+	return nil, nil
+}
+
+func (m *mockBillRepo) Delete(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *mockBillRepo) Update(ctx context.Context, b *models.Bill) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, b)
+	}
+	return nil
+}
+
+func (m *mockBillRepo) CreateSplits(ctx context.Context, billID int, splits []models.BillSplit) error {
+	if m.CreateSplitsFunc != nil {
+		return m.CreateSplitsFunc(ctx, billID, splits)
+	}
+	return nil
+}
+
+func (m *mockBillRepo) UpdateSplits(ctx context.Context, billID int, splits []models.BillSplit) error {
+	if m.UpdateSplitsFunc != nil {
+		return m.UpdateSplitsFunc(ctx, billID, splits)
+	}
+	return nil
+}
+
+func (m *mockBillRepo) MarkSplitPaid(ctx context.Context, splitID int) error {
+	return nil
+}
+
+func (m *mockBillRepo) FindSplitByID(ctx context.Context, splitID int) (*models.BillSplit, error) {
+	return nil, nil
+}
+
+func (m *mockBillRepo) FindSplitsBetween(ctx context.Context, homeID, debtorID, creditorID int) ([]models.BillSplit, error) {
+	if m.FindSplitsBetweenFunc != nil {
+		return m.FindSplitsBetweenFunc(ctx, homeID, debtorID, creditorID)
+	}
+	return nil, nil
+}
+
+func (m *mockBillRepo) SetSplitPayment(ctx context.Context, splitID int, paidAmount models.Money, paid bool) error {
+	if m.SetSplitPaymentFunc != nil {
+		return m.SetSplitPaymentFunc(ctx, splitID, paidAmount, paid)
+	}
+	return nil
+}
+
+func (m *mockBillRepo) FindSplitDebts(ctx context.Context, homeID int) ([]models.Debt, error) {
+	if m.FindSplitDebtsFunc != nil {
+		return m.FindSplitDebtsFunc(ctx, homeID)
+	}
+	return nil, nil
+}
+
+func (m *mockBillRepo) CreateRevision(ctx context.Context, rev *models.BillRevision) error {
+	if m.CreateRevisionFunc != nil {
+		return m.CreateRevisionFunc(ctx, rev)
+	}
+	return nil
+}
+
+func (m *mockBillRepo) FindRevisions(ctx context.Context, billID int) ([]models.BillRevision, error) {
+	return nil, nil
+}
+
+func (m *mockBillRepo) FindImportHashes(ctx context.Context, homeID int, hashes []string) (map[string]bool, error) {
+	if m.FindImportHashesFunc != nil {
+		return m.FindImportHashesFunc(ctx, homeID, hashes)
+	}
+	return map[string]bool{}, nil
+}
+
+func (m *mockBillRepo) FindDuplicates(ctx context.Context, homeID int, receiptHash, vendor, date string, total models.Money) ([]models.Bill, error) {
+	if m.FindDuplicatesFunc != nil {
+		return m.FindDuplicatesFunc(ctx, homeID, receiptHash, vendor, date, total)
+	}
+	return nil, nil
+}
+
+func (m *mockBillRepo) FindCategorized(ctx context.Context, homeID, limit int) ([]models.Bill, error) {
+	if m.FindCategorizedFunc != nil {
+		return m.FindCategorizedFunc(ctx, homeID, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockBillRepo) SpendingSummary(ctx context.Context, f models.SpendingFilter) (*models.SpendingStat, error) {
+	if m.SpendingSummaryFunc != nil {
+		return m.SpendingSummaryFunc(ctx, f)
+	}
+	return &models.SpendingStat{}, nil
+}
+
+func (m *mockBillRepo) SpendingByGroup(ctx context.Context, f models.SpendingFilter) ([]models.SpendingStat, error) {
+	if m.SpendingByGroupFunc != nil {
+		return m.SpendingByGroupFunc(ctx, f)
+	}
+	return nil, nil
+}
+
+func (m *mockBillRepo) SpendingByMonth(ctx context.Context, f models.SpendingFilter) (map[string][]models.MonthlySpending, error) {
+	if m.SpendingByMonthFunc != nil {
+		return m.SpendingByMonthFunc(ctx, f)
+	}
+	return nil, nil
+}
+
+func setupLedgerService(debts []models.Debt, err error) *services.LedgerService {
+	return services.NewLedgerService(&mockBillRepo{
+		FindSplitDebtsFunc: func(ctx context.Context, homeID int) ([]models.Debt, error) {
+			return debts, err
+		},
+	}, &mockSettlementRepo{}, homeWithCurrency("USD"))
+}
+
+func TestLedgerService_GetBalances_NetsOpposingDebts(t *testing.T) {
+	svc := setupLedgerService([]models.Debt{
+		{DebtorID: 2, CreditorID: 1, Amount: 3000},
+		{DebtorID: 1, CreditorID: 2, Amount: 1000},
+	}, nil)
+
+	result, err := svc.GetBalances(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.MemberBalance{{UserID: 1, Net: 2000}, {UserID: 2, Net: -2000}}, result.Balances)
+	assert.Equal(t, []models.Transfer{{From: 2, To: 1, Amount: 2000}}, result.Transfers)
+}
+
+func TestLedgerService_GetBalances_SimplifiesChains(t *testing.T) {
+	// 3 owes 2 and 2 owes 1 the same amount, so 3 can pay 1 directly
+	svc := setupLedgerService([]models.Debt{
+		{DebtorID: 3, CreditorID: 2, Amount: 2550},
+		{DebtorID: 2, CreditorID: 1, Amount: 2550},
+	}, nil)
+
+	result, err := svc.GetBalances(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.Transfer{{From: 3, To: 1, Amount: 2550}}, result.Transfers)
+	assert.Zero(t, result.Balances[1].Net)
+}
+
+func TestLedgerService_GetBalances_ClearsAllDebts(t *testing.T) {
+	debts := []models.Debt{
+		{DebtorID: 2, CreditorID: 1, Amount: 3333},
+		{DebtorID: 3, CreditorID: 1, Amount: 3333},
+		{DebtorID: 4, CreditorID: 2, Amount: 1210},
+		{DebtorID: 1, CreditorID: 4, Amount: 10},
+	}
+	svc := setupLedgerService(debts, nil)
+
+	result, err := svc.GetBalances(context.Background(), 1)
+	require.NoError(t, err)
+
+	// applying the transfers must bring every member back to zero
+	net := make(map[int]models.Money)
+	for _, b := range result.Balances {
+		net[b.UserID] = b.Net
+	}
+	for _, tr := range result.Transfers {
+		assert.Positive(t, tr.Amount)
+		net[tr.From] += tr.Amount
+		net[tr.To] -= tr.Amount
+	}
+	for userID, amount := range net {
+		assert.Zero(t, amount, "user %d is not settled", userID)
+	}
+	assert.LessOrEqual(t, len(result.Transfers), len(result.Balances)-1)
+}
+
+func TestLedgerService_GetBalances_SubtractsSettlements(t *testing.T) {
+	billRepo := &mockBillRepo{
+		FindSplitDebtsFunc: func(ctx context.Context, homeID int) ([]models.Debt, error) {
+			return []models.Debt{{DebtorID: 2, CreditorID: 1, Amount: 5000}}, nil
+		},
+	}
+	// 2 has already paid 1 back 30 of the 50
+	settlementRepo := &mockSettlementRepo{
+		SumByPairFunc: func(ctx context.Context, homeID int) ([]models.Debt, error) {
+			return []models.Debt{{DebtorID: 1, CreditorID: 2, Amount: 3000}}, nil
+		},
+	}
+	svc := services.NewLedgerService(billRepo, settlementRepo, homeWithCurrency("PLN"))
+
+	result, err := svc.GetBalances(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, "PLN", result.Currency)
+	assert.Equal(t, []models.MemberBalance{{UserID: 1, Net: 2000}, {UserID: 2, Net: -2000}}, result.Balances)
+	assert.Equal(t, []models.Transfer{{From: 2, To: 1, Amount: 2000}}, result.Transfers)
+}
+
+func TestLedgerService_GetBalances_Empty(t *testing.T) {
+	svc := setupLedgerService(nil, nil)
+
+	result, err := svc.GetBalances(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Balances)
+	assert.NotNil(t, result.Transfers)
+	assert.Empty(t, result.Transfers)
+}
+
+func TestLedgerService_GetBalances_RepoError(t *testing.T) {
+	svc := setupLedgerService(nil, errors.New("db error"))
+
+	result, err := svc.GetBalances(context.Background(), 1)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+// Mock OCRJobRepository keeping jobs in memory
+type mockOCRJobRepo struct {
+	mu    sync.Mutex
+	jobs  map[string]*models.OCRJob
+	files map[string][]models.OCRJobFile
+}
+
+func newMockOCRJobRepo() *mockOCRJobRepo {
+	return &mockOCRJobRepo{jobs: map[string]*models.OCRJob{}, files: map[string][]models.OCRJobFile{}}
+}
+
+func (m *mockOCRJobRepo) Create(ctx context.Context, job *models.OCRJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.CreatedAt = time.Now()
+	stored := *job
+	stored.Files = nil
+	m.jobs[job.ID] = &stored
+	m.files[job.ID] = append([]models.OCRJobFile{}, job.Files...)
+	return nil
+}
+
+func (m *mockOCRJobRepo) FindFiles(ctx context.Context, jobID string) ([]models.OCRJobFile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files[jobID], nil
+}
+
+func (m *mockOCRJobRepo) FindByID(ctx context.Context, id string) (*models.OCRJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *job
+	return &found, nil
+}
+
+func (m *mockOCRJobRepo) FindDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]models.OCRJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []models.OCRJob
+	for _, job := range m.jobs {
+		if (job.Status == models.OCRJobQueued && !job.NextAttemptAt.After(now)) ||
+			(job.Status == models.OCRJobProcessing && job.StartedAt.Before(staleBefore)) {
+			due = append(due, *job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].CreatedAt.Before(due[j].CreatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (m *mockOCRJobRepo) MarkProcessing(ctx context.Context, ids []string, startedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		job := m.jobs[id]
+		job.Status = models.OCRJobProcessing
+		job.StartedAt = &startedAt
+		job.Attempts++
+	}
+	return nil
+}
+
+func (m *mockOCRJobRepo) Complete(ctx context.Context, id string, result datatypes.JSON, finishedAt time.Time) (bool, error) {
+	return m.finish(id, func(job *models.OCRJob) {
+		job.Status = models.OCRJobCompleted
+		job.Result = result
+		job.FinishedAt = &finishedAt
+	})
+}
+
+func (m *mockOCRJobRepo) Retry(ctx context.Context, id, lastError string, nextAttemptAt time.Time) error {
+	_, err := m.finish(id, func(job *models.OCRJob) {
+		job.Status = models.OCRJobQueued
+		job.LastError = lastError
+		job.NextAttemptAt = nextAttemptAt
+	})
+	return err
+}
+
+func (m *mockOCRJobRepo) Fail(ctx context.Context, id, lastError string, finishedAt time.Time) (bool, error) {
+	return m.finish(id, func(job *models.OCRJob) {
+		job.Status = models.OCRJobFailed
+		job.LastError = lastError
+		job.FinishedAt = &finishedAt
+	})
+}
+
+func (m *mockOCRJobRepo) finish(id string, update func(job *models.OCRJob)) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job := m.jobs[id]
+	if job == nil || job.Status != models.OCRJobProcessing {
+		return false, nil
+	}
+	update(job)
+	if job.Status != models.OCRJobQueued {
+		delete(m.files, id)
+	}
+	return true, nil
+}
+
+func (m *mockOCRJobRepo) CountQueued(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, job := range m.jobs {
+		if job.Status == models.OCRJobQueued {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *mockOCRJobRepo) DeleteFinishedBefore(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func (m *mockOCRJobRepo) job(id string) models.OCRJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.jobs[id]
+}
+
+// work dispatches the due jobs and waits until a worker is done with the given one
+func work(t *testing.T, svc *services.OCRJobService, repo *mockOCRJobRepo, jobID string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.Work(ctx)
+		close(done)
+	}()
+
+	n, err := svc.Dispatch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Eventually(t, func() bool {
+		return repo.job(jobID).Status != models.OCRJobProcessing
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func submit(t *testing.T, svc *services.OCRJobService) *models.OCRJob {
+	job, err := svc.Submit(context.Background(), 7, 0, []models.OCRFile{{Data: []byte("receipt"), MimeType: "image/png"}}, "pl")
+	require.NoError(t, err)
+	return job
+}
+
+func TestOCRJobService_SubmitAndGet(t *testing.T) {
+	repo := newMockOCRJobRepo()
+	svc := services.NewOCRJobService(repo, services.NewOCRService(services.NewFakeOCRProvider(), newMockOCRCache(), &mockBillRepo{}), &mockOutbox{}, 1)
+	ctx := context.Background()
+
+	job, err := svc.Submit(ctx, 7, 0, []models.OCRFile{{Data: []byte("receipt"), MimeType: "image/png"}}, "pl")
+	require.NoError(t, err)
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, models.OCRJobQueued, job.Status)
+
+	found, err := svc.Get(ctx, 7, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", found.MimeType)
+
+	// someone else's job looks like no job at all
+	_, err = svc.Get(ctx, 8, job.ID)
+	assert.ErrorIs(t, err, services.ErrOCRJobNotFound)
+	_, err = svc.Get(ctx, 7, "missing")
+	assert.ErrorIs(t, err, services.ErrOCRJobNotFound)
+}
+
+func TestOCRJobService_Submit_Files(t *testing.T) {
+	repo := newMockOCRJobRepo()
+	svc := services.NewOCRJobService(repo, services.NewOCRService(services.NewTextOCRProvider(), newMockOCRCache(), &mockBillRepo{}), &mockOutbox{}, 1)
+	ctx := context.Background()
+
+	job, err := svc.Submit(ctx, 7, 0, []models.OCRFile{
+		{Data: []byte("Kiosk\nWater 1.50\n"), MimeType: "text/plain"},
+		{Data: []byte("Water 1.50\nTOTAL 1.50\n"), MimeType: "text/plain"},
+	}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.FileCount)
+
+	files, err := repo.FindFiles(ctx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, 1, files[1].Position)
+
+	work(t, svc, repo, job.ID)
+
+	stored := repo.job(job.ID)
+	assert.Equal(t, models.OCRJobCompleted, stored.Status)
+	assert.Contains(t, string(stored.Result), `"pages":[`)
+	assert.Empty(t, repo.files[job.ID])
+
+	_, err = svc.Submit(ctx, 7, 0, nil, "")
+	assert.ErrorIs(t, err, services.ErrNoOCRFiles)
+}
+
+func TestOCRJobService_Dispatch_Bounded(t *testing.T) {
+	repo := newMockOCRJobRepo()
+	svc := services.NewOCRJobService(repo, services.NewOCRService(services.NewFakeOCRProvider(), newMockOCRCache(), &mockBillRepo{}), &mockOutbox{}, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := svc.Submit(ctx, 7, 0, []models.OCRFile{{Data: []byte("receipt"), MimeType: "image/png"}}, "")
+		require.NoError(t, err)
+	}
+
+	n, err := svc.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// both workers are taken until the claimed jobs finish
+	n, err = svc.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	queued, err := repo.CountQueued(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), queued)
+}
+
+func TestOCRJobService_Work_Completed(t *testing.T) {
+	repo := newMockOCRJobRepo()
+	outbox := &mockOutbox{}
+	svc := services.NewOCRJobService(repo, services.NewOCRService(services.NewFakeOCRProvider(), newMockOCRCache(), &mockBillRepo{}), outbox, 1)
+
+	job := submit(t, svc)
+	work(t, svc, repo, job.ID)
+
+	stored := repo.job(job.ID)
+	assert.Equal(t, models.OCRJobCompleted, stored.Status)
+	assert.Contains(t, string(stored.Result), `"vendor":"Fake Market"`)
+	assert.NotNil(t, stored.FinishedAt)
+	// the uploads are not kept once the receipt is read
+	assert.Empty(t, repo.files[job.ID])
+
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, event.UserChannel(7), outbox.channels[0])
+	assert.Equal(t, event.ModuleOCR, outbox.events[0].Module)
+	assert.Equal(t, event.ActionCompleted, outbox.events[0].Action)
+
+	// finished jobs are not claimed again
+	n, err := svc.Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestOCRJobService_Work_Retries(t *testing.T) {
+	repo := newMockOCRJobRepo()
+	outbox := &mockOutbox{}
+	provider := services.NewFakeOCRProvider()
+	provider.SetError(errors.New("service unavailable"))
+	svc := services.NewOCRJobService(repo, services.NewOCRService(provider, newMockOCRCache(), &mockBillRepo{}), outbox, 1)
+	ctx := context.Background()
+
+	job := submit(t, svc)
+	before := time.Now()
+	work(t, svc, repo, job.ID)
+
+	stored := repo.job(job.ID)
+	assert.Equal(t, models.OCRJobQueued, stored.Status)
+	assert.Contains(t, stored.LastError, "service unavailable")
+	assert.True(t, stored.NextAttemptAt.After(before.Add(4*time.Second)))
+	assert.Empty(t, outbox.events)
+
+	// not due again until the backoff passes
+	n, err := svc.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// the last attempt fails the job for good
+	for attempt := 2; attempt <= 3; attempt++ {
+		repo.mu.Lock()
+		repo.jobs[job.ID].NextAttemptAt = time.Now()
+		repo.mu.Unlock()
+
+		work(t, svc, repo, job.ID)
+	}
+
+	stored = repo.job(job.ID)
+	assert.Equal(t, models.OCRJobFailed, stored.Status)
+	assert.Equal(t, 3, stored.Attempts)
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, event.ActionFailed, outbox.events[0].Action)
+	assert.Equal(t, 3, provider.Calls())
+}
+
+func TestOCRJobService_Work_Unsupported(t *testing.T) {
+	repo := newMockOCRJobRepo()
+	outbox := &mockOutbox{}
+	svc := services.NewOCRJobService(repo, services.NewOCRService(services.NewTextOCRProvider(), newMockOCRCache(), &mockBillRepo{}), outbox, 1)
+
+	job := submit(t, svc)
+	work(t, svc, repo, job.ID)
+
+	// retrying can't help, so the first failure is final
+	stored := repo.job(job.ID)
+	assert.Equal(t, models.OCRJobFailed, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, event.ActionFailed, outbox.events[0].Action)
+}
@@ -0,0 +1,512 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock OCRCacheRepository keeping entries in memory
+type mockOCRCache struct {
+	mu      sync.Mutex
+	entries map[string]models.OCRCacheEntry
+}
+
+func newMockOCRCache() *mockOCRCache {
+	return &mockOCRCache{entries: map[string]models.OCRCacheEntry{}}
+}
+
+func (m *mockOCRCache) Find(ctx context.Context, hash, provider string) (*models.OCRCacheEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[provider+":"+hash]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (m *mockOCRCache) Save(ctx context.Context, entry *models.OCRCacheEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.CreatedAt = time.Now()
+	m.entries[entry.Provider+":"+entry.Hash] = *entry
+	return nil
+}
+
+func (m *mockOCRCache) DeleteBefore(ctx context.Context, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.entries {
+		if entry.CreatedAt.Before(before) {
+			delete(m.entries, key)
+		}
+	}
+	return nil
+}
+
+const textReceipt = `   BIEDRONKA Sklep 1234
+ul. Marszałkowska 10, Warszawa
+NIP 123-456-78-90
+2025-03-14 18:22
+
+Mleko 3,2% 2 x 3,49   6,98 C
+Chleb żytni           4,50 C
+3 x Jogurt naturalny  5,97 A
+Sugar 1,234.50
+SUMA PTU              1,23
+SUMA PLN           1 251,55
+Karta                1 251,55
+`
+
+func TestTextOCRProvider_PlainText(t *testing.T) {
+	p := services.NewTextOCRProvider()
+
+	result, err := p.Extract(context.Background(), []byte(textReceipt), "text/plain", "pl")
+	require.NoError(t, err)
+
+	assert.Equal(t, "BIEDRONKA Sklep 1234", result.Vendor)
+	assert.Equal(t, "2025-03-14", result.Date)
+	assert.Equal(t, models.Money(125155), result.Total)
+
+	require.Len(t, result.Items, 4)
+	assert.Equal(t, models.OCRItem{Name: "Mleko 3,2%", Quantity: 2, Price: 698}, result.Items[0])
+	assert.Equal(t, models.OCRItem{Name: "Chleb żytni", Quantity: 1, Price: 450}, result.Items[1])
+	assert.Equal(t, models.OCRItem{Name: "Jogurt naturalny", Quantity: 3, Price: 597}, result.Items[2])
+	assert.Equal(t, models.OCRItem{Name: "Sugar", Quantity: 1, Price: 123450}, result.Items[3])
+	assert.Greater(t, result.Confidence, 0.5)
+}
+
+func TestTextOCRProvider_Totals(t *testing.T) {
+	receipt := "Corner Shop\n03/14/2025\nApples 1.20\nBarley 2.30\nSubtotal 3.50\nTax 0.35\nTOTAL $3.85\nCash 5.00\nChange 1.15\n"
+
+	result, err := services.NewTextOCRProvider().Extract(context.Background(), []byte(receipt), "text/plain", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Corner Shop", result.Vendor)
+	assert.Equal(t, "03/14/2025", result.Date)
+	assert.Equal(t, models.Money(385), result.Total)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "Barley", result.Items[1].Name)
+	assert.Equal(t, []models.OCRItem{{Name: "Tax", Quantity: 1, Price: 35, Kind: models.ReceiptLineTax}}, result.Adjustments)
+}
+
+func TestTextOCRProvider_PDF(t *testing.T) {
+	// name and price are separate text objects on one baseline, as tills lay them out
+	content := "BT /F1 12 Tf 50 760 Td (Fresh Foods) Tj 0 -20 Td (2025-02-01) Tj ET\n" +
+		"BT /F1 10 Tf 1 0 0 1 50 700 Tm [(Ban)-20(anas)] TJ ET BT 1 0 0 1 300 700 Tm (2.40) Tj ET\n" +
+		"BT 1 0 0 1 50 680 Tm (Caf\\351 beans) Tj 1 0 0 1 300 680 Tm (8.10) Tj ET\n" +
+		"BT 14 TL 1 0 0 1 50 660 Tm [(TO)-400(TAL)] TJ T* (thank you) Tj ET"
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj << /Length %d /Filter /FlateDecode >> stream\n", compressed.Len())
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj << /Length 20 >> stream\nBT (10.50) Tj ET\nendstream\nendobj\n%%EOF\n")
+
+	result, err := services.NewTextOCRProvider().Extract(context.Background(), pdf.Bytes(), "application/pdf", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Fresh Foods\n2025-02-01\nBananas 2.40\nCafé beans 8.10\nTO TAL\nthank you\n10.50", result.RawText)
+	assert.Equal(t, "Fresh Foods", result.Vendor)
+	assert.Equal(t, "2025-02-01", result.Date)
+	// no amount next to the total, so the largest one is taken
+	assert.Equal(t, models.Money(1050), result.Total)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "Bananas", result.Items[0].Name)
+	assert.Equal(t, models.Money(810), result.Items[1].Price)
+}
+
+func TestTextOCRProvider_Unsupported(t *testing.T) {
+	p := services.NewTextOCRProvider()
+	ctx := context.Background()
+
+	_, err := p.Extract(ctx, []byte{0xff, 0xd8, 0xff}, "image/jpeg", "")
+	assert.ErrorIs(t, err, services.ErrUnsupportedOCRFile)
+
+	// a scan has no text layer
+	_, err = p.Extract(ctx, []byte("%PDF-1.4\n1 0 obj << /Subtype /Image /Filter /DCTDecode >> stream\n\xff\xd8\nendstream\n"), "application/pdf", "")
+	assert.ErrorIs(t, err, services.ErrUnsupportedOCRFile)
+
+	_, err = p.Extract(ctx, []byte("  \n"), "text/plain", "")
+	assert.ErrorIs(t, err, services.ErrUnsupportedOCRFile)
+}
+
+func TestFakeOCRProvider(t *testing.T) {
+	p := services.NewFakeOCRProvider()
+	ctx := context.Background()
+
+	first, err := p.Extract(ctx, []byte("a"), "image/png", "")
+	require.NoError(t, err)
+	first.Items[0].Name = "changed"
+
+	second, err := p.Extract(ctx, []byte("b"), "image/png", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Milk", second.Items[0].Name)
+	assert.Equal(t, models.Money(1250), second.Total)
+
+	p.SetError(errors.New("quota exceeded"))
+	_, err = p.Extract(ctx, nil, "image/png", "")
+	assert.EqualError(t, err, "quota exceeded")
+	assert.Equal(t, 3, p.Calls())
+}
+
+func TestNewOCRProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		key      string
+		wantErr  bool
+	}{
+		{name: "Gemini", provider: services.OCRProviderGemini, key: "key"},
+		{name: "Gemini Without Key", provider: services.OCRProviderGemini, wantErr: true},
+		{name: "Text", provider: services.OCRProviderText},
+		{name: "Fake", provider: services.OCRProviderFake},
+		{name: "Unknown", provider: "tesseract", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := services.NewOCRProvider(tt.provider, tt.key)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.provider, p.Name())
+		})
+	}
+}
+
+func TestOCRService_Process(t *testing.T) {
+	svc := services.NewOCRService(services.NewTextOCRProvider(), newMockOCRCache(), &mockBillRepo{})
+	result, err := svc.Process(context.Background(), []models.OCRFile{{Data: []byte("Kiosk\nWater 1.50\nTotal 1.50\n"), MimeType: "text/plain"}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(150), result.Total)
+
+	// the provider's error stays recognisable through the service
+	_, err = svc.Process(context.Background(), []models.OCRFile{{Data: []byte("\x89PNG"), MimeType: "image/png"}}, "")
+	assert.ErrorIs(t, err, services.ErrUnsupportedOCRFile)
+}
+
+func TestOCRService_Process_Cached(t *testing.T) {
+	provider := services.NewFakeOCRProvider()
+	cache := newMockOCRCache()
+	svc := services.NewOCRService(provider, cache, &mockBillRepo{})
+	ctx := context.Background()
+
+	sum := sha256.Sum256([]byte("receipt"))
+	hash := hex.EncodeToString(sum[:])
+
+	first, err := svc.Process(ctx, []models.OCRFile{{Data: []byte("receipt"), MimeType: "image/png"}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, hash, first.ReceiptHash)
+
+	// the same file again is answered from the cache
+	second, err := svc.Process(ctx, []models.OCRFile{{Data: []byte("receipt"), MimeType: "image/png"}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.Calls())
+	assert.Equal(t, "Fake Market", second.Vendor)
+	assert.Equal(t, hash, second.ReceiptHash)
+
+	_, err = svc.Process(ctx, []models.OCRFile{{Data: []byte("another receipt"), MimeType: "image/png"}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.Calls())
+
+	// a receipt nothing was read from is tried again next time
+	provider.SetResult(models.OCRResult{RawText: "blurry"})
+	for i := 0; i < 2; i++ {
+		_, err = svc.Process(ctx, []models.OCRFile{{Data: []byte("blurry receipt"), MimeType: "image/png"}}, "")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, provider.Calls())
+	assert.Len(t, cache.entries, 2)
+}
+
+func TestOCRService_Process_Normalizes(t *testing.T) {
+	provider := services.NewFakeOCRProvider()
+	provider.SetResult(models.OCRResult{
+		Vendor: "Getränkemarkt",
+		Date:   "14.03.2025",
+		Total:  1044,
+		Items: []models.OCRItem{
+			{Name: "Mineralwasser", Quantity: 6, Price: 594},
+			{Name: "Pfand", Price: 150},
+			{Name: "Apfelsaft", Price: 400},
+			{Name: "Rabatt Apfelsaft", Price: 100},
+			{Name: "MwSt 19%", Price: 167},
+		},
+		RawText: "Getränkemarkt\n14.03.2025\nSumme 10,44 EUR",
+	})
+	svc := services.NewOCRService(provider, newMockOCRCache(), &mockBillRepo{})
+
+	result, err := svc.Process(context.Background(), []models.OCRFile{{Data: []byte("receipt"), MimeType: "image/png"}}, "de")
+	require.NoError(t, err)
+
+	require.NotNil(t, result.ParsedDate)
+	assert.Equal(t, time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC), *result.ParsedDate)
+	assert.Equal(t, "EUR", result.Currency)
+
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, models.ReceiptLineItem, result.Items[1].Kind)
+	require.Len(t, result.Adjustments, 3)
+	assert.Equal(t, models.OCRItem{Name: "Rabatt Apfelsaft", Price: -100, Kind: models.ReceiptLineDiscount}, result.Adjustments[1])
+	assert.Equal(t, models.Money(167), result.Tax)
+	assert.Equal(t, models.Money(100), result.Discount)
+	assert.Equal(t, models.Money(150), result.Deposit)
+	// 5.94 + 4.00 - 1.00 + 1.50 is the total, so the VAT is already in the prices
+	assert.True(t, result.TaxIncluded)
+
+	// a cached result comes back the same
+	again, err := svc.Process(context.Background(), []models.OCRFile{{Data: []byte("receipt"), MimeType: "image/png"}}, "de")
+	require.NoError(t, err)
+	assert.Equal(t, result, again)
+}
+
+func TestOCRService_Process_Dates(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     string
+		rawText  string
+		language string
+		want     string
+	}{
+		{name: "ISO", date: "2025-03-04", want: "2025-03-04"},
+		{name: "Day First Dots", date: "04.03.25", want: "2025-03-04"},
+		{name: "Day First Slashes", date: "04/03/2025", want: "2025-03-04"},
+		{name: "Month First In The US", date: "03/04/2025", language: "en-US", want: "2025-03-04"},
+		{name: "Day Above 12", date: "03/14/2025", want: "2025-03-14"},
+		{name: "Polish Month", date: "4 marca 2025 r.", want: "2025-03-04"},
+		{name: "English Month", date: "March 4, 2025", want: "2025-03-04"},
+		{name: "From The Raw Text", rawText: "NIP 123-456-78-90\n2025.03.04 12:01", want: "2025-03-04"},
+		{name: "No Such Day", date: "31.02.2025"},
+		{name: "Not A Date", date: "tomorrow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := services.NewFakeOCRProvider()
+			provider.SetResult(models.OCRResult{Vendor: "Shop", Date: tt.date, RawText: tt.rawText})
+			svc := services.NewOCRService(provider, newMockOCRCache(), &mockBillRepo{})
+
+			result, err := svc.Process(context.Background(), []models.OCRFile{{Data: []byte(tt.name), MimeType: "image/png"}}, tt.language)
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, result.ParsedDate)
+				return
+			}
+			require.NotNil(t, result.ParsedDate)
+			assert.Equal(t, tt.want, result.ParsedDate.Format("2006-01-02"))
+		})
+	}
+}
+
+func TestOCRService_Process_Currency(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		rawText  string
+		want     string
+	}{
+		{name: "From The Provider", currency: "pln", rawText: "TOTAL $3.85", want: "PLN"},
+		{name: "Code", rawText: "Corner Shop\nTOTAL USD 3.85\nEUR 3.55", want: "USD"},
+		{name: "Sign", rawText: "Chleb 4,50 zł", want: "PLN"},
+		{name: "None", rawText: "Bread 4.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := services.NewFakeOCRProvider()
+			provider.SetResult(models.OCRResult{Vendor: "Shop", Currency: tt.currency, RawText: tt.rawText})
+			svc := services.NewOCRService(provider, newMockOCRCache(), &mockBillRepo{})
+
+			result, err := svc.Process(context.Background(), []models.OCRFile{{Data: []byte(tt.name), MimeType: "image/png"}}, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Currency)
+		})
+	}
+}
+
+func TestOCRService_SuggestCategory(t *testing.T) {
+	groceries, household := 1, 2
+	repo := &mockBillRepo{
+		FindCategorizedFunc: func(ctx context.Context, homeID, limit int) ([]models.Bill, error) {
+			assert.Equal(t, 4, homeID)
+			// newest first
+			return []models.Bill{
+				{ID: 9, BillCategoryID: &household, OCRData: []byte(`{"vendor":"Biedronka"}`)},
+				{ID: 8, BillCategoryID: &groceries, Description: "biedronka"},
+				{ID: 7, BillCategoryID: &household, OCRData: []byte(`{"vendor":"Rossmann"}`)},
+				{ID: 6, BillCategoryID: &groceries, OCRData: []byte(`{"vendor":"BIEDRONKA 4411"}`)},
+			}, nil
+		},
+	}
+	svc := services.NewOCRService(services.NewFakeOCRProvider(), newMockOCRCache(), repo)
+	ctx := context.Background()
+
+	result := &models.OCRResult{Vendor: "BIEDRONKA Sklep 1234"}
+	require.NoError(t, svc.SuggestCategory(ctx, 4, result))
+	require.NotNil(t, result.SuggestedCategoryID)
+	assert.Equal(t, groceries, *result.SuggestedCategoryID)
+
+	// a new shop has nothing to go by
+	result = &models.OCRResult{Vendor: "Żabka"}
+	require.NoError(t, svc.SuggestCategory(ctx, 4, result))
+	assert.Nil(t, result.SuggestedCategoryID)
+}
+
+func TestOCRService_Process_MergesPieces(t *testing.T) {
+	svc := services.NewOCRService(services.NewTextOCRProvider(), newMockOCRCache(), &mockBillRepo{})
+
+	// two photos of one receipt, overlapping by two lines
+	files := []models.OCRFile{
+		{Data: []byte("Corner Shop\n2025-03-14\nApples 1.20\nBarley 2.30\nBread 3.00\n"), MimeType: "text/plain"},
+		{Data: []byte("Barley  2.30\nBread 3.00\nMilk 0.90\nTOTAL 7.40\n"), MimeType: "text/plain"},
+	}
+	result, err := svc.Process(context.Background(), files, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Corner Shop", result.Vendor)
+	assert.Equal(t, models.Money(740), result.Total)
+	require.Len(t, result.Items, 4)
+	assert.Equal(t, "Milk", result.Items[3].Name)
+	assert.Equal(t, "Corner Shop\n2025-03-14\nApples 1.20\nBarley 2.30\nBread 3.00\nMilk 0.90\nTOTAL 7.40", result.RawText)
+	assert.Equal(t, 1.0, result.Confidence)
+
+	require.Len(t, result.Pages, 2)
+	assert.Equal(t, models.OCRPage{Page: 2, File: 2, Confidence: 0.4}, result.Pages[1])
+	assert.Greater(t, result.Pages[0].Confidence, result.Pages[1].Confidence)
+
+	// a second file makes it a different receipt
+	single, err := svc.Process(context.Background(), files[:1], "")
+	require.NoError(t, err)
+	assert.NotEqual(t, single.ReceiptHash, result.ReceiptHash)
+
+	files[1] = models.OCRFile{Data: []byte("\x89PNG"), MimeType: "image/png"}
+	_, err = svc.Process(context.Background(), files, "")
+	assert.ErrorIs(t, err, services.ErrUnsupportedOCRFile)
+	assert.Contains(t, err.Error(), "file 2")
+
+	_, err = svc.Process(context.Background(), nil, "")
+	assert.ErrorIs(t, err, services.ErrNoOCRFiles)
+}
+
+func TestOCRService_Process_PDFPages(t *testing.T) {
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	pdf.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >> endobj\n")
+	pdf.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 5 0 R >> endobj\n")
+	pdf.WriteString("4 0 obj << /Type /Page /Parent 2 0 R /Contents [6 0 R] >> endobj\n")
+	for num, content := range map[int]string{
+		5: "BT 1 0 0 1 50 700 Tm (Fresh Foods) Tj 1 0 0 1 50 680 Tm (Bananas 2.40) Tj ET",
+		6: "BT 1 0 0 1 50 700 Tm (Coffee 8.10) Tj 1 0 0 1 50 680 Tm (TOTAL 10.50) Tj ET",
+	} {
+		fmt.Fprintf(&pdf, "%d 0 obj << /Length %d >> stream\n%s\nendstream\nendobj\n", num, len(content)+1, content)
+	}
+	pdf.WriteString("%%EOF\n")
+
+	svc := services.NewOCRService(services.NewTextOCRProvider(), newMockOCRCache(), &mockBillRepo{})
+	result, err := svc.Process(context.Background(), []models.OCRFile{{Data: pdf.Bytes(), MimeType: "application/pdf"}}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Fresh Foods", result.Vendor)
+	assert.Equal(t, models.Money(1050), result.Total)
+	require.Len(t, result.Items, 2)
+	require.Len(t, result.Pages, 2)
+	assert.Equal(t, 1, result.Pages[1].File)
+	assert.Equal(t, 2, result.Pages[1].Page)
+}
+
+// geminiStub answers generateContent calls with the given text and records what it was sent
+func geminiStub(t *testing.T, answer func(mimeType, prompt string) string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.URL.Query().Get("key"))
+
+		var req struct {
+			Contents []struct {
+				Parts []struct {
+					Text       string `json:"text"`
+					InlineData *struct {
+						MimeType string `json:"mimeType"`
+					} `json:"inlineData"`
+				} `json:"parts"`
+			} `json:"contents"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		parts := req.Contents[0].Parts
+		require.Len(t, parts, 2)
+
+		text := answer(parts[0].InlineData.MimeType, parts[1].Text)
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"candidates": []interface{}{
+				map[string]interface{}{"content": map[string]interface{}{"parts": []interface{}{map[string]interface{}{"text": text}}}},
+			},
+		}))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOCRService_Process_GeminiPDFPages(t *testing.T) {
+	server := geminiStub(t, func(mimeType, prompt string) string {
+		require.Equal(t, "application/pdf", mimeType)
+		assert.Contains(t, prompt, "page by page")
+		return `{"pages": [
+			{"vendor": "Fresh Foods", "date": "2025-03-14", "total": 0, "items": [{"name": "Bananas", "quantity": 1, "price": 2.40, "kind": "item"}], "raw_text": "Fresh Foods\nBananas 2.40"},
+			{"vendor": "", "date": "", "total": 0, "items": [], "raw_text": ""},
+			{"vendor": "", "date": "", "total": 10.50, "items": [{"name": "Coffee", "quantity": 1, "price": 8.10, "kind": "item"}], "raw_text": "Coffee 8.10\nTOTAL 10.50"}
+		]}`
+	})
+
+	svc := services.NewOCRService(services.NewGeminiOCRProviderWithURL("test-key", server.URL), newMockOCRCache(), &mockBillRepo{})
+	result, err := svc.Process(context.Background(), []models.OCRFile{{Data: []byte("%PDF-1.4\n"), MimeType: "application/pdf"}}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Fresh Foods", result.Vendor)
+	assert.Equal(t, models.Money(1050), result.Total)
+	require.Len(t, result.Items, 2)
+
+	// the blank page is skipped and each page has its own confidence
+	require.Len(t, result.Pages, 2)
+	assert.Equal(t, 1, result.Pages[1].File)
+	assert.Equal(t, 2, result.Pages[1].Page)
+	assert.Greater(t, result.Pages[0].Confidence, result.Pages[1].Confidence)
+}
+
+func TestOCRService_Process_GeminiImage(t *testing.T) {
+	server := geminiStub(t, func(mimeType, prompt string) string {
+		require.Equal(t, "image/png", mimeType)
+		assert.NotContains(t, prompt, "page by page")
+		return `{"vendor": "Kiosk", "date": "", "total": 1.50, "items": [{"name": "Water", "quantity": 1, "price": 1.50}], "raw_text": "Kiosk\nWater 1.50"}`
+	})
+
+	svc := services.NewOCRService(services.NewGeminiOCRProviderWithURL("test-key", server.URL), newMockOCRCache(), &mockBillRepo{})
+	result, err := svc.Process(context.Background(), []models.OCRFile{{Data: []byte("\x89PNG"), MimeType: "image/png"}}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Kiosk", result.Vendor)
+	require.Len(t, result.Pages, 1)
+	assert.Equal(t, result.Confidence, result.Pages[0].Confidence)
+}
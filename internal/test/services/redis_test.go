@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newFakeRedis serves the plain key commands the services cache with (GET, SET, DEL, INCR)
+// from memory, so tests can check what is served from the cache without a Redis server.
+func newFakeRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	data := make(map[string]string)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeRedis(conn, &mu, data)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func serveFakeRedis(conn net.Conn, mu *sync.Mutex, data map[string]string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(r)
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			reply = "+PONG\r\n"
+		case "GET":
+			if v, ok := data[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case "SET":
+			data[args[1]] = args[2]
+			reply = "+OK\r\n"
+		case "DEL":
+			n := 0
+			for _, key := range args[1:] {
+				if _, ok := data[key]; ok {
+					delete(data, key)
+					n++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", n)
+		case "INCR":
+			n, _ := strconv.Atoi(data[args[1]])
+			n++
+			data[args[1]] = strconv.Itoa(n)
+			reply = fmt.Sprintf(":%d\r\n", n)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		mu.Unlock()
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// readRESPCommand reads one command, sent by clients as an array of bulk strings
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("bad command header %q", line)
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("bad argument header %q", header)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
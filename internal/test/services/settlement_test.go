@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Dragodui/diploma-server/internal/event"
+	"github.com/Dragodui/diploma-server/internal/models"
+	"github.com/Dragodui/diploma-server/internal/services"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock SettlementRepository
+type mockSettlementRepo struct {
+	created []models.Settlement
+
+	CreateFunc      func(ctx context.Context, s *models.Settlement) error
+	FindBetweenFunc func(ctx context.Context, homeID, fromID, toID int) ([]models.Settlement, error)
+	SumByPairFunc   func(ctx context.Context, homeID int) ([]models.Debt, error)
+}
+
+func (m *mockSettlementRepo) Create(ctx context.Context, s *models.Settlement) error {
+	if m.CreateFunc != nil {
+		if err := m.CreateFunc(ctx, s); err != nil {
+			return err
+		}
+	}
+	m.created = append(m.created, *s)
+	return nil
+}
+
+func (m *mockSettlementRepo) FindByHomeID(ctx context.Context, homeID int) ([]models.Settlement, error) {
+	return m.created, nil
+}
+
+func (m *mockSettlementRepo) FindBetween(ctx context.Context, homeID, fromID, toID int) ([]models.Settlement, error) {
+	if m.FindBetweenFunc != nil {
+		return m.FindBetweenFunc(ctx, homeID, fromID, toID)
+	}
+	return m.created, nil
+}
+
+func (m *mockSettlementRepo) SumByPair(ctx context.Context, homeID int) ([]models.Debt, error) {
+	if m.SumByPairFunc != nil {
+		return m.SumByPairFunc(ctx, homeID)
+	}
+	return nil, nil
+}
+
+func (m *mockSettlementRepo) BackfillFromPaidSplits(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+type splitPayment struct {
+	paidAmount models.Money
+	paid       bool
+}
+
+// billRepoWithSplits serves the given splits and records the payments set on them
+func billRepoWithSplits(splits []models.BillSplit, payments map[int]splitPayment) *mockBillRepo {
+	return &mockBillRepo{
+		FindSplitsBetweenFunc: func(ctx context.Context, homeID, debtorID, creditorID int) ([]models.BillSplit, error) {
+			return splits, nil
+		},
+		SetSplitPaymentFunc: func(ctx context.Context, splitID int, paidAmount models.Money, paid bool) error {
+			payments[splitID] = splitPayment{paidAmount, paid}
+			return nil
+		},
+	}
+}
+
+func TestSettlementService_RecordSettlement_PartialPayment(t *testing.T) {
+	payments := make(map[int]splitPayment)
+	billRepo := billRepoWithSplits([]models.BillSplit{
+		{ID: 1, BillID: 10, UserID: 2, Amount: 3000},
+		{ID: 2, BillID: 11, UserID: 2, Amount: 2000},
+	}, payments)
+	settlementRepo := &mockSettlementRepo{}
+	outbox := &mockOutbox{}
+	var notified int
+	notifSvc := &mockNotifSvc{
+		CreateFunc: func(ctx context.Context, from *int, to int, description string) error {
+			notified = to
+			return nil
+		},
+	}
+	svc := services.NewSettlementService(settlementRepo, billRepo, homeWithCurrency("USD"), redis.NewClient(&redis.Options{Addr: "localhost:6379"}), notifSvc, outbox)
+
+	settlement, err := svc.RecordSettlement(context.Background(), 1, 2, 1, 4000, "rent")
+
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(4000), settlement.Amount)
+	// the oldest split is paid off, the next one only partly
+	assert.Equal(t, splitPayment{3000, true}, payments[1])
+	assert.Equal(t, splitPayment{1000, false}, payments[2])
+	assert.Equal(t, 1, notified)
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, event.HomeChannel(1), outbox.channels[0])
+	assert.Equal(t, event.ModuleBill, outbox.events[0].Module)
+	assert.Equal(t, event.ActionSettled, outbox.events[0].Action)
+}
+
+func TestSettlementService_RecordSettlement_PinnedSplitFirst(t *testing.T) {
+	payments := make(map[int]splitPayment)
+	billRepo := billRepoWithSplits([]models.BillSplit{
+		{ID: 1, BillID: 10, UserID: 2, Amount: 3000},
+		{ID: 2, BillID: 11, UserID: 2, Amount: 2000},
+	}, payments)
+	splitID := 2
+	settlementRepo := &mockSettlementRepo{
+		created: []models.Settlement{{FromUserID: 2, ToUserID: 1, Amount: 2000, SplitID: &splitID}},
+	}
+	svc := services.NewSettlementService(settlementRepo, billRepo, homeWithCurrency("USD"), redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, &mockOutbox{})
+
+	_, err := svc.RecordSettlement(context.Background(), 1, 2, 1, 500, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, splitPayment{500, false}, payments[1])
+	assert.Equal(t, splitPayment{2000, true}, payments[2])
+}
+
+func TestSettlementService_RecordSettlement_ForeignCurrencyBill(t *testing.T) {
+	payments := make(map[int]splitPayment)
+	// a 10 EUR split is worth 43 PLN at the bill's snapshot rate
+	billRepo := billRepoWithSplits([]models.BillSplit{
+		{ID: 1, BillID: 10, UserID: 2, Amount: 1000, Bill: &models.Bill{ID: 10, Currency: "EUR", ExchangeRate: 4.3}},
+	}, payments)
+	settlementRepo := &mockSettlementRepo{}
+	svc := services.NewSettlementService(settlementRepo, billRepo, homeWithCurrency("PLN"), redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, &mockOutbox{})
+
+	settlement, err := svc.RecordSettlement(context.Background(), 1, 2, 1, 2150, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "PLN", settlement.Currency)
+	assert.Equal(t, splitPayment{500, false}, payments[1])
+
+	_, err = svc.RecordSettlement(context.Background(), 1, 2, 1, 2150, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, splitPayment{1000, true}, payments[1])
+}
+
+func TestSettlementService_RecordSettlement_Self(t *testing.T) {
+	settlementRepo := &mockSettlementRepo{}
+	outbox := &mockOutbox{}
+	svc := services.NewSettlementService(settlementRepo, &mockBillRepo{}, homeWithCurrency("USD"), redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+	_, err := svc.RecordSettlement(context.Background(), 1, 2, 2, 1000, "")
+
+	assert.ErrorIs(t, err, services.ErrSelfSettlement)
+	assert.Empty(t, settlementRepo.created)
+	assert.Empty(t, outbox.events)
+}
+
+func TestSettlementService_RecordSettlement_RepoError(t *testing.T) {
+	settlementRepo := &mockSettlementRepo{
+		CreateFunc: func(ctx context.Context, s *models.Settlement) error {
+			return errors.New("db error")
+		},
+	}
+	outbox := &mockOutbox{}
+	svc := services.NewSettlementService(settlementRepo, &mockBillRepo{}, homeWithCurrency("USD"), redis.NewClient(&redis.Options{Addr: "localhost:6379"}), &mockNotifSvc{}, outbox)
+
+	settlement, err := svc.RecordSettlement(context.Background(), 1, 2, 1, 1000, "")
+
+	assert.Error(t, err)
+	assert.Nil(t, settlement)
+	assert.Empty(t, outbox.events)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/Dragodui/diploma-server/internal/models"
 	"github.com/Dragodui/diploma-server/internal/repository"
@@ -26,6 +27,8 @@ type mockShoppingRepo struct {
 	CreateItemFunc            func(ctx context.Context, i *models.ShoppingItem) error
 	FindItemsByCategoryIDFunc func(ctx context.Context, categoryID int) ([]models.ShoppingItem, error)
 	FindItemByIDFunc          func(ctx context.Context, id int) (*models.ShoppingItem, error)
+	FindOpenItemsForHomeFunc  func(ctx context.Context, homeID int) ([]models.ShoppingItem, error)
+	FindItemsForUpdateFunc    func(ctx context.Context, homeID int, ids []int) ([]models.ShoppingItem, error)
 	DeleteItemFunc            func(ctx context.Context, id int) error
 	MarkIsBoughtFunc          func(ctx context.Context, id int) error
 	EditItemFunc              func(ctx context.Context, item *models.ShoppingItem, updates map[string]interface{}) error
@@ -87,6 +90,20 @@ func (m *mockShoppingRepo) FindItemByID(ctx context.Context, id int) (*models.Sh
 	return nil, nil
 }
 
+func (m *mockShoppingRepo) FindOpenItemsForHome(ctx context.Context, homeID int) ([]models.ShoppingItem, error) {
+	if m.FindOpenItemsForHomeFunc != nil {
+		return m.FindOpenItemsForHomeFunc(ctx, homeID)
+	}
+	return []models.ShoppingItem{}, nil
+}
+
+func (m *mockShoppingRepo) FindItemsForUpdate(ctx context.Context, homeID int, ids []int) ([]models.ShoppingItem, error) {
+	if m.FindItemsForUpdateFunc != nil {
+		return m.FindItemsForUpdateFunc(ctx, homeID, ids)
+	}
+	return []models.ShoppingItem{}, nil
+}
+
 func (m *mockShoppingRepo) DeleteItem(ctx context.Context, id int) error {
 	if m.DeleteItemFunc != nil {
 		return m.DeleteItemFunc(ctx, id)
@@ -111,7 +128,7 @@ func (m *mockShoppingRepo) EditItem(ctx context.Context, item *models.ShoppingIt
 // Test helpers
 func setupShoppingService(t *testing.T, repo repository.ShoppingRepository) *services.ShoppingService {
 	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
-	return services.NewShoppingService(repo, redisClient)
+	return services.NewShoppingService(repo, redisClient, &mockOutbox{})
 }
 
 // CreateCategory Tests
@@ -372,3 +389,110 @@ func TestShoppingService_MarkIsBought_Success(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+// Receipt Tests
+func TestShoppingService_ReconcileReceipt(t *testing.T) {
+	repo := &mockShoppingRepo{
+		FindOpenItemsForHomeFunc: func(ctx context.Context, homeID int) ([]models.ShoppingItem, error) {
+			require.Equal(t, 1, homeID)
+			return []models.ShoppingItem{
+				{ID: 1, Name: "Milk"},
+				{ID: 2, Name: "Tomatoes"},
+				{ID: 3, Name: "Rye bread"},
+				{ID: 4, Name: "Śmietana"},
+				{ID: 5, Name: "Toilet paper"},
+			}, nil
+		},
+	}
+
+	svc := setupShoppingService(t, repo)
+	result, err := svc.ReconcileReceipt(context.Background(), 1, []models.OCRItem{
+		{Name: "MILK CHOCOLATE 100G", Price: 350},
+		{Name: "MILK 1L", Price: 99},
+		{Name: "TOMATOS", Price: 420},
+		{Name: "BREAD RYE", Price: 510},
+		{Name: "SMIET. 18% 200ml", Price: 389},
+		{Name: "Bananas", Price: 200},
+		{Name: "VAT A 23%", Price: 120, Kind: models.ReceiptLineTax},
+	})
+	require.NoError(t, err)
+
+	// the closer milk wins and each list item is proposed once
+	require.Len(t, result.Matches, 4)
+	assert.Equal(t, "MILK 1L", result.Matches[0].ReceiptItem.Name)
+	assert.Equal(t, 1, result.Matches[0].Item.ID)
+	assert.Equal(t, 1.0, result.Matches[0].Score)
+	assert.Equal(t, 2, result.Matches[1].Item.ID)
+	assert.Equal(t, 3, result.Matches[2].Item.ID)
+	assert.Equal(t, 4, result.Matches[3].Item.ID)
+
+	require.Len(t, result.Unmatched, 2)
+	assert.Equal(t, "MILK CHOCOLATE 100G", result.Unmatched[0].Name)
+	assert.Equal(t, "Bananas", result.Unmatched[1].Name)
+}
+
+func TestShoppingService_ConfirmPurchases(t *testing.T) {
+	// items 1 and 2 are on the list of home 1
+	items := map[int]*models.ShoppingItem{
+		1: {ID: 1, CategoryID: 1, Name: "Milk"},
+		2: {ID: 2, CategoryID: 1, Name: "Bread"},
+	}
+	var toggled []int
+	repo := &mockShoppingRepo{
+		FindItemsForUpdateFunc: func(ctx context.Context, homeID int, ids []int) ([]models.ShoppingItem, error) {
+			require.Equal(t, 1, homeID)
+			var found []models.ShoppingItem
+			for _, id := range ids {
+				if item, ok := items[id]; ok {
+					found = append(found, *item)
+				}
+			}
+			return found, nil
+		},
+		EditItemFunc: func(ctx context.Context, item *models.ShoppingItem, updates map[string]interface{}) error {
+			stored := items[item.ID]
+			stored.IsBought = updates["is_bought"].(bool)
+			stored.BoughtDate = updates["bought_date"].(*time.Time)
+			if price, ok := updates["price"].(models.Money); ok {
+				stored.Price = &price
+			}
+			return nil
+		},
+		MarkIsBoughtFunc: func(ctx context.Context, id int) error {
+			toggled = append(toggled, id)
+			return nil
+		},
+	}
+	svc := setupShoppingService(t, repo)
+	ctx := context.Background()
+
+	price := models.Money(99)
+	err := svc.ConfirmPurchases(ctx, 1, []models.ShoppingPurchase{{ItemID: 1, Price: &price}, {ItemID: 2}})
+	require.NoError(t, err)
+	assert.True(t, items[1].IsBought)
+	assert.NotNil(t, items[1].BoughtDate)
+	assert.Equal(t, models.Money(99), *items[1].Price)
+	assert.True(t, items[2].IsBought)
+	assert.Nil(t, items[2].Price)
+	// set, not toggled
+	assert.Empty(t, toggled)
+
+	// a housemate confirming the same receipt finds the items bought and changes nothing
+	err = svc.ConfirmPurchases(ctx, 1, []models.ShoppingPurchase{{ItemID: 1}})
+	assert.ErrorIs(t, err, services.ErrShoppingItemNotOpen)
+	assert.True(t, items[1].IsBought)
+
+	items[3] = &models.ShoppingItem{ID: 3, CategoryID: 1, Name: "Eggs"}
+
+	// items of another home are not found
+	err = svc.ConfirmPurchases(ctx, 1, []models.ShoppingPurchase{{ItemID: 7}, {ItemID: 3}})
+	assert.ErrorIs(t, err, services.ErrShoppingItemNotOpen)
+
+	err = svc.ConfirmPurchases(ctx, 1, []models.ShoppingPurchase{{ItemID: 3}, {ItemID: 3}})
+	assert.ErrorIs(t, err, services.ErrShoppingItemNotOpen)
+
+	price = -1
+	err = svc.ConfirmPurchases(ctx, 1, []models.ShoppingPurchase{{ItemID: 3, Price: &price}})
+	assert.ErrorIs(t, err, models.ErrInvalidMoney)
+	assert.False(t, items[3].IsBought)
+}
@@ -0,0 +1,235 @@
+package websocket_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	ws "github.com/Dragodui/diploma-server/internal/http/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubscriber records Redis subscriptions and lets tests inject messages.
+type fakeSubscriber struct {
+	mu         sync.Mutex
+	subscribed map[string]int
+	messages   chan *redis.Message
+
+	// delay slows Unsubscribe down like a network round trip; block holds Subscribe until closed
+	delay time.Duration
+	block chan struct{}
+	// Unsubscribe calls started so far
+	unsubscribes int
+}
+
+func newFakeSubscriber() *fakeSubscriber {
+	return &fakeSubscriber{
+		subscribed: make(map[string]int),
+		messages:   make(chan *redis.Message),
+	}
+}
+
+func (f *fakeSubscriber) Subscribe(ctx context.Context, channels ...string) error {
+	f.mu.Lock()
+	block := f.block
+	f.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ch := range channels {
+		f.subscribed[ch]++
+	}
+	return nil
+}
+
+func (f *fakeSubscriber) Unsubscribe(ctx context.Context, channels ...string) error {
+	f.mu.Lock()
+	f.unsubscribes++
+	f.mu.Unlock()
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ch := range channels {
+		delete(f.subscribed, ch)
+	}
+	return nil
+}
+
+func (f *fakeSubscriber) Channel(opts ...redis.ChannelOption) <-chan *redis.Message {
+	return f.messages
+}
+
+func (f *fakeSubscriber) Close() error {
+	close(f.messages)
+	return nil
+}
+
+func (f *fakeSubscriber) unsubscribeCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.unsubscribes
+}
+
+func (f *fakeSubscriber) count(channel string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.subscribed[channel]
+}
+
+func TestHub_SharesRedisSubscription(t *testing.T) {
+	sub := newFakeSubscriber()
+	hub := ws.NewHub(sub)
+	ctx := context.Background()
+
+	a := ws.NewClient(nil, 1, hub)
+	b := ws.NewClient(nil, 2, hub)
+
+	require.NoError(t, hub.Subscribe(ctx, a, "events:home:1"))
+	require.NoError(t, hub.Subscribe(ctx, b, "events:home:1"))
+	assert.Equal(t, 1, sub.count("events:home:1"))
+
+	hub.Unregister(a)
+	assert.Equal(t, 1, sub.count("events:home:1"))
+
+	require.NoError(t, hub.Unsubscribe(ctx, b, "events:home:1"))
+	assert.Equal(t, 0, sub.count("events:home:1"))
+}
+
+func TestHub_RoutesByChannel(t *testing.T) {
+	sub := newFakeSubscriber()
+	hub := ws.NewHub(sub)
+	go hub.Run()
+	defer hub.Close()
+	ctx := context.Background()
+
+	a := ws.NewClient(nil, 1, hub)
+	b := ws.NewClient(nil, 2, hub)
+	require.NoError(t, hub.Subscribe(ctx, a, "events:home:1", "events:user:1"))
+	require.NoError(t, hub.Subscribe(ctx, b, "events:home:2"))
+
+	sub.messages <- &redis.Message{Channel: "events:home:1", Payload: "bill"}
+	sub.messages <- &redis.Message{Channel: "events:home:2", Payload: "task"}
+
+	select {
+	case msg := <-a.Messages():
+		assert.Equal(t, "bill", msg.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("client a did not receive its home event")
+	}
+
+	select {
+	case msg := <-b.Messages():
+		assert.Equal(t, "task", msg.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("client b did not receive its home event")
+	}
+
+	assert.Empty(t, a.Messages())
+}
+
+func TestHub_DisconnectsSlowConsumer(t *testing.T) {
+	sub := newFakeSubscriber()
+	hub := ws.NewHub(sub)
+	go hub.Run()
+	defer hub.Close()
+	ctx := context.Background()
+
+	slow := ws.NewClient(nil, 1, hub)
+	fast := ws.NewClient(nil, 2, hub)
+	require.NoError(t, hub.Subscribe(ctx, slow, "events:home:1"))
+	require.NoError(t, hub.Subscribe(ctx, fast, "events:home:1"))
+
+	// the fast client reads every event while nobody drains the slow one
+	for i := 0; i < 300; i++ {
+		sub.messages <- &redis.Message{Channel: "events:home:1", Payload: "event"}
+
+		select {
+		case <-fast.Messages():
+		case <-time.After(time.Second):
+			t.Fatalf("fast client was blocked by the slow one at event %d", i)
+		}
+	}
+
+	select {
+	case <-slow.Done():
+	case <-time.After(time.Second):
+		t.Fatal("slow client was not disconnected")
+	}
+
+	select {
+	case <-fast.Done():
+		t.Fatal("fast client should stay connected")
+	default:
+	}
+}
+
+func TestHub_ConcurrentSubscribeAndUnsubscribe(t *testing.T) {
+	sub := newFakeSubscriber()
+	sub.delay = 2 * time.Millisecond
+	hub := ws.NewHub(sub)
+	ctx := context.Background()
+	const channel = "events:home:1"
+
+	a := ws.NewClient(nil, 1, hub)
+	b := ws.NewClient(nil, 2, hub)
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, hub.Subscribe(ctx, a, channel))
+
+		// b joins while Redis is still busy unsubscribing after a left
+		calls := sub.unsubscribeCalls()
+		done := make(chan error)
+		go func() {
+			done <- hub.Unsubscribe(ctx, a, channel)
+		}()
+		require.Eventually(t, func() bool { return sub.unsubscribeCalls() > calls }, time.Second, 10*time.Microsecond)
+		require.NoError(t, hub.Subscribe(ctx, b, channel))
+		require.NoError(t, <-done)
+		require.Equal(t, 1, sub.count(channel), "round %d", i)
+
+		require.NoError(t, hub.Unsubscribe(ctx, b, channel))
+		require.Equal(t, 0, sub.count(channel), "round %d", i)
+	}
+}
+
+func TestHub_SubscribeDoesNotStallDispatch(t *testing.T) {
+	sub := newFakeSubscriber()
+	hub := ws.NewHub(sub)
+	go hub.Run()
+	defer hub.Close()
+	ctx := context.Background()
+
+	a := ws.NewClient(nil, 1, hub)
+	require.NoError(t, hub.Subscribe(ctx, a, "events:home:1"))
+
+	// Redis hangs on the next subscribe
+	sub.mu.Lock()
+	sub.block = make(chan struct{})
+	sub.mu.Unlock()
+	done := make(chan error)
+	go func() {
+		done <- hub.Subscribe(ctx, ws.NewClient(nil, 2, hub), "events:home:2")
+	}()
+
+	select {
+	case sub.messages <- &redis.Message{Channel: "events:home:1", Payload: "bill"}:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch waited for a Redis subscribe")
+	}
+	select {
+	case msg := <-a.Messages():
+		assert.Equal(t, "bill", msg.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("client a did not receive its event")
+	}
+
+	close(sub.block)
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, sub.count("events:home:2"))
+}
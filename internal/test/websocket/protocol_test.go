@@ -0,0 +1,100 @@
+package websocket_test
+
+import (
+	"context"
+	"testing"
+
+	ws "github.com/Dragodui/diploma-server/internal/http/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_HandleMessage(t *testing.T) {
+	tests := []struct {
+		name         string
+		message      string
+		expectedType string
+		expectedCode string
+		expectedID   string
+	}{
+		{
+			name:         "Ping",
+			message:      `{"type":"ping","id":"1"}`,
+			expectedType: ws.MessagePong,
+			expectedID:   "1",
+		},
+		{
+			name:         "Invalid JSON",
+			message:      `{bad json}`,
+			expectedType: ws.MessageError,
+			expectedCode: ws.ErrCodeInvalidMessage,
+		},
+		{
+			name:         "Unknown type",
+			message:      `{"type":"publish","id":"2"}`,
+			expectedType: ws.MessageError,
+			expectedCode: ws.ErrCodeUnknownType,
+			expectedID:   "2",
+		},
+		{
+			name:         "Subscribe without home",
+			message:      `{"type":"subscribe"}`,
+			expectedType: ws.MessageError,
+			expectedCode: ws.ErrCodeInvalidHome,
+		},
+		{
+			name:         "Subscribe to foreign home",
+			message:      `{"type":"subscribe","home_id":3,"id":"3"}`,
+			expectedType: ws.MessageError,
+			expectedCode: ws.ErrCodeNotMember,
+			expectedID:   "3",
+		},
+		{
+			name:         "Unsubscribe from foreign home",
+			message:      `{"type":"unsubscribe","home_id":3}`,
+			expectedType: ws.MessageError,
+			expectedCode: ws.ErrCodeNotMember,
+		},
+		{
+			name:         "Ack without event",
+			message:      `{"type":"ack"}`,
+			expectedType: ws.MessageError,
+			expectedCode: ws.ErrCodeInvalidMessage,
+		},
+		{
+			name:         "Ack with invalid event",
+			message:      `{"type":"ack","event_id":"latest","id":"4"}`,
+			expectedType: ws.MessageError,
+			expectedCode: ws.ErrCodeInvalidMessage,
+			expectedID:   "4",
+		},
+		{
+			name:         "Subscribe with invalid since",
+			message:      `{"type":"subscribe","home_id":3,"since":"yesterday"}`,
+			expectedType: ws.MessageError,
+			expectedCode: ws.ErrCodeInvalidMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := ws.NewClient(nil, 5, ws.NewHub(newFakeSubscriber()))
+
+			reply := client.HandleMessage(context.Background(), []byte(tt.message))
+
+			require.NotNil(t, reply)
+			assert.Equal(t, tt.expectedType, reply.Type)
+			assert.Equal(t, tt.expectedCode, reply.Code)
+			assert.Equal(t, tt.expectedID, reply.ID)
+		})
+	}
+}
+
+func TestClient_HandleMessage_Ack(t *testing.T) {
+	client := ws.NewClient(nil, 5, ws.NewHub(newFakeSubscriber()))
+
+	reply := client.HandleMessage(context.Background(), []byte(`{"type":"ack","event_id":"1700000000000-0"}`))
+
+	assert.Nil(t, reply)
+	assert.Equal(t, "1700000000000-0", client.LastAck())
+}
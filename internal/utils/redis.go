@@ -99,6 +99,14 @@ func GetBillKey(billID int) string {
 	return "bill:" + strconv.Itoa(billID)
 }
 
+func GetBillsVersionKey(homeID int) string {
+	return "bills:home:" + strconv.Itoa(homeID) + ":version"
+}
+
+func GetBillsPageKey(homeID int, version int64, query string) string {
+	return "bills:home:" + strconv.Itoa(homeID) + ":v" + strconv.FormatInt(version, 10) + ":" + query
+}
+
 func GetRoomKey(roomID int) string {
 	return "room:" + strconv.Itoa(roomID)
 }